@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"rabbitmq-exporter/exporter"
+)
+
+// requireAdmin wraps a handler with HTTP basic authentication against the
+// configured admin credentials
+func requireAdmin(username, password string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="rabbitmq-exporter admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requirePost rejects requests that don't use the POST method
+func requirePost(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func circuitBreakerResetHandler(collector *exporter.Collector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collector.ResetCircuitBreaker()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Circuit breaker reset"))
+	}
+}
+
+// refreshHandler starts a collection outside the schedule. It answers before
+// the collection finishes; a new snapshot_id shows when it has.
+func refreshHandler(collector *exporter.Collector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collector.Refresh()
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("Refresh triggered"))
+	}
+}
+
+func reloadHandler(reloader *configReloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := reloader.Reload(r.Context()); err != nil {
+			log.Printf("Config reload failed: %v", err)
+			http.Error(w, "Config reload failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Config reloaded"))
+	}
+}
+
+// registerAdminHandlers mounts the admin endpoints on mux. Admin endpoints are
+// only available when admin credentials are configured.
+func registerAdminHandlers(mux *http.ServeMux, config exporter.Config, collector *exporter.Collector, reloader *configReloader) bool {
+	if config.AdminUsername == "" || config.AdminPassword == "" {
+		return false
+	}
+
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		return requirePost(requireAdmin(config.AdminUsername, config.AdminPassword, h))
+	}
+
+	mux.HandleFunc("/-/circuit-breaker/reset", auth(circuitBreakerResetHandler(collector)))
+	mux.HandleFunc("/-/refresh", auth(refreshHandler(collector)))
+	mux.HandleFunc("/-/reload", auth(reloadHandler(reloader)))
+	return true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCircuitBreakerResetEndpoint(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	m := metrics.NewMetrics()
+	collector := exporter.NewCollector(client, m, time.Hour)
+	defer collector.Stop()
+
+	mux := http.NewServeMux()
+	config := exporter.Config{AdminUsername: "admin", AdminPassword: "secret"}
+	if !registerAdminHandlers(mux, config, collector, nil) {
+		t.Fatal("Expected admin handlers to be registered")
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		username string
+		password string
+		expected int
+	}{
+		{name: "Missing credentials", method: http.MethodPost, expected: http.StatusUnauthorized},
+		{name: "Wrong password", method: http.MethodPost, username: "admin", password: "wrong", expected: http.StatusUnauthorized},
+		{name: "Wrong method", method: http.MethodGet, username: "admin", password: "secret", expected: http.StatusMethodNotAllowed},
+		{name: "Authorized reset", method: http.MethodPost, username: "admin", password: "secret", expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/-/circuit-breaker/reset", nil)
+			if tt.username != "" {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+
+	if got := testutil.ToFloat64(m.CircuitBreakerManualResets.WithLabelValues("rabbitmq_api")); got != 1 {
+		t.Errorf("Expected 1 manual reset, got %v", got)
+	}
+}
+
+func TestRegisterAdminHandlers_Disabled(t *testing.T) {
+	if registerAdminHandlers(http.NewServeMux(), exporter.Config{}, nil, nil) {
+		t.Error("Expected admin handlers to be disabled without credentials")
+	}
+}
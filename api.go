@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"rabbitmq-exporter/exporter"
+)
+
+// writeJSON encodes v as the response body
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func snapshotHandler(collector *exporter.Collector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, collector.Snapshot())
+	}
+}
+
+// registerAPIHandlers mounts the read-only JSON API on mux
+func registerAPIHandlers(mux *http.ServeMux, collector *exporter.Collector) {
+	mux.HandleFunc("/api/v1/snapshot", snapshotHandler(collector))
+	mux.HandleFunc("/api/v1/cardinality", cardinalityHandler(collector))
+}
+
+func cardinalityHandler(collector *exporter.Collector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topN := exporter.DefaultCardinalityTopN
+		if v := r.URL.Query().Get("top"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "Invalid top parameter", http.StatusBadRequest)
+				return
+			}
+			topN = n
+		}
+
+		report, err := collector.Cardinality(topN)
+		if err != nil {
+			http.Error(w, "Failed to build cardinality report: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, report)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSnapshotEndpoint(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name":"orders","vhost":"/","type":"classic","messages":3,"messages_ready":2,"messages_unacknowledged":1,"consumers":1}]`))
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	m := metrics.NewMetrics()
+	collector := exporter.NewCollector(client, m, time.Hour)
+	defer collector.Stop()
+
+	collector.Warm(time.Second)
+	collector.Warm(time.Second)
+
+	mux := http.NewServeMux()
+	registerAPIHandlers(mux, collector)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/snapshot", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var snapshot exporter.Snapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if !snapshot.Valid || len(snapshot.Queues) != 1 {
+		t.Fatalf("Unexpected snapshot: %+v", snapshot)
+	}
+	if q := snapshot.Queues[0]; q.Name != "orders" || q.Messages != 3 || q.Consumers != 1 {
+		t.Errorf("Unexpected queue snapshot: %+v", q)
+	}
+
+	// The initial background collection may or may not have completed, so
+	// compare against the gauge rather than a fixed sequence number
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	if _, err := registry.Gather(); err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	if snapshot.SnapshotID < 2 {
+		t.Errorf("Expected snapshot ID to count collections, got %d", snapshot.SnapshotID)
+	}
+	if got := testutil.ToFloat64(m.SnapshotID); uint64(got) != collector.Snapshot().SnapshotID {
+		t.Errorf("Expected snapshot_id gauge %d, got %v", collector.Snapshot().SnapshotID, got)
+	}
+}
+
+func TestCardinalityEndpoint(t *testing.T) {
+	collector := newTestCollector(t, `[{"name":"orders","vhost":"/"}]`)
+
+	mux := http.NewServeMux()
+	registerAPIHandlers(mux, collector)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/cardinality?top=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var report exporter.CardinalityReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if len(report.TopLabelValues) != 1 || report.TotalSeries == 0 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/cardinality?top=x", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid top, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"rabbitmq-exporter/eventexchange"
+	"rabbitmq-exporter/metrics"
+)
+
+// brokerEventMetrics counts the events consumed from the event exchange
+type brokerEventMetrics struct {
+	metrics *metrics.Metrics
+}
+
+func (b brokerEventMetrics) Event(name string) {
+	b.metrics.BrokerEventsTotal.WithLabelValues(name).Inc()
+}
+
+func (b brokerEventMetrics) Connected(connected bool) {
+	value := 0.0
+	if connected {
+		value = 1.0
+	}
+	b.metrics.EventExchangeConnected.WithLabelValues(eventexchange.Exchange).Set(value)
+}
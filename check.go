@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rabbitmq-exporter/amqpprobe"
+	"rabbitmq-exporter/eventexchange"
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/notifier"
+	"rabbitmq-exporter/otlp"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validate the config and connectivity to RabbitMQ",
+	Long: `Loads and validates the config, then performs a health check and a single
+queue listing against the management API. Exits non-zero if anything fails.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runCheck,
+}
+
+func init() {
+	checkCmd.Flags().String("config", "", "Path to config file (default: config.yaml)")
+	checkCmd.Flags().Bool("offline", false, "Only validate the config, don't contact RabbitMQ")
+
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	configFile, _ := cmd.Flags().GetString("config")
+	offline, _ := cmd.Flags().GetBool("offline")
+
+	cfg, err := loadConfig(configFile, true)
+	if err != nil {
+		return checkFailed(out, "config", err)
+	}
+	if err := validateConfig(cfg); err != nil {
+		return checkFailed(out, "config", err)
+	}
+	fmt.Fprintln(out, "config: OK")
+
+	if offline {
+		return nil
+	}
+
+	client, closeClient, err := newRabbitMQClient(cfg)
+	if err != nil {
+		return checkFailed(out, "client", err)
+	}
+	defer closeClient()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 2*cfg.Timeout)
+	defer cancel()
+
+	if err := client.HealthCheck(ctx); err != nil {
+		return checkFailed(out, "health check", connectionError(err))
+	}
+	fmt.Fprintf(out, "health check: OK (%s)\n", cfg.RabbitMQURL)
+
+	start := time.Now()
+	queues, err := client.GetQueues(ctx)
+	if err != nil {
+		return checkFailed(out, "queues", err)
+	}
+	fmt.Fprintf(out, "queues: OK (%d queues in %v)\n", len(queues), time.Since(start).Round(time.Millisecond))
+
+	return nil
+}
+
+func checkFailed(out io.Writer, step string, err error) error {
+	fmt.Fprintf(out, "%s: FAILED\n", step)
+	return fmt.Errorf("%s check failed: %w", step, err)
+}
+
+// validateConfig checks the settings run would only reject once it gets to
+// them, reporting every problem at once
+func validateConfig(cfg exporter.Config) error {
+	var errs []error
+
+	if err := exporter.SetLogLevel(cfg.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := exporter.NewDepthThresholdMatcher(cfg.QueueDepthThresholds, exporter.DepthThresholds{
+		Warning:  exporter.DefaultDepthWarning,
+		Critical: exporter.DefaultDepthCritical,
+	}); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.QueueLabelRegex != "" {
+		if _, err := exporter.NewQueueLabeler(cfg.QueueLabelRegex); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, err := exporter.NewVhostMatcher(cfg.RollupOnlyVhosts); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := rabbitmq.NewDeadLetterRules(cfg.DeadLetter.Patterns, cfg.DeadLetter.Exchanges); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := exporter.NewCanaryTracker(cfg.CanaryQueues, time.Now()); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := exporter.NewDepthSLOTracker(cfg.DepthSLOs); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := exporter.NewSeverityTiers(cfg.SeverityTiers); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.OTLP.Enabled() {
+		switch cfg.OTLP.Protocol {
+		case "", otlp.ProtocolGRPC, otlp.ProtocolHTTP:
+		default:
+			errs = append(errs, fmt.Errorf("invalid OTLP protocol %q (expected grpc or http)", cfg.OTLP.Protocol))
+		}
+	}
+	if cfg.Output.TextfileDir != "" {
+		if info, err := os.Stat(cfg.Output.TextfileDir); err != nil {
+			errs = append(errs, fmt.Errorf("textfile directory: %w", err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("textfile directory %s is not a directory", cfg.Output.TextfileDir))
+		}
+	}
+	if cfg.Probe.Enabled {
+		if _, err := exporter.NewProber(cfg.Probe, exporter.ProbeTarget{}, cfg.Timeout, nil, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cfg.Notifier.Enabled() {
+		if _, err := notifier.NewClient(cfg.Notifier.Config); err != nil {
+			errs = append(errs, err)
+		}
+		if cfg.Notifier.For < 0 || cfg.Notifier.RepeatInterval < 0 {
+			errs = append(errs, errors.New("notifier for and repeat_interval must not be negative"))
+		}
+	}
+	if cfg.AnomalyDetection.Enabled {
+		if _, err := exporter.NewDepthBaselines(cfg.AnomalyDetection, cfg.ScrapeInterval); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cfg.AMQPProbe.Enabled() {
+		if _, err := amqpprobe.New(cfg.AMQPProbe, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cfg.EventExchange.Enabled() {
+		if _, err := eventexchange.New(cfg.EventExchange, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, port := range cfg.HealthChecks.Ports {
+		if port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("invalid health check port %d", port))
+		}
+	}
+	if cfg.MaxQueuesPerVhost < 0 {
+		errs = append(errs, fmt.Errorf("max_queues_per_vhost must not be negative"))
+	}
+	if cfg.ExpectedNodes < 0 {
+		errs = append(errs, fmt.Errorf("expected_nodes must not be negative"))
+	}
+	if cfg.BacklogGrowthCollections < 0 {
+		errs = append(errs, fmt.Errorf("backlog_growth_collections must not be negative"))
+	}
+	if cfg.StateSaveInterval < 0 {
+		errs = append(errs, fmt.Errorf("state_save_interval must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"rabbitmq-exporter/exporter"
+)
+
+func writeCheckConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func runCheckCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs(append([]string{"check"}, args...))
+	defer rootCmd.SetArgs(nil)
+	defer rootCmd.SetOut(nil)
+	defer checkCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Value.Set(f.DefValue)
+		f.Changed = false
+	})
+
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestValidateConfig(t *testing.T) {
+	if err := validateConfig(exporter.Config{}); err != nil {
+		t.Errorf("Expected empty config to be valid, got %v", err)
+	}
+
+	cfg := exporter.Config{
+		LogLevel:             "loud",
+		QueueDepthThresholds: []exporter.DepthThresholdConfig{{Pattern: "(", Critical: 10}},
+	}
+	cfg.DeadLetter.Patterns = []string{"["}
+	cfg.Output.TextfileDir = filepath.Join(t.TempDir(), "missing")
+	cfg.HealthChecks.Ports = []int{70000}
+
+	err := validateConfig(cfg)
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, want := range []string{"log level", "queue depth threshold", "textfile directory", "health check port"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+	if !strings.Contains(err.Error(), `"["`) {
+		t.Errorf("Expected the dead letter pattern to be reported, got %v", err)
+	}
+}
+
+func TestCheckCommand_UnknownKey(t *testing.T) {
+	path := writeCheckConfig(t, "scrape_intervall: 5s\n")
+
+	out, err := runCheckCommand(t, "--config", path, "--offline")
+	if err == nil {
+		t.Fatal("Expected unknown key to fail the check")
+	}
+	if !strings.Contains(err.Error(), "scrape_intervall") {
+		t.Errorf("Expected error to name the unknown key, got %v", err)
+	}
+	if !strings.Contains(out, "config: FAILED") {
+		t.Errorf("Unexpected output: %q", out)
+	}
+}
+
+func TestCheckCommand_Connectivity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/overview":
+			w.Write([]byte(`{}`))
+		case "/api/queues":
+			w.Write([]byte(`[{"name":"orders","vhost":"/"},{"name":"payments","vhost":"/"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	path := writeCheckConfig(t, "rabbitmq_url: "+server.URL+"\n")
+	out, err := runCheckCommand(t, "--config", path)
+	if err != nil {
+		t.Fatalf("Expected check to pass, got %v (output %q)", err, out)
+	}
+	if !strings.Contains(out, "queues: OK (2 queues") {
+		t.Errorf("Unexpected output: %q", out)
+	}
+}
+
+func TestCheckCommand_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"not_authorised","reason":"Login failed"}`))
+	}))
+	defer server.Close()
+
+	path := writeCheckConfig(t, "rabbitmq_url: "+server.URL+"\n")
+	out, err := runCheckCommand(t, "--config", path)
+	if err == nil {
+		t.Fatal("Expected check to fail")
+	}
+	if !strings.Contains(err.Error(), "monitoring tag") {
+		t.Errorf("Expected the credentials hint, got %v", err)
+	}
+	if !strings.Contains(out, "health check: FAILED") {
+		t.Errorf("Unexpected output: %q", out)
+	}
+}
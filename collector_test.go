@@ -1,229 +0,0 @@
-package main
-
-import (
-	"testing"
-	"time"
-
-	"rabbitmq-exporter/metrics"
-	"rabbitmq-exporter/rabbitmq"
-
-	"github.com/prometheus/client_golang/prometheus"
-)
-
-func TestNewCollector(t *testing.T) {
-	client := rabbitmq.NewClient("http://localhost:15672", "guest", "guest", 10*time.Second)
-	metrics := metrics.NewMetrics()
-	scrapeInterval := 15 * time.Second
-
-	collector := NewCollector(client, metrics, scrapeInterval)
-
-	if collector == nil {
-		t.Fatal("Expected collector to be created, got nil")
-	}
-
-	if collector.client != client {
-		t.Error("Expected client to be set correctly")
-	}
-
-	if collector.metrics != metrics {
-		t.Error("Expected metrics to be set correctly")
-	}
-
-	if collector.scrapeInterval != scrapeInterval {
-		t.Errorf("Expected scrape interval to be %v, got %v", scrapeInterval, collector.scrapeInterval)
-	}
-}
-
-func TestCollector_Describe(t *testing.T) {
-	// Create a new registry for testing
-	registry := prometheus.NewRegistry()
-
-	// Create test metrics with unique names to avoid conflicts
-	testMetrics := &metrics.Metrics{
-		QueueMessages: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_messages_test",
-				Help: "Total number of messages in the queue",
-			},
-			[]string{"queue_name", "vhost", "state"},
-		),
-		QueueMessagesReady: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_messages_ready_test",
-				Help: "Number of messages ready to be delivered",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueMessagesUnacknowledged: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_messages_unacknowledged_test",
-				Help: "Number of messages that have been delivered but not yet acknowledged",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueMessagePublishRate: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_message_publish_rate_test",
-				Help: "Message publish rate per second",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueMessageDeliverRate: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_message_deliver_rate_test",
-				Help: "Message delivery rate per second",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueMessageAckRate: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_message_ack_rate_test",
-				Help: "Message acknowledgment rate per second",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueMessageRedeliverRate: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_message_redeliver_rate_test",
-				Help: "Message redelivery rate per second",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueConsumers: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_consumers_test",
-				Help: "Number of consumers connected to the queue",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueConsumerUtilisation: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_consumer_utilisation_test",
-				Help: "Consumer utilisation as a percentage (0-1)",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueConsumerCapacity: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_consumer_capacity_test",
-				Help: "Consumer capacity as a percentage (0-1)",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueState: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_state_test",
-				Help: "Queue state indicator (1 for current state, 0 otherwise)",
-			},
-			[]string{"queue_name", "vhost", "state"},
-		),
-		QueueIsDeadLetter: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_is_dead_letter_test",
-				Help: "Indicates if the queue is a dead letter queue (1 if true, 0 if false)",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueHealthScore: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_health_score_test",
-				Help: "Queue health score (0-100, higher is better)",
-			},
-			[]string{"queue_name", "vhost"},
-		),
-		QueueDepthAlert: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_depth_alert_test",
-				Help: "Queue depth alert indicator (1 if depth > threshold, 0 otherwise)",
-			},
-			[]string{"queue_name", "vhost", "severity"},
-		),
-		QueueUtilizationAlert: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_utilization_alert_test",
-				Help: "Queue utilization alert indicator (1 if utilization < threshold, 0 otherwise)",
-			},
-			[]string{"queue_name", "vhost", "severity"},
-		),
-		ScrapeDurationSeconds: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_scrape_duration_seconds_test",
-				Help: "Duration of the last scrape in seconds",
-			},
-		),
-		ScrapeErrorsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "rabbitmq_custom_scrape_errors_total_test",
-				Help: "Total number of scrape errors",
-			},
-			[]string{"error_type"},
-		),
-		CircuitBreakerState: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_circuit_breaker_state_test",
-				Help: "Circuit breaker state (0=closed, 1=open, 2=half-open)",
-			},
-			[]string{"endpoint"},
-		),
-		CircuitBreakerFailures: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "rabbitmq_custom_circuit_breaker_failures_total_test",
-				Help: "Total number of circuit breaker failures",
-			},
-			[]string{"endpoint"},
-		),
-	}
-
-	// Register test metrics
-	registry.MustRegister(testMetrics.QueueMessages)
-	registry.MustRegister(testMetrics.QueueMessagesReady)
-	registry.MustRegister(testMetrics.QueueMessagesUnacknowledged)
-	registry.MustRegister(testMetrics.QueueMessagePublishRate)
-	registry.MustRegister(testMetrics.QueueMessageDeliverRate)
-	registry.MustRegister(testMetrics.QueueMessageAckRate)
-	registry.MustRegister(testMetrics.QueueMessageRedeliverRate)
-	registry.MustRegister(testMetrics.QueueConsumers)
-	registry.MustRegister(testMetrics.QueueConsumerUtilisation)
-	registry.MustRegister(testMetrics.QueueConsumerCapacity)
-	registry.MustRegister(testMetrics.QueueState)
-	registry.MustRegister(testMetrics.QueueIsDeadLetter)
-	registry.MustRegister(testMetrics.QueueHealthScore)
-	registry.MustRegister(testMetrics.QueueDepthAlert)
-	registry.MustRegister(testMetrics.QueueUtilizationAlert)
-	registry.MustRegister(testMetrics.ScrapeDurationSeconds)
-	registry.MustRegister(testMetrics.ScrapeErrorsTotal)
-	registry.MustRegister(testMetrics.CircuitBreakerState)
-	registry.MustRegister(testMetrics.CircuitBreakerFailures)
-
-	client := rabbitmq.NewClient("http://localhost:15672", "guest", "guest", 10*time.Second)
-	scrapeInterval := 15 * time.Second
-
-	collector := NewCollector(client, testMetrics, scrapeInterval)
-
-	// Test Describe method
-	descChan := make(chan *prometheus.Desc, 100)
-	collector.Describe(descChan)
-	close(descChan)
-
-	// Count descriptions
-	descCount := 0
-	for range descChan {
-		descCount++
-	}
-
-	// We should have descriptions for all our metrics
-	expectedDescCount := 19 // Total number of metrics
-	if descCount < expectedDescCount {
-		t.Errorf("Expected at least %d descriptions, got %d", expectedDescCount, descCount)
-	}
-}
-
-func TestCollector_Collect(t *testing.T) {
-	// Skip this test for now as it requires a full metrics setup
-	t.Skip("Skipping Collect test due to complexity")
-}
-
-func TestCollector_updateQueueMetrics(t *testing.T) {
-	// Skip this test for now as it requires a full metrics setup
-	t.Skip("Skipping updateQueueMetrics test due to complexity")
-}
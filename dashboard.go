@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rabbitmq-exporter/exporter"
+)
+
+// DashboardOptions controls the generated Grafana dashboard
+type DashboardOptions struct {
+	Title string
+	UID   string
+	// ClusterLabel is the label that tells clusters apart, e.g. an external
+	// label added by Prometheus. When set the dashboard gets a cluster
+	// variable and every query is filtered by it.
+	ClusterLabel string
+	// AnnotationTags adds an annotation query for events pushed by the
+	// Grafana annotation sink; annotations must carry all of them
+	AnnotationTags []string
+	// Namespace is the metric name prefix, defaulting to rabbitmq_custom
+	Namespace string
+	// OmitHealthScore drops the health score panel, for exporters with
+	// health_metrics off
+	OmitHealthScore bool
+}
+
+// Dashboard is the subset of the Grafana dashboard model the generator uses
+type Dashboard struct {
+	UID           string              `json:"uid,omitempty"`
+	Title         string              `json:"title"`
+	Tags          []string            `json:"tags"`
+	Timezone      string              `json:"timezone"`
+	SchemaVersion int                 `json:"schemaVersion"`
+	Refresh       string              `json:"refresh"`
+	Time          DashboardTime       `json:"time"`
+	Templating    DashboardTemplating `json:"templating"`
+	Annotations   DashboardAnnotation `json:"annotations"`
+	Panels        []DashboardPanel    `json:"panels"`
+}
+
+type DashboardTime struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type DashboardTemplating struct {
+	List []DashboardVariable `json:"list"`
+}
+
+type DashboardVariable struct {
+	Name       string               `json:"name"`
+	Label      string               `json:"label,omitempty"`
+	Type       string               `json:"type"`
+	Query      string               `json:"query"`
+	Datasource *DashboardDatasource `json:"datasource,omitempty"`
+	Refresh    int                  `json:"refresh,omitempty"`
+	IncludeAll bool                 `json:"includeAll"`
+	Multi      bool                 `json:"multi"`
+	AllValue   string               `json:"allValue,omitempty"`
+	Sort       int                  `json:"sort,omitempty"`
+}
+
+type DashboardAnnotation struct {
+	List []DashboardAnnotationQuery `json:"list"`
+}
+
+type DashboardAnnotationQuery struct {
+	Name       string               `json:"name"`
+	Datasource *DashboardDatasource `json:"datasource"`
+	Enable     bool                 `json:"enable"`
+	IconColor  string               `json:"iconColor"`
+	Target     map[string]any       `json:"target"`
+}
+
+type DashboardDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type DashboardPanel struct {
+	ID          int                  `json:"id"`
+	Type        string               `json:"type"`
+	Title       string               `json:"title"`
+	GridPos     DashboardGridPos     `json:"gridPos"`
+	Datasource  *DashboardDatasource `json:"datasource,omitempty"`
+	Targets     []DashboardTarget    `json:"targets,omitempty"`
+	FieldConfig *DashboardFieldConf  `json:"fieldConfig,omitempty"`
+}
+
+type DashboardGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type DashboardTarget struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+}
+
+type DashboardFieldConf struct {
+	Defaults DashboardFieldDefaults `json:"defaults"`
+}
+
+type DashboardFieldDefaults struct {
+	Unit string   `json:"unit,omitempty"`
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+}
+
+var dashboardCmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Print a Grafana dashboard for this exporter's metrics",
+	RunE:  runDashboard,
+}
+
+func init() {
+	dashboardCmd.Flags().String("config", "", "Path to config file (default: config.yaml)")
+	dashboardCmd.Flags().StringP("output", "o", "", "Write the dashboard to a file instead of stdout")
+	dashboardCmd.Flags().String("title", "RabbitMQ Queues", "Dashboard title")
+	dashboardCmd.Flags().String("uid", "rabbitmq-custom-exporter", "Dashboard UID")
+	dashboardCmd.Flags().String("cluster-label", "", "Label that identifies the cluster; adds a cluster variable when set")
+
+	rootCmd.AddCommand(dashboardCmd)
+}
+
+func runDashboard(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfig(configFile, false)
+	if err != nil {
+		return err
+	}
+	if err := checkGeneratedNaming(cfg.MetricNaming, "dashboard"); err != nil {
+		return err
+	}
+
+	opts := DashboardOptions{}
+	opts.Title, _ = cmd.Flags().GetString("title")
+	opts.UID, _ = cmd.Flags().GetString("uid")
+	opts.ClusterLabel, _ = cmd.Flags().GetString("cluster-label")
+	if opts.ClusterLabel == "" && cfg.ClusterLabel.Enabled {
+		opts.ClusterLabel = exporter.ClusterLabelName
+	}
+	opts.Namespace = cfg.MetricNamespace
+	opts.OmitHealthScore = !cfg.HealthMetrics
+	if cfg.GrafanaAnnotations.Enabled() {
+		opts.AnnotationTags = append([]string{"rabbitmq"}, cfg.GrafanaAnnotations.Tags...)
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if path, _ := cmd.Flags().GetString("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create dashboard file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(GenerateDashboard(opts))
+}
+
+// GenerateDashboard builds a dashboard wired to the exporter's metric names
+// and label sets
+func GenerateDashboard(opts DashboardOptions) Dashboard {
+	metric := metricNamer(opts.Namespace)
+	datasource := &DashboardDatasource{Type: "prometheus", UID: "${datasource}"}
+
+	variables := []DashboardVariable{
+		{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+	}
+
+	// Every selector is built from the variables that exist, so queries only
+	// filter by cluster when a cluster label is configured
+	var scope []string
+	if opts.ClusterLabel != "" {
+		variables = append(variables, labelVariable(datasource, "cluster", "Cluster",
+			fmt.Sprintf("label_values(%s, %s)", metric("up"), opts.ClusterLabel)))
+		scope = append(scope, fmt.Sprintf(`%s=~"$cluster"`, opts.ClusterLabel))
+	}
+	exporterSelector := selector(scope)
+
+	variables = append(variables, labelVariable(datasource, "vhost", "Virtual host",
+		fmt.Sprintf("label_values(%s%s, vhost)", metric("queue_messages_ready"), exporterSelector)))
+	scope = append(scope, `vhost=~"$vhost"`)
+	variables = append(variables, labelVariable(datasource, "queue", "Queue",
+		fmt.Sprintf("label_values(%s{%s}, queue_name)", metric("queue_messages_ready"), strings.Join(scope, ", "))))
+	scope = append(scope, `queue_name=~"$queue"`)
+	queueSelector := selector(scope)
+
+	queueLegend := "{{vhost}}/{{queue_name}}"
+	if opts.ClusterLabel != "" {
+		queueLegend = "{{" + opts.ClusterLabel + "}} " + queueLegend
+	}
+
+	b := &panelBuilder{datasource: datasource}
+
+	b.add("stat", "Exporter up", 4, 4, nil, DashboardTarget{Expr: "min(" + metric("up") + exporterSelector + ")"})
+	b.add("stat", "Data age", 4, 4, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "s"}},
+		DashboardTarget{Expr: "time() - max(" + metric("last_scrape_timestamp_seconds") + exporterSelector + ")"})
+	b.add("stat", "Messages", 4, 4, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "short"}},
+		DashboardTarget{Expr: "sum(" + metric("queue_messages_ready") + queueSelector + ") + sum(" + metric("queue_messages_unacknowledged") + queueSelector + ")"})
+	b.add("stat", "Consumers", 4, 4, nil, DashboardTarget{Expr: "sum(" + metric("queue_consumers") + queueSelector + ")"})
+	b.add("stat", "Circuit breakers open", 4, 4, nil, DashboardTarget{Expr: "sum(" + metric("circuit_breaker_state") + exporterSelector + ")"})
+	b.add("stat", "Scrape duration", 4, 4, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "s"}},
+		DashboardTarget{Expr: "max(" + metric("scrape_duration_seconds") + exporterSelector + ")"})
+
+	b.add("timeseries", "Messages ready", 12, 8, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "short"}},
+		DashboardTarget{Expr: metric("queue_messages_ready") + queueSelector, LegendFormat: queueLegend})
+	b.add("timeseries", "Messages unacknowledged", 12, 8, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "short"}},
+		DashboardTarget{Expr: metric("queue_messages_unacknowledged") + queueSelector, LegendFormat: queueLegend})
+	b.add("timeseries", "Publish rate", 12, 8, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "ops"}},
+		DashboardTarget{Expr: metric("queue_message_publish_rate") + queueSelector, LegendFormat: queueLegend})
+	b.add("timeseries", "Deliver rate", 12, 8, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "ops"}},
+		DashboardTarget{Expr: metric("queue_message_deliver_rate") + queueSelector, LegendFormat: queueLegend})
+	b.add("timeseries", "Consumer utilisation", 12, 8, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "percentunit", Min: floatPtr(0), Max: floatPtr(1)}},
+		DashboardTarget{Expr: metric("queue_consumer_utilisation") + queueSelector, LegendFormat: queueLegend})
+	if !opts.OmitHealthScore {
+		b.add("timeseries", "Health score", 12, 8, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Min: floatPtr(0), Max: floatPtr(100)}},
+			DashboardTarget{Expr: metric("queue_health_score") + queueSelector, LegendFormat: queueLegend})
+	}
+	b.add("timeseries", "Queue memory", 12, 8, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "bytes"}},
+		DashboardTarget{Expr: metric("queue_memory_bytes") + queueSelector, LegendFormat: queueLegend})
+	b.add("timeseries", "Redeliver rate", 12, 8, &DashboardFieldConf{Defaults: DashboardFieldDefaults{Unit: "ops"}},
+		DashboardTarget{Expr: metric("queue_message_redeliver_rate") + queueSelector, LegendFormat: queueLegend})
+
+	annotations := DashboardAnnotation{List: []DashboardAnnotationQuery{}}
+	if len(opts.AnnotationTags) > 0 {
+		annotations.List = append(annotations.List, DashboardAnnotationQuery{
+			Name:       "RabbitMQ events",
+			Datasource: &DashboardDatasource{Type: "grafana", UID: "-- Grafana --"},
+			Enable:     true,
+			IconColor:  "orange",
+			Target: map[string]any{
+				"type":     "tags",
+				"tags":     opts.AnnotationTags,
+				"matchAny": false,
+				"limit":    100,
+			},
+		})
+	}
+
+	return Dashboard{
+		UID:           opts.UID,
+		Title:         opts.Title,
+		Tags:          []string{"rabbitmq", "rabbitmq-custom-exporter"},
+		Timezone:      "browser",
+		SchemaVersion: 39,
+		Refresh:       "30s",
+		Time:          DashboardTime{From: "now-6h", To: "now"},
+		Templating:    DashboardTemplating{List: variables},
+		Annotations:   annotations,
+		Panels:        b.panels,
+	}
+}
+
+func selector(matchers []string) string {
+	if len(matchers) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(matchers, ", ") + "}"
+}
+
+func labelVariable(datasource *DashboardDatasource, name, label, query string) DashboardVariable {
+	return DashboardVariable{
+		Name:       name,
+		Label:      label,
+		Type:       "query",
+		Query:      query,
+		Datasource: datasource,
+		Refresh:    2,
+		IncludeAll: true,
+		Multi:      true,
+		AllValue:   ".*",
+		Sort:       1,
+	}
+}
+
+// panelBuilder lays panels out left to right on Grafana's 24 column grid
+type panelBuilder struct {
+	datasource *DashboardDatasource
+	panels     []DashboardPanel
+	x, y, rowH int
+}
+
+func (b *panelBuilder) add(kind, title string, w, h int, fieldConfig *DashboardFieldConf, targets ...DashboardTarget) {
+	if b.x+w > 24 {
+		b.x = 0
+		b.y += b.rowH
+		b.rowH = 0
+	}
+
+	for i := range targets {
+		targets[i].RefID = string(rune('A' + i))
+	}
+
+	b.panels = append(b.panels, DashboardPanel{
+		ID:          len(b.panels) + 1,
+		Type:        kind,
+		Title:       title,
+		GridPos:     DashboardGridPos{H: h, W: w, X: b.x, Y: b.y},
+		Datasource:  b.datasource,
+		Targets:     targets,
+		FieldConfig: fieldConfig,
+	})
+
+	b.x += w
+	if h > b.rowH {
+		b.rowH = h
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/metrics"
+)
+
+var fqNamePattern = regexp.MustCompile(`fqName: "([^"]+)"`)
+var metricRefPattern = regexp.MustCompile(`rabbitmq_custom_[a-z_]+`)
+
+// exportedMetricNames lists every metric the exporter can emit
+func exportedMetricNames() map[string]bool {
+	m := metrics.NewMetrics()
+
+	descs := append([]*prometheus.Desc{}, m.QueueDescs()...)
+	for _, c := range m.GetAllCollectors() {
+		ch := make(chan *prometheus.Desc, 16)
+		go func(c prometheus.Collector) {
+			c.Describe(ch)
+			close(ch)
+		}(c)
+		for d := range ch {
+			descs = append(descs, d)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, d := range descs {
+		if match := fqNamePattern.FindStringSubmatch(d.String()); match != nil {
+			names[match[1]] = true
+		}
+	}
+	return names
+}
+
+func TestGenerateDashboard_MetricNames(t *testing.T) {
+	exported := exportedMetricNames()
+	dashboard := GenerateDashboard(DashboardOptions{Title: "test"})
+
+	var exprs []string
+	for _, v := range dashboard.Templating.List {
+		exprs = append(exprs, v.Query)
+	}
+	for _, p := range dashboard.Panels {
+		for _, target := range p.Targets {
+			exprs = append(exprs, target.Expr)
+		}
+	}
+
+	for _, expr := range exprs {
+		for _, name := range metricRefPattern.FindAllString(expr, -1) {
+			if !exported[name] {
+				t.Errorf("Dashboard references unknown metric %s in %q", name, expr)
+			}
+		}
+	}
+}
+
+func TestGenerateDashboard_ClusterVariable(t *testing.T) {
+	dashboard := GenerateDashboard(DashboardOptions{})
+	for _, v := range dashboard.Templating.List {
+		if v.Name == "cluster" {
+			t.Error("Expected no cluster variable without a cluster label")
+		}
+	}
+
+	dashboard = GenerateDashboard(DashboardOptions{ClusterLabel: "rabbitmq_cluster"})
+	var found bool
+	for _, v := range dashboard.Templating.List {
+		if v.Name == "cluster" {
+			found = true
+			if !strings.Contains(v.Query, "rabbitmq_cluster") {
+				t.Errorf("Cluster variable doesn't use the cluster label: %q", v.Query)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("Expected a cluster variable")
+	}
+
+	for _, p := range dashboard.Panels {
+		for _, target := range p.Targets {
+			if !strings.Contains(target.Expr, `rabbitmq_cluster=~"$cluster"`) {
+				t.Errorf("Panel %q isn't filtered by cluster: %q", p.Title, target.Expr)
+			}
+		}
+	}
+}
+
+func TestGenerateDashboard_Layout(t *testing.T) {
+	dashboard := GenerateDashboard(DashboardOptions{})
+
+	ids := make(map[int]bool)
+	for i, a := range dashboard.Panels {
+		if ids[a.ID] {
+			t.Errorf("Duplicate panel id %d", a.ID)
+		}
+		ids[a.ID] = true
+
+		if a.GridPos.X+a.GridPos.W > 24 {
+			t.Errorf("Panel %q overflows the grid: %+v", a.Title, a.GridPos)
+		}
+		for _, b := range dashboard.Panels[i+1:] {
+			if a.GridPos.X < b.GridPos.X+b.GridPos.W && b.GridPos.X < a.GridPos.X+a.GridPos.W &&
+				a.GridPos.Y < b.GridPos.Y+b.GridPos.H && b.GridPos.Y < a.GridPos.Y+a.GridPos.H {
+				t.Errorf("Panels %q and %q overlap", a.Title, b.Title)
+			}
+		}
+	}
+}
+
+func TestGenerateDashboard_OmitHealthScore(t *testing.T) {
+	dashboard := GenerateDashboard(DashboardOptions{OmitHealthScore: true})
+	for _, p := range dashboard.Panels {
+		if p.Title == "Health score" {
+			t.Error("Expected no health score panel")
+		}
+	}
+}
+
+func TestGenerateDashboard_Annotations(t *testing.T) {
+	dashboard := GenerateDashboard(DashboardOptions{AnnotationTags: []string{"rabbitmq", "prod"}})
+	if len(dashboard.Annotations.List) != 1 {
+		t.Fatalf("Expected one annotation query, got %d", len(dashboard.Annotations.List))
+	}
+
+	data, err := json.Marshal(dashboard)
+	if err != nil {
+		t.Fatalf("Failed to marshal dashboard: %v", err)
+	}
+	if !strings.Contains(string(data), `"tags":["rabbitmq","prod"]`) {
+		t.Errorf("Annotation query missing tags: %s", data)
+	}
+}
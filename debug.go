@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"rabbitmq-exporter/exporter"
+)
+
+// newRegistry creates the registry served on /metrics, optionally including
+// the Go runtime and process collectors
+func newRegistry(runtimeMetrics bool) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	if runtimeMetrics {
+		registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+	return registry
+}
+
+// registerPprofHandlers mounts net/http/pprof under /debug/pprof/. When admin
+// credentials are configured the profiles require them, since goroutine dumps
+// and heap profiles expose exporter internals.
+func registerPprofHandlers(mux *http.ServeMux, config exporter.Config) {
+	wrap := func(h http.HandlerFunc) http.HandlerFunc {
+		if config.AdminUsername == "" || config.AdminPassword == "" {
+			return h
+		}
+		return requireAdmin(config.AdminUsername, config.AdminPassword, h)
+	}
+
+	mux.HandleFunc("/debug/pprof/", wrap(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", wrap(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", wrap(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", wrap(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", wrap(pprof.Trace))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rabbitmq-exporter/exporter"
+)
+
+func TestNewRegistry_RuntimeMetrics(t *testing.T) {
+	hasGoroutines := func(runtimeMetrics bool) bool {
+		families, err := newRegistry(runtimeMetrics).Gather()
+		if err != nil {
+			t.Fatalf("Gather failed: %v", err)
+		}
+		for _, f := range families {
+			if f.GetName() == "go_goroutines" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !hasGoroutines(true) {
+		t.Error("Expected go_goroutines with runtime metrics enabled")
+	}
+	if hasGoroutines(false) {
+		t.Error("Expected no go_goroutines with runtime metrics disabled")
+	}
+}
+
+func TestPprofHandlers(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   exporter.Config
+		auth     bool
+		expected int
+	}{
+		{name: "Open without admin credentials", config: exporter.Config{}, expected: http.StatusOK},
+		{name: "Requires admin credentials", config: exporter.Config{AdminUsername: "admin", AdminPassword: "secret"}, expected: http.StatusUnauthorized},
+		{name: "Authorized", config: exporter.Config{AdminUsername: "admin", AdminPassword: "secret"}, auth: true, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			registerPprofHandlers(mux, tt.config)
+
+			req := httptest.NewRequest(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil)
+			if tt.auth {
+				req.SetBasicAuth("admin", "secret")
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveKeys are JSON object keys, matched as substrings of the
+// lowercased key, whose values are never written to a dump
+var sensitiveKeys = []string{"password", "passphrase", "secret", "token", "api_key", "authorization"}
+
+// DumpManifest describes a debug dump
+type DumpManifest struct {
+	ExporterVersion string            `json:"exporter_version"`
+	RabbitMQURL     string            `json:"rabbitmq_url"`
+	CreatedAt       time.Time         `json:"created_at"`
+	Files           map[string]string `json:"files"`
+	Errors          map[string]string `json:"errors,omitempty"`
+}
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Troubleshooting tools",
+}
+
+var debugDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Write the raw management API responses to disk for support tickets",
+	Long: `Fetches /api/overview, /api/queues and every other endpoint the configured
+features use, and writes the JSON responses to a new directory. Passwords,
+tokens and credentials in URLs are redacted.`,
+	SilenceUsage: true,
+	RunE:         runDebugDump,
+}
+
+func init() {
+	debugDumpCmd.Flags().String("config", "", "Path to config file (default: config.yaml)")
+	debugDumpCmd.Flags().String("output-dir", ".", "Directory to create the dump in")
+	debugDumpCmd.Flags().Bool("all", false, "Dump every endpoint, not only the ones enabled features use")
+
+	debugCmd.AddCommand(debugDumpCmd)
+	rootCmd.AddCommand(debugCmd)
+}
+
+func runDebugDump(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("config")
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	all, _ := cmd.Flags().GetBool("all")
+
+	cfg, err := loadConfig(configFile, false)
+	if err != nil {
+		return err
+	}
+
+	client, closeClient, err := newRabbitMQClient(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	now := time.Now().UTC()
+	dir := filepath.Join(outputDir, "rabbitmq-dump-"+now.Format("20060102T150405Z"))
+
+	manifest, err := writeDump(cmd.Context(), client, dir, dumpEndpoints(cfg, all), cfg.Timeout)
+	if err != nil {
+		return err
+	}
+	manifest.ExporterVersion = Version
+	manifest.RabbitMQURL = exporter.RedactURL(cfg.RabbitMQURL)
+	manifest.CreatedAt = now
+
+	if err := writeDumpFile(filepath.Join(dir, "manifest.json"), manifest); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d endpoints to %s\n", len(manifest.Files), dir)
+	if len(manifest.Errors) > 0 {
+		return fmt.Errorf("%d endpoints failed, see %s", len(manifest.Errors), filepath.Join(dir, "manifest.json"))
+	}
+	return nil
+}
+
+// dumpEndpoints returns the endpoints the collector would call with cfg
+func dumpEndpoints(cfg exporter.Config, all bool) []string {
+	endpoints := []string{rabbitmq.EndpointOverview}
+	if all || cfg.Collect.Queues {
+		endpoints = append(endpoints, rabbitmq.EndpointQueues)
+	}
+	if all || cfg.Collect.Nodes {
+		endpoints = append(endpoints, rabbitmq.EndpointNodes)
+	}
+	if all || (cfg.Collect.Queues && (len(cfg.DeadLetter.Exchanges) > 0 || cfg.Collect.Bindings)) {
+		endpoints = append(endpoints, rabbitmq.EndpointBindings)
+	}
+	if all || (cfg.Collect.Queues && cfg.Collect.Policies) {
+		endpoints = append(endpoints, rabbitmq.EndpointPolicies, rabbitmq.EndpointOperatorPolicies)
+	}
+	if all || cfg.Collect.Channels {
+		endpoints = append(endpoints, rabbitmq.EndpointChannels)
+	}
+	if all || cfg.Collect.Connections {
+		endpoints = append(endpoints, rabbitmq.EndpointConnections)
+	}
+	if all || (cfg.Collect.Queues && cfg.Collect.Consumers) {
+		endpoints = append(endpoints, rabbitmq.EndpointConsumers)
+	}
+	// Aliveness tests and health checks aren't listings: each call runs a
+	// check against one vhost or listener, and aliveness tests publish a
+	// message, so they are left out
+	return endpoints
+}
+
+// writeDump fetches each endpoint into dir/<endpoint>.json. Failed
+// endpoints are recorded in the manifest rather than aborting the dump.
+func writeDump(ctx context.Context, client *rabbitmq.Client, dir string, endpoints []string, timeout time.Duration) (DumpManifest, error) {
+	manifest := DumpManifest{Files: make(map[string]string)}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return manifest, fmt.Errorf("failed to create dump directory: %w", err)
+	}
+
+	for _, endpoint := range endpoints {
+		reqCtx, cancel := context.WithTimeout(ctx, timeout)
+		body, err := client.GetRaw(reqCtx, endpoint)
+		cancel()
+		if err == nil {
+			body, err = redactJSON(body)
+		}
+		if err == nil {
+			name := endpoint + ".json"
+			err = os.WriteFile(filepath.Join(dir, name), body, 0o600)
+			manifest.Files[endpoint] = name
+		}
+		if err != nil {
+			if manifest.Errors == nil {
+				manifest.Errors = make(map[string]string)
+			}
+			manifest.Errors[endpoint] = err.Error()
+			delete(manifest.Files, endpoint)
+		}
+	}
+
+	return manifest, nil
+}
+
+func writeDumpFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// redactJSON replaces the values of sensitive keys and the passwords of
+// URLs anywhere in a JSON document, and indents it for reading
+func redactJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	out, err := json.MarshalIndent(redactValue(doc), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSensitiveKey(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(value)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+		return v
+	case string:
+		return exporter.RedactURL(v)
+	default:
+		return v
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"rabbitmq-exporter/exporter"
+)
+
+// redactedConfig renders cfg under its config file keys, with durations as
+// strings and secrets redacted the same way debug dumps redact them
+func redactedConfig(cfg exporter.Config) map[string]interface{} {
+	return redactValue(configValue(reflect.ValueOf(cfg), "")).(map[string]interface{})
+}
+
+func configValue(v reflect.Value, key string) interface{} {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		configFields(v, fields)
+		return fields
+	case reflect.Map:
+		entries := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			// Request headers, such as the OTLP ones, usually carry a token
+			if strings.HasSuffix(key, "headers") {
+				entries[k.String()] = redacted
+				continue
+			}
+			entries[k.String()] = configValue(v.MapIndex(k), k.String())
+		}
+		return entries
+	case reflect.Slice:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = configValue(v.Index(i), key)
+		}
+		return items
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return configValue(v.Elem(), key)
+	default:
+		return v.Interface()
+	}
+}
+
+// configFields adds v's fields under their mapstructure names, flattening
+// squashed structs into fields
+func configFields(v reflect.Value, fields map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if opts == "squash" {
+			configFields(v.Field(i), fields)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = configValue(v.Field(i), name)
+	}
+}
+
+// configHash identifies the effective configuration, so pods can be
+// compared without diffing whole configs. Secrets are left out, as they are
+// everywhere else.
+func configHash(cfg exporter.Config) string {
+	data, _ := json.Marshal(redactedConfig(cfg))
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// configInfoValues returns the values of rabbitmq_custom_exporter_config_info,
+// in metrics.ConfigInfoLabels order
+func configInfoValues(cfg exporter.Config, configFile string) []string {
+	profile := cfg.Profile
+	if profile == "" {
+		profile = "none"
+	}
+	return []string{
+		configFile,
+		configHash(cfg),
+		exporter.RedactURL(cfg.RabbitMQURL),
+		cfg.ScrapeInterval.String(),
+		cfg.Timeout.String(),
+		cfg.CollectionTimeout.String(),
+		cfg.MetricNaming,
+		profile,
+		strings.Join(cfg.Collect.Enabled(), ","),
+		strconv.Itoa(cfg.MaxConcurrentRequests),
+	}
+}
+
+// configHandler serves the effective configuration, after defaults,
+// profiles, environment variables, flags and reloads have been applied
+func configHandler(current func() exporter.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, redactedConfig(current()))
+	}
+}
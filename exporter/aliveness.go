@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// alivenessResult is the outcome of one vhost's aliveness test
+type alivenessResult struct {
+	Vhost    string
+	OK       bool
+	Duration time.Duration
+}
+
+// WithAlivenessVhosts runs the management API aliveness test in each vhost
+// on every collection
+func WithAlivenessVhosts(vhosts []string) CollectorOption {
+	return func(c *Collector) {
+		c.alivenessVhosts = vhosts
+	}
+}
+
+// runAlivenessTests tests the configured vhosts concurrently. A failing
+// vhost doesn't stop the others from being tested.
+func (c *Collector) runAlivenessTests(ctx context.Context) []alivenessResult {
+	results := make([]alivenessResult, len(c.alivenessVhosts))
+	var wg sync.WaitGroup
+	for i, vhost := range c.alivenessVhosts {
+		wg.Add(1)
+		go func(i int, vhost string) {
+			defer wg.Done()
+			// Only the request itself is timed, not the wait for a
+			// request slot
+			var elapsed time.Duration
+			err := c.client.AlivenessTest(rabbitmq.WithRequestTime(ctx, &elapsed), vhost)
+			if err != nil {
+				log.Printf("Aliveness test in vhost %q failed: %v", vhost, err)
+			}
+			results[i] = alivenessResult{Vhost: vhost, OK: err == nil, Duration: elapsed}
+		}(i, vhost)
+	}
+	wg.Wait()
+	return results
+}
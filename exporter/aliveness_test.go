@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_Aliveness(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/queues", "/api/nodes":
+			w.Write([]byte(`[]`))
+		case "/api/aliveness-test/%2F":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/api/aliveness-test/payments":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithAlivenessVhosts([]string{"/", "payments"}))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_aliveness_success Whether the last aliveness test, which publishes and consumes a message, succeeded in the vhost (1 = success)
+# TYPE rabbitmq_custom_aliveness_success gauge
+rabbitmq_custom_aliveness_success{vhost="/"} 1
+rabbitmq_custom_aliveness_success{vhost="payments"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_aliveness_success"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_aliveness_duration_seconds"); n != 2 {
+		t.Errorf("Expected a duration for each vhost, got %d", n)
+	}
+}
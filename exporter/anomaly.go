@@ -0,0 +1,150 @@
+package exporter
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+const (
+	DefaultAnomalyWindow     = time.Hour
+	DefaultAnomalyThreshold  = 3.0
+	DefaultAnomalyMinSamples = 20
+)
+
+// AnomalyConfig configures depth anomaly detection against each queue's own
+// baseline
+type AnomalyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Window is roughly how far back the baseline looks. Older samples
+	// still count, with exponentially decreasing weight.
+	Window time.Duration `mapstructure:"window"`
+	// Threshold is the absolute z-score from which depth is anomalous
+	Threshold float64 `mapstructure:"threshold"`
+	// MinSamples is the number of collections before a queue is scored
+	MinSamples int `mapstructure:"min_samples"`
+}
+
+// DepthScore is a queue's depth relative to its baseline
+type DepthScore struct {
+	ZScore  float64
+	Anomaly bool
+}
+
+type depthBaseline struct {
+	mean     float64
+	variance float64
+	samples  int
+	score    DepthScore
+	scored   bool
+}
+
+// DepthBaselines keeps an exponentially weighted mean and variance of every
+// queue's depth, so depth can be judged against what is normal for that
+// queue rather than a static threshold
+type DepthBaselines struct {
+	alpha      float64
+	threshold  float64
+	minSamples int
+
+	mu     sync.Mutex
+	queues map[string]map[string]*depthBaseline
+}
+
+// NewDepthBaselines sizes the baseline window in collections of
+// scrapeInterval
+func NewDepthBaselines(cfg AnomalyConfig, scrapeInterval time.Duration) (*DepthBaselines, error) {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultAnomalyWindow
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = DefaultAnomalyThreshold
+	}
+	if cfg.MinSamples == 0 {
+		cfg.MinSamples = DefaultAnomalyMinSamples
+	}
+	if cfg.Threshold < 0 {
+		return nil, fmt.Errorf("anomaly threshold must be positive, got %g", cfg.Threshold)
+	}
+	if cfg.MinSamples < 0 {
+		return nil, fmt.Errorf("anomaly min_samples must not be negative, got %d", cfg.MinSamples)
+	}
+	if scrapeInterval <= 0 {
+		return nil, fmt.Errorf("anomaly detection needs a positive scrape interval")
+	}
+
+	// The usual EWMA span: the weight of a window's worth of samples adds up
+	// to about 86%
+	samples := math.Max(float64(cfg.Window)/float64(scrapeInterval), 1)
+	return &DepthBaselines{
+		alpha:      2 / (samples + 1),
+		threshold:  cfg.Threshold,
+		minSamples: cfg.MinSamples,
+		queues:     make(map[string]map[string]*depthBaseline),
+	}, nil
+}
+
+// Observe scores every queue's depth against its baseline, then folds the
+// depth into it. Baselines of queues that no longer exist are dropped.
+// Streams only grow, so they are not tracked.
+func (b *DepthBaselines) Observe(queues []rabbitmq.Queue) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seen := make(map[string]map[string]*depthBaseline, len(b.queues))
+	for _, q := range queues {
+		if q.IsStreamQueue() {
+			continue
+		}
+
+		baseline := b.queues[q.Vhost][q.Name]
+		if baseline == nil {
+			baseline = &depthBaseline{mean: float64(q.Messages)}
+		}
+		b.update(baseline, float64(q.Messages))
+
+		if seen[q.Vhost] == nil {
+			seen[q.Vhost] = make(map[string]*depthBaseline)
+		}
+		seen[q.Vhost][q.Name] = baseline
+	}
+	b.queues = seen
+}
+
+func (b *DepthBaselines) update(baseline *depthBaseline, depth float64) {
+	if baseline.samples >= b.minSamples {
+		// Queue depth is a whole number of messages, so a queue that has
+		// been perfectly steady still needs to deviate by more than a few
+		// messages to be anomalous
+		stddev := math.Max(math.Sqrt(baseline.variance), 1)
+		z := (depth - baseline.mean) / stddev
+		baseline.score = DepthScore{ZScore: z, Anomaly: math.Abs(z) >= b.threshold}
+		baseline.scored = true
+	}
+
+	diff := depth - baseline.mean
+	incr := b.alpha * diff
+	baseline.mean += incr
+	baseline.variance = (1 - b.alpha) * (baseline.variance + diff*incr)
+	baseline.samples++
+}
+
+// Score returns the queue's score from the last collection. It returns false
+// until the queue has MinSamples collections of history.
+func (b *DepthBaselines) Score(vhost, name string) (DepthScore, bool) {
+	if b == nil {
+		return DepthScore{}, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	baseline := b.queues[vhost][name]
+	if baseline == nil || !baseline.scored {
+		return DepthScore{}, false
+	}
+	return baseline.score, true
+}
@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDepthBaselines_FlagsDeviationFromOwnBaseline(t *testing.T) {
+	baselines, err := NewDepthBaselines(AnomalyConfig{Window: 10 * time.Minute, MinSamples: 5}, 15*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both queues fluctuate around very different normal depths
+	for i := 0; i < 60; i++ {
+		jitter := int64(i%3 - 1)
+		baselines.Observe([]rabbitmq.Queue{
+			{Name: "small", Vhost: "/", Messages: 10 + jitter},
+			{Name: "large", Vhost: "/", Messages: 100000 + 1000*jitter},
+		})
+	}
+	for _, name := range []string{"small", "large"} {
+		if score, ok := baselines.Score("/", name); !ok || score.Anomaly {
+			t.Errorf("Expected %s to be normal, got %+v (scored %v)", name, score, ok)
+		}
+	}
+
+	// 500 messages is nothing for the large queue but a spike for the small
+	baselines.Observe([]rabbitmq.Queue{
+		{Name: "small", Vhost: "/", Messages: 500},
+		{Name: "large", Vhost: "/", Messages: 100500},
+	})
+	if score, _ := baselines.Score("/", "small"); !score.Anomaly || score.ZScore <= 0 {
+		t.Errorf("Expected the small queue's spike to be anomalous, got %+v", score)
+	}
+	if score, _ := baselines.Score("/", "large"); score.Anomaly {
+		t.Errorf("Expected the large queue to stay normal, got %+v", score)
+	}
+}
+
+func TestDepthBaselines_WarmUpAndPruning(t *testing.T) {
+	baselines, err := NewDepthBaselines(AnomalyConfig{MinSamples: 3}, 15*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queues := []rabbitmq.Queue{
+		{Name: "orders", Vhost: "/", Messages: 5},
+		{Name: "events", Vhost: "/", Type: "stream", Messages: 5},
+	}
+	for i := 0; i < 3; i++ {
+		baselines.Observe(queues)
+		if _, ok := baselines.Score("/", "orders"); ok {
+			t.Fatalf("Expected no score during warm-up, sample %d", i+1)
+		}
+	}
+	baselines.Observe(queues)
+	if score, ok := baselines.Score("/", "orders"); !ok || score.ZScore != 0 {
+		t.Errorf("Expected a zero score for a steady queue, got %+v (scored %v)", score, ok)
+	}
+	if _, ok := baselines.Score("/", "events"); ok {
+		t.Error("Expected streams not to be scored")
+	}
+
+	baselines.Observe(nil)
+	if _, ok := baselines.Score("/", "orders"); ok {
+		t.Error("Expected the baseline of a removed queue to be dropped")
+	}
+}
+
+func TestNewDepthBaselines_Validation(t *testing.T) {
+	if _, err := NewDepthBaselines(AnomalyConfig{Threshold: -1}, time.Second); err == nil {
+		t.Error("Expected an error for a negative threshold")
+	}
+	if _, err := NewDepthBaselines(AnomalyConfig{}, 0); err == nil {
+		t.Error("Expected an error without a scrape interval")
+	}
+}
+
+func TestCollector_DepthAnomaly(t *testing.T) {
+	collector, _ := newTestCollector(t, `[{"name":"orders","vhost":"/","messages":7}]`)
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_depth_zscore"); n != 0 {
+		t.Errorf("Expected no z-score without anomaly detection, got %d", n)
+	}
+
+	baselines, _ := NewDepthBaselines(AnomalyConfig{MinSamples: 1}, time.Second)
+	collector.depthBaselines = baselines
+	collector.collectQueueData()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_depth_anomaly Queue depth anomaly indicator (1 if the depth z-score exceeds the anomaly threshold, 0 otherwise)
+# TYPE rabbitmq_custom_queue_depth_anomaly gauge
+rabbitmq_custom_queue_depth_anomaly{queue_name="orders",type="classic",vhost="/"} 0
+# HELP rabbitmq_custom_queue_depth_zscore Standard deviations between the queue's depth and its rolling baseline
+# TYPE rabbitmq_custom_queue_depth_zscore gauge
+rabbitmq_custom_queue_depth_zscore{queue_name="orders",type="classic",vhost="/"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_depth_anomaly", "rabbitmq_custom_queue_depth_zscore"); err != nil {
+		t.Error(err)
+	}
+}
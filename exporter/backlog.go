@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// DefaultBacklogGrowthCollections is how many consecutive collections a
+// queue's backlog must grow for before it is flagged as growing
+const DefaultBacklogGrowthCollections = 5
+
+// WithBacklogGrowthCollections sets how many consecutive collections with a
+// positive net growth rate flag a queue's backlog as growing. Non-positive
+// values keep DefaultBacklogGrowthCollections.
+func WithBacklogGrowthCollections(n int) CollectorOption {
+	return func(c *Collector) {
+		if n > 0 {
+			c.growthCollections = n
+		}
+	}
+}
+
+// netGrowthRate is how fast the queue's backlog grows: messages published
+// per second minus messages consumed, with or without acks
+func netGrowthRate(queue rabbitmq.Queue) float64 {
+	return queue.GetPublishRate() - queue.GetConsumeRate()
+}
+
+// growthTracker counts, per queue key, the consecutive collections in which
+// the queue's backlog grew
+type growthTracker struct {
+	streaks map[string]int
+}
+
+func newGrowthTracker() *growthTracker {
+	return &growthTracker{streaks: make(map[string]int)}
+}
+
+// Observe extends the streak of every queue that is growing and resets the
+// others. Deleted queues are forgotten. The returned map is not modified
+// afterwards.
+func (t *growthTracker) Observe(queues []rabbitmq.Queue) map[string]int {
+	streaks := make(map[string]int, len(queues))
+	for _, q := range queues {
+		key := queueKey(q)
+		if netGrowthRate(q) > 0 {
+			streaks[key] = t.streaks[key] + 1
+		} else {
+			streaks[key] = 0
+		}
+	}
+	t.streaks = streaks
+	return streaks
+}
+
+func (c *Collector) collectBacklogGrowthMetrics(ch chan<- prometheus.Metric, queue rabbitmq.Queue, labels []string) {
+	emitGauge(ch, c.metrics.QueueNetGrowthRate, netGrowthRate(queue), labels...)
+
+	c.mu.RLock()
+	streak := c.growthStreaks[queueKey(queue)]
+	c.mu.RUnlock()
+	growing := 0.0
+	if streak >= c.growthCollections {
+		growing = 1.0
+	}
+	emitGauge(ch, c.metrics.QueueBacklogGrowing, growing, labels...)
+}
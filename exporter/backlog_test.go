@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func growingQueue(name string, publish, consume float64) rabbitmq.Queue {
+	return rabbitmq.Queue{Name: name, Vhost: "/", MessageStats: &rabbitmq.MessageStats{
+		PublishDetails:      &rabbitmq.RateDetails{Rate: publish},
+		DeliverDetails:      &rabbitmq.RateDetails{Rate: consume / 2},
+		DeliverNoAckDetails: &rabbitmq.RateDetails{Rate: consume / 2},
+	}}
+}
+
+func TestGrowthTracker_Observe(t *testing.T) {
+	tracker := newGrowthTracker()
+	tracker.Observe([]rabbitmq.Queue{growingQueue("orders", 10, 4), growingQueue("jobs", 10, 4)})
+	streaks := tracker.Observe([]rabbitmq.Queue{growingQueue("orders", 10, 4), growingQueue("jobs", 4, 10)})
+	if streaks["orders@/"] != 2 || streaks["jobs@/"] != 0 {
+		t.Errorf("Expected streaks of 2 and 0, got %v", streaks)
+	}
+
+	streaks = tracker.Observe([]rabbitmq.Queue{growingQueue("jobs", 10, 4)})
+	if _, ok := streaks["orders@/"]; ok || streaks["jobs@/"] != 1 {
+		t.Errorf("Expected deleted queues to be forgotten and streaks to restart, got %v", streaks)
+	}
+}
+
+func TestCollector_BacklogGrowthMetrics(t *testing.T) {
+	client := &exportertest.Client{Queues: []rabbitmq.Queue{growingQueue("orders", 10, 4), growingQueue("jobs", 4, 4)}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithBacklogGrowthCollections(2))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP rabbitmq_custom_queue_backlog_growing Whether the queue's net growth rate has been positive for backlog_growth_collections consecutive collections (1 = growing)
+# TYPE rabbitmq_custom_queue_backlog_growing gauge
+rabbitmq_custom_queue_backlog_growing{queue_name="jobs",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_backlog_growing{queue_name="orders",type="classic",vhost="/"} 0
+`), "rabbitmq_custom_queue_backlog_growing"); err != nil {
+		t.Error(err)
+	}
+
+	collector.collectQueueData()
+	expected := `
+# HELP rabbitmq_custom_queue_backlog_growing Whether the queue's net growth rate has been positive for backlog_growth_collections consecutive collections (1 = growing)
+# TYPE rabbitmq_custom_queue_backlog_growing gauge
+rabbitmq_custom_queue_backlog_growing{queue_name="jobs",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_backlog_growing{queue_name="orders",type="classic",vhost="/"} 1
+# HELP rabbitmq_custom_queue_net_growth_rate Messages published per second minus messages consumed per second, with or without acks; positive while the backlog grows
+# TYPE rabbitmq_custom_queue_net_growth_rate gauge
+rabbitmq_custom_queue_net_growth_rate{queue_name="jobs",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_net_growth_rate{queue_name="orders",type="classic",vhost="/"} 6
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_backlog_growing", "rabbitmq_custom_queue_net_growth_rate"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,21 @@
+package exporter
+
+import "rabbitmq-exporter/rabbitmq"
+
+// countQueueBindings counts the bindings of each queue by vhost and queue
+// name. Bindings from the default exchange are implicit for every queue, so
+// they don't count: a queue without other bindings only receives messages
+// published to it by name.
+func countQueueBindings(bindings []rabbitmq.Binding) map[string]map[string]int {
+	counts := make(map[string]map[string]int)
+	for _, b := range bindings {
+		if b.DestinationType != "queue" || b.Source == "" {
+			continue
+		}
+		if counts[b.Vhost] == nil {
+			counts[b.Vhost] = make(map[string]int)
+		}
+		counts[b.Vhost][b.Destination]++
+	}
+	return counts
+}
@@ -0,0 +1,72 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCountQueueBindings(t *testing.T) {
+	counts := countQueueBindings([]rabbitmq.Binding{
+		{Source: "", Vhost: "/", Destination: "orders", DestinationType: "queue"},
+		{Source: "events", Vhost: "/", Destination: "orders", DestinationType: "queue", RoutingKey: "order.created"},
+		{Source: "events", Vhost: "/", Destination: "orders", DestinationType: "queue", RoutingKey: "order.updated"},
+		{Source: "events", Vhost: "/", Destination: "audit", DestinationType: "exchange"},
+		{Source: "", Vhost: "/", Destination: "orphan", DestinationType: "queue"},
+	})
+
+	if counts["/"]["orders"] != 2 {
+		t.Errorf("Expected 2 bindings for orders, got %d", counts["/"]["orders"])
+	}
+	if n, ok := counts["/"]["orphan"]; ok {
+		t.Errorf("Expected the default exchange binding not to count, got %d", n)
+	}
+	if _, ok := counts["/"]["audit"]; ok {
+		t.Error("Expected exchange-to-exchange bindings to be ignored")
+	}
+}
+
+func TestCollector_Bindings(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues":
+			w.Write([]byte(`[{"name":"orders","vhost":"/"},{"name":"orphan","vhost":"/"}]`))
+		case "/api/bindings":
+			w.Write([]byte(`[
+				{"source":"","vhost":"/","destination":"orders","destination_type":"queue","routing_key":"orders"},
+				{"source":"","vhost":"/","destination":"orphan","destination_type":"queue","routing_key":"orphan"},
+				{"source":"events","vhost":"/","destination":"orders","destination_type":"queue","routing_key":"#"}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithCollectGroups(CollectGroups{Queues: true, Bindings: true}))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_bindings Number of bindings to the queue, excluding the implicit default exchange binding
+# TYPE rabbitmq_custom_queue_bindings gauge
+rabbitmq_custom_queue_bindings{queue_name="orders",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_bindings{queue_name="orphan",type="classic",vhost="/"} 0
+# HELP rabbitmq_custom_queue_unbound Indicates if the queue has no bindings besides the default exchange (1 if true, 0 if false)
+# TYPE rabbitmq_custom_queue_unbound gauge
+rabbitmq_custom_queue_unbound{queue_name="orders",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_unbound{queue_name="orphan",type="classic",vhost="/"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_bindings", "rabbitmq_custom_queue_unbound"); err != nil {
+		t.Error(err)
+	}
+}
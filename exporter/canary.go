@@ -0,0 +1,121 @@
+package exporter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+const DefaultCanaryMaxSilence = 5 * time.Minute
+
+// CanaryConfig describes a queue that an upstream producer publishes
+// heartbeats to
+type CanaryConfig struct {
+	Pipeline   string        `mapstructure:"pipeline"`
+	Vhost      string        `mapstructure:"vhost"`
+	Queue      string        `mapstructure:"queue"`
+	MaxSilence time.Duration `mapstructure:"max_silence"`
+}
+
+// CanaryStatus reports producer liveness for a single canary queue
+type CanaryStatus struct {
+	Pipeline    string
+	Vhost       string
+	Queue       string
+	Found       bool
+	SinceChange time.Duration
+	Alive       bool
+}
+
+type canaryState struct {
+	config     CanaryConfig
+	found      bool
+	messages   int64
+	published  int64
+	lastChange time.Time
+}
+
+// CanaryTracker records when each canary queue last showed producer activity,
+// either as a change in message count or in the cumulative publish counter
+type CanaryTracker struct {
+	mu       sync.Mutex
+	canaries []*canaryState
+}
+
+func NewCanaryTracker(configs []CanaryConfig, now time.Time) (*CanaryTracker, error) {
+	t := &CanaryTracker{}
+
+	for _, cfg := range configs {
+		if cfg.Queue == "" {
+			return nil, fmt.Errorf("canary queue for pipeline %q has no queue name", cfg.Pipeline)
+		}
+		if cfg.Pipeline == "" {
+			cfg.Pipeline = cfg.Queue
+		}
+		if cfg.Vhost == "" {
+			cfg.Vhost = "/"
+		}
+		if cfg.MaxSilence <= 0 {
+			cfg.MaxSilence = DefaultCanaryMaxSilence
+		}
+		t.canaries = append(t.canaries, &canaryState{config: cfg, lastChange: now})
+	}
+
+	return t, nil
+}
+
+// Observe updates canary state from a fresh queue snapshot
+func (t *CanaryTracker) Observe(queues []rabbitmq.Queue, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, canary := range t.canaries {
+		var queue *rabbitmq.Queue
+		for i := range queues {
+			if queues[i].Name == canary.config.Queue && queues[i].Vhost == canary.config.Vhost {
+				queue = &queues[i]
+				break
+			}
+		}
+
+		if queue == nil {
+			canary.found = false
+			continue
+		}
+
+		published := int64(0)
+		if queue.MessageStats != nil {
+			published = queue.MessageStats.Publish
+		}
+
+		if canary.found && (queue.Messages != canary.messages || published != canary.published) {
+			canary.lastChange = now
+		}
+
+		canary.found = true
+		canary.messages = queue.Messages
+		canary.published = published
+	}
+}
+
+// Status returns the liveness of every configured canary as of now
+func (t *CanaryTracker) Status(now time.Time) []CanaryStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]CanaryStatus, 0, len(t.canaries))
+	for _, canary := range t.canaries {
+		since := now.Sub(canary.lastChange)
+		statuses = append(statuses, CanaryStatus{
+			Pipeline:    canary.config.Pipeline,
+			Vhost:       canary.config.Vhost,
+			Queue:       canary.config.Queue,
+			Found:       canary.found,
+			SinceChange: since,
+			Alive:       canary.found && since <= canary.config.MaxSilence,
+		})
+	}
+	return statuses
+}
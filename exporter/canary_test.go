@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCanaryTracker(t *testing.T) {
+	start := time.Now()
+	tracker, err := NewCanaryTracker([]CanaryConfig{
+		{Pipeline: "orders", Queue: "orders.heartbeat", MaxSilence: time.Minute},
+	}, start)
+	if err != nil {
+		t.Fatalf("Expected tracker to be created, got %v", err)
+	}
+
+	queue := rabbitmq.Queue{
+		Name:         "orders.heartbeat",
+		Vhost:        "/",
+		Messages:     1,
+		MessageStats: &rabbitmq.MessageStats{Publish: 10},
+	}
+
+	tracker.Observe([]rabbitmq.Queue{queue}, start)
+	status := tracker.Status(start.Add(30 * time.Second))[0]
+	if !status.Found || !status.Alive {
+		t.Errorf("Expected canary to be found and alive, got %+v", status)
+	}
+
+	// No activity for longer than max silence
+	tracker.Observe([]rabbitmq.Queue{queue}, start.Add(90*time.Second))
+	status = tracker.Status(start.Add(90 * time.Second))[0]
+	if status.Alive {
+		t.Errorf("Expected canary to be dead after max silence, got %+v", status)
+	}
+
+	// Publish counter moves even though the depth is unchanged
+	queue.MessageStats = &rabbitmq.MessageStats{Publish: 11}
+	tracker.Observe([]rabbitmq.Queue{queue}, start.Add(100*time.Second))
+	status = tracker.Status(start.Add(100 * time.Second))[0]
+	if !status.Alive || status.SinceChange != 0 {
+		t.Errorf("Expected canary to be alive after publish activity, got %+v", status)
+	}
+
+	// Canary queue disappears
+	tracker.Observe(nil, start.Add(110*time.Second))
+	status = tracker.Status(start.Add(110 * time.Second))[0]
+	if status.Found || status.Alive {
+		t.Errorf("Expected missing canary to be reported dead, got %+v", status)
+	}
+}
+
+func TestNewCanaryTracker_Invalid(t *testing.T) {
+	if _, err := NewCanaryTracker([]CanaryConfig{{Pipeline: "orders"}}, time.Now()); err == nil {
+		t.Error("Expected error for canary without queue name")
+	}
+}
+
+func TestCollector_CanaryAgeWhileStale(t *testing.T) {
+	tracker, err := NewCanaryTracker([]CanaryConfig{{Pipeline: "orders", Queue: "orders.heartbeat", MaxSilence: time.Minute}}, time.Now())
+	if err != nil {
+		t.Fatalf("NewCanaryTracker: %v", err)
+	}
+	client := &exportertest.Client{Queues: []rabbitmq.Queue{{Name: "orders.heartbeat", Vhost: "/", Messages: 1}}}
+	m := metrics.NewMetrics()
+	collector := NewCollector(client, m, time.Hour, WithCanaries(tracker), WithMaxCacheAge(time.Minute))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	alive := m.CanaryProducerAlive.WithLabelValues("orders", "orders.heartbeat", "/")
+	age := m.CanarySecondsSinceChange.WithLabelValues("orders", "orders.heartbeat", "/")
+	testutil.CollectAndCount(collector)
+	if got := testutil.ToFloat64(alive); got != 1 {
+		t.Fatalf("Expected the canary to be alive on a fresh snapshot, got %v", got)
+	}
+
+	// The producer dies during an outage that leaves the snapshot stale
+	collector.mu.Lock()
+	collector.cacheTimestamp = time.Now().Add(-2 * time.Minute)
+	collector.mu.Unlock()
+	tracker.mu.Lock()
+	tracker.canaries[0].lastChange = time.Now().Add(-10 * time.Minute)
+	tracker.mu.Unlock()
+
+	testutil.CollectAndCount(collector)
+	if got := testutil.ToFloat64(age); got < 600 {
+		t.Errorf("Expected the canary age to keep growing while stale, got %v", got)
+	}
+	if got := testutil.ToFloat64(alive); got != 0 {
+		t.Errorf("Expected the canary to be reported dead while stale, got %v", got)
+	}
+}
@@ -0,0 +1,129 @@
+package exporter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const DefaultCardinalityTopN = 10
+
+// queueNameSeparators split a queue name into its naming prefix, e.g.
+// "orders.created" and "orders-retry" both belong to "orders"
+const queueNameSeparators = ".-_:/"
+
+// CardinalityReport summarizes where the exporter's series come from
+type CardinalityReport struct {
+	SnapshotID     uint64             `json:"snapshot_id"`
+	TotalSeries    int                `json:"total_series"`
+	Families       []CardinalityCount `json:"families"`
+	Vhosts         []CardinalityCount `json:"vhosts"`
+	Prefixes       []CardinalityCount `json:"prefixes"`
+	TopLabelValues []LabelValueCount  `json:"top_label_values"`
+}
+
+// CardinalityCount is the number of series attributed to a single key
+type CardinalityCount struct {
+	Name   string `json:"name"`
+	Series int    `json:"series"`
+}
+
+// LabelValueCount is the number of series carrying a label value
+type LabelValueCount struct {
+	Label  string `json:"label"`
+	Value  string `json:"value"`
+	Series int    `json:"series"`
+}
+
+// queueNamePrefix returns the part of a queue name before its first separator
+func queueNamePrefix(name string) string {
+	if i := strings.IndexAny(name, queueNameSeparators); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// Cardinality builds a report of the series the current snapshot produces,
+// listing the topN label values contributing the most series
+func (c *Collector) Cardinality(topN int) (CardinalityReport, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(snapshotCollector{c: c}); err != nil {
+		return CardinalityReport{}, err
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		return CardinalityReport{}, err
+	}
+
+	c.mu.RLock()
+	report := CardinalityReport{SnapshotID: c.snapshotID}
+	c.mu.RUnlock()
+
+	vhosts := make(map[string]int)
+	prefixes := make(map[string]int)
+	labelValues := make(map[[2]string]int)
+
+	for _, family := range families {
+		series := len(family.GetMetric())
+		if series == 0 {
+			continue
+		}
+		report.TotalSeries += series
+		report.Families = append(report.Families, CardinalityCount{Name: family.GetName(), Series: series})
+
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case "vhost":
+					vhosts[label.GetValue()]++
+				case "queue_name":
+					prefixes[queueNamePrefix(label.GetValue())]++
+				}
+				labelValues[[2]string{label.GetName(), label.GetValue()}]++
+			}
+		}
+	}
+
+	sortCounts(report.Families)
+	report.Vhosts = countsFromMap(vhosts)
+	report.Prefixes = countsFromMap(prefixes)
+
+	for key, series := range labelValues {
+		report.TopLabelValues = append(report.TopLabelValues, LabelValueCount{Label: key[0], Value: key[1], Series: series})
+	}
+	sort.Slice(report.TopLabelValues, func(i, j int) bool {
+		a, b := report.TopLabelValues[i], report.TopLabelValues[j]
+		if a.Series != b.Series {
+			return a.Series > b.Series
+		}
+		if a.Label != b.Label {
+			return a.Label < b.Label
+		}
+		return a.Value < b.Value
+	})
+	if topN > 0 && len(report.TopLabelValues) > topN {
+		report.TopLabelValues = report.TopLabelValues[:topN]
+	}
+
+	return report, nil
+}
+
+func countsFromMap(m map[string]int) []CardinalityCount {
+	counts := make([]CardinalityCount, 0, len(m))
+	for name, series := range m {
+		counts = append(counts, CardinalityCount{Name: name, Series: series})
+	}
+	sortCounts(counts)
+	return counts
+}
+
+// sortCounts orders counts by descending series count, then by name
+func sortCounts(counts []CardinalityCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Series != counts[j].Series {
+			return counts[i].Series > counts[j].Series
+		}
+		return counts[i].Name < counts[j].Name
+	})
+}
@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQueueNamePrefix(t *testing.T) {
+	tests := map[string]string{
+		"orders.created": "orders",
+		"orders-retry":   "orders",
+		"billing_dlq":    "billing",
+		"plain":          "plain",
+		".hidden":        ".hidden",
+	}
+	for name, expected := range tests {
+		if got := queueNamePrefix(name); got != expected {
+			t.Errorf("queueNamePrefix(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestCollector_Cardinality(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"orders.created","vhost":"/"},
+		{"name":"orders.cancelled","vhost":"/"},
+		{"name":"billing","vhost":"payments"}
+	]`)
+
+	report, err := collector.Cardinality(3)
+	if err != nil {
+		t.Fatalf("Cardinality failed: %v", err)
+	}
+
+	counts := func(list []CardinalityCount) map[string]int {
+		out := make(map[string]int)
+		for _, c := range list {
+			out[c.Name] = c.Series
+		}
+		return out
+	}
+
+	families := counts(report.Families)
+	if families["rabbitmq_custom_queue_consumers"] != 3 {
+		t.Errorf("Expected 3 consumer series, got %d", families["rabbitmq_custom_queue_consumers"])
+	}
+	total := 0
+	for _, f := range report.Families {
+		total += f.Series
+	}
+	if total != report.TotalSeries {
+		t.Errorf("Total series %d doesn't match family sum %d", report.TotalSeries, total)
+	}
+
+	// Every vhost has the same rollup series whatever its queue count
+	vhosts := counts(report.Vhosts)
+	rollups := 0
+	for name, series := range families {
+		if strings.HasPrefix(name, "rabbitmq_custom_vhost_") {
+			rollups += series / len(vhosts)
+		}
+	}
+	if vhosts["/"]-rollups != 2*(vhosts["payments"]-rollups) {
+		t.Errorf("Expected / to have twice the series of payments, got %v", vhosts)
+	}
+	if report.Prefixes[0].Name != "orders" {
+		t.Errorf("Expected orders to be the largest prefix, got %+v", report.Prefixes)
+	}
+	if len(report.TopLabelValues) != 3 {
+		t.Errorf("Expected 3 top label values, got %d", len(report.TopLabelValues))
+	}
+
+	if report.SnapshotID != collector.Snapshot().SnapshotID {
+		t.Errorf("Expected report snapshot %d, got %d", collector.Snapshot().SnapshotID, report.SnapshotID)
+	}
+}
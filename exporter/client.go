@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// QueueFetcher is the part of Client every collection needs: the queue list,
+// and a reachability check for when queue collection is disabled
+type QueueFetcher interface {
+	GetQueues(ctx context.Context) ([]rabbitmq.Queue, error)
+	HealthCheck(ctx context.Context) error
+}
+
+// Client is the management API client a Collector reads from.
+// *rabbitmq.Client implements it, and services embedding the collector can
+// supply their own, e.g. one sharing their HTTP transport. The exportertest
+// package provides an in-memory implementation for tests.
+type Client interface {
+	QueueFetcher
+
+	GetNodes(ctx context.Context) ([]rabbitmq.Node, error)
+	GetBindings(ctx context.Context) ([]rabbitmq.Binding, error)
+	GetChannels(ctx context.Context) ([]rabbitmq.Channel, error)
+	GetConsumers(ctx context.Context) ([]rabbitmq.Consumer, error)
+	GetConnections(ctx context.Context) ([]rabbitmq.Connection, error)
+	GetOverview(ctx context.Context) (*rabbitmq.Overview, error)
+	GetPolicies(ctx context.Context) ([]rabbitmq.Policy, error)
+	GetOperatorPolicies(ctx context.Context) ([]rabbitmq.Policy, error)
+	AlivenessTest(ctx context.Context, vhost string) error
+	RunHealthCheck(ctx context.Context, check string) (rabbitmq.HealthCheckResult, error)
+
+	// CircuitBreakerStatus reports the breaker of each endpoint, for the
+	// circuit breaker metrics
+	CircuitBreakerStatus() map[string]rabbitmq.BreakerStatus
+	ResetCircuitBreaker()
+	// BackoffUntil returns when a Retry-After pause ends, so background
+	// collection can wait it out
+	BackoffUntil() time.Time
+}
+
+var _ Client = (*rabbitmq.Client)(nil)
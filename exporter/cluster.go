@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// ClusterLabelName is the label that carries the cluster name
+const ClusterLabelName = "cluster"
+
+// ClusterLabelConfig attaches the cluster name to every metric
+type ClusterLabelConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Name overrides the cluster_name reported by /api/overview
+	Name string `mapstructure:"name"`
+}
+
+// ClusterIdentity holds the cluster name used for the cluster label. It is
+// detected from the broker and can be re-detected, e.g. after a reload.
+type ClusterIdentity struct {
+	override string
+	name     atomic.Value
+}
+
+func NewClusterIdentity(cfg ClusterLabelConfig) *ClusterIdentity {
+	c := &ClusterIdentity{override: cfg.Name}
+	c.name.Store(cfg.Name)
+	return c
+}
+
+// Detect reads cluster_name from /api/overview unless the name is
+// configured explicitly. The previous name is kept when detection fails.
+func (c *ClusterIdentity) Detect(ctx context.Context, client *rabbitmq.Client) error {
+	if c.override != "" {
+		return nil
+	}
+
+	overview, err := client.GetOverview(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to detect cluster name: %w", err)
+	}
+	c.name.Store(overview.ClusterName)
+	return nil
+}
+
+// Name returns the current cluster name, or an empty string before it has
+// been detected
+func (c *ClusterIdentity) Name() string {
+	return c.name.Load().(string)
+}
@@ -0,0 +1,61 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+func TestClusterIdentity_Detect(t *testing.T) {
+	name := "rabbit@prod"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/overview" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"cluster_name":"` + name + `"}`))
+	}))
+	defer server.Close()
+	client := rabbitmq.NewClient(server.URL, "guest", "guest", time.Second)
+
+	identity := NewClusterIdentity(ClusterLabelConfig{Enabled: true})
+	if identity.Name() != "" {
+		t.Errorf("Expected no name before detection, got %q", identity.Name())
+	}
+	if err := identity.Detect(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+	if identity.Name() != "rabbit@prod" {
+		t.Errorf("Expected rabbit@prod, got %q", identity.Name())
+	}
+
+	// Re-detection picks up a renamed cluster
+	name = "rabbit@renamed"
+	identity.Detect(context.Background(), client)
+	if identity.Name() != "rabbit@renamed" {
+		t.Errorf("Expected rabbit@renamed, got %q", identity.Name())
+	}
+
+	server.Close()
+	if err := identity.Detect(context.Background(), client); err == nil {
+		t.Error("Expected detection to fail without a broker")
+	}
+	if identity.Name() != "rabbit@renamed" {
+		t.Errorf("Expected the previous name to be kept, got %q", identity.Name())
+	}
+}
+
+func TestClusterIdentity_Override(t *testing.T) {
+	identity := NewClusterIdentity(ClusterLabelConfig{Enabled: true, Name: "eu-prod"})
+	client := rabbitmq.NewClient("http://127.0.0.1:1", "guest", "guest", time.Second)
+	if err := identity.Detect(context.Background(), client); err != nil {
+		t.Errorf("Expected no request with a configured name, got %v", err)
+	}
+	if identity.Name() != "eu-prod" {
+		t.Errorf("Expected eu-prod, got %q", identity.Name())
+	}
+}
@@ -0,0 +1,1273 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Collector struct {
+	client         Client
+	metrics        *metrics.Metrics
+	scrapeInterval time.Duration
+	lastScrape     time.Time
+
+	mu                sync.RWMutex
+	cachedQueues      []rabbitmq.Queue
+	exportedQueues    []rabbitmq.Queue
+	otherQueues       []rabbitmq.Queue
+	aggregated        map[string]int
+	vhostRollups      []vhostRollup
+	depthDistribution depthDistribution
+	publishing        []vhostPublishing
+	userActivity      []userActivity
+	protocolCounts    []protocolCount
+	cacheTimestamp    time.Time
+	cacheValid        bool
+	collectionError   error
+	lastAttempt       time.Time // last finished collection, with or without error
+	deadLetterBound   map[string]map[string]bool
+	bindingCounts     map[string]map[string]int
+	consumerSummaries map[string]*consumerSummary
+	snapshotID        uint64
+	breakerFailures   map[string]uint64
+	policyLists       policyLists
+	policyCounts      []policyQueueCount
+	overview          *rabbitmq.Overview
+	nodes             []rabbitmq.Node
+	aliveness         []alivenessResult
+	healthResults     []healthCheckResult
+	leaders           *leaderTracker
+	leaderChanges     map[string]float64
+	queuesPerNode     map[string]int
+	membership        *membershipTracker
+	growth            *growthTracker
+	growthStreaks     map[string]int
+
+	// depthThresholds and deadLetter are swapped by a config reload
+	depthThresholds   atomic.Pointer[DepthThresholdMatcher]
+	canaries          *CanaryTracker
+	depthSLOs         *DepthSLOTracker
+	severityTiers     []SeverityTier
+	healthMetrics     bool
+	alertNotifier     *AlertNotifier
+	depthBaselines    *DepthBaselines
+	events            *EventDetector
+	eventSink         EventSink
+	queueDiff         *QueueDiffLogger
+	deadLetter        atomic.Pointer[rabbitmq.DeadLetterRules]
+	collectionHooks   []func()
+	collect           CollectGroups
+	queueLabels       *QueueLabeler
+	alivenessVhosts   []string
+	rollupOnly        *VhostMatcher
+	maxQueuesPerVhost int
+	expectedNodes     int
+	growthCollections int
+	ignoreArgument    string
+	collectionTimeout time.Duration
+	maxCacheAge       time.Duration
+	started           time.Time
+	healthChecks      []string
+	jitter            time.Duration
+	watchdogIntervals int
+	// randFloat replaces rand.Float64 in tests
+	randFloat func() float64
+
+	// collecting holds the ID of the collection in flight, or 0
+	collecting    atomic.Uint64
+	collections   atomic.Uint64
+	lastCompleted atomic.Int64
+
+	// The background loop, replaced when the watchdog restarts it
+	loopMu           sync.Mutex
+	loopGeneration   uint64
+	loopDone         chan struct{}
+	cancelCollection context.CancelFunc
+
+	stopChan    chan struct{}
+	refreshChan chan struct{}
+	stopOnce    sync.Once
+	// stopCtx parents every collection and is cancelled by Stop, so
+	// requests in flight don't hold up shutdown
+	stopCtx    context.Context
+	cancelStop context.CancelFunc
+}
+
+// CollectGroups selects the metric groups the collector fetches from the
+// management API. Groups without a collector yet are accepted so configs can
+// opt in ahead of time.
+type CollectGroups struct {
+	Queues      bool `mapstructure:"queues"`
+	Nodes       bool `mapstructure:"nodes"`
+	Overview    bool `mapstructure:"overview"`
+	Exchanges   bool `mapstructure:"exchanges"`
+	Connections bool `mapstructure:"connections"`
+	Policies    bool `mapstructure:"policies"`
+	Bindings    bool `mapstructure:"bindings"`
+	Channels    bool `mapstructure:"channels"`
+	Consumers   bool `mapstructure:"consumers"`
+}
+
+// Enabled lists the names of the enabled groups
+func (g CollectGroups) Enabled() []string {
+	var names []string
+	for _, group := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"queues", g.Queues},
+		{"nodes", g.Nodes},
+		{"overview", g.Overview},
+		{"exchanges", g.Exchanges},
+		{"connections", g.Connections},
+		{"policies", g.Policies},
+		{"bindings", g.Bindings},
+		{"channels", g.Channels},
+		{"consumers", g.Consumers},
+	} {
+		if group.enabled {
+			names = append(names, group.name)
+		}
+	}
+	return names
+}
+
+// DefaultCollectionTimeout bounds a whole background collection, across
+// every management API request it makes
+const DefaultCollectionTimeout = 30 * time.Second
+
+// DefaultCollectGroups keeps the per-queue focus of the exporter; the
+// higher-cardinality groups are opt-in
+var DefaultCollectGroups = CollectGroups{Queues: true, Nodes: true, Overview: true}
+
+// Reasons a background collection is skipped
+const (
+	skipReasonBusy    = "busy"
+	skipReasonOverdue = "overdue"
+	skipReasonBackoff = "backoff"
+)
+
+// Phases of a collection, reported by CollectionPhaseSeconds
+const (
+	phaseFetch  = "fetch"
+	phaseDecode = "decode"
+	phaseUpdate = "update"
+)
+
+// CollectorOption customizes a Collector at construction time
+type CollectorOption func(*Collector)
+
+// WithCollectionTimeout bounds each background collection. Non-positive
+// values keep DefaultCollectionTimeout.
+func WithCollectionTimeout(timeout time.Duration) CollectorOption {
+	return func(c *Collector) {
+		if timeout > 0 {
+			c.collectionTimeout = timeout
+		}
+	}
+}
+
+// WithMaxCacheAge sets how old the snapshot may get before queue and
+// cluster series are withheld. The default is twice the scrape interval.
+func WithMaxCacheAge(age time.Duration) CollectorOption {
+	return func(c *Collector) {
+		if age > 0 {
+			c.maxCacheAge = age
+		}
+	}
+}
+
+// WithDepthThresholds sets the per-queue depth alert thresholds
+func WithDepthThresholds(matcher *DepthThresholdMatcher) CollectorOption {
+	return func(c *Collector) {
+		c.depthThresholds.Store(matcher)
+	}
+}
+
+// WithCanaries enables producer liveness tracking for canary queues
+func WithCanaries(tracker *CanaryTracker) CollectorOption {
+	return func(c *Collector) {
+		c.canaries = tracker
+	}
+}
+
+// WithDepthBaselines scores queue depth against each queue's baseline
+func WithDepthBaselines(baselines *DepthBaselines) CollectorOption {
+	return func(c *Collector) {
+		c.depthBaselines = baselines
+	}
+}
+
+// WithEvents enables detection of major cluster events, which are published
+// to sink as they occur
+func WithEvents(detector *EventDetector, sink EventSink) CollectorOption {
+	return func(c *Collector) {
+		c.events = detector
+		c.eventSink = sink
+	}
+}
+
+// WithQueueDiffLogging logs queue additions, removals and state transitions
+// between collections at debug level
+func WithQueueDiffLogging(logger *QueueDiffLogger) CollectorOption {
+	return func(c *Collector) {
+		c.queueDiff = logger
+	}
+}
+
+// WithDeadLetterRules sets how dead letter queues are detected
+func WithDeadLetterRules(rules *rabbitmq.DeadLetterRules) CollectorOption {
+	return func(c *Collector) {
+		c.deadLetter.Store(rules)
+	}
+}
+
+// WithQueueLabels adds labels extracted from queue names to every per-queue
+// metric. The metrics must have been created with the labeler's names.
+func WithQueueLabels(labeler *QueueLabeler) CollectorOption {
+	return func(c *Collector) {
+		c.queueLabels = labeler
+	}
+}
+
+// WithCollectGroups selects which metric groups are collected
+func WithCollectGroups(groups CollectGroups) CollectorOption {
+	return func(c *Collector) {
+		c.collect = groups
+	}
+}
+
+// WithHealthMetrics turns the health score and the depth and utilisation
+// alert indicators on or off. They are on by default.
+func WithHealthMetrics(enabled bool) CollectorOption {
+	return func(c *Collector) {
+		c.healthMetrics = enabled
+	}
+}
+
+// WithCollectionHook registers a function called after every successful
+// background collection, once the new snapshot is in place. Hooks run on the
+// collection goroutine and must not block.
+func WithCollectionHook(hook func()) CollectorOption {
+	return func(c *Collector) {
+		c.collectionHooks = append(c.collectionHooks, hook)
+	}
+}
+
+// NewCollector returns a Collector that collects from client in the
+// background every scrapeInterval until Stop is called. Register it with a
+// prometheus.Registerer to serve its metrics; the stateful ones in metrics
+// are served through it as well.
+func NewCollector(client Client, metrics *metrics.Metrics, scrapeInterval time.Duration, opts ...CollectorOption) *Collector {
+	c := newCollector(client, metrics, scrapeInterval, opts...)
+	c.startBackgroundCollection()
+	go c.watchdog()
+	return c
+}
+
+// newCollector builds a Collector without starting background collection
+func newCollector(client Client, metrics *metrics.Metrics, scrapeInterval time.Duration, opts ...CollectorOption) *Collector {
+	defaultThresholds, _ := NewDepthThresholdMatcher(nil, DepthThresholds{
+		Warning:  DefaultDepthWarning,
+		Critical: DefaultDepthCritical,
+	})
+
+	defaultDeadLetter, _ := rabbitmq.NewDeadLetterRules(nil, nil)
+
+	c := &Collector{
+		client:            client,
+		metrics:           metrics,
+		scrapeInterval:    scrapeInterval,
+		breakerFailures:   make(map[string]uint64),
+		leaders:           newLeaderTracker(),
+		membership:        newMembershipTracker(),
+		growth:            newGrowthTracker(),
+		severityTiers:     DefaultSeverityTiers(),
+		healthMetrics:     true,
+		collect:           DefaultCollectGroups,
+		collectionTimeout: DefaultCollectionTimeout,
+		maxCacheAge:       2 * scrapeInterval,
+		started:           time.Now(),
+		watchdogIntervals: DefaultWatchdogIntervals,
+		growthCollections: DefaultBacklogGrowthCollections,
+		ignoreArgument:    DefaultIgnoreArgument,
+		stopChan:          make(chan struct{}),
+		refreshChan:       make(chan struct{}, 1),
+	}
+	c.depthThresholds.Store(defaultThresholds)
+	c.deadLetter.Store(defaultDeadLetter)
+	c.stopCtx, c.cancelStop = context.WithCancel(context.Background())
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// startBackgroundCollection starts a collection loop in place of any earlier
+// one. A replaced loop exits once its current collection returns.
+func (c *Collector) startBackgroundCollection() {
+	c.loopMu.Lock()
+	defer c.loopMu.Unlock()
+
+	c.loopGeneration++
+	c.loopDone = make(chan struct{})
+	c.lastCompleted.Store(time.Now().UnixNano())
+	go c.backgroundCollection(c.loopGeneration, c.loopDone)
+}
+
+// currentLoop reports whether generation is the loop in charge
+func (c *Collector) currentLoop(generation uint64) bool {
+	c.loopMu.Lock()
+	defer c.loopMu.Unlock()
+	return c.loopGeneration == generation
+}
+
+func (c *Collector) backgroundCollection(generation uint64, done chan struct{}) {
+	scheduled := time.Now().Add(c.firstCollection())
+	timer := time.NewTimer(time.Until(scheduled))
+	defer timer.Stop()
+	defer close(done)
+
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-timer.C:
+			c.collectQueueData()
+			if !c.currentLoop(generation) {
+				return
+			}
+			var skipped int
+			scheduled, skipped = c.nextCollection(scheduled, time.Now())
+			if skipped > 0 {
+				log.Printf("Background collection overran %d scrape intervals; skipping them", skipped)
+				c.metrics.CollectionsSkippedTotal.WithLabelValues(skipReasonOverdue).Add(float64(skipped))
+			}
+			if c.client != nil {
+				scheduled, skipped = c.afterBackoff(scheduled, c.client.BackoffUntil())
+				if skipped > 0 {
+					log.Printf("RabbitMQ asked to retry after %s; skipping %d collections", c.client.BackoffUntil().Format(time.RFC3339), skipped)
+					c.metrics.CollectionsSkippedTotal.WithLabelValues(skipReasonBackoff).Add(float64(skipped))
+				}
+			}
+			timer.Reset(time.Until(scheduled.Add(c.jitterOffset())))
+		case <-c.refreshChan:
+			c.collectQueueData()
+			if !c.currentLoop(generation) {
+				return
+			}
+		}
+	}
+}
+
+// Warm runs a collection right away and waits up to timeout for it to
+// finish, so the first scrapes after startup see queue data rather than
+// none. It reports whether the cache holds a snapshot. A collection still
+// running after timeout carries on in the background.
+func (c *Collector) Warm(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.collectQueueData()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+	return c.CacheValid()
+}
+
+// CacheValid reports whether the last collection produced a snapshot
+func (c *Collector) CacheValid() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cacheValid
+}
+
+// Refresh requests an immediate background collection outside the ticker.
+// Requests made while one is already pending are coalesced.
+func (c *Collector) Refresh() {
+	select {
+	case c.refreshChan <- struct{}{}:
+	default:
+	}
+}
+
+// ResetCircuitBreaker manually closes every endpoint's circuit breaker and
+// triggers an immediate collection. Manual resets cover the whole API and are
+// counted under endpoint="rabbitmq_api".
+func (c *Collector) ResetCircuitBreaker() {
+	c.client.ResetCircuitBreaker()
+	c.metrics.CircuitBreakerManualResets.WithLabelValues("rabbitmq_api").Inc()
+
+	c.mu.Lock()
+	c.updateCircuitBreakerMetrics()
+	c.mu.Unlock()
+
+	c.Refresh()
+}
+
+func (c *Collector) collectQueueData() {
+	// A collection still in flight has the fresher view of the broker, so
+	// an overlapping one is dropped rather than queued behind it
+	id := c.collections.Add(1)
+	if !c.collecting.CompareAndSwap(0, id) {
+		c.metrics.CollectionsSkippedTotal.WithLabelValues(skipReasonBusy).Inc()
+		return
+	}
+	defer c.finishCollection(id)
+
+	start := time.Now()
+	var timings rabbitmq.Timings
+	ctx, cancel := context.WithTimeout(c.stopCtx, c.collectionTimeout)
+	defer cancel()
+	ctx = rabbitmq.WithTimings(ctx, &timings)
+	c.loopMu.Lock()
+	c.cancelCollection = cancel
+	c.loopMu.Unlock()
+
+	// Without queue collection the overview still tells whether the broker
+	// is reachable, so up stays meaningful
+	var queues []rabbitmq.Queue
+	var overview *rabbitmq.Overview
+	var err error
+	switch {
+	case c.collect.Queues:
+		queues, err = c.client.GetQueues(ctx)
+		if err == nil {
+			queues = c.dropIgnoredQueues(queues)
+		}
+	case c.collect.Overview:
+		overview, err = c.client.GetOverview(ctx)
+	default:
+		err = c.client.HealthCheck(ctx)
+	}
+
+	// The remaining requests don't depend on each other, so they run
+	// concurrently, bounded by the client's request limit
+	var (
+		wg              sync.WaitGroup
+		nodes           []rabbitmq.Node
+		publishing      []vhostPublishing
+		channels        []rabbitmq.Channel
+		connections     []rabbitmq.Connection
+		aliveness       []alivenessResult
+		healthResults   []healthCheckResult
+		deadLetterBound map[string]map[string]bool
+		bindingCounts   map[string]map[string]int
+		consumers       map[string]*consumerSummary
+		policies        policyLists
+		policiesFetched bool
+	)
+	run := func(fetch func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetch()
+		}()
+	}
+
+	if err == nil && c.collect.Queues && c.collect.Overview {
+		run(func() {
+			var overviewErr error
+			overview, overviewErr = c.client.GetOverview(ctx)
+			if overviewErr != nil {
+				log.Printf("Failed to fetch overview: %v", overviewErr)
+			}
+		})
+	}
+	if err == nil && c.collect.Nodes {
+		run(func() {
+			var nodesErr error
+			nodes, nodesErr = c.client.GetNodes(ctx)
+			if nodesErr != nil {
+				log.Printf("Failed to fetch nodes: %v", nodesErr)
+				nodes = nil
+			}
+		})
+	}
+	if err == nil && c.collect.Channels {
+		run(func() {
+			var channelsErr error
+			channels, channelsErr = c.client.GetChannels(ctx)
+			if channelsErr != nil {
+				log.Printf("Failed to fetch channels: %v", channelsErr)
+				channels = nil
+			} else {
+				publishing = rollupChannels(channels)
+			}
+		})
+	}
+	if err == nil && c.collect.Connections {
+		run(func() {
+			var connectionsErr error
+			connections, connectionsErr = c.client.GetConnections(ctx)
+			if connectionsErr != nil {
+				log.Printf("Failed to fetch connections: %v", connectionsErr)
+				connections = nil
+			}
+		})
+	}
+	if err == nil && len(c.alivenessVhosts) > 0 {
+		run(func() { aliveness = c.runAlivenessTests(ctx) })
+	}
+	if err == nil && len(c.healthChecks) > 0 {
+		run(func() { healthResults = c.runHealthChecks(ctx) })
+	}
+	deadLetter := c.deadLetter.Load()
+	if err == nil && c.collect.Queues && (deadLetter.HasExchanges() || c.collect.Bindings) {
+		run(func() {
+			bindings, bindErr := c.client.GetBindings(ctx)
+			if bindErr != nil {
+				log.Printf("Failed to fetch bindings: %v", bindErr)
+				return
+			}
+			if deadLetter.HasExchanges() {
+				deadLetterBound = deadLetter.BoundQueues(bindings)
+			}
+			if c.collect.Bindings {
+				bindingCounts = countQueueBindings(bindings)
+			}
+		})
+	}
+	if err == nil && c.collect.Queues && c.collect.Consumers {
+		run(func() {
+			list, consumersErr := c.client.GetConsumers(ctx)
+			if consumersErr != nil {
+				log.Printf("Failed to fetch consumers: %v", consumersErr)
+				return
+			}
+			consumers = summarizeConsumers(list)
+		})
+	}
+	if err == nil && c.collect.Queues && c.collect.Policies {
+		run(func() { policies, policiesFetched = c.fetchPolicies(ctx) })
+	}
+	wg.Wait()
+	fetched := time.Now()
+
+	if err == nil && c.events != nil {
+		c.detectEvents(queues, nodes)
+	}
+
+	succeeded := false
+	defer func() {
+		if succeeded {
+			for _, hook := range c.collectionHooks {
+				hook()
+			}
+		}
+	}()
+	defer func() {
+		finished := time.Now()
+		c.metrics.CollectionDurationSeconds.Observe(finished.Sub(start).Seconds())
+		c.metrics.CollectionPhaseSeconds.WithLabelValues(phaseFetch).Set(timings.Fetch().Seconds())
+		c.metrics.CollectionPhaseSeconds.WithLabelValues(phaseDecode).Set(timings.Decode().Seconds())
+		c.metrics.CollectionPhaseSeconds.WithLabelValues(phaseUpdate).Set(finished.Sub(fetched).Seconds())
+	}()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.updateCircuitBreakerMetrics()
+
+	// The watchdog gave up on this collection, and a newer one is in charge
+	// of the snapshot
+	if c.collecting.Load() != id {
+		return
+	}
+	// Stop cancelled this collection, so its error says nothing about the
+	// broker; /metrics keeps serving the last snapshot while the server drains
+	if c.stopCtx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		c.metrics.Up.Set(0)
+		c.collectionError = err
+		c.cacheValid = false
+		c.lastAttempt = time.Now()
+		if time.Since(c.lastScrape) > time.Minute {
+			log.Printf("Background collection error: %v", err)
+		}
+		return
+	}
+
+	if c.canaries != nil {
+		c.canaries.Observe(queues, time.Now())
+	}
+	if c.depthSLOs != nil {
+		c.depthSLOs.Observe(queues, c.depthThresholds.Load(), time.Now())
+	}
+	if c.queueDiff != nil {
+		c.queueDiff.Log(queues, time.Now())
+	}
+	if c.depthBaselines != nil {
+		c.depthBaselines.Observe(queues)
+	}
+
+	if deadLetterBound != nil {
+		c.deadLetterBound = deadLetterBound
+	}
+	if c.alertNotifier != nil {
+		c.alertNotifier.Evaluate(queues, c.depthThresholds.Load(), c.severityTiers, func(q rabbitmq.Queue) bool {
+			return deadLetter.Match(&q) || c.deadLetterBound[q.Vhost][q.Name]
+		}, time.Now())
+	}
+	if bindingCounts != nil {
+		c.bindingCounts = bindingCounts
+	}
+	if consumers != nil {
+		c.consumerSummaries = consumers
+	}
+	// Unlike the per-queue data above, stale cluster totals, rates and
+	// alarms would be misleading, so a failed overview or nodes fetch drops
+	// them
+	c.overview = overview
+	c.nodes = nodes
+	if nodes != nil {
+		c.membership.Observe(nodes)
+	}
+	c.publishing = publishing
+	c.userActivity, c.protocolCounts = nil, nil
+	if connections != nil {
+		c.userActivity = rollupUsers(connections, channels)
+		c.protocolCounts = countProtocols(connections)
+	}
+	c.aliveness = aliveness
+	c.healthResults = healthResults
+	if c.collect.Policies {
+		// Keep the last known policies when listing them fails, so counts
+		// still follow the queues
+		if policiesFetched {
+			c.policyLists = policies
+		}
+		c.policyCounts = countPolicyQueues(queues, c.policyLists)
+	}
+
+	c.cachedQueues = queues
+	selection := c.selectQueues(queues)
+	c.exportedQueues = selection.individual
+	c.otherQueues = selection.other
+	c.aggregated = selection.aggregated
+	c.vhostRollups = rollupVhosts(queues)
+	c.depthDistribution = newDepthDistribution(queues)
+	c.leaderChanges = c.leaders.Observe(queues)
+	c.growthStreaks = c.growth.Observe(queues)
+	c.queuesPerNode = countQueuesPerNode(queues)
+	c.cacheTimestamp = time.Now()
+	c.cacheValid = true
+	c.snapshotID++
+	c.collectionError = nil
+	c.lastScrape = time.Now()
+	c.lastAttempt = c.lastScrape
+
+	c.metrics.Up.Set(1)
+	c.metrics.LastScrapeTimestampSeconds.Set(float64(c.lastScrape.UnixNano()) / 1e9)
+	succeeded = true
+}
+
+// detectEvents publishes the events between the previous collection and
+// this one. nodes is nil when they could not be fetched.
+func (c *Collector) detectEvents(queues []rabbitmq.Queue, nodes []rabbitmq.Node) {
+	for _, event := range c.events.Detect(queues, nodes, time.Now()) {
+		log.Printf("Cluster event (%s): %s", event.Kind, event.Text)
+		c.eventSink.Publish(event)
+	}
+}
+
+// updateCircuitBreakerMetrics exports each endpoint's breaker state and adds
+// failures recorded since the previous update. Callers must hold c.mu.
+func (c *Collector) updateCircuitBreakerMetrics() {
+	for endpoint, status := range c.client.CircuitBreakerStatus() {
+		state := 0.0
+		if status.Open {
+			state = 1.0
+		}
+		c.metrics.CircuitBreakerState.WithLabelValues(endpoint).Set(state)
+
+		if delta := status.TotalFailures - c.breakerFailures[endpoint]; delta > 0 {
+			c.metrics.CircuitBreakerFailures.WithLabelValues(endpoint).Add(float64(delta))
+		}
+		c.breakerFailures[endpoint] = status.TotalFailures
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.metrics.QueueDescs() {
+		ch <- desc
+	}
+	for _, desc := range c.metrics.ClusterDescs() {
+		ch <- desc
+	}
+
+	collectors := c.metrics.GetAllCollectors()
+	for _, collector := range collectors {
+		collector.Describe(ch)
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+
+	c.mu.RLock()
+	queues := c.exportedQueues
+	otherQueues := c.otherQueues
+	cacheValid := c.cacheValid
+	cacheTimestamp := c.cacheTimestamp
+	collectionError := c.collectionError
+	snapshotID := c.snapshotID
+	c.mu.RUnlock()
+
+	c.metrics.SnapshotID.Set(float64(snapshotID))
+
+	// Canary age depends on the time of the scrape, so it keeps growing while
+	// the snapshot is stale and a producer that died meanwhile is reported
+	c.updateCanaryMetrics()
+
+	// Frozen values from an old snapshot would hide an outage, so queue and
+	// cluster series are withheld once it's too old or the last collection
+	// failed
+	if cacheTimestamp.IsZero() {
+		c.metrics.CacheAgeSeconds.Set(time.Since(c.started).Seconds())
+	} else {
+		c.metrics.CacheAgeSeconds.Set(time.Since(cacheTimestamp).Seconds())
+	}
+	if !cacheValid || time.Since(cacheTimestamp) > c.maxCacheAge {
+		c.metrics.CacheStale.Set(1)
+		if collectionError != nil {
+			c.metrics.ScrapeErrorsTotal.WithLabelValues(rabbitmq.ErrorType(collectionError)).Inc()
+		}
+		c.metrics.ScrapeDurationSeconds.Set(time.Since(start).Seconds())
+		c.collectMetrics(ch)
+		return
+	}
+
+	// Queue metrics are built from the cached snapshot on every scrape rather
+	// than stored in shared vectors, so concurrent scrapes can't observe a
+	// partially reset state
+	for _, queue := range queues {
+		c.collectQueueMetrics(ch, queue)
+	}
+	for _, other := range otherQueues {
+		c.collectOtherQueueMetrics(ch, other)
+	}
+	c.collectClusterMetrics(ch)
+	c.metrics.CacheStale.Set(0)
+
+	c.metrics.ScrapeDurationSeconds.Set(time.Since(start).Seconds())
+	c.collectMetrics(ch)
+}
+
+func (c *Collector) collectQueueMetrics(ch chan<- prometheus.Metric, queue rabbitmq.Queue) {
+	state := queue.GetQueueState()
+	stateStr := string(state)
+	labels := append([]string{queue.Name, queue.Vhost, queue.GetQueueType()}, c.queueLabels.Values(queue.Name)...)
+	// Cap the slice so the appends below never share a backing array
+	labels = labels[:len(labels):len(labels)]
+	labelsWithState := append(labels, stateStr)
+
+	emitGauge(ch, c.metrics.QueueMessages, float64(queue.Messages), labelsWithState...)
+	emitGauge(ch, c.metrics.QueueMessagesReady, float64(queue.MessagesReady), labels...)
+	emitGauge(ch, c.metrics.QueueMessagesUnacknowledged, float64(queue.MessagesUnacknowledged), labels...)
+
+	emitGauge(ch, c.metrics.QueueMemoryBytes, float64(queue.Memory), labels...)
+	emitGauge(ch, c.metrics.QueueMessageBytes, float64(queue.MessageBytes), labels...)
+	emitGauge(ch, c.metrics.QueueMessageBytesReady, float64(queue.MessageBytesReady), labels...)
+	emitGauge(ch, c.metrics.QueueMessageBytesUnacknowledged, float64(queue.MessageBytesUnacknowledged), labels...)
+	emitGauge(ch, c.metrics.QueueMessagesRAM, float64(queue.MessagesRAM), labels...)
+	emitGauge(ch, c.metrics.QueueMessagesPersistent, float64(queue.MessagesPersistent), labels...)
+	emitGauge(ch, c.metrics.QueueMessagePublishRate, queue.GetPublishRate(), labels...)
+	emitGauge(ch, c.metrics.QueueMessageDeliverRate, queue.GetDeliverRate(), labels...)
+	emitGauge(ch, c.metrics.QueueMessageAckRate, queue.GetAckRate(), labels...)
+	emitGauge(ch, c.metrics.QueueMessageRedeliverRate, queue.GetRedeliverRate(), labels...)
+	// A queue without message_stats has either seen no traffic yet or lost
+	// its stats, e.g. while a node restarts. Reporting zero for the latter
+	// would look like a counter reset, so the counters are left out.
+	if stats := queue.MessageStats; stats != nil {
+		emitCounter(ch, c.metrics.QueueMessagesPublishedTotal, float64(stats.Publish), labels...)
+		emitCounter(ch, c.metrics.QueueMessagesDeliveredTotal, float64(stats.Deliver), labels...)
+		emitCounter(ch, c.metrics.QueueMessagesAcknowledgedTotal, float64(stats.Ack), labels...)
+		emitCounter(ch, c.metrics.QueueMessagesRedeliveredTotal, float64(stats.Redeliver), labels...)
+		emitCounter(ch, c.metrics.QueueMessagesGetTotal, float64(stats.Get), labels...)
+	}
+
+	emitGauge(ch, c.metrics.QueueConsumers, float64(queue.Consumers), labels...)
+	emitGauge(ch, c.metrics.QueueConsumerUtilisation, queue.ConsumerUtilisation, labels...)
+	emitGauge(ch, c.metrics.QueueConsumerCapacity, queue.GetConsumerCapacity(), labels...)
+
+	for _, s := range rabbitmq.QueueStates {
+		value := 0.0
+		if s == state {
+			value = 1.0
+		}
+		stateLabels := append(labels, string(s))
+		emitGauge(ch, c.metrics.QueueState, value, stateLabels...)
+	}
+
+	dlqValue := 0.0
+	if c.isDeadLetterQueue(queue) {
+		dlqValue = 1.0
+	}
+	emitGauge(ch, c.metrics.QueueIsDeadLetter, dlqValue, labels...)
+
+	if c.collect.Bindings {
+		c.collectBindingMetrics(ch, queue, labels)
+	}
+	if c.collect.Policies {
+		emitGauge(ch, c.metrics.QueuePolicyInfo, 1.0, append(labels, queue.Policy, queue.OperatorPolicy)...)
+	}
+
+	emitGauge(ch, c.metrics.QueueInfo, 1.0, append(labels,
+		strconv.FormatBool(queue.Durable),
+		strconv.FormatBool(queue.AutoDelete),
+		strconv.FormatBool(queue.Exclusive),
+		queue.Policy,
+		queue.GetArgumentString("x-max-length"),
+		queue.GetArgumentString("x-max-length-bytes"),
+		queue.GetArgumentString("x-message-ttl"),
+		queue.GetArgumentString("x-overflow"),
+	)...)
+	if queue.Node != "" {
+		emitGauge(ch, c.metrics.QueueNodeInfo, 1.0, append(labels, queue.Node)...)
+	}
+	if mode := queue.QueueMode(); mode != "" {
+		emitGauge(ch, c.metrics.QueueModeInfo, 1.0, append(labels, mode)...)
+	}
+	if queue.IdleSince != nil {
+		emitGauge(ch, c.metrics.QueueIdleSeconds, idleSeconds(queue.IdleSince.Time, time.Now()), labels...)
+	}
+	c.collectLeaderChanges(ch, queue, labels)
+	c.collectPriorityMetrics(ch, queue, labels)
+	if c.collect.Consumers {
+		c.collectConsumerMetrics(ch, queue, labels)
+	}
+
+	if queue.IsQuorumQueue() {
+		c.collectQuorumMetrics(ch, queue, labels)
+	}
+
+	// Stream message counts are retained history rather than backlog, so
+	// depth-based health and alerting don't apply to them
+	if queue.IsStreamQueue() {
+		c.collectStreamMetrics(ch, queue, labels)
+		return
+	}
+
+	c.collectSaturationMetrics(ch, queue, labels)
+	emitGauge(ch, c.metrics.QueueEstimatedDrainSeconds, estimatedDrainSeconds(queue), labels...)
+	emitGauge(ch, c.metrics.QueueAckRatio, rateRatio(queue.GetAckRate(), queue.GetDeliverRate(), 1), labels...)
+	emitGauge(ch, c.metrics.QueueRedeliveryRatio, rateRatio(queue.GetRedeliverRate(), queue.GetDeliverRate(), 0), labels...)
+	c.collectBacklogGrowthMetrics(ch, queue, labels)
+	if score, ok := c.depthBaselines.Score(queue.Vhost, queue.Name); ok {
+		anomaly := 0.0
+		if score.Anomaly {
+			anomaly = 1.0
+		}
+		emitGauge(ch, c.metrics.QueueDepthZScore, score.ZScore, labels...)
+		emitGauge(ch, c.metrics.QueueDepthAnomaly, anomaly, labels...)
+	}
+	if c.healthMetrics {
+		c.collectHealthMetrics(ch, queue, labels)
+	}
+}
+
+// idleSeconds is how long a queue that went idle at since has been idle at
+// now. Clock skew between the broker and the exporter can put since in the
+// future, which counts as just gone idle.
+func idleSeconds(since, now time.Time) float64 {
+	return math.Max(now.Sub(since).Seconds(), 0)
+}
+
+// rateRatio divides rate by base, capped at 1 since the broker samples the
+// two rates independently. Without a base rate it returns idle rather than
+// NaN or +Inf.
+func rateRatio(rate, base, idle float64) float64 {
+	if base <= 0 {
+		return idle
+	}
+	return math.Min(rate/base, 1)
+}
+
+// estimatedDrainSeconds predicts how long the ready messages take to be
+// delivered at the current rate. Queues with a backlog but no deliveries get
+// MaxDrainSeconds rather than +Inf, so the metric stays usable in
+// aggregations.
+func estimatedDrainSeconds(queue rabbitmq.Queue) float64 {
+	if queue.MessagesReady <= 0 {
+		return 0
+	}
+	rate := queue.GetDeliverRate()
+	if rate <= 0 {
+		return MaxDrainSeconds
+	}
+	return math.Min(float64(queue.MessagesReady)/rate, MaxDrainSeconds)
+}
+
+// collectSaturationMetrics reports how close a queue is to its max-length
+// limits. RabbitMQ only counts ready messages towards them.
+func (c *Collector) collectSaturationMetrics(ch chan<- prometheus.Metric, queue rabbitmq.Queue, labels []string) {
+	if limit, ok := queue.MaxLength(); ok && limit > 0 {
+		emitGauge(ch, c.metrics.QueueMaxLengthRatio, float64(queue.MessagesReady)/float64(limit), labels...)
+	}
+	if limit, ok := queue.MaxLengthBytes(); ok && limit > 0 {
+		emitGauge(ch, c.metrics.QueueMaxLengthBytesRatio, float64(queue.MessageBytesReady)/float64(limit), labels...)
+	}
+}
+
+func (c *Collector) collectStreamMetrics(ch chan<- prometheus.Metric, queue rabbitmq.Queue, labels []string) {
+	emitGauge(ch, c.metrics.QueueStreamCommittedOffset, float64(queue.CommittedOffset), labels...)
+	emitGauge(ch, c.metrics.QueueStreamReaders, float64(queue.Readers), labels...)
+	emitGauge(ch, c.metrics.QueueStreamSegments, float64(queue.Segments), labels...)
+}
+
+// collectBindingMetrics reports a queue's bindings once they have been
+// listed at least once
+func (c *Collector) collectBindingMetrics(ch chan<- prometheus.Metric, queue rabbitmq.Queue, labels []string) {
+	c.mu.RLock()
+	counts := c.bindingCounts
+	c.mu.RUnlock()
+	if counts == nil {
+		return
+	}
+
+	bindings := counts[queue.Vhost][queue.Name]
+	unbound := 0.0
+	if bindings == 0 {
+		unbound = 1.0
+	}
+	emitGauge(ch, c.metrics.QueueBindings, float64(bindings), labels...)
+	emitGauge(ch, c.metrics.QueueUnbound, unbound, labels...)
+}
+
+func (c *Collector) isDeadLetterQueue(queue rabbitmq.Queue) bool {
+	if c.deadLetter.Load().Match(&queue) {
+		return true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.deadLetterBound[queue.Vhost][queue.Name]
+}
+
+func (c *Collector) collectQuorumMetrics(ch chan<- prometheus.Metric, queue rabbitmq.Queue, labels []string) {
+	if queue.Leader != "" {
+		emitGauge(ch, c.metrics.QueueQuorumLeader, 1.0, append(labels, queue.Leader)...)
+	}
+
+	emitGauge(ch, c.metrics.QueueQuorumMembers, float64(len(queue.Members)), labels...)
+	emitGauge(ch, c.metrics.QueueQuorumOnlineMembers, float64(len(queue.Online)), labels...)
+
+	underReplicated := 0.0
+	if queue.IsUnderReplicated() {
+		underReplicated = 1.0
+	}
+	emitGauge(ch, c.metrics.QueueQuorumUnderReplicated, underReplicated, labels...)
+
+	for node, files := range queue.OpenFiles {
+		emitGauge(ch, c.metrics.QueueQuorumOpenFiles, float64(files), append(labels, node)...)
+	}
+}
+
+func (c *Collector) updateCanaryMetrics() {
+	if c.canaries == nil {
+		return
+	}
+
+	for _, status := range c.canaries.Status(time.Now()) {
+		labels := []string{status.Pipeline, status.Queue, status.Vhost}
+		c.metrics.CanarySecondsSinceChange.WithLabelValues(labels...).Set(status.SinceChange.Seconds())
+
+		alive := 0.0
+		if status.Alive {
+			alive = 1.0
+		}
+		c.metrics.CanaryProducerAlive.WithLabelValues(labels...).Set(alive)
+	}
+}
+
+func (c *Collector) collectHealthMetrics(ch chan<- prometheus.Metric, queue rabbitmq.Queue, labels []string) {
+	healthScore := 100.0
+	depth := c.depthThresholds.Load().ForQueue(queue)
+
+	if queue.Messages > depth.Warning {
+		healthScore -= 20
+	}
+	if queue.Messages > depth.Critical {
+		healthScore -= 30
+	}
+
+	if queue.ConsumerUtilisation < UtilisationWarning {
+		healthScore -= 25
+	}
+	if queue.ConsumerUtilisation < UtilisationCritical {
+		healthScore -= 40
+	}
+
+	redeliverRate := queue.GetRedeliverRate()
+	if redeliverRate > 1.0 {
+		healthScore -= 15
+	}
+	if redeliverRate > 5.0 {
+		healthScore -= 25
+	}
+
+	if healthScore < 0 {
+		healthScore = 0
+	}
+
+	emitGauge(ch, c.metrics.QueueHealthScore, healthScore, labels...)
+
+	for _, tier := range c.severityTiers {
+		threshold, ok := tier.DepthThreshold(depth)
+		if !ok {
+			continue
+		}
+		alert := 0.0
+		if queue.Messages > threshold {
+			alert = 1.0
+		}
+		emitGauge(ch, c.metrics.QueueDepthAlert, alert, append(labels, tier.Severity)...)
+	}
+
+	for _, tier := range c.severityTiers {
+		if tier.Utilisation == 0 {
+			continue
+		}
+		alert := 0.0
+		if queue.ConsumerUtilisation < tier.Utilisation {
+			alert = 1.0
+		}
+		emitGauge(ch, c.metrics.QueueUtilizationAlert, alert, append(labels, tier.Severity)...)
+	}
+}
+
+// collectClusterMetrics emits the metrics aggregated across queues during
+// the last collection
+func (c *Collector) collectClusterMetrics(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	policyCounts := c.policyCounts
+	overview := c.overview
+	nodes := c.nodes
+	rollups := c.vhostRollups
+	aggregated := c.aggregated
+	distribution := c.depthDistribution
+	publishing := c.publishing
+	userActivity := c.userActivity
+	protocolCounts := c.protocolCounts
+	aliveness := c.aliveness
+	healthResults := c.healthResults
+	queuesPerNode := c.queuesPerNode
+	c.mu.RUnlock()
+
+	for _, p := range policyCounts {
+		emitGauge(ch, c.metrics.PolicyQueues, float64(p.Queues), p.Vhost, p.Name, p.Kind)
+	}
+	if overview != nil {
+		c.collectOverviewMetrics(ch, overview)
+	}
+	c.collectVhostRollups(ch, rollups)
+	c.collectDepthDistribution(ch, distribution)
+	c.collectPublishingMetrics(ch, publishing)
+	c.collectUserMetrics(ch, userActivity, c.collect.Channels)
+	c.collectProtocolMetrics(ch, protocolCounts)
+	for vhost, n := range aggregated {
+		emitGauge(ch, c.metrics.VhostAggregatedQueues, float64(n), vhost)
+	}
+	for _, node := range nodes {
+		c.collectNodeMetrics(ch, node)
+	}
+	for node, n := range queuesPerNode {
+		emitGauge(ch, c.metrics.NodeQueues, float64(n), node)
+	}
+	if c.collect.Nodes {
+		c.collectMembershipMetrics(ch, nodes)
+	}
+	if c.depthSLOs != nil {
+		c.collectDepthSLOMetrics(ch)
+	}
+	for _, r := range aliveness {
+		success := 0.0
+		if r.OK {
+			success = 1.0
+		}
+		emitGauge(ch, c.metrics.AlivenessSuccess, success, r.Vhost)
+		emitGauge(ch, c.metrics.AlivenessDurationSeconds, r.Duration.Seconds(), r.Vhost)
+	}
+	for _, r := range healthResults {
+		passed := 0.0
+		if r.Passed {
+			passed = 1.0
+		}
+		emitGauge(ch, c.metrics.HealthCheckPassed, passed, r.Check)
+	}
+}
+
+func (c *Collector) collectNodeMetrics(ch chan<- prometheus.Metric, node rabbitmq.Node) {
+	memAlarm, diskAlarm := 0.0, 0.0
+	if node.MemAlarm {
+		memAlarm = 1.0
+	}
+	if node.DiskFreeAlarm {
+		diskAlarm = 1.0
+	}
+	emitGauge(ch, c.metrics.NodeMemAlarm, memAlarm, node.Name)
+	emitGauge(ch, c.metrics.NodeDiskFreeAlarm, diskAlarm, node.Name)
+	emitGauge(ch, c.metrics.NodePartitions, float64(len(node.Partitions)), node.Name)
+	if node.Running {
+		c.collectNodeIOMetrics(ch, node)
+		c.collectNodeRuntimeMetrics(ch, node)
+	}
+}
+
+func (c *Collector) collectOverviewMetrics(ch chan<- prometheus.Metric, overview *rabbitmq.Overview) {
+	emitGauge(ch, c.metrics.ClusterQueues, float64(overview.ObjectTotals.Queues))
+	emitGauge(ch, c.metrics.ClusterExchanges, float64(overview.ObjectTotals.Exchanges))
+	emitGauge(ch, c.metrics.ClusterConnections, float64(overview.ObjectTotals.Connections))
+	emitGauge(ch, c.metrics.ClusterChannels, float64(overview.ObjectTotals.Channels))
+	emitGauge(ch, c.metrics.ClusterConsumers, float64(overview.ObjectTotals.Consumers))
+
+	emitGauge(ch, c.metrics.ClusterMessages, float64(overview.QueueTotals.Messages))
+	emitGauge(ch, c.metrics.ClusterMessagesReady, float64(overview.QueueTotals.MessagesReady))
+	emitGauge(ch, c.metrics.ClusterMessagesUnacknowledged, float64(overview.QueueTotals.MessagesUnacknowledged))
+
+	emitGauge(ch, c.metrics.ClusterMessagePublishRate, overview.GetPublishRate())
+	emitGauge(ch, c.metrics.ClusterMessageDeliverRate, overview.GetDeliverRate())
+	emitGauge(ch, c.metrics.ClusterMessageAckRate, overview.GetAckRate())
+	emitGauge(ch, c.metrics.ClusterMessageRedeliverRate, overview.GetRedeliverRate())
+	emitGauge(ch, c.metrics.ClusterMessageConfirmRate, overview.GetConfirmRate())
+	if stats := overview.MessageStats; stats != nil {
+		emitCounter(ch, c.metrics.ClusterMessagesPublishedTotal, float64(stats.Publish))
+		emitCounter(ch, c.metrics.ClusterMessagesDeliveredTotal, float64(stats.DeliverGet))
+		emitCounter(ch, c.metrics.ClusterMessagesAcknowledgedTotal, float64(stats.Ack))
+		emitCounter(ch, c.metrics.ClusterMessagesRedeliveredTotal, float64(stats.Redeliver))
+		emitCounter(ch, c.metrics.ClusterMessagesGetTotal, float64(stats.Get))
+		emitCounter(ch, c.metrics.ClusterMessagesConfirmedTotal, float64(stats.Confirm))
+		emitCounter(ch, c.metrics.ClusterMessagesReturnedUnroutableTotal, float64(stats.ReturnUnroutable))
+	}
+
+	// Older brokers don't report churn
+	if churn := overview.ChurnRates; churn != nil {
+		for _, r := range []struct {
+			object, event string
+			rate          float64
+		}{
+			{"connection", "created", churn.ConnectionCreatedDetails.Rate},
+			{"connection", "closed", churn.ConnectionClosedDetails.Rate},
+			{"channel", "created", churn.ChannelCreatedDetails.Rate},
+			{"channel", "closed", churn.ChannelClosedDetails.Rate},
+			{"queue", "created", churn.QueueCreatedDetails.Rate},
+			{"queue", "declared", churn.QueueDeclaredDetails.Rate},
+			{"queue", "deleted", churn.QueueDeletedDetails.Rate},
+		} {
+			emitGauge(ch, c.metrics.ClusterChurnRate, r.rate, r.object, r.event)
+		}
+	}
+}
+
+// emitGauge sends a const gauge for a single series
+func emitGauge(ch chan<- prometheus.Metric, desc *prometheus.Desc, value float64, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+}
+
+// emitCounter sends a const counter for a single series
+func emitCounter(ch chan<- prometheus.Metric, desc *prometheus.Desc, value float64, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, labels...)
+}
+
+func (c *Collector) collectMetrics(ch chan<- prometheus.Metric) {
+	collectors := c.metrics.GetAllCollectors()
+	for _, collector := range collectors {
+		collector.Collect(ch)
+	}
+}
+
+// SnapshotCollector returns a prometheus.Collector serving the cached
+// snapshot without the per-scrape bookkeeping Collect performs, for pushes
+// and reports that shouldn't skew scrape metrics
+func (c *Collector) SnapshotCollector() prometheus.Collector {
+	return snapshotCollector{c: c}
+}
+
+// snapshotCollector is the collector returned by SnapshotCollector
+type snapshotCollector struct {
+	c *Collector
+}
+
+func (s snapshotCollector) Describe(ch chan<- *prometheus.Desc) {
+	s.c.Describe(ch)
+}
+
+func (s snapshotCollector) Collect(ch chan<- prometheus.Metric) {
+	s.c.mu.RLock()
+	queues := s.c.exportedQueues
+	otherQueues := s.c.otherQueues
+	cacheValid := s.c.cacheValid
+	s.c.mu.RUnlock()
+
+	if cacheValid {
+		for _, queue := range queues {
+			s.c.collectQueueMetrics(ch, queue)
+		}
+		for _, other := range otherQueues {
+			s.c.collectOtherQueueMetrics(ch, other)
+		}
+		s.c.collectClusterMetrics(ch)
+	}
+	// Canary liveness depends on the time of the report, and with
+	// textfile_only nothing else updates it
+	s.c.updateCanaryMetrics()
+	s.c.collectMetrics(ch)
+}
+
+// Snapshot returns the cached queue data along with the sequence number of
+// the collection it came from
+func (c *Collector) Snapshot() Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	snapshot := Snapshot{
+		SnapshotID: c.snapshotID,
+		Timestamp:  c.cacheTimestamp,
+		Valid:      c.cacheValid,
+		Queues:     make([]QueueSnapshot, 0, len(c.cachedQueues)),
+	}
+	if c.collectionError != nil {
+		snapshot.Error = c.collectionError.Error()
+	}
+	for _, q := range c.cachedQueues {
+		snapshot.Queues = append(snapshot.Queues, newQueueSnapshot(q))
+	}
+	return snapshot
+}
+
+// Stop ends background collection, cancelling any management API requests in
+// flight, and waits for the collection loop to exit. Calling it again is a
+// no-op.
+func (c *Collector) Stop() {
+	c.stopOnce.Do(c.stop)
+}
+
+func (c *Collector) stop() {
+	close(c.stopChan)
+	c.cancelStop()
+
+	c.loopMu.Lock()
+	done := c.loopDone
+	c.loopMu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
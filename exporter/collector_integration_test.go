@@ -0,0 +1,157 @@
+//go:build integration
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"rabbitmq-exporter/amqpprobe"
+	"rabbitmq-exporter/integration"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+func TestIntegration_Exposition(t *testing.T) {
+	ctx := context.Background()
+
+	broker, err := integration.Start(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start broker: %v", err)
+	}
+	defer broker.Terminate(ctx)
+
+	if err := broker.DeclareQueue("it.orders", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.DeclareQueue("it.payments", amqp.Table{"x-queue-type": "quorum"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.Publish(ctx, "it.orders", 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.Publish(ctx, "it.payments", 3); err != nil {
+		t.Fatal(err)
+	}
+	cancelConsumer, err := broker.Consume("it.payments", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancelConsumer()
+
+	client := rabbitmq.NewClient(broker.ManagementURL, broker.Username, broker.Password, 10*time.Second)
+	defer client.Close()
+
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	expected := []string{
+		`rabbitmq_custom_queue_messages_ready{queue_name="it.orders",type="classic",vhost="/"} 5`,
+		`rabbitmq_custom_queue_consumers{queue_name="it.orders",type="classic",vhost="/"} 0`,
+		`rabbitmq_custom_queue_messages_ready{queue_name="it.payments",type="quorum",vhost="/"} 2`,
+		`rabbitmq_custom_queue_messages_unacknowledged{queue_name="it.payments",type="quorum",vhost="/"} 1`,
+		`rabbitmq_custom_queue_consumers{queue_name="it.payments",type="quorum",vhost="/"} 1`,
+		`rabbitmq_custom_queue_quorum_members{queue_name="it.payments",type="quorum",vhost="/"} 1`,
+	}
+
+	var exposition string
+	ok := integration.Eventually(ctx, 30*time.Second, func() bool {
+		collector.collectQueueData()
+		exposition, err = scrape(server.URL)
+		if err != nil {
+			return false
+		}
+		for _, line := range expected {
+			if !strings.Contains(exposition, line) {
+				return false
+			}
+		}
+		return true
+	})
+	if !ok {
+		t.Fatalf("Exposition did not converge, last error %v, last output:\n%s", err, exposition)
+	}
+}
+
+func TestIntegration_BrokerDown(t *testing.T) {
+	ctx := context.Background()
+
+	broker, err := integration.Start(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start broker: %v", err)
+	}
+
+	client := rabbitmq.NewClient(broker.ManagementURL, broker.Username, broker.Password, 2*time.Second)
+	defer client.Close()
+	if err := client.HealthCheck(ctx); err != nil {
+		t.Fatalf("Health check failed against running broker: %v", err)
+	}
+
+	if err := broker.Terminate(ctx); err != nil {
+		t.Fatalf("Failed to stop broker: %v", err)
+	}
+	if err := client.HealthCheck(ctx); err == nil {
+		t.Error("Expected health check to fail after the broker stopped")
+	}
+}
+
+func TestIntegration_AMQPProbe(t *testing.T) {
+	ctx := context.Background()
+
+	broker, err := integration.Start(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start broker: %v", err)
+	}
+	defer broker.Terminate(ctx)
+
+	if err := broker.DeclareQueue("it.probe", amqp.Table{"x-queue-type": "quorum"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, queue := range []string{"", "it.probe"} {
+		prober, err := amqpprobe.New(amqpprobe.Config{URL: broker.AMQPURL, Queue: queue, Timeout: 5 * time.Second}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roundTrip, err := prober.Probe(ctx)
+		if err != nil {
+			t.Errorf("Probe with queue %q failed: %v", queue, err)
+		} else if roundTrip <= 0 {
+			t.Errorf("Expected a positive round trip with queue %q, got %v", queue, roundTrip)
+		}
+	}
+
+	prober, _ := amqpprobe.New(amqpprobe.Config{URL: broker.AMQPURL, Queue: "it.missing"}, nil)
+	if _, err := prober.Probe(ctx); err == nil {
+		t.Error("Expected a missing probe queue to fail the probe")
+	}
+}
+
+func scrape(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("scrape returned HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
@@ -0,0 +1,1640 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewCollector(t *testing.T) {
+	client := rabbitmq.NewClient("http://localhost:15672", "guest", "guest", 10*time.Second)
+	metrics := metrics.NewMetrics()
+	scrapeInterval := 15 * time.Second
+
+	collector := NewCollector(client, metrics, scrapeInterval)
+
+	if collector == nil {
+		t.Fatal("Expected collector to be created, got nil")
+	}
+
+	if collector.client != client {
+		t.Error("Expected client to be set correctly")
+	}
+
+	if collector.metrics != metrics {
+		t.Error("Expected metrics to be set correctly")
+	}
+
+	if collector.scrapeInterval != scrapeInterval {
+		t.Errorf("Expected scrape interval to be %v, got %v", scrapeInterval, collector.scrapeInterval)
+	}
+}
+
+func TestCollector_Describe(t *testing.T) {
+	// Create a new registry for testing
+	registry := prometheus.NewRegistry()
+
+	// Create test metrics with unique names to avoid conflicts
+	testMetrics := &metrics.Metrics{
+		QueueMessages: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_test",
+			"Total number of messages in the queue",
+			[]string{"queue_name", "vhost", "type", "state"}, nil,
+		),
+		QueueMessagesReady: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_ready_test",
+			"Number of messages ready to be delivered",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagesUnacknowledged: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_unacknowledged_test",
+			"Number of messages that have been delivered but not yet acknowledged",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMemoryBytes: prometheus.NewDesc(
+			"rabbitmq_custom_queue_memory_bytes_test",
+			"Bytes of memory consumed by the queue process, including stack, heap and internal structures",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessageBytes: prometheus.NewDesc(
+			"rabbitmq_custom_queue_message_bytes_test",
+			"Sum of the size of all message bodies in the queue",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessageBytesReady: prometheus.NewDesc(
+			"rabbitmq_custom_queue_message_bytes_ready_test",
+			"Sum of the size of message bodies ready to be delivered",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessageBytesUnacknowledged: prometheus.NewDesc(
+			"rabbitmq_custom_queue_message_bytes_unacknowledged_test",
+			"Sum of the size of message bodies delivered but not yet acknowledged",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagesRAM: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_ram_test",
+			"Number of messages held in RAM",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagesPersistent: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_persistent_test",
+			"Number of persistent messages in the queue",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMaxLengthRatio: prometheus.NewDesc(
+			"rabbitmq_custom_queue_max_length_ratio_test",
+			"Ready messages as a fraction of the queue's max-length limit, from arguments or policy",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMaxLengthBytesRatio: prometheus.NewDesc(
+			"rabbitmq_custom_queue_max_length_bytes_ratio_test",
+			"Ready message bytes as a fraction of the queue's max-length-bytes limit, from arguments or policy",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagePublishRate: prometheus.NewDesc(
+			"rabbitmq_custom_queue_message_publish_rate_test",
+			"Message publish rate per second",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessageDeliverRate: prometheus.NewDesc(
+			"rabbitmq_custom_queue_message_deliver_rate_test",
+			"Message delivery rate per second",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessageAckRate: prometheus.NewDesc(
+			"rabbitmq_custom_queue_message_ack_rate_test",
+			"Message acknowledgment rate per second",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessageRedeliverRate: prometheus.NewDesc(
+			"rabbitmq_custom_queue_message_redeliver_rate_test",
+			"Message redelivery rate per second",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagesPublishedTotal: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_published_total_test",
+			"Total number of messages published to the queue",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagesDeliveredTotal: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_delivered_total_test",
+			"Total number of messages delivered to consumers from the queue",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagesAcknowledgedTotal: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_acknowledged_total_test",
+			"Total number of messages acknowledged by consumers of the queue",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagesRedeliveredTotal: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_redelivered_total_test",
+			"Total number of messages redelivered from the queue",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagesGetTotal: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_get_total_test",
+			"Total number of messages fetched from the queue with basic.get in manual acknowledgement mode",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueEstimatedDrainSeconds: prometheus.NewDesc(
+			"rabbitmq_custom_queue_estimated_drain_seconds_test",
+			"Estimated time to deliver the ready messages at the current delivery rate, capped at 7 days",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueIdleSeconds: prometheus.NewDesc(
+			"rabbitmq_custom_queue_idle_seconds_test",
+			"Seconds since the queue last saw activity, from idle_since. Only exported while the queue is idle.",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueAckRatio: prometheus.NewDesc(
+			"rabbitmq_custom_queue_ack_ratio_test",
+			"Ack rate as a fraction of the deliver rate, capped at 1. 1 while nothing is delivered.",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueRedeliveryRatio: prometheus.NewDesc(
+			"rabbitmq_custom_queue_redelivery_ratio_test",
+			"Redeliver rate as a fraction of the deliver rate, capped at 1. 0 while nothing is delivered.",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueNetGrowthRate: prometheus.NewDesc(
+			"rabbitmq_custom_queue_net_growth_rate_test",
+			"Messages published per second minus messages consumed per second, with or without acks; positive while the backlog grows",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueBacklogGrowing: prometheus.NewDesc(
+			"rabbitmq_custom_queue_backlog_growing_test",
+			"Whether the queue's net growth rate has been positive for backlog_growth_collections consecutive collections (1 = growing)",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueConsumers: prometheus.NewDesc(
+			"rabbitmq_custom_queue_consumers_test",
+			"Number of consumers connected to the queue",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueConsumerUtilisation: prometheus.NewDesc(
+			"rabbitmq_custom_queue_consumer_utilisation_test",
+			"Consumer utilisation as a percentage (0-1)",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueConsumerCapacity: prometheus.NewDesc(
+			"rabbitmq_custom_queue_consumer_capacity_test",
+			"Fraction of time the queue can deliver to consumers immediately (0-1), falling back to utilisation on brokers before 3.8",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueState: prometheus.NewDesc(
+			"rabbitmq_custom_queue_state_test",
+			"Queue state indicator (1 for current state, 0 otherwise)",
+			[]string{"queue_name", "vhost", "type", "state"}, nil,
+		),
+		QueueIsDeadLetter: prometheus.NewDesc(
+			"rabbitmq_custom_queue_is_dead_letter_test",
+			"Indicates if the queue is a dead letter queue (1 if true, 0 if false)",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueInfo: prometheus.NewDesc(
+			"rabbitmq_custom_queue_info_test",
+			"Queue configuration, always 1. Argument labels are empty when the argument isn't set.",
+			[]string{"queue_name", "vhost", "type", "durable", "auto_delete", "exclusive", "policy", "max_length", "max_length_bytes", "message_ttl", "overflow"}, nil,
+		),
+		QueuePolicyInfo: prometheus.NewDesc(
+			"rabbitmq_custom_queue_policy_info_test",
+			"Policy and operator policy applied to the queue, always 1. Labels are empty when none applies.",
+			[]string{"queue_name", "vhost", "type", "policy", "operator_policy"}, nil,
+		),
+		QueueNodeInfo: prometheus.NewDesc(
+			"rabbitmq_custom_queue_node_info_test",
+			"Node hosting the queue, always 1: the node of a classic queue, or the leader of a quorum queue or stream",
+			[]string{"queue_name", "vhost", "type", "node"}, nil,
+		),
+		QueueModeInfo: prometheus.NewDesc(
+			"rabbitmq_custom_queue_mode_info_test",
+			"Mode a classic queue runs in (default or lazy), always 1, whether set by argument or policy",
+			[]string{"queue_name", "vhost", "type", "mode"}, nil,
+		),
+		QueueLeaderChangesTotal: prometheus.NewDesc(
+			"rabbitmq_custom_queue_leader_changes_total_test",
+			"Number of times the node hosting the queue changed since the exporter started, e.g. on failover or rebalancing",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMaxPriority: prometheus.NewDesc(
+			"rabbitmq_custom_queue_max_priority_test",
+			"Highest message priority the queue supports, from its x-max-priority argument",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueMessagesByPriority: prometheus.NewDesc(
+			"rabbitmq_custom_queue_messages_ready_by_priority_test",
+			"Number of messages ready for delivery in a priority queue, by priority level",
+			[]string{"queue_name", "vhost", "type", "priority"}, nil,
+		),
+		QueueConsumersByAckMode: prometheus.NewDesc(
+			"rabbitmq_custom_queue_consumers_by_ack_mode_test",
+			"Number of consumers of the queue by acknowledgement mode: manual, or auto for consumers that don't ack",
+			[]string{"queue_name", "vhost", "type", "ack_mode"}, nil,
+		),
+		QueueConsumerPrefetch: prometheus.NewDesc(
+			"rabbitmq_custom_queue_consumer_prefetch_test",
+			"Distribution of the prefetch limits of the queue's consumers. Unlimited prefetch (0) only counts towards +Inf and the count.",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueSingleActiveConsumerActive: prometheus.NewDesc(
+			"rabbitmq_custom_queue_single_active_consumer_active_test",
+			"Whether a single active consumer queue has a consumer receiving messages (1) or none (0)",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueBindings: prometheus.NewDesc(
+			"rabbitmq_custom_queue_bindings_test",
+			"Number of bindings to the queue, excluding the implicit default exchange binding",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueUnbound: prometheus.NewDesc(
+			"rabbitmq_custom_queue_unbound_test",
+			"Indicates if the queue has no bindings besides the default exchange (1 if true, 0 if false)",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		PolicyQueues: prometheus.NewDesc(
+			"rabbitmq_custom_policy_queues_test",
+			"Number of queues the policy applies to, by kind (policy or operator_policy)",
+			[]string{"vhost", "policy", "kind"}, nil,
+		),
+		QueueQuorumLeader: prometheus.NewDesc(
+			"rabbitmq_custom_queue_quorum_leader_test",
+			"Node currently leading the quorum queue (1 for the leader node)",
+			[]string{"queue_name", "vhost", "type", "node"}, nil,
+		),
+		QueueQuorumMembers: prometheus.NewDesc(
+			"rabbitmq_custom_queue_quorum_members_test",
+			"Number of configured quorum queue members",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueQuorumOnlineMembers: prometheus.NewDesc(
+			"rabbitmq_custom_queue_quorum_online_members_test",
+			"Number of quorum queue members currently online",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueQuorumUnderReplicated: prometheus.NewDesc(
+			"rabbitmq_custom_queue_quorum_under_replicated_test",
+			"Indicates if fewer quorum queue members are online than configured (1 if true, 0 if false)",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueQuorumOpenFiles: prometheus.NewDesc(
+			"rabbitmq_custom_queue_quorum_open_files_test",
+			"Number of open files held by the quorum queue on each member node",
+			[]string{"queue_name", "vhost", "type", "node"}, nil,
+		),
+		QueueStreamCommittedOffset: prometheus.NewDesc(
+			"rabbitmq_custom_queue_stream_committed_offset_test",
+			"Last committed offset of the stream",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueStreamReaders: prometheus.NewDesc(
+			"rabbitmq_custom_queue_stream_readers_test",
+			"Number of readers attached to the stream",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueStreamSegments: prometheus.NewDesc(
+			"rabbitmq_custom_queue_stream_segments_test",
+			"Number of segment files backing the stream",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueHealthScore: prometheus.NewDesc(
+			"rabbitmq_custom_queue_health_score_test",
+			"Queue health score (0-100, higher is better)",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueDepthAlert: prometheus.NewDesc(
+			"rabbitmq_custom_queue_depth_alert_test",
+			"Queue depth alert indicator (1 if depth > threshold, 0 otherwise)",
+			[]string{"queue_name", "vhost", "type", "severity"}, nil,
+		),
+		QueueUtilizationAlert: prometheus.NewDesc(
+			"rabbitmq_custom_queue_utilization_alert_test",
+			"Queue utilization alert indicator (1 if utilization < threshold, 0 otherwise)",
+			[]string{"queue_name", "vhost", "type", "severity"}, nil,
+		),
+		QueueDepthZScore: prometheus.NewDesc(
+			"rabbitmq_custom_queue_depth_zscore_test",
+			"Standard deviations between the queue's depth and its rolling baseline",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		QueueDepthAnomaly: prometheus.NewDesc(
+			"rabbitmq_custom_queue_depth_anomaly_test",
+			"Queue depth anomaly indicator (1 if the depth z-score exceeds the anomaly threshold, 0 otherwise)",
+			[]string{"queue_name", "vhost", "type"}, nil,
+		),
+		ClusterQueues: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_queues_test",
+			"Number of queues in the cluster",
+			nil, nil,
+		),
+		ClusterExchanges: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_exchanges_test",
+			"Number of exchanges in the cluster",
+			nil, nil,
+		),
+		ClusterConnections: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_connections_test",
+			"Number of client connections to the cluster",
+			nil, nil,
+		),
+		ClusterChannels: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_channels_test",
+			"Number of channels open on the cluster",
+			nil, nil,
+		),
+		ClusterConsumers: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_consumers_test",
+			"Number of consumers in the cluster",
+			nil, nil,
+		),
+		ClusterMessages: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_test",
+			"Total number of messages in all queues",
+			nil, nil,
+		),
+		ClusterMessagesReady: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_ready_test",
+			"Number of messages ready to be delivered across all queues",
+			nil, nil,
+		),
+		ClusterMessagesUnacknowledged: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_unacknowledged_test",
+			"Number of unacknowledged messages across all queues",
+			nil, nil,
+		),
+		ClusterMessagePublishRate: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_message_publish_rate_test",
+			"Rate of messages published to the cluster",
+			nil, nil,
+		),
+		ClusterMessageDeliverRate: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_message_deliver_rate_test",
+			"Rate of messages delivered to consumers or fetched with basic.get across the cluster",
+			nil, nil,
+		),
+		ClusterMessageAckRate: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_message_ack_rate_test",
+			"Rate of messages acknowledged across the cluster",
+			nil, nil,
+		),
+		ClusterMessageRedeliverRate: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_message_redeliver_rate_test",
+			"Rate of messages redelivered across the cluster",
+			nil, nil,
+		),
+		ClusterMessageConfirmRate: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_message_confirm_rate_test",
+			"Rate of publisher confirms sent by the cluster",
+			nil, nil,
+		),
+		ClusterChurnRate: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_churn_rate_test",
+			"Rate at which connections, channels and queues are created and closed, by object and event",
+			[]string{"object", "event"}, nil,
+		),
+		ClusterMessagesPublishedTotal: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_published_total_test",
+			"Total number of messages published to the cluster",
+			nil, nil,
+		),
+		ClusterMessagesDeliveredTotal: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_delivered_total_test",
+			"Total number of messages delivered to consumers or fetched with basic.get across the cluster",
+			nil, nil,
+		),
+		ClusterMessagesAcknowledgedTotal: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_acknowledged_total_test",
+			"Total number of messages acknowledged across the cluster",
+			nil, nil,
+		),
+		ClusterMessagesRedeliveredTotal: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_redelivered_total_test",
+			"Total number of messages redelivered across the cluster",
+			nil, nil,
+		),
+		ClusterMessagesGetTotal: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_get_total_test",
+			"Total number of messages fetched with basic.get in manual acknowledgement mode across the cluster",
+			nil, nil,
+		),
+		ClusterMessagesConfirmedTotal: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_confirmed_total_test",
+			"Total number of publisher confirms sent by the cluster",
+			nil, nil,
+		),
+		ClusterMessagesReturnedUnroutableTotal: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_messages_returned_unroutable_total_test",
+			"Total number of mandatory messages returned to publishers as unroutable",
+			nil, nil,
+		),
+		VhostQueues: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_queues_test",
+			"Number of queues in the vhost",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessages: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_messages_test",
+			"Total number of messages in the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessagesReady: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_messages_ready_test",
+			"Number of messages ready to be delivered in the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessagesUnacknowledged: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_messages_unacknowledged_test",
+			"Number of unacknowledged messages in the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostConsumers: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_consumers_test",
+			"Number of consumers on the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessagePublishRate: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_message_publish_rate_test",
+			"Rate of messages published to the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessageDeliverRate: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_message_deliver_rate_test",
+			"Rate of messages delivered from the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostQueuesByFlag: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_queues_by_flag_test",
+			"Number of the vhost's queues that are auto_delete, exclusive or non_durable",
+			[]string{"vhost", "flag"}, nil,
+		),
+		VhostMessagesByFlag: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_messages_by_flag_test",
+			"Number of messages in the vhost's auto_delete, exclusive or non_durable queues",
+			[]string{"vhost", "flag"}, nil,
+		),
+		VhostAggregatedQueues: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_aggregated_queues_test",
+			"Number of queues beyond max_queues_per_vhost that are only exported as part of the aggregate series",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessageConfirmRate: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_message_confirm_rate_test",
+			"Rate of publisher confirms sent on the vhost's channels",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessageUnroutableRate: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_message_unroutable_rate_test",
+			"Rate of published messages that matched no queue, by outcome (returned to a mandatory publisher or dropped)",
+			[]string{"vhost", "outcome"}, nil,
+		),
+		VhostUnroutableAlert: prometheus.NewDesc(
+			"rabbitmq_custom_vhost_unroutable_alert_test",
+			"Whether messages published in the vhost are currently unroutable (1 = alert)",
+			[]string{"vhost"}, nil,
+		),
+		UserConnections: prometheus.NewDesc(
+			"rabbitmq_custom_user_connections_test",
+			"Number of open connections of the user, by application (client-provided connection name)",
+			[]string{"user", "application"}, nil,
+		),
+		UserChannels: prometheus.NewDesc(
+			"rabbitmq_custom_user_channels_test",
+			"Number of channels open on the user's connections, by application",
+			[]string{"user", "application"}, nil,
+		),
+		UserReceiveBytesRate: prometheus.NewDesc(
+			"rabbitmq_custom_user_receive_bytes_rate_test",
+			"Rate in bytes per second received from the user's connections, by application",
+			[]string{"user", "application"}, nil,
+		),
+		UserSendBytesRate: prometheus.NewDesc(
+			"rabbitmq_custom_user_send_bytes_rate_test",
+			"Rate in bytes per second sent to the user's connections, by application",
+			[]string{"user", "application"}, nil,
+		),
+		UserPublishRate: prometheus.NewDesc(
+			"rabbitmq_custom_user_message_publish_rate_test",
+			"Rate of messages published on the user's channels, by application",
+			[]string{"user", "application"}, nil,
+		),
+		ConnectionsByProtocol: prometheus.NewDesc(
+			"rabbitmq_custom_connections_by_protocol_test",
+			"Number of open connections by protocol and whether they use TLS",
+			[]string{"protocol", "tls"}, nil,
+		),
+		QueueDepthDistribution: prometheus.NewDesc(
+			"rabbitmq_custom_queue_depth_distribution_test",
+			"Distribution of queue depths in messages across all queues except streams",
+			nil, nil,
+		),
+		NodeMemAlarm: prometheus.NewDesc(
+			"rabbitmq_custom_node_mem_alarm_test",
+			"Whether the node's memory alarm is set, blocking publishers (1 = alarm)",
+			[]string{"node"}, nil,
+		),
+		NodeDiskFreeAlarm: prometheus.NewDesc(
+			"rabbitmq_custom_node_disk_free_alarm_test",
+			"Whether the node's free disk space alarm is set, blocking publishers (1 = alarm)",
+			[]string{"node"}, nil,
+		),
+		NodePartitions: prometheus.NewDesc(
+			"rabbitmq_custom_node_partitions_test",
+			"Number of cluster peers the node has detected a network partition with",
+			[]string{"node"}, nil,
+		),
+		NodeQueues: prometheus.NewDesc(
+			"rabbitmq_custom_node_queues_test",
+			"Number of queues hosted by the node: classic queues living on it, and quorum queues and streams it leads",
+			[]string{"node"}, nil,
+		),
+		ClusterNodesRunning: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_nodes_running_test",
+			"Number of cluster nodes listed as running",
+			nil, nil,
+		),
+		ClusterNodesExpected: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_nodes_expected_test",
+			"Number of running nodes the cluster is expected to have, from expected_nodes",
+			nil, nil,
+		),
+		ClusterNodeJoinsTotal: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_node_joins_total_test",
+			"Number of times a node joined the cluster or started running again since the exporter started",
+			nil, nil,
+		),
+		ClusterNodeDeparturesTotal: prometheus.NewDesc(
+			"rabbitmq_custom_cluster_node_departures_total_test",
+			"Number of times a node left the cluster or stopped running since the exporter started",
+			nil, nil,
+		),
+		DepthSLORatio: prometheus.NewDesc(
+			"rabbitmq_custom_queue_depth_slo_ratio_test",
+			"Fraction of collections within the SLO window in which the queue's depth stayed at or under its threshold",
+			[]string{"queue_name", "vhost"}, nil,
+		),
+		DepthSLOSamples: prometheus.NewDesc(
+			"rabbitmq_custom_queue_depth_slo_samples_test",
+			"Number of collections within the SLO window that the depth SLO ratio is based on",
+			[]string{"queue_name", "vhost"}, nil,
+		),
+		NodeIOOperations: prometheus.NewDesc(
+			"rabbitmq_custom_node_io_operations_total_test",
+			"Total file I/O operations of the node by operation (read, write, sync, seek)",
+			[]string{"node", "operation"}, nil,
+		),
+		NodeIOBytes: prometheus.NewDesc(
+			"rabbitmq_custom_node_io_bytes_total_test",
+			"Total bytes read and written by the node's file I/O, by operation",
+			[]string{"node", "operation"}, nil,
+		),
+		NodeIOOperationRate: prometheus.NewDesc(
+			"rabbitmq_custom_node_io_operation_rate_test",
+			"Rate of file I/O operations per second of the node by operation (read, write, sync)",
+			[]string{"node", "operation"}, nil,
+		),
+		NodeIOAverageTimeSeconds: prometheus.NewDesc(
+			"rabbitmq_custom_node_io_average_time_seconds_test",
+			"Average duration of the node's recent file I/O operations by operation (read, write, sync)",
+			[]string{"node", "operation"}, nil,
+		),
+		NodeMnesiaTransactions: prometheus.NewDesc(
+			"rabbitmq_custom_node_mnesia_transactions_total_test",
+			"Total Mnesia schema database transactions of the node by storage (ram, disk)",
+			[]string{"node", "storage"}, nil,
+		),
+		NodeMessageStoreOperations: prometheus.NewDesc(
+			"rabbitmq_custom_node_message_store_operations_total_test",
+			"Total reads and writes of the node's queue index and message store, by store (queue_index, message_store) and operation",
+			[]string{"node", "store", "operation"}, nil,
+		),
+		NodeGCRuns: prometheus.NewDesc(
+			"rabbitmq_custom_node_gc_runs_total_test",
+			"Total garbage collections run by the node's Erlang runtime",
+			[]string{"node"}, nil,
+		),
+		NodeGCRunRate: prometheus.NewDesc(
+			"rabbitmq_custom_node_gc_run_rate_test",
+			"Rate of garbage collections per second on the node",
+			[]string{"node"}, nil,
+		),
+		NodeGCBytesReclaimed: prometheus.NewDesc(
+			"rabbitmq_custom_node_gc_bytes_reclaimed_total_test",
+			"Total bytes of memory reclaimed by garbage collection on the node",
+			[]string{"node"}, nil,
+		),
+		NodeGCBytesReclaimedRate: prometheus.NewDesc(
+			"rabbitmq_custom_node_gc_bytes_reclaimed_rate_test",
+			"Rate in bytes per second of memory reclaimed by garbage collection on the node",
+			[]string{"node"}, nil,
+		),
+		NodeContextSwitches: prometheus.NewDesc(
+			"rabbitmq_custom_node_context_switches_total_test",
+			"Total Erlang process context switches on the node",
+			[]string{"node"}, nil,
+		),
+		NodeRunQueue: prometheus.NewDesc(
+			"rabbitmq_custom_node_run_queue_test",
+			"Number of Erlang processes on the node waiting for a scheduler",
+			[]string{"node"}, nil,
+		),
+		AlivenessSuccess: prometheus.NewDesc(
+			"rabbitmq_custom_aliveness_success_test",
+			"Whether the last aliveness test, which publishes and consumes a message, succeeded in the vhost (1 = success)",
+			[]string{"vhost"}, nil,
+		),
+		AlivenessDurationSeconds: prometheus.NewDesc(
+			"rabbitmq_custom_aliveness_duration_seconds_test",
+			"Duration of the last aliveness test in the vhost",
+			[]string{"vhost"}, nil,
+		),
+		HealthCheckPassed: prometheus.NewDesc(
+			"rabbitmq_custom_health_check_passed_test",
+			"Whether the last run of the management API health check passed (1 = passed)",
+			[]string{"check"}, nil,
+		),
+		CanarySecondsSinceChange: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_canary_seconds_since_change_test",
+				Help: "Seconds since the canary queue last showed producer activity",
+			},
+			[]string{"pipeline", "queue_name", "vhost"},
+		),
+		CanaryProducerAlive: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_canary_producer_alive_test",
+				Help: "Indicates if the pipeline producer published within its max silence window (1 if alive, 0 otherwise)",
+			},
+			[]string{"pipeline", "queue_name", "vhost"},
+		),
+		AMQPProbeSuccess: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_amqp_probe_success_test",
+				Help: "Whether the last AMQP probe published and consumed its message in time (1 = success)",
+			},
+			[]string{"vhost"},
+		),
+		AMQPProbeRoundTripSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_amqp_probe_round_trip_seconds_test",
+				Help: "Time from publishing the last successful AMQP probe message to consuming it",
+			},
+			[]string{"vhost"},
+		),
+		BrokerEventsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rabbitmq_custom_broker_events_total_test",
+				Help: "Events consumed from the event exchange, by routing key such as queue.created",
+			},
+			[]string{"event"},
+		),
+		EventExchangeConnected: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_event_exchange_connected_test",
+				Help: "Whether the exporter is subscribed to the event exchange (1 = connected)",
+			},
+			[]string{"exchange"},
+		),
+		ScrapeDurationSeconds: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_scrape_duration_seconds_test",
+				Help: "Duration of the last scrape in seconds",
+			},
+		),
+		CacheAgeSeconds: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_cache_age_seconds_test",
+				Help: "Age of the snapshot queue and cluster metrics are served from, or time since startup before the first one",
+			},
+		),
+		CacheStale: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_cache_stale_test",
+				Help: "Whether queue and cluster series were withheld from the last scrape because the snapshot was older than cache_max_age or the last collection failed (1) or not (0)",
+			},
+		),
+		CollectionDurationSeconds: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "rabbitmq_custom_collection_duration_seconds_test",
+				Help:    "Duration of background collections from the management API, including failed ones",
+				Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+			},
+		),
+		CollectionPhaseSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_collection_phase_seconds_test",
+				Help: "Time the last collection spent waiting on management API requests (phase=\"fetch\"), decoding their responses (phase=\"decode\") and updating the snapshot metrics are served from (phase=\"update\"). Requests run concurrently, so fetch and decode add up across them.",
+			},
+			[]string{"phase"},
+		),
+		ScrapeErrorsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rabbitmq_custom_scrape_errors_total_test",
+				Help: "Total number of scrape errors",
+			},
+			[]string{"error_type"},
+		),
+		SnapshotID: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_snapshot_id_test",
+				Help: "Sequence number of the collection the exposed queue metrics were taken from",
+			},
+		),
+		Up: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_up_test",
+				Help: "Whether the most recent background collection from the management API succeeded (1) or failed (0)",
+			},
+		),
+		LastScrapeTimestampSeconds: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_last_scrape_timestamp_seconds_test",
+				Help: "Unix timestamp of the last successful background collection",
+			},
+		),
+		CollectionsSkippedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rabbitmq_custom_collections_skipped_total_test",
+				Help: "Background collections skipped because the previous one was still running (reason=\"busy\"), had overrun their slot (reason=\"overdue\") or fell within a Retry-After pause (reason=\"backoff\")",
+			},
+			[]string{"reason"},
+		),
+		CollectionWatchdogRestarts: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "rabbitmq_custom_collection_watchdog_restarts_total_test",
+				Help: "Times the watchdog restarted background collection because no collection had completed in time",
+			},
+		),
+		BuildInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_exporter_build_info_test",
+				Help: "Exporter build information; the value is always 1",
+			},
+			[]string{"version", "commit", "go_version"},
+		),
+		ConfigInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_exporter_config_info_test",
+				Help: "Settings the exporter loaded, with config_hash identifying the whole effective configuration; the value is always 1",
+			},
+			metrics.ConfigInfoLabels,
+		),
+		CircuitBreakerState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rabbitmq_custom_circuit_breaker_state_test",
+				Help: "Circuit breaker state (0=closed, 1=open, 2=half-open)",
+			},
+			[]string{"endpoint"},
+		),
+		CircuitBreakerFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rabbitmq_custom_circuit_breaker_failures_total_test",
+				Help: "Total number of circuit breaker failures",
+			},
+			[]string{"endpoint"},
+		),
+		CircuitBreakerManualResets: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rabbitmq_custom_circuit_breaker_manual_resets_total_test",
+				Help: "Total number of manual circuit breaker resets via the admin API",
+			},
+			[]string{"endpoint"},
+		),
+		APIRequestDurationSeconds: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "rabbitmq_custom_api_request_duration_seconds_test",
+				Help:    "Duration of management API requests by endpoint and HTTP status code (code=\"error\" when no response was received)",
+				Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30},
+			},
+			[]string{"endpoint", "code"},
+		),
+	}
+
+	// Register test metrics
+	registry.MustRegister(testMetrics.CanarySecondsSinceChange)
+	registry.MustRegister(testMetrics.CanaryProducerAlive)
+	registry.MustRegister(testMetrics.AMQPProbeSuccess)
+	registry.MustRegister(testMetrics.AMQPProbeRoundTripSeconds)
+	registry.MustRegister(testMetrics.BrokerEventsTotal)
+	registry.MustRegister(testMetrics.EventExchangeConnected)
+	registry.MustRegister(testMetrics.ScrapeDurationSeconds)
+	registry.MustRegister(testMetrics.CacheAgeSeconds)
+	registry.MustRegister(testMetrics.CacheStale)
+	registry.MustRegister(testMetrics.CollectionDurationSeconds)
+	registry.MustRegister(testMetrics.CollectionPhaseSeconds)
+	registry.MustRegister(testMetrics.ScrapeErrorsTotal)
+	registry.MustRegister(testMetrics.SnapshotID)
+	registry.MustRegister(testMetrics.Up)
+	registry.MustRegister(testMetrics.LastScrapeTimestampSeconds)
+	registry.MustRegister(testMetrics.CollectionsSkippedTotal)
+	registry.MustRegister(testMetrics.CollectionWatchdogRestarts)
+	registry.MustRegister(testMetrics.BuildInfo)
+	registry.MustRegister(testMetrics.ConfigInfo)
+	registry.MustRegister(testMetrics.CircuitBreakerState)
+	registry.MustRegister(testMetrics.CircuitBreakerFailures)
+	registry.MustRegister(testMetrics.CircuitBreakerManualResets)
+	registry.MustRegister(testMetrics.APIRequestDurationSeconds)
+
+	client := rabbitmq.NewClient("http://localhost:15672", "guest", "guest", 10*time.Second)
+	scrapeInterval := 15 * time.Second
+
+	collector := NewCollector(client, testMetrics, scrapeInterval)
+
+	// Test Describe method
+	descChan := make(chan *prometheus.Desc, 200)
+	collector.Describe(descChan)
+	close(descChan)
+
+	// Count descriptions
+	descCount := 0
+	for range descChan {
+		descCount++
+	}
+
+	// We should have descriptions for all our metrics
+	expectedDescCount := 146 // Total number of metrics
+	if descCount < expectedDescCount {
+		t.Errorf("Expected at least %d descriptions, got %d", expectedDescCount, descCount)
+	}
+}
+
+var _ Client = (*exportertest.Client)(nil)
+
+func TestCollector_Collect(t *testing.T) {
+	client := &exportertest.Client{
+		Queues: []rabbitmq.Queue{
+			{Name: "orders", Vhost: "/", Type: "quorum", Messages: 12, Consumers: 2},
+			{Name: "billing", Vhost: "/", Messages: 3},
+		},
+	}
+	m := metrics.NewMetrics()
+	collector := NewCollector(client, m, time.Hour)
+	defer collector.Stop()
+
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_consumers Number of consumers connected to the queue
+# TYPE rabbitmq_custom_queue_consumers gauge
+rabbitmq_custom_queue_consumers{queue_name="billing",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_consumers{queue_name="orders",type="quorum",vhost="/"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_queue_consumers"); err != nil {
+		t.Error(err)
+	}
+	if got := testutil.ToFloat64(m.Up); got != 1 {
+		t.Errorf("Expected up 1, got %v", got)
+	}
+
+	client.SetError("GetQueues", errors.New("connection refused"))
+	collector.collectQueueData()
+
+	if got := testutil.ToFloat64(m.Up); got != 0 {
+		t.Errorf("Expected up 0 after a failed collection, got %v", got)
+	}
+	if got := client.Calls("GetQueues"); got < 2 {
+		t.Errorf("Expected at least 2 queue requests, got %d", got)
+	}
+}
+
+// queueMetricsCollector exposes collectQueueMetrics for one queue as a
+// prometheus.Collector
+type queueMetricsCollector struct {
+	collector *Collector
+	queue     rabbitmq.Queue
+}
+
+func (q queueMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(q, ch)
+}
+
+func (q queueMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	q.collector.collectQueueMetrics(ch, q.queue)
+}
+
+func TestCollector_collectQueueMetrics(t *testing.T) {
+	collector := NewCollector(&exportertest.Client{}, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+
+	queue := rabbitmq.Queue{
+		Name:                   "orders",
+		Vhost:                  "/",
+		Messages:               15,
+		MessagesReady:          10,
+		MessagesUnacknowledged: 5,
+	}
+
+	expected := `
+# HELP rabbitmq_custom_queue_messages_ready Number of messages ready to be delivered
+# TYPE rabbitmq_custom_queue_messages_ready gauge
+rabbitmq_custom_queue_messages_ready{queue_name="orders",type="classic",vhost="/"} 10
+# HELP rabbitmq_custom_queue_messages_unacknowledged Number of messages that have been delivered but not yet acknowledged
+# TYPE rabbitmq_custom_queue_messages_unacknowledged gauge
+rabbitmq_custom_queue_messages_unacknowledged{queue_name="orders",type="classic",vhost="/"} 5
+`
+	err := testutil.CollectAndCompare(queueMetricsCollector{collector, queue}, strings.NewReader(expected),
+		"rabbitmq_custom_queue_messages_ready", "rabbitmq_custom_queue_messages_unacknowledged")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// newTestCollector returns a collector whose cache holds the queues served by
+// a fake management API
+func newTestCollector(t *testing.T, queuesJSON string) (*Collector, *metrics.Metrics) {
+	t.Helper()
+
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/nodes" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(queuesJSON))
+	}))
+	t.Cleanup(rabbit.Close)
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	m := metrics.NewMetrics()
+	collector := NewCollector(client, m, time.Hour)
+	t.Cleanup(collector.Stop)
+
+	collector.collectQueueData()
+	return collector, m
+}
+
+func TestCollector_QueueTypeLabel(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"orders","vhost":"/","type":"quorum","consumers":3},
+		{"name":"legacy","vhost":"/","consumers":1}
+	]`)
+
+	expected := `
+# HELP rabbitmq_custom_queue_consumers Number of consumers connected to the queue
+# TYPE rabbitmq_custom_queue_consumers gauge
+rabbitmq_custom_queue_consumers{queue_name="legacy",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_consumers{queue_name="orders",type="quorum",vhost="/"} 3
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_queue_consumers"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_QueueInfo(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"orders","vhost":"/","durable":true,"policy":"ha","arguments":{"x-max-length":5000,"x-overflow":"reject-publish","x-message-ttl":60000}},
+		{"name":"amq.gen-1","vhost":"/","auto_delete":true,"exclusive":true}
+	]`)
+
+	expected := `
+# HELP rabbitmq_custom_queue_info Queue configuration, always 1. Argument labels are empty when the argument isn't set.
+# TYPE rabbitmq_custom_queue_info gauge
+rabbitmq_custom_queue_info{auto_delete="false",durable="true",exclusive="false",max_length="5000",max_length_bytes="",message_ttl="60000",overflow="reject-publish",policy="ha",queue_name="orders",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_info{auto_delete="true",durable="false",exclusive="true",max_length="",max_length_bytes="",message_ttl="",overflow="",policy="",queue_name="amq.gen-1",type="classic",vhost="/"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_queue_info"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_QueueNodeInfo(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"orders","vhost":"/","type":"quorum","node":"rabbit@b","leader":"rabbit@b"},
+		{"name":"legacy","vhost":"/","node":"rabbit@a"},
+		{"name":"unplaced","vhost":"/"}
+	]`)
+
+	expected := `
+# HELP rabbitmq_custom_queue_node_info Node hosting the queue, always 1: the node of a classic queue, or the leader of a quorum queue or stream
+# TYPE rabbitmq_custom_queue_node_info gauge
+rabbitmq_custom_queue_node_info{node="rabbit@a",queue_name="legacy",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_node_info{node="rabbit@b",queue_name="orders",type="quorum",vhost="/"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_queue_node_info"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_QueueModeInfo(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"archive","vhost":"/","backing_queue_status":{"mode":"lazy"}},
+		{"name":"orders","vhost":"/","effective_policy_definition":{"queue-mode":"lazy"}},
+		{"name":"legacy","vhost":"/"},
+		{"name":"events","vhost":"/","type":"quorum"}
+	]`)
+
+	expected := `
+# HELP rabbitmq_custom_queue_mode_info Mode a classic queue runs in (default or lazy), always 1, whether set by argument or policy
+# TYPE rabbitmq_custom_queue_mode_info gauge
+rabbitmq_custom_queue_mode_info{mode="default",queue_name="legacy",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_mode_info{mode="lazy",queue_name="archive",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_mode_info{mode="lazy",queue_name="orders",type="classic",vhost="/"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_queue_mode_info"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_QueueIdleSeconds(t *testing.T) {
+	idleSince := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	collector, _ := newTestCollector(t, `[
+		{"name":"stale","vhost":"/","idle_since":"`+idleSince+`"},
+		{"name":"busy","vhost":"/"}
+	]`)
+
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_idle_seconds"); n != 1 {
+		t.Fatalf("Expected an idle series for the idle queue only, got %d", n)
+	}
+	now := time.Now()
+	if got := idleSeconds(now.Add(-30*24*time.Hour), now); got != 30*24*3600 {
+		t.Errorf("Expected 30 days idle, got %vs", got)
+	}
+	if got := idleSeconds(now.Add(time.Minute), now); got != 0 {
+		t.Errorf("Expected a future idle_since to count as 0, got %v", got)
+	}
+}
+
+func TestCollector_RateRatios(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"orders","vhost":"/","message_stats":{"deliver_details":{"rate":10},"ack_details":{"rate":8},"redeliver_details":{"rate":2}}},
+		{"name":"catching-up","vhost":"/","message_stats":{"deliver_details":{"rate":5},"ack_details":{"rate":20}}},
+		{"name":"quiet","vhost":"/"}
+	]`)
+
+	expected := `
+# HELP rabbitmq_custom_queue_ack_ratio Ack rate as a fraction of the deliver rate, capped at 1. 1 while nothing is delivered.
+# TYPE rabbitmq_custom_queue_ack_ratio gauge
+rabbitmq_custom_queue_ack_ratio{queue_name="catching-up",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_ack_ratio{queue_name="orders",type="classic",vhost="/"} 0.8
+rabbitmq_custom_queue_ack_ratio{queue_name="quiet",type="classic",vhost="/"} 1
+# HELP rabbitmq_custom_queue_redelivery_ratio Redeliver rate as a fraction of the deliver rate, capped at 1. 0 while nothing is delivered.
+# TYPE rabbitmq_custom_queue_redelivery_ratio gauge
+rabbitmq_custom_queue_redelivery_ratio{queue_name="catching-up",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_redelivery_ratio{queue_name="orders",type="classic",vhost="/"} 0.2
+rabbitmq_custom_queue_redelivery_ratio{queue_name="quiet",type="classic",vhost="/"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_ack_ratio", "rabbitmq_custom_queue_redelivery_ratio"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_MaxLengthSaturation(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"orders","vhost":"/","messages":900,"messages_ready":800,"messages_unacknowledged":100,"message_bytes_ready":256,"arguments":{"x-max-length":1000},"effective_policy_definition":{"max-length-bytes":1024}},
+		{"name":"unbounded","vhost":"/","messages_ready":5000}
+	]`)
+
+	expected := `
+# HELP rabbitmq_custom_queue_max_length_bytes_ratio Ready message bytes as a fraction of the queue's max-length-bytes limit, from arguments or policy
+# TYPE rabbitmq_custom_queue_max_length_bytes_ratio gauge
+rabbitmq_custom_queue_max_length_bytes_ratio{queue_name="orders",type="classic",vhost="/"} 0.25
+# HELP rabbitmq_custom_queue_max_length_ratio Ready messages as a fraction of the queue's max-length limit, from arguments or policy
+# TYPE rabbitmq_custom_queue_max_length_ratio gauge
+rabbitmq_custom_queue_max_length_ratio{queue_name="orders",type="classic",vhost="/"} 0.8
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_max_length_ratio", "rabbitmq_custom_queue_max_length_bytes_ratio"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_EstimatedDrainSeconds(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"draining","vhost":"/","messages_ready":600,"message_stats":{"deliver_details":{"rate":20}}},
+		{"name":"stuck","vhost":"/","messages_ready":10},
+		{"name":"slow","vhost":"/","messages_ready":1000000000,"message_stats":{"deliver_details":{"rate":0.5}}},
+		{"name":"empty","vhost":"/","message_stats":{"deliver_details":{"rate":5}}},
+		{"name":"events","vhost":"/","type":"stream","messages_ready":5000}
+	]`)
+
+	expected := `
+# HELP rabbitmq_custom_queue_estimated_drain_seconds Estimated time to deliver the ready messages at the current delivery rate, capped at 7 days
+# TYPE rabbitmq_custom_queue_estimated_drain_seconds gauge
+rabbitmq_custom_queue_estimated_drain_seconds{queue_name="draining",type="classic",vhost="/"} 30
+rabbitmq_custom_queue_estimated_drain_seconds{queue_name="empty",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_estimated_drain_seconds{queue_name="slow",type="classic",vhost="/"} 604800
+rabbitmq_custom_queue_estimated_drain_seconds{queue_name="stuck",type="classic",vhost="/"} 604800
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_estimated_drain_seconds"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_MessageCounters(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"orders","vhost":"/","message_stats":{"publish":120,"deliver":100,"ack":95,"redeliver":3,"get":7}},
+		{"name":"new","vhost":"/"}
+	]`)
+
+	expected := `
+# HELP rabbitmq_custom_queue_messages_get_total Total number of messages fetched from the queue with basic.get in manual acknowledgement mode
+# TYPE rabbitmq_custom_queue_messages_get_total counter
+rabbitmq_custom_queue_messages_get_total{queue_name="orders",type="classic",vhost="/"} 7
+# HELP rabbitmq_custom_queue_messages_published_total Total number of messages published to the queue
+# TYPE rabbitmq_custom_queue_messages_published_total counter
+rabbitmq_custom_queue_messages_published_total{queue_name="orders",type="classic",vhost="/"} 120
+# HELP rabbitmq_custom_queue_messages_redelivered_total Total number of messages redelivered from the queue
+# TYPE rabbitmq_custom_queue_messages_redelivered_total counter
+rabbitmq_custom_queue_messages_redelivered_total{queue_name="orders",type="classic",vhost="/"} 3
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_messages_published_total",
+		"rabbitmq_custom_queue_messages_redelivered_total",
+		"rabbitmq_custom_queue_messages_get_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_Overview(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues":
+			w.Write([]byte(`[]`))
+		case "/api/overview":
+			w.Write([]byte(`{
+				"cluster_name":"rabbit@prod",
+				"object_totals":{"queues":12,"exchanges":20,"connections":5,"channels":9,"consumers":7},
+				"queue_totals":{"messages":150,"messages_ready":100,"messages_unacknowledged":50},
+				"message_stats":{"publish":1000,"publish_details":{"rate":42.5},"deliver_get":990,"deliver_get_details":{"rate":40},"ack_details":{"rate":39},"confirm_details":{"rate":42},"return_unroutable":4},
+				"churn_rates":{"connection_created_details":{"rate":0.5},"queue_deleted_details":{"rate":2}}
+			}`))
+		}
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_cluster_churn_rate Rate at which connections, channels and queues are created and closed, by object and event
+# TYPE rabbitmq_custom_cluster_churn_rate gauge
+rabbitmq_custom_cluster_churn_rate{event="closed",object="channel"} 0
+rabbitmq_custom_cluster_churn_rate{event="closed",object="connection"} 0
+rabbitmq_custom_cluster_churn_rate{event="created",object="channel"} 0
+rabbitmq_custom_cluster_churn_rate{event="created",object="connection"} 0.5
+rabbitmq_custom_cluster_churn_rate{event="created",object="queue"} 0
+rabbitmq_custom_cluster_churn_rate{event="declared",object="queue"} 0
+rabbitmq_custom_cluster_churn_rate{event="deleted",object="queue"} 2
+# HELP rabbitmq_custom_cluster_connections Number of client connections to the cluster
+# TYPE rabbitmq_custom_cluster_connections gauge
+rabbitmq_custom_cluster_connections 5
+# HELP rabbitmq_custom_cluster_message_deliver_rate Rate of messages delivered to consumers or fetched with basic.get across the cluster
+# TYPE rabbitmq_custom_cluster_message_deliver_rate gauge
+rabbitmq_custom_cluster_message_deliver_rate 40
+# HELP rabbitmq_custom_cluster_message_publish_rate Rate of messages published to the cluster
+# TYPE rabbitmq_custom_cluster_message_publish_rate gauge
+rabbitmq_custom_cluster_message_publish_rate 42.5
+# HELP rabbitmq_custom_cluster_messages_delivered_total Total number of messages delivered to consumers or fetched with basic.get across the cluster
+# TYPE rabbitmq_custom_cluster_messages_delivered_total counter
+rabbitmq_custom_cluster_messages_delivered_total 990
+# HELP rabbitmq_custom_cluster_messages_published_total Total number of messages published to the cluster
+# TYPE rabbitmq_custom_cluster_messages_published_total counter
+rabbitmq_custom_cluster_messages_published_total 1000
+# HELP rabbitmq_custom_cluster_messages_ready Number of messages ready to be delivered across all queues
+# TYPE rabbitmq_custom_cluster_messages_ready gauge
+rabbitmq_custom_cluster_messages_ready 100
+# HELP rabbitmq_custom_cluster_messages_returned_unroutable_total Total number of mandatory messages returned to publishers as unroutable
+# TYPE rabbitmq_custom_cluster_messages_returned_unroutable_total counter
+rabbitmq_custom_cluster_messages_returned_unroutable_total 4
+# HELP rabbitmq_custom_cluster_queues Number of queues in the cluster
+# TYPE rabbitmq_custom_cluster_queues gauge
+rabbitmq_custom_cluster_queues 12
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_cluster_churn_rate",
+		"rabbitmq_custom_cluster_connections",
+		"rabbitmq_custom_cluster_message_deliver_rate",
+		"rabbitmq_custom_cluster_message_publish_rate",
+		"rabbitmq_custom_cluster_messages_delivered_total",
+		"rabbitmq_custom_cluster_messages_published_total",
+		"rabbitmq_custom_cluster_messages_ready",
+		"rabbitmq_custom_cluster_messages_returned_unroutable_total",
+		"rabbitmq_custom_cluster_queues",
+	); err != nil {
+		t.Error(err)
+	}
+
+	collector.collect.Overview = false
+	collector.collectQueueData()
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_cluster_queues"); n != 0 {
+		t.Errorf("Expected no overview metrics with the group disabled, got %d", n)
+	}
+}
+
+func TestCollector_NodeAlarms(t *testing.T) {
+	nodesStatus := http.StatusOK
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues":
+			w.Write([]byte(`[]`))
+		case "/api/nodes":
+			w.WriteHeader(nodesStatus)
+			w.Write([]byte(`[
+				{"name":"rabbit@a","running":true,"mem_alarm":true,"disk_free_alarm":false,"partitions":[]},
+				{"name":"rabbit@b","running":true,"mem_alarm":false,"disk_free_alarm":true,"partitions":["rabbit@c"]},
+				{"name":"rabbit@c","running":true,"partitions":["rabbit@a","rabbit@b"]}
+			]`))
+		}
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_node_disk_free_alarm Whether the node's free disk space alarm is set, blocking publishers (1 = alarm)
+# TYPE rabbitmq_custom_node_disk_free_alarm gauge
+rabbitmq_custom_node_disk_free_alarm{node="rabbit@a"} 0
+rabbitmq_custom_node_disk_free_alarm{node="rabbit@b"} 1
+rabbitmq_custom_node_disk_free_alarm{node="rabbit@c"} 0
+# HELP rabbitmq_custom_node_mem_alarm Whether the node's memory alarm is set, blocking publishers (1 = alarm)
+# TYPE rabbitmq_custom_node_mem_alarm gauge
+rabbitmq_custom_node_mem_alarm{node="rabbit@a"} 1
+rabbitmq_custom_node_mem_alarm{node="rabbit@b"} 0
+rabbitmq_custom_node_mem_alarm{node="rabbit@c"} 0
+# HELP rabbitmq_custom_node_partitions Number of cluster peers the node has detected a network partition with
+# TYPE rabbitmq_custom_node_partitions gauge
+rabbitmq_custom_node_partitions{node="rabbit@a"} 0
+rabbitmq_custom_node_partitions{node="rabbit@b"} 1
+rabbitmq_custom_node_partitions{node="rabbit@c"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_node_disk_free_alarm",
+		"rabbitmq_custom_node_mem_alarm",
+		"rabbitmq_custom_node_partitions",
+	); err != nil {
+		t.Error(err)
+	}
+
+	// A failed fetch drops the alarms rather than reporting stale values
+	nodesStatus = http.StatusInternalServerError
+	collector.collectQueueData()
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_node_mem_alarm"); n != 0 {
+		t.Errorf("Expected no node metrics after a failed fetch, got %d", n)
+	}
+}
+
+func TestCollector_ConcurrentScrapes(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"a","vhost":"/","consumers":1},
+		{"name":"b","vhost":"/","consumers":1},
+		{"name":"c","vhost":"/","consumers":1}
+	]`)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			families, err := registry.Gather()
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, f := range families {
+				if f.GetName() == "rabbitmq_custom_queue_consumers" && len(f.GetMetric()) != 3 {
+					errs <- fmt.Errorf("expected 3 consumer series, got %d", len(f.GetMetric()))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestCollector_RemovedQueueSeriesDropped(t *testing.T) {
+	var mu sync.Mutex
+	queuesJSON := `[{"name":"orders","vhost":"/"},{"name":"temp","vhost":"/"}]`
+
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write([]byte(queuesJSON))
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+
+	collector.collectQueueData()
+	if got := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_consumers"); got != 2 {
+		t.Fatalf("Expected 2 consumer series, got %d", got)
+	}
+
+	mu.Lock()
+	queuesJSON = `[{"name":"orders","vhost":"/"}]`
+	mu.Unlock()
+	collector.collectQueueData()
+
+	// Only the removed queue's series disappear; series for remaining queues
+	// are emitted on every scrape without a reset gap
+	expected := `
+# HELP rabbitmq_custom_queue_consumers Number of consumers connected to the queue
+# TYPE rabbitmq_custom_queue_consumers gauge
+rabbitmq_custom_queue_consumers{queue_name="orders",type="classic",vhost="/"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_queue_consumers"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_UpAndLastScrapeTimestamp(t *testing.T) {
+	var mu sync.Mutex
+	status := http.StatusOK
+
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+		w.Write([]byte(`[]`))
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	m := metrics.NewMetrics()
+	collector := NewCollector(client, m, time.Hour)
+	defer collector.Stop()
+
+	before := float64(time.Now().Unix())
+	collector.collectQueueData()
+
+	if got := testutil.ToFloat64(m.Up); got != 1 {
+		t.Errorf("Expected up 1 after a successful collection, got %v", got)
+	}
+	lastScrape := testutil.ToFloat64(m.LastScrapeTimestampSeconds)
+	if lastScrape < before {
+		t.Errorf("Expected last scrape timestamp >= %v, got %v", before, lastScrape)
+	}
+
+	mu.Lock()
+	status = http.StatusInternalServerError
+	mu.Unlock()
+	collector.collectQueueData()
+
+	if got := testutil.ToFloat64(m.Up); got != 0 {
+		t.Errorf("Expected up 0 after a failed collection, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.LastScrapeTimestampSeconds); got != lastScrape {
+		t.Errorf("Expected last scrape timestamp to stay %v after a failure, got %v", lastScrape, got)
+	}
+}
+
+func TestCollector_CircuitBreakerMetricsPerEndpoint(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second, rabbitmq.WithCircuitBreaker(10, time.Hour))
+	m := metrics.NewMetrics()
+	collector := newCollector(client, m, time.Hour)
+
+	base := testutil.ToFloat64(m.CircuitBreakerFailures.WithLabelValues(rabbitmq.EndpointQueues))
+	collector.collectQueueData()
+	collector.collectQueueData()
+
+	// Each failure is counted once, not re-added on every collection
+	if got := testutil.ToFloat64(m.CircuitBreakerFailures.WithLabelValues(rabbitmq.EndpointQueues)) - base; got != 2 {
+		t.Errorf("Expected 2 new queue endpoint failures, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.CircuitBreakerState.WithLabelValues(rabbitmq.EndpointQueues)); got != 0 {
+		t.Errorf("Expected queues breaker to stay closed below the threshold, got %v", got)
+	}
+}
+
+func TestCollector_CollectionHook(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer rabbit.Close()
+
+	var mu sync.Mutex
+	calls := 0
+	hook := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+	}
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := newCollector(client, metrics.NewMetrics(), time.Hour, WithCollectionHook(hook))
+
+	mu.Lock()
+	before := calls
+	mu.Unlock()
+
+	collector.collectQueueData()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != before+1 {
+		t.Errorf("Expected hook to run once per successful collection, got %d calls", calls-before)
+	}
+}
+
+func TestCollector_ScrapeErrorType(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	m := metrics.NewMetrics()
+	collector := NewCollector(client, m, time.Hour)
+	defer collector.Stop()
+
+	collector.collectQueueData()
+	testutil.CollectAndCount(collector)
+
+	if got := testutil.ToFloat64(m.ScrapeErrorsTotal.WithLabelValues(rabbitmq.ErrorTypeAuth)); got != 1 {
+		t.Errorf("Expected one auth scrape error, got %v", got)
+	}
+}
+
+func TestCollector_QueuesDisabled(t *testing.T) {
+	var paths []string
+	var mu sync.Mutex
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	m := metrics.NewMetrics()
+	collector := NewCollector(client, m, time.Hour, WithCollectGroups(CollectGroups{Nodes: true}))
+	defer collector.Stop()
+
+	collector.collectQueueData()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 2 || paths[0] != "/api/overview" || paths[1] != "/api/nodes" {
+		t.Errorf("Expected only the overview and nodes to be fetched, got %v", paths)
+	}
+	if got := testutil.ToFloat64(m.Up); got != 1 {
+		t.Errorf("Expected up=1 from the overview, got %v", got)
+	}
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_messages"); n != 0 {
+		t.Errorf("Expected no queue series, got %d", n)
+	}
+}
+
+func TestCollector_Warm(t *testing.T) {
+	release := make(chan struct{})
+	var slow atomic.Bool
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() && r.URL.Path == "/api/queues" {
+			<-release
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer rabbit.Close()
+	defer close(release)
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", 5*time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+	if !collector.Warm(5 * time.Second) {
+		t.Error("Expected the cache to be warm after the initial collection")
+	}
+
+	slow.Store(true)
+	cold := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer cold.Stop()
+	start := time.Now()
+	if cold.Warm(50 * time.Millisecond) {
+		t.Error("Expected the cache to be cold while the collection is still running")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Warm to give up after its timeout, took %v", elapsed)
+	}
+}
+
+func TestCollector_StopCancelsInFlightRequests(t *testing.T) {
+	var hang atomic.Bool
+	hanging := make(chan struct{})
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hang.Load() && r.URL.Path == "/api/queues" {
+			close(hanging)
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`[{"name":"orders","vhost":"/"}]`))
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Minute)
+	m := metrics.NewMetrics()
+	collector := newCollector(client, m, time.Hour, WithCollectionTimeout(time.Minute))
+	collector.collectQueueData()
+
+	hang.Store(true)
+	go collector.collectQueueData()
+	<-hanging
+
+	stopped := make(chan struct{})
+	go func() {
+		collector.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Stop to return without waiting for the request")
+	}
+	// A second Stop, such as a deferred one, is harmless
+	collector.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for collector.collecting.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the in-flight collection to be cancelled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !collector.CacheValid() {
+		t.Error("Expected the cancelled collection to leave the last snapshot in place")
+	}
+	if got := testutil.ToFloat64(m.Up); got != 1 {
+		t.Errorf("Expected up to stay 1, got %v", got)
+	}
+}
+
+func TestCollector_CollectionTimings(t *testing.T) {
+	collector, m := newTestCollector(t, `[{"name":"orders","vhost":"/","messages":5}]`)
+	collector.collectQueueData()
+
+	if got := testutil.CollectAndCount(m.CollectionPhaseSeconds); got != 3 {
+		t.Errorf("Expected fetch, decode and update phases, got %d series", got)
+	}
+	if got := testutil.ToFloat64(m.CollectionPhaseSeconds.WithLabelValues(phaseFetch)); got <= 0 {
+		t.Errorf("Expected time spent fetching, got %v", got)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(m.CollectionDurationSeconds)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := families[0].GetMetric()[0].GetHistogram().GetSampleCount(); got != 2 {
+		t.Errorf("Expected two collections observed, got %d", got)
+	}
+}
+
+func TestCollector_StaleCacheWithheld(t *testing.T) {
+	collector, m := newTestCollector(t, `[{"name":"orders","vhost":"/","messages":5}]`)
+	WithMaxCacheAge(time.Minute)(collector)
+
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_messages"); n != 1 {
+		t.Fatalf("Expected the fresh snapshot to be served, got %d series", n)
+	}
+	if got := testutil.ToFloat64(m.CacheStale); got != 0 {
+		t.Errorf("Expected a fresh cache, got stale=%v", got)
+	}
+
+	collector.mu.Lock()
+	collector.cacheTimestamp = time.Now().Add(-2 * time.Minute)
+	collector.mu.Unlock()
+
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_messages"); n != 0 {
+		t.Errorf("Expected stale queue series to be withheld, got %d", n)
+	}
+	if got := testutil.ToFloat64(m.CacheStale); got != 1 {
+		t.Errorf("Expected the cache to be marked stale, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.CacheAgeSeconds); got < 120 {
+		t.Errorf("Expected a cache age of at least 120s, got %v", got)
+	}
+	if err := collector.Readiness(); err == nil {
+		t.Error("Expected a stale cache to fail readiness")
+	}
+}
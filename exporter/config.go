@@ -0,0 +1,133 @@
+package exporter
+
+import (
+	"time"
+
+	"rabbitmq-exporter/amqpprobe"
+	"rabbitmq-exporter/eventexchange"
+	"rabbitmq-exporter/grafana"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/otlp"
+	"rabbitmq-exporter/rabbitmq"
+	"rabbitmq-exporter/sshtunnel"
+)
+
+type Config struct {
+	RabbitMQURL      string        `mapstructure:"rabbitmq_url"`
+	RabbitMQUsername string        `mapstructure:"rabbitmq_username"`
+	RabbitMQPassword string        `mapstructure:"rabbitmq_password"`
+	ScrapeInterval   time.Duration `mapstructure:"scrape_interval"`
+	ListenPort       int           `mapstructure:"listen_port"`
+	ListenAddress    string        `mapstructure:"listen_address"`
+	Timeout          time.Duration `mapstructure:"timeout"`
+	LogLevel         string        `mapstructure:"log_level"`
+	MetricNamespace  string        `mapstructure:"metric_namespace"`
+	MetricNaming     string        `mapstructure:"metric_naming"`
+
+	// Timeouts refine Timeout, the overall request timeout
+	Timeouts          rabbitmq.Timeouts `mapstructure:"timeouts"`
+	CollectionTimeout time.Duration     `mapstructure:"collection_timeout"`
+	// WarmupTimeout bounds the collection run at startup before /metrics
+	// is served
+	WarmupTimeout time.Duration `mapstructure:"warmup_timeout"`
+	// CacheMaxAge is how old the snapshot may get before queue and cluster
+	// series are withheld from /metrics
+	CacheMaxAge time.Duration `mapstructure:"cache_max_age"`
+	// HealthMaxStaleness is how old a result /health may answer from
+	HealthMaxStaleness time.Duration `mapstructure:"health_max_staleness"`
+	// ShutdownTimeout is how long in-flight HTTP requests may take to finish
+	// once a shutdown signal arrives
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+
+	// RequestHeaders are added to every management API request
+	RequestHeaders map[string]string `mapstructure:"request_headers"`
+
+	// MaxConcurrentRequests bounds the management API requests in flight
+	// during a collection
+	MaxConcurrentRequests int `mapstructure:"max_concurrent_requests"`
+
+	// CollectionJitter moves each background collection up to this much
+	// early or late
+	CollectionJitter time.Duration `mapstructure:"collection_jitter"`
+
+	// WatchdogIntervals is how many scrape intervals may pass without a
+	// completed collection before background collection is restarted
+	WatchdogIntervals int `mapstructure:"watchdog_intervals"`
+
+	CircuitBreakerMaxFailures  int           `mapstructure:"circuit_breaker_max_failures"`
+	CircuitBreakerResetTimeout time.Duration `mapstructure:"circuit_breaker_reset_timeout"`
+	// MaxRetryAfter caps how long a Retry-After header pauses requests
+	MaxRetryAfter time.Duration `mapstructure:"max_retry_after"`
+
+	// Optional window the management API averages message rates over
+	MsgRatesAge  time.Duration `mapstructure:"msg_rates_age"`
+	MsgRatesIncr time.Duration `mapstructure:"msg_rates_incr"`
+
+	Profile string `mapstructure:"profile"`
+
+	SSHTunnel sshtunnel.Config `mapstructure:"ssh_tunnel"`
+
+	GrafanaAnnotations GrafanaAnnotationsConfig `mapstructure:"grafana_annotations"`
+	OTLP               otlp.Config              `mapstructure:"otlp"`
+	Output             OutputConfig             `mapstructure:"output"`
+	Probe              ProbeConfig              `mapstructure:"probe"`
+	AMQPProbe          amqpprobe.Config         `mapstructure:"amqp_probe"`
+	EventExchange      eventexchange.Config     `mapstructure:"event_exchange"`
+	ClusterLabel       ClusterLabelConfig       `mapstructure:"cluster_label"`
+	HealthChecks       HealthChecksConfig       `mapstructure:"health_checks"`
+	MetricTransition   MetricTransitionConfig   `mapstructure:"metric_transition"`
+
+	AdminUsername string `mapstructure:"admin_username"`
+	AdminPassword string `mapstructure:"admin_password"`
+	// AdminListenAddress moves the admin and pprof endpoints to a server of
+	// their own
+	AdminListenAddress string `mapstructure:"admin_listen_address"`
+
+	QueueDepthThresholds []DepthThresholdConfig `mapstructure:"queue_depth_thresholds"`
+	QueueLabelRegex      string                 `mapstructure:"queue_label_regex"`
+	RollupOnlyVhosts     []string               `mapstructure:"rollup_only_vhosts"`
+	MaxQueuesPerVhost    int                    `mapstructure:"max_queues_per_vhost"`
+	IgnoreQueueArgument  string                 `mapstructure:"ignore_queue_argument"`
+	CanaryQueues         []CanaryConfig         `mapstructure:"canary_queues"`
+	DepthSLOs            []DepthSLOConfig       `mapstructure:"depth_slos"`
+	SeverityTiers        []SeverityTierConfig   `mapstructure:"severity_tiers"`
+	AlivenessVhosts      []string               `mapstructure:"aliveness_vhosts"`
+	ExpectedNodes        int                    `mapstructure:"expected_nodes"`
+	DeadLetter           DeadLetterConfig       `mapstructure:"dead_letter"`
+	AnomalyDetection     AnomalyConfig          `mapstructure:"anomaly_detection"`
+	Notifier             NotifierConfig         `mapstructure:"notifier"`
+	// BacklogGrowthCollections is how many consecutive collections a queue's
+	// backlog must grow for to be flagged
+	BacklogGrowthCollections int `mapstructure:"backlog_growth_collections"`
+
+	Collect CollectGroups `mapstructure:"collect"`
+	// HealthMetrics exports the health score and the depth and utilisation
+	// alert indicators derived from the other queue metrics
+	HealthMetrics bool `mapstructure:"health_metrics"`
+
+	EnablePprof    bool `mapstructure:"enable_pprof"`
+	RuntimeMetrics bool `mapstructure:"runtime_metrics"`
+
+	StateFile         string        `mapstructure:"state_file"`
+	StateSaveInterval time.Duration `mapstructure:"state_save_interval"`
+}
+
+// GrafanaAnnotationsConfig configures pushing cluster events to Grafana
+type GrafanaAnnotationsConfig struct {
+	grafana.Config             `mapstructure:",squash"`
+	MassQueueDeletionThreshold int `mapstructure:"mass_queue_deletion_threshold"`
+}
+
+// DeadLetterConfig configures how dead letter queues are detected
+type DeadLetterConfig struct {
+	Patterns  []string `mapstructure:"patterns"`
+	Exchanges []string `mapstructure:"exchanges"`
+}
+
+// MetricTransitionConfig enables exporting renamed metrics under both their
+// old and new names while dashboards migrate
+type MetricTransitionConfig struct {
+	Enabled bool             `mapstructure:"enabled"`
+	Sunset  string           `mapstructure:"sunset"`
+	Renames []metrics.Rename `mapstructure:"renames"`
+}
@@ -0,0 +1,107 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// ConsumerPrefetchBuckets are the upper bounds of the consumer prefetch
+// histogram
+var ConsumerPrefetchBuckets = []float64{1, 10, 100, 1000}
+
+// Acknowledgement modes, the ack_mode label values
+const (
+	ackModeManual = "manual"
+	ackModeAuto   = "auto"
+)
+
+// consumerSummary aggregates the consumers of one queue
+type consumerSummary struct {
+	manual int
+	auto   int
+	// singleActive is set when a consumer of a single active consumer
+	// queue is receiving messages
+	singleActive bool
+
+	prefetchCount   uint64
+	prefetchSum     float64
+	prefetchBuckets map[float64]uint64
+}
+
+func newConsumerSummary() *consumerSummary {
+	s := &consumerSummary{prefetchBuckets: make(map[float64]uint64, len(ConsumerPrefetchBuckets))}
+	for _, bound := range ConsumerPrefetchBuckets {
+		s.prefetchBuckets[bound] = 0
+	}
+	return s
+}
+
+func (s *consumerSummary) add(consumer rabbitmq.Consumer) {
+	if consumer.AckRequired {
+		s.manual++
+	} else {
+		s.auto++
+	}
+	if consumer.ActivityStatus == "single_active" {
+		s.singleActive = true
+	}
+
+	s.prefetchCount++
+	// Unlimited prefetch is beyond every bucket, and would make the sum
+	// meaningless
+	if consumer.PrefetchCount == 0 {
+		return
+	}
+	prefetch := float64(consumer.PrefetchCount)
+	s.prefetchSum += prefetch
+	// Prometheus histogram buckets are cumulative
+	for _, bound := range ConsumerPrefetchBuckets {
+		if prefetch <= bound {
+			s.prefetchBuckets[bound]++
+		}
+	}
+}
+
+// summarizeConsumers groups consumers by queue, keyed like queueKey
+func summarizeConsumers(consumers []rabbitmq.Consumer) map[string]*consumerSummary {
+	summaries := make(map[string]*consumerSummary)
+	for _, consumer := range consumers {
+		key := queueKey(rabbitmq.Queue{Name: consumer.Queue.Name, Vhost: consumer.Queue.Vhost})
+		summary, ok := summaries[key]
+		if !ok {
+			summary = newConsumerSummary()
+			summaries[key] = summary
+		}
+		summary.add(consumer)
+	}
+	return summaries
+}
+
+// collectConsumerMetrics exports the consumer details of a queue once
+// consumers have been listed. Queues without consumers report zeros, so
+// their series don't come and go with the consumers.
+func (c *Collector) collectConsumerMetrics(ch chan<- prometheus.Metric, queue rabbitmq.Queue, labels []string) {
+	c.mu.RLock()
+	summaries := c.consumerSummaries
+	c.mu.RUnlock()
+	if summaries == nil {
+		return
+	}
+
+	summary, ok := summaries[queueKey(queue)]
+	if !ok {
+		summary = newConsumerSummary()
+	}
+	emitGauge(ch, c.metrics.QueueConsumersByAckMode, float64(summary.manual), append(labels, ackModeManual)...)
+	emitGauge(ch, c.metrics.QueueConsumersByAckMode, float64(summary.auto), append(labels, ackModeAuto)...)
+	ch <- prometheus.MustNewConstHistogram(c.metrics.QueueConsumerPrefetch, summary.prefetchCount, summary.prefetchSum, summary.prefetchBuckets, labels...)
+
+	if queue.IsSingleActiveConsumer() {
+		active := 0.0
+		if summary.singleActive {
+			active = 1.0
+		}
+		emitGauge(ch, c.metrics.QueueSingleActiveConsumerActive, active, labels...)
+	}
+}
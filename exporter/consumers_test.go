@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_ConsumerMetrics(t *testing.T) {
+	orders := rabbitmq.ConsumerQueue{Name: "orders", Vhost: "/"}
+	jobs := rabbitmq.ConsumerQueue{Name: "jobs", Vhost: "/"}
+	client := &exportertest.Client{
+		Queues: []rabbitmq.Queue{
+			{Name: "orders", Vhost: "/", Consumers: 3},
+			{Name: "jobs", Vhost: "/", Consumers: 2, Arguments: map[string]interface{}{"x-single-active-consumer": true}},
+			{Name: "idle", Vhost: "/", Arguments: map[string]interface{}{"x-single-active-consumer": true}},
+		},
+		Consumers: []rabbitmq.Consumer{
+			{Queue: orders, AckRequired: false, PrefetchCount: 1, ActivityStatus: "up"},
+			{Queue: orders, AckRequired: false, PrefetchCount: 1, ActivityStatus: "up"},
+			{Queue: orders, AckRequired: true, PrefetchCount: 0, ActivityStatus: "up"},
+			{Queue: jobs, AckRequired: true, PrefetchCount: 50, ActivityStatus: "single_active"},
+			{Queue: jobs, AckRequired: true, PrefetchCount: 50, ActivityStatus: "waiting"},
+		},
+	}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour,
+		WithCollectGroups(CollectGroups{Queues: true, Consumers: true}))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_consumers_by_ack_mode Number of consumers of the queue by acknowledgement mode: manual, or auto for consumers that don't ack
+# TYPE rabbitmq_custom_queue_consumers_by_ack_mode gauge
+rabbitmq_custom_queue_consumers_by_ack_mode{ack_mode="auto",queue_name="idle",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_consumers_by_ack_mode{ack_mode="auto",queue_name="jobs",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_consumers_by_ack_mode{ack_mode="auto",queue_name="orders",type="classic",vhost="/"} 2
+rabbitmq_custom_queue_consumers_by_ack_mode{ack_mode="manual",queue_name="idle",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_consumers_by_ack_mode{ack_mode="manual",queue_name="jobs",type="classic",vhost="/"} 2
+rabbitmq_custom_queue_consumers_by_ack_mode{ack_mode="manual",queue_name="orders",type="classic",vhost="/"} 1
+# HELP rabbitmq_custom_queue_single_active_consumer_active Whether a single active consumer queue has a consumer receiving messages (1) or none (0)
+# TYPE rabbitmq_custom_queue_single_active_consumer_active gauge
+rabbitmq_custom_queue_single_active_consumer_active{queue_name="idle",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_single_active_consumer_active{queue_name="jobs",type="classic",vhost="/"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_consumers_by_ack_mode", "rabbitmq_custom_queue_single_active_consumer_active"); err != nil {
+		t.Error(err)
+	}
+
+	summary := summarizeConsumers(client.Consumers)["orders@/"]
+	if summary.prefetchCount != 3 || summary.prefetchSum != 2 {
+		t.Errorf("Expected 3 prefetch observations summing to 2, got %d and %v", summary.prefetchCount, summary.prefetchSum)
+	}
+	if summary.prefetchBuckets[1] != 2 || summary.prefetchBuckets[1000] != 2 {
+		t.Errorf("Expected unlimited prefetch outside every bucket, got %v", summary.prefetchBuckets)
+	}
+}
+
+func TestCollector_ConsumersDisabled(t *testing.T) {
+	client := &exportertest.Client{Queues: []rabbitmq.Queue{{Name: "orders", Vhost: "/"}}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	if got := client.Calls("GetConsumers"); got != 0 {
+		t.Errorf("Expected no consumer listing by default, got %d", got)
+	}
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_consumers_by_ack_mode"); n != 0 {
+		t.Errorf("Expected no consumer series by default, got %d", n)
+	}
+}
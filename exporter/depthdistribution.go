@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// DepthDistributionBuckets are the upper bounds, in messages, of the queue
+// depth histogram
+var DepthDistributionBuckets = []float64{0, 10, 100, 1000, 10000, 100000}
+
+// depthDistribution is a histogram of queue depths
+type depthDistribution struct {
+	count   uint64
+	sum     float64
+	buckets map[float64]uint64
+}
+
+// newDepthDistribution buckets the depth of every queue. Streams are left out
+// like in the other depth checks, since their message count is retained
+// history rather than backlog.
+func newDepthDistribution(queues []rabbitmq.Queue) depthDistribution {
+	d := depthDistribution{buckets: make(map[float64]uint64, len(DepthDistributionBuckets))}
+	for _, bound := range DepthDistributionBuckets {
+		d.buckets[bound] = 0
+	}
+
+	for _, q := range queues {
+		if q.IsStreamQueue() {
+			continue
+		}
+		depth := float64(q.Messages)
+		d.count++
+		d.sum += depth
+		// Prometheus histogram buckets are cumulative
+		for _, bound := range DepthDistributionBuckets {
+			if depth <= bound {
+				d.buckets[bound]++
+			}
+		}
+	}
+	return d
+}
+
+func (c *Collector) collectDepthDistribution(ch chan<- prometheus.Metric, d depthDistribution) {
+	ch <- prometheus.MustNewConstHistogram(c.metrics.QueueDepthDistribution, d.count, d.sum, d.buckets)
+}
@@ -0,0 +1,36 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_DepthDistribution(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"empty","vhost":"/"},
+		{"name":"small","vhost":"/","messages":5},
+		{"name":"medium","vhost":"/","messages":500},
+		{"name":"large","vhost":"payments","messages":250000},
+		{"name":"events","vhost":"/","type":"stream","messages":1000000}
+	]`)
+
+	expected := `
+# HELP rabbitmq_custom_queue_depth_distribution Distribution of queue depths in messages across all queues except streams
+# TYPE rabbitmq_custom_queue_depth_distribution histogram
+rabbitmq_custom_queue_depth_distribution_bucket{le="0"} 1
+rabbitmq_custom_queue_depth_distribution_bucket{le="10"} 2
+rabbitmq_custom_queue_depth_distribution_bucket{le="100"} 2
+rabbitmq_custom_queue_depth_distribution_bucket{le="1000"} 3
+rabbitmq_custom_queue_depth_distribution_bucket{le="10000"} 3
+rabbitmq_custom_queue_depth_distribution_bucket{le="100000"} 3
+rabbitmq_custom_queue_depth_distribution_bucket{le="+Inf"} 4
+rabbitmq_custom_queue_depth_distribution_sum 250505
+rabbitmq_custom_queue_depth_distribution_count 4
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_depth_distribution"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,169 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"rabbitmq-exporter/grafana"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+const DefaultMassQueueDeletionThreshold = 50
+
+const (
+	EventNodeDown          = "node_down"
+	EventPartition         = "partition"
+	EventAlarm             = "alarm"
+	EventMassQueueDeletion = "mass_queue_deletion"
+)
+
+// ClusterEvent is a notable broker event detected between two collections
+type ClusterEvent struct {
+	Kind string
+	Node string
+	Text string
+	Time time.Time
+}
+
+// EventSink receives detected cluster events
+type EventSink interface {
+	Publish(event ClusterEvent)
+}
+
+// EventDetector compares consecutive snapshots to detect major broker events
+type EventDetector struct {
+	massDeletionThreshold int
+
+	initialized bool
+	nodes       map[string]rabbitmq.Node
+	queues      map[string]struct{}
+}
+
+func NewEventDetector(massDeletionThreshold int) *EventDetector {
+	if massDeletionThreshold <= 0 {
+		massDeletionThreshold = DefaultMassQueueDeletionThreshold
+	}
+	return &EventDetector{massDeletionThreshold: massDeletionThreshold}
+}
+
+// Detect returns the events that occurred since the previous snapshot. A nil
+// node list means nodes could not be fetched and node events are skipped.
+// The first snapshot only establishes a baseline.
+func (d *EventDetector) Detect(queues []rabbitmq.Queue, nodes []rabbitmq.Node, now time.Time) []ClusterEvent {
+	var events []ClusterEvent
+
+	currentQueues := make(map[string]struct{}, len(queues))
+	for _, q := range queues {
+		currentQueues[queueKey(q)] = struct{}{}
+	}
+
+	if d.initialized {
+		deleted := 0
+		for key := range d.queues {
+			if _, ok := currentQueues[key]; !ok {
+				deleted++
+			}
+		}
+		if deleted >= d.massDeletionThreshold {
+			events = append(events, ClusterEvent{
+				Kind: EventMassQueueDeletion,
+				Text: fmt.Sprintf("%d queues deleted since the previous collection", deleted),
+				Time: now,
+			})
+		}
+	}
+	d.queues = currentQueues
+
+	if nodes == nil {
+		d.initialized = true
+		return events
+	}
+
+	currentNodes := make(map[string]rabbitmq.Node, len(nodes))
+	for _, n := range nodes {
+		currentNodes[n.Name] = n
+	}
+
+	if d.initialized && d.nodes != nil {
+		for name, prev := range d.nodes {
+			curr, ok := currentNodes[name]
+			if prev.Running && (!ok || !curr.Running) {
+				events = append(events, ClusterEvent{Kind: EventNodeDown, Node: name, Text: fmt.Sprintf("Node %s is down", name), Time: now})
+			}
+		}
+		for name, curr := range currentNodes {
+			prev := d.nodes[name]
+			if len(curr.Partitions) > 0 && len(prev.Partitions) == 0 {
+				events = append(events, ClusterEvent{
+					Kind: EventPartition,
+					Node: name,
+					Text: fmt.Sprintf("Node %s detected a network partition with %v", name, curr.Partitions),
+					Time: now,
+				})
+			}
+			if curr.MemAlarm && !prev.MemAlarm {
+				events = append(events, ClusterEvent{Kind: EventAlarm, Node: name, Text: fmt.Sprintf("Memory alarm raised on node %s", name), Time: now})
+			}
+			if curr.DiskFreeAlarm && !prev.DiskFreeAlarm {
+				events = append(events, ClusterEvent{Kind: EventAlarm, Node: name, Text: fmt.Sprintf("Disk free alarm raised on node %s", name), Time: now})
+			}
+		}
+	}
+
+	d.nodes = currentNodes
+	d.initialized = true
+	return events
+}
+
+// GrafanaEventSink pushes cluster events to Grafana as annotations from a
+// background worker so collection is never blocked on Grafana
+type GrafanaEventSink struct {
+	client *grafana.Client
+	events chan ClusterEvent
+	done   chan struct{}
+}
+
+func NewGrafanaEventSink(client *grafana.Client) *GrafanaEventSink {
+	s := &GrafanaEventSink{
+		client: client,
+		events: make(chan ClusterEvent, 100),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Publish queues an event for delivery, dropping it if the queue is full
+func (s *GrafanaEventSink) Publish(event ClusterEvent) {
+	select {
+	case s.events <- event:
+	default:
+		log.Printf("Dropping Grafana annotation, queue full: %s", event.Text)
+	}
+}
+
+func (s *GrafanaEventSink) run() {
+	defer close(s.done)
+
+	for event := range s.events {
+		tags := []string{"rabbitmq", event.Kind}
+		if event.Node != "" {
+			tags = append(tags, "node:"+event.Node)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := s.client.CreateAnnotation(ctx, grafana.Annotation{Time: event.Time, Text: event.Text, Tags: tags})
+		cancel()
+		if err != nil {
+			log.Printf("Failed to push Grafana annotation: %v", err)
+		}
+	}
+}
+
+// Close flushes queued events and stops the worker
+func (s *GrafanaEventSink) Close() {
+	close(s.events)
+	<-s.done
+}
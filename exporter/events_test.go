@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+func eventKinds(events []ClusterEvent) map[string]int {
+	kinds := make(map[string]int)
+	for _, e := range events {
+		kinds[e.Kind]++
+	}
+	return kinds
+}
+
+func TestEventDetector_NodeEvents(t *testing.T) {
+	detector := NewEventDetector(0)
+	now := time.Now()
+
+	baseline := []rabbitmq.Node{
+		{Name: "rabbit@a", Running: true},
+		{Name: "rabbit@b", Running: true},
+		{Name: "rabbit@c", Running: true},
+	}
+	if events := detector.Detect(nil, baseline, now); len(events) != 0 {
+		t.Fatalf("Expected no events for the baseline snapshot, got %v", events)
+	}
+
+	changed := []rabbitmq.Node{
+		{Name: "rabbit@a", Running: true, MemAlarm: true},
+		{Name: "rabbit@b", Running: false},
+		{Name: "rabbit@c", Running: true, Partitions: []string{"rabbit@a"}, DiskFreeAlarm: true},
+	}
+	kinds := eventKinds(detector.Detect(nil, changed, now))
+
+	if kinds[EventNodeDown] != 1 {
+		t.Errorf("Expected 1 node down event, got %d", kinds[EventNodeDown])
+	}
+	if kinds[EventPartition] != 1 {
+		t.Errorf("Expected 1 partition event, got %d", kinds[EventPartition])
+	}
+	if kinds[EventAlarm] != 2 {
+		t.Errorf("Expected 2 alarm events, got %d", kinds[EventAlarm])
+	}
+
+	// Unchanged state must not re-raise events
+	if events := detector.Detect(nil, changed, now); len(events) != 0 {
+		t.Errorf("Expected no events for an unchanged snapshot, got %v", events)
+	}
+}
+
+func TestEventDetector_MassQueueDeletion(t *testing.T) {
+	detector := NewEventDetector(3)
+	now := time.Now()
+
+	var queues []rabbitmq.Queue
+	for i := 0; i < 5; i++ {
+		queues = append(queues, rabbitmq.Queue{Name: fmt.Sprintf("q%d", i), Vhost: "/"})
+	}
+	detector.Detect(queues, nil, now)
+
+	if events := detector.Detect(queues[:3], nil, now); len(events) != 0 {
+		t.Errorf("Expected no event below the deletion threshold, got %v", events)
+	}
+
+	kinds := eventKinds(detector.Detect(nil, nil, now))
+	if kinds[EventMassQueueDeletion] != 1 {
+		t.Errorf("Expected a mass queue deletion event, got %v", kinds)
+	}
+}
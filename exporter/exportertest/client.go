@@ -0,0 +1,211 @@
+// Package exportertest provides an in-memory exporter.Client, so collectors
+// can be tested without a management API.
+package exportertest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// Client serves canned management API responses. Set its fields before
+// handing it to a collector, and use the Set methods afterwards, since the
+// collector reads it from its own goroutines.
+type Client struct {
+	mu sync.Mutex
+
+	Queues           []rabbitmq.Queue
+	Nodes            []rabbitmq.Node
+	Bindings         []rabbitmq.Binding
+	Channels         []rabbitmq.Channel
+	Consumers        []rabbitmq.Consumer
+	Connections      []rabbitmq.Connection
+	Policies         []rabbitmq.Policy
+	OperatorPolicies []rabbitmq.Policy
+	// Overview defaults to an empty overview
+	Overview *rabbitmq.Overview
+	// HealthChecks holds the result of each named check; checks not listed
+	// pass
+	HealthChecks map[string]rabbitmq.HealthCheckResult
+	Breakers     map[string]rabbitmq.BreakerStatus
+	Backoff      time.Time
+
+	// Errors makes a method fail, keyed by its name, e.g. "GetQueues". An
+	// "AlivenessTest" error fails every vhost.
+	Errors map[string]error
+
+	calls map[string]int
+}
+
+// SetQueues replaces the queues returned by GetQueues
+func (c *Client) SetQueues(queues []rabbitmq.Queue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Queues = queues
+}
+
+// SetNodes replaces the nodes returned by GetNodes
+func (c *Client) SetNodes(nodes []rabbitmq.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Nodes = nodes
+}
+
+// SetError makes method fail with err, or succeed again when err is nil
+func (c *Client) SetError(method string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Errors == nil {
+		c.Errors = make(map[string]error)
+	}
+	c.Errors[method] = err
+}
+
+// Calls returns how many times method has been called
+func (c *Client) Calls(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[method]
+}
+
+// call records a call to method and returns the error it should fail with
+func (c *Client) call(method string) error {
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[method]++
+	return c.Errors[method]
+}
+
+func (c *Client) GetQueues(ctx context.Context) ([]rabbitmq.Queue, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetQueues"); err != nil {
+		return nil, err
+	}
+	return append([]rabbitmq.Queue(nil), c.Queues...), nil
+}
+
+func (c *Client) HealthCheck(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.call("HealthCheck")
+}
+
+func (c *Client) GetNodes(ctx context.Context) ([]rabbitmq.Node, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetNodes"); err != nil {
+		return nil, err
+	}
+	return append([]rabbitmq.Node(nil), c.Nodes...), nil
+}
+
+func (c *Client) GetBindings(ctx context.Context) ([]rabbitmq.Binding, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetBindings"); err != nil {
+		return nil, err
+	}
+	return append([]rabbitmq.Binding(nil), c.Bindings...), nil
+}
+
+func (c *Client) GetChannels(ctx context.Context) ([]rabbitmq.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetChannels"); err != nil {
+		return nil, err
+	}
+	return append([]rabbitmq.Channel(nil), c.Channels...), nil
+}
+
+func (c *Client) GetConsumers(ctx context.Context) ([]rabbitmq.Consumer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetConsumers"); err != nil {
+		return nil, err
+	}
+	return append([]rabbitmq.Consumer(nil), c.Consumers...), nil
+}
+
+func (c *Client) GetConnections(ctx context.Context) ([]rabbitmq.Connection, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetConnections"); err != nil {
+		return nil, err
+	}
+	return append([]rabbitmq.Connection(nil), c.Connections...), nil
+}
+
+func (c *Client) GetOverview(ctx context.Context) (*rabbitmq.Overview, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetOverview"); err != nil {
+		return nil, err
+	}
+	if c.Overview == nil {
+		return &rabbitmq.Overview{}, nil
+	}
+	overview := *c.Overview
+	return &overview, nil
+}
+
+func (c *Client) GetPolicies(ctx context.Context) ([]rabbitmq.Policy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetPolicies"); err != nil {
+		return nil, err
+	}
+	return append([]rabbitmq.Policy(nil), c.Policies...), nil
+}
+
+func (c *Client) GetOperatorPolicies(ctx context.Context) ([]rabbitmq.Policy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetOperatorPolicies"); err != nil {
+		return nil, err
+	}
+	return append([]rabbitmq.Policy(nil), c.OperatorPolicies...), nil
+}
+
+func (c *Client) AlivenessTest(ctx context.Context, vhost string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.call("AlivenessTest")
+}
+
+func (c *Client) RunHealthCheck(ctx context.Context, check string) (rabbitmq.HealthCheckResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("RunHealthCheck"); err != nil {
+		return rabbitmq.HealthCheckResult{}, err
+	}
+	if result, ok := c.HealthChecks[check]; ok {
+		return result, nil
+	}
+	return rabbitmq.HealthCheckResult{Passed: true}, nil
+}
+
+func (c *Client) CircuitBreakerStatus() map[string]rabbitmq.BreakerStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := make(map[string]rabbitmq.BreakerStatus, len(c.Breakers))
+	for endpoint, breaker := range c.Breakers {
+		status[endpoint] = breaker
+	}
+	return status
+}
+
+func (c *Client) ResetCircuitBreaker() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.call("ResetCircuitBreaker")
+}
+
+func (c *Client) BackoffUntil() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Backoff
+}
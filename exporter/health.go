@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Readiness reports why the collector can't serve current queue data, or nil
+// when it can. It only looks at the cached collection, so probing it never
+// reaches RabbitMQ. A snapshot counts as recent under the same rule /metrics
+// uses to stop serving cached queue series.
+func (c *Collector) Readiness() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.cacheValid {
+		if c.collectionError != nil {
+			return fmt.Errorf("last collection failed: %w", c.collectionError)
+		}
+		return errors.New("no collection has completed yet")
+	}
+	if age := time.Since(c.cacheTimestamp); age > c.maxCacheAge {
+		return fmt.Errorf("last collection finished %v ago", age.Round(time.Second))
+	}
+	return nil
+}
+
+// LastCollection returns when a collection last finished and its error, or
+// the zero time when none has finished yet
+func (c *Collector) LastCollection() (time.Time, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastAttempt, c.collectionError
+}
+
+// LastSuccess returns when a collection last succeeded, or the zero time
+// when none has
+func (c *Collector) LastSuccess() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastScrape
+}
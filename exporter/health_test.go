@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollector_Readiness(t *testing.T) {
+	collector, _ := newTestCollector(t, `[{"name":"orders","vhost":"/"}]`)
+
+	tests := []struct {
+		name  string
+		setup func()
+		err   string
+	}{
+		{name: "Recent collection", setup: func() {}},
+		{
+			name: "Stale collection",
+			setup: func() {
+				collector.cacheTimestamp = time.Now().Add(-3 * time.Hour)
+			},
+			err: "finished 3h0m0s ago",
+		},
+		{
+			name: "Failed collection",
+			setup: func() {
+				collector.cacheValid = false
+				collector.collectionError = errors.New("connection refused")
+			},
+			err: "connection refused",
+		},
+		{
+			name: "No collection yet",
+			setup: func() {
+				collector.cacheValid = false
+				collector.collectionError = nil
+			},
+			err: "no collection has completed yet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector.mu.Lock()
+			tt.setup()
+			collector.mu.Unlock()
+
+			err := collector.Readiness()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("Expected ready, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
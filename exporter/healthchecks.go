@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+)
+
+// HealthChecksConfig enables the management API health checks
+type HealthChecksConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Ports are checked for an active listener, e.g. 5672 for AMQP
+	Ports []int `mapstructure:"ports"`
+}
+
+// Checks returns the health checks to run, named like their
+// /api/health/checks paths
+func (c HealthChecksConfig) Checks() []string {
+	if !c.Enabled {
+		return nil
+	}
+	checks := []string{"alarms", "virtual-hosts"}
+	for _, port := range c.Ports {
+		checks = append(checks, "port-listener/"+strconv.Itoa(port))
+	}
+	return checks
+}
+
+// healthCheckResult is the outcome of one health check
+type healthCheckResult struct {
+	Check  string
+	Passed bool
+}
+
+// WithHealthChecks runs the named management API health checks on every
+// collection
+func WithHealthChecks(checks []string) CollectorOption {
+	return func(c *Collector) {
+		c.healthChecks = checks
+	}
+}
+
+// runHealthChecks runs the configured checks concurrently. Checks that could
+// not be run, e.g. because the broker predates them, are logged and left out.
+func (c *Collector) runHealthChecks(ctx context.Context) []healthCheckResult {
+	ran := make([]*healthCheckResult, len(c.healthChecks))
+	var wg sync.WaitGroup
+	for i, check := range c.healthChecks {
+		wg.Add(1)
+		go func(i int, check string) {
+			defer wg.Done()
+			result, err := c.client.RunHealthCheck(ctx, check)
+			if err != nil {
+				log.Printf("Failed to run health check %s: %v", check, err)
+				return
+			}
+			if !result.Passed {
+				log.Printf("Health check %s failed: %s", check, result.Reason)
+			}
+			ran[i] = &healthCheckResult{Check: check, Passed: result.Passed}
+		}(i, check)
+	}
+	wg.Wait()
+
+	results := make([]healthCheckResult, 0, len(ran))
+	for _, result := range ran {
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	return results
+}
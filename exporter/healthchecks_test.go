@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHealthChecksConfig_Checks(t *testing.T) {
+	if checks := (HealthChecksConfig{Ports: []int{5672}}).Checks(); checks != nil {
+		t.Errorf("Expected no checks while disabled, got %v", checks)
+	}
+
+	got := HealthChecksConfig{Enabled: true, Ports: []int{5672, 5552}}.Checks()
+	want := []string{"alarms", "virtual-hosts", "port-listener/5672", "port-listener/5552"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestCollector_HealthChecks(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues", "/api/nodes":
+			w.Write([]byte(`[]`))
+		case "/api/health/checks/alarms", "/api/health/checks/port-listener/5672":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/api/health/checks/virtual-hosts":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":"failed","reason":"Some virtual hosts are down","virtual-hosts":["payments"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer rabbit.Close()
+
+	checks := HealthChecksConfig{Enabled: true, Ports: []int{5672, 5552}}.Checks()
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithHealthChecks(checks))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	// The 5552 listener check gets a 404, so it couldn't run and is left out
+	expected := `
+# HELP rabbitmq_custom_health_check_passed Whether the last run of the management API health check passed (1 = passed)
+# TYPE rabbitmq_custom_health_check_passed gauge
+rabbitmq_custom_health_check_passed{check="alarms"} 1
+rabbitmq_custom_health_check_passed{check="port-listener/5672"} 1
+rabbitmq_custom_health_check_passed{check="virtual-hosts"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_health_check_passed"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,35 @@
+package exporter
+
+import (
+	"strings"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// DefaultIgnoreArgument is the queue argument that opts a queue out of
+// collection when set to true
+const DefaultIgnoreArgument = "x-exporter-ignore"
+
+// WithIgnoreArgument sets the queue argument that opts a queue out of
+// collection. An empty name keeps DefaultIgnoreArgument.
+func WithIgnoreArgument(name string) CollectorOption {
+	return func(c *Collector) {
+		if name != "" {
+			c.ignoreArgument = name
+		}
+	}
+}
+
+// dropIgnoredQueues removes the queues that declare the ignore argument.
+// Application teams set it on scratch queues they don't want monitored, so
+// the queues are left out everywhere, rollups and events included, as if
+// they didn't exist.
+func (c *Collector) dropIgnoredQueues(queues []rabbitmq.Queue) []rabbitmq.Queue {
+	kept := queues[:0:0]
+	for _, q := range queues {
+		if !strings.EqualFold(q.GetArgumentString(c.ignoreArgument), "true") {
+			kept = append(kept, q)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,29 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_IgnoreArgument(t *testing.T) {
+	collector, _ := newTestCollector(t, `[
+		{"name":"orders","vhost":"/","messages":5},
+		{"name":"scratch","vhost":"/","messages":9,"arguments":{"x-exporter-ignore":true}},
+		{"name":"tmp","vhost":"/","messages":1,"arguments":{"x-exporter-ignore":"TRUE"}},
+		{"name":"kept","vhost":"/","messages":2,"arguments":{"x-exporter-ignore":false,"x-monitoring-ignore":true}}
+	]`)
+
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_messages"); n != 2 {
+		t.Errorf("Expected the ignored queues to be dropped, got %d series", n)
+	}
+	if got := len(collector.cachedQueues); got != 2 {
+		t.Errorf("Expected 2 cached queues, got %d", got)
+	}
+
+	WithIgnoreArgument("x-monitoring-ignore")(collector)
+	collector.collectQueueData()
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_messages"); n != 3 {
+		t.Errorf("Expected only the queue with the custom argument to be dropped, got %d series", n)
+	}
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// leaderTracker follows the node hosting each queue across collections, so
+// failovers and rebalancing show up as leadership changes
+type leaderTracker struct {
+	nodes   map[string]string
+	changes map[string]float64
+}
+
+func newLeaderTracker() *leaderTracker {
+	return &leaderTracker{nodes: make(map[string]string), changes: make(map[string]float64)}
+}
+
+// Observe records the node of each queue and returns the number of changes
+// per queue key since the exporter started. A queue reported without a
+// node, e.g. during a leader election, keeps its last known node. Deleted
+// queues are forgotten. The returned map is not modified afterwards.
+func (t *leaderTracker) Observe(queues []rabbitmq.Queue) map[string]float64 {
+	nodes := make(map[string]string, len(queues))
+	changes := make(map[string]float64, len(queues))
+	for _, q := range queues {
+		key := queueKey(q)
+		previous, known := t.nodes[key]
+		node := q.Node
+		if node == "" {
+			node = previous
+		}
+		if node == "" {
+			continue
+		}
+		nodes[key] = node
+		changes[key] = t.changes[key]
+		if known && node != previous {
+			changes[key]++
+		}
+	}
+	t.nodes = nodes
+	t.changes = changes
+	return changes
+}
+
+// countQueuesPerNode counts queues by the node hosting them
+func countQueuesPerNode(queues []rabbitmq.Queue) map[string]int {
+	counts := make(map[string]int)
+	for _, q := range queues {
+		if q.Node != "" {
+			counts[q.Node]++
+		}
+	}
+	return counts
+}
+
+// collectLeaderChanges exports the queue's leadership changes once its node
+// is known
+func (c *Collector) collectLeaderChanges(ch chan<- prometheus.Metric, queue rabbitmq.Queue, labels []string) {
+	c.mu.RLock()
+	changes, ok := c.leaderChanges[queueKey(queue)]
+	c.mu.RUnlock()
+	if ok {
+		emitCounter(ch, c.metrics.QueueLeaderChangesTotal, changes, labels...)
+	}
+}
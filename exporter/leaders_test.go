@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLeaderTracker_Observe(t *testing.T) {
+	tracker := newLeaderTracker()
+	queue := func(name, node string) rabbitmq.Queue {
+		return rabbitmq.Queue{Name: name, Vhost: "/", Node: node}
+	}
+
+	changes := tracker.Observe([]rabbitmq.Queue{queue("orders", "rabbit@a"), queue("billing", "")})
+	if changes["orders@/"] != 0 || len(changes) != 1 {
+		t.Errorf("Expected only orders tracked without changes, got %v", changes)
+	}
+
+	// A missing node during an election keeps the last known one
+	changes = tracker.Observe([]rabbitmq.Queue{queue("orders", "")})
+	if changes["orders@/"] != 0 {
+		t.Errorf("Expected no change while the node is unknown, got %v", changes)
+	}
+
+	changes = tracker.Observe([]rabbitmq.Queue{queue("orders", "rabbit@b"), queue("billing", "rabbit@b")})
+	if changes["orders@/"] != 1 || changes["billing@/"] != 0 {
+		t.Errorf("Expected one change for orders, got %v", changes)
+	}
+
+	changes = tracker.Observe([]rabbitmq.Queue{queue("orders", "rabbit@a")})
+	if changes["orders@/"] != 2 {
+		t.Errorf("Expected two changes for orders, got %v", changes)
+	}
+	if _, ok := changes["billing@/"]; ok {
+		t.Error("Expected the deleted queue to be forgotten")
+	}
+}
+
+func TestCollector_LeaderChanges(t *testing.T) {
+	client := &exportertest.Client{Queues: []rabbitmq.Queue{
+		{Name: "orders", Vhost: "/", Type: "quorum", Node: "rabbit@a"},
+		{Name: "billing", Vhost: "/", Node: "rabbit@a"},
+	}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	client.SetQueues([]rabbitmq.Queue{
+		{Name: "orders", Vhost: "/", Type: "quorum", Node: "rabbit@b"},
+		{Name: "billing", Vhost: "/", Node: "rabbit@a"},
+	})
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_node_queues Number of queues hosted by the node: classic queues living on it, and quorum queues and streams it leads
+# TYPE rabbitmq_custom_node_queues gauge
+rabbitmq_custom_node_queues{node="rabbit@a"} 1
+rabbitmq_custom_node_queues{node="rabbit@b"} 1
+# HELP rabbitmq_custom_queue_leader_changes_total Number of times the node hosting the queue changed since the exporter started, e.g. on failover or rebalancing
+# TYPE rabbitmq_custom_queue_leader_changes_total counter
+rabbitmq_custom_queue_leader_changes_total{queue_name="billing",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_leader_changes_total{queue_name="orders",type="quorum",vhost="/"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_node_queues", "rabbitmq_custom_queue_leader_changes_total"); err != nil {
+		t.Error(err)
+	}
+}
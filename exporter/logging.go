@@ -0,0 +1,39 @@
+package exporter
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	LogLevelInfo  = "info"
+	LogLevelDebug = "debug"
+)
+
+var debugLogging bool
+
+// SetLogLevel configures the global log level
+func SetLogLevel(level string) error {
+	switch strings.ToLower(level) {
+	case "", LogLevelInfo:
+		debugLogging = false
+	case LogLevelDebug:
+		debugLogging = true
+	default:
+		return fmt.Errorf("invalid log level %q (expected info or debug)", level)
+	}
+	return nil
+}
+
+// DebugLogging reports whether debug logging is enabled
+func DebugLogging() bool {
+	return debugLogging
+}
+
+// debugf logs only when debug logging is enabled
+func debugf(format string, args ...interface{}) {
+	if debugLogging {
+		log.Printf("DEBUG: "+format, args...)
+	}
+}
@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// WithExpectedNodes sets how many running nodes the cluster should have.
+// Zero leaves the expected count unexported.
+func WithExpectedNodes(n int) CollectorOption {
+	return func(c *Collector) {
+		c.expectedNodes = n
+	}
+}
+
+// membershipTracker follows the running nodes across collections. A node
+// joins when it is first listed as running, or starts running again, and
+// leaves when it stops running or disappears from /api/nodes.
+type membershipTracker struct {
+	running     map[string]bool
+	initialized bool
+	joins       float64
+	departures  float64
+}
+
+func newMembershipTracker() *membershipTracker {
+	return &membershipTracker{running: make(map[string]bool)}
+}
+
+// Observe compares nodes with the previous listing. The first listing only
+// sets the baseline.
+func (t *membershipTracker) Observe(nodes []rabbitmq.Node) {
+	running := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		if n.Running {
+			running[n.Name] = true
+		}
+	}
+	if t.initialized {
+		for name := range running {
+			if !t.running[name] {
+				t.joins++
+			}
+		}
+		for name := range t.running {
+			if !running[name] {
+				t.departures++
+			}
+		}
+	}
+	t.running = running
+	t.initialized = true
+}
+
+// collectMembershipMetrics exports the running node count, if nodes were
+// fetched, the membership changes, once a listing has been seen, and the
+// expected count when configured
+func (c *Collector) collectMembershipMetrics(ch chan<- prometheus.Metric, nodes []rabbitmq.Node) {
+	c.mu.RLock()
+	initialized := c.membership.initialized
+	joins, departures := c.membership.joins, c.membership.departures
+	c.mu.RUnlock()
+
+	if nodes != nil {
+		running := 0
+		for _, n := range nodes {
+			if n.Running {
+				running++
+			}
+		}
+		emitGauge(ch, c.metrics.ClusterNodesRunning, float64(running))
+	}
+	if initialized {
+		emitCounter(ch, c.metrics.ClusterNodeJoinsTotal, joins)
+		emitCounter(ch, c.metrics.ClusterNodeDeparturesTotal, departures)
+	}
+	if c.expectedNodes > 0 {
+		emitGauge(ch, c.metrics.ClusterNodesExpected, float64(c.expectedNodes))
+	}
+}
@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMembershipTracker_Observe(t *testing.T) {
+	tracker := newMembershipTracker()
+	tracker.Observe([]rabbitmq.Node{{Name: "a", Running: true}, {Name: "b", Running: true}})
+	if tracker.joins != 0 || tracker.departures != 0 {
+		t.Fatalf("Expected the first listing to only set the baseline, got %v joins and %v departures", tracker.joins, tracker.departures)
+	}
+
+	// b stops, c joins
+	tracker.Observe([]rabbitmq.Node{{Name: "a", Running: true}, {Name: "b"}, {Name: "c", Running: true}})
+	// b comes back, c is removed
+	tracker.Observe([]rabbitmq.Node{{Name: "a", Running: true}, {Name: "b", Running: true}})
+	if tracker.joins != 2 || tracker.departures != 2 {
+		t.Errorf("Expected 2 joins and 2 departures, got %v and %v", tracker.joins, tracker.departures)
+	}
+}
+
+func TestCollector_MembershipMetrics(t *testing.T) {
+	client := &exportertest.Client{Nodes: []rabbitmq.Node{
+		{Name: "rabbit@a", Running: true},
+		{Name: "rabbit@b", Running: true},
+		{Name: "rabbit@c", Running: true},
+	}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithExpectedNodes(3))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	// rabbit@c silently drops out of the listing
+	client.SetNodes(client.Nodes[:2])
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_cluster_node_departures_total Number of times a node left the cluster or stopped running since the exporter started
+# TYPE rabbitmq_custom_cluster_node_departures_total counter
+rabbitmq_custom_cluster_node_departures_total 1
+# HELP rabbitmq_custom_cluster_node_joins_total Number of times a node joined the cluster or started running again since the exporter started
+# TYPE rabbitmq_custom_cluster_node_joins_total counter
+rabbitmq_custom_cluster_node_joins_total 0
+# HELP rabbitmq_custom_cluster_nodes_expected Number of running nodes the cluster is expected to have, from expected_nodes
+# TYPE rabbitmq_custom_cluster_nodes_expected gauge
+rabbitmq_custom_cluster_nodes_expected 3
+# HELP rabbitmq_custom_cluster_nodes_running Number of cluster nodes listed as running
+# TYPE rabbitmq_custom_cluster_nodes_running gauge
+rabbitmq_custom_cluster_nodes_running 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_cluster_node_departures_total",
+		"rabbitmq_custom_cluster_node_joins_total",
+		"rabbitmq_custom_cluster_nodes_expected",
+		"rabbitmq_custom_cluster_nodes_running",
+	); err != nil {
+		t.Error(err)
+	}
+
+	// A failed listing is no evidence of departures
+	client.SetError("GetNodes", errors.New("connection refused"))
+	collector.collectQueueData()
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_cluster_nodes_running"); n != 0 {
+		t.Errorf("Expected no running count after a failed listing, got %d", n)
+	}
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP rabbitmq_custom_cluster_node_departures_total Number of times a node left the cluster or stopped running since the exporter started
+# TYPE rabbitmq_custom_cluster_node_departures_total counter
+rabbitmq_custom_cluster_node_departures_total 1
+`), "rabbitmq_custom_cluster_node_departures_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,41 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// collectNodeIOMetrics exports the file I/O, Mnesia and message store
+// counters of a running node. Stopped nodes report none of them.
+func (c *Collector) collectNodeIOMetrics(ch chan<- prometheus.Metric, node rabbitmq.Node) {
+	emitCounter(ch, c.metrics.NodeIOOperations, float64(node.IOReadCount), node.Name, "read")
+	emitCounter(ch, c.metrics.NodeIOOperations, float64(node.IOWriteCount), node.Name, "write")
+	emitCounter(ch, c.metrics.NodeIOOperations, float64(node.IOSyncCount), node.Name, "sync")
+	emitCounter(ch, c.metrics.NodeIOOperations, float64(node.IOSeekCount), node.Name, "seek")
+	emitCounter(ch, c.metrics.NodeIOBytes, float64(node.IOReadBytes), node.Name, "read")
+	emitCounter(ch, c.metrics.NodeIOBytes, float64(node.IOWriteBytes), node.Name, "write")
+
+	rates := []struct {
+		operation string
+		details   *rabbitmq.RateDetails
+		avgMillis float64
+	}{
+		{"read", node.IOReadCountDetails, node.IOReadAvgTime},
+		{"write", node.IOWriteCountDetails, node.IOWriteAvgTime},
+		{"sync", node.IOSyncCountDetails, node.IOSyncAvgTime},
+	}
+	for _, r := range rates {
+		if r.details != nil {
+			emitGauge(ch, c.metrics.NodeIOOperationRate, r.details.Rate, node.Name, r.operation)
+		}
+		emitGauge(ch, c.metrics.NodeIOAverageTimeSeconds, r.avgMillis/1000, node.Name, r.operation)
+	}
+
+	emitCounter(ch, c.metrics.NodeMnesiaTransactions, float64(node.MnesiaRAMTxCount), node.Name, "ram")
+	emitCounter(ch, c.metrics.NodeMnesiaTransactions, float64(node.MnesiaDiskTxCount), node.Name, "disk")
+	emitCounter(ch, c.metrics.NodeMessageStoreOperations, float64(node.QueueIndexReadCount), node.Name, "queue_index", "read")
+	emitCounter(ch, c.metrics.NodeMessageStoreOperations, float64(node.QueueIndexWriteCount), node.Name, "queue_index", "write")
+	emitCounter(ch, c.metrics.NodeMessageStoreOperations, float64(node.MsgStoreReadCount), node.Name, "message_store", "read")
+	emitCounter(ch, c.metrics.NodeMessageStoreOperations, float64(node.MsgStoreWriteCount), node.Name, "message_store", "write")
+}
@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_NodeIOMetrics(t *testing.T) {
+	var nodes []rabbitmq.Node
+	if err := json.Unmarshal([]byte(`[
+		{"name":"rabbit@a","running":true,
+		 "io_read_count":10,"io_read_bytes":4096,"io_read_avg_time":0.5,"io_read_count_details":{"rate":2},
+		 "io_write_count":20,"io_write_bytes":8192,"io_write_avg_time":1.5,"io_write_count_details":{"rate":4},
+		 "io_sync_count":5,"io_sync_avg_time":12,"io_sync_count_details":{"rate":1},"io_seek_count":3,
+		 "mnesia_ram_tx_count":7,"mnesia_disk_tx_count":2,
+		 "queue_index_read_count":30,"queue_index_write_count":40,"msg_store_read_count":50,"msg_store_write_count":60},
+		{"name":"rabbit@b","running":false}
+	]`), &nodes); err != nil {
+		t.Fatalf("Expected nodes to unmarshal, got %v", err)
+	}
+	client := &exportertest.Client{Nodes: nodes}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_node_io_average_time_seconds Average duration of the node's recent file I/O operations by operation (read, write, sync)
+# TYPE rabbitmq_custom_node_io_average_time_seconds gauge
+rabbitmq_custom_node_io_average_time_seconds{node="rabbit@a",operation="read"} 0.0005
+rabbitmq_custom_node_io_average_time_seconds{node="rabbit@a",operation="sync"} 0.012
+rabbitmq_custom_node_io_average_time_seconds{node="rabbit@a",operation="write"} 0.0015
+# HELP rabbitmq_custom_node_io_operation_rate Rate of file I/O operations per second of the node by operation (read, write, sync)
+# TYPE rabbitmq_custom_node_io_operation_rate gauge
+rabbitmq_custom_node_io_operation_rate{node="rabbit@a",operation="read"} 2
+rabbitmq_custom_node_io_operation_rate{node="rabbit@a",operation="sync"} 1
+rabbitmq_custom_node_io_operation_rate{node="rabbit@a",operation="write"} 4
+# HELP rabbitmq_custom_node_message_store_operations_total Total reads and writes of the node's queue index and message store, by store (queue_index, message_store) and operation
+# TYPE rabbitmq_custom_node_message_store_operations_total counter
+rabbitmq_custom_node_message_store_operations_total{node="rabbit@a",operation="read",store="message_store"} 50
+rabbitmq_custom_node_message_store_operations_total{node="rabbit@a",operation="read",store="queue_index"} 30
+rabbitmq_custom_node_message_store_operations_total{node="rabbit@a",operation="write",store="message_store"} 60
+rabbitmq_custom_node_message_store_operations_total{node="rabbit@a",operation="write",store="queue_index"} 40
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_node_io_average_time_seconds",
+		"rabbitmq_custom_node_io_operation_rate",
+		"rabbitmq_custom_node_message_store_operations_total",
+	); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_node_io_operations_total"); n != 4 {
+		t.Errorf("Expected 4 I/O operation series for the running node only, got %d", n)
+	}
+}
+
+func TestCollector_NodeRuntimeMetrics(t *testing.T) {
+	client := &exportertest.Client{Nodes: []rabbitmq.Node{
+		{Name: "rabbit@a", Running: true, GCNum: 1000, GCNumDetails: &rabbitmq.RateDetails{Rate: 25},
+			GCBytesReclaimed: 1 << 20, RunQueue: 3},
+		{Name: "rabbit@b", Running: false},
+	}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_node_gc_run_rate Rate of garbage collections per second on the node
+# TYPE rabbitmq_custom_node_gc_run_rate gauge
+rabbitmq_custom_node_gc_run_rate{node="rabbit@a"} 25
+# HELP rabbitmq_custom_node_gc_runs_total Total garbage collections run by the node's Erlang runtime
+# TYPE rabbitmq_custom_node_gc_runs_total counter
+rabbitmq_custom_node_gc_runs_total{node="rabbit@a"} 1000
+# HELP rabbitmq_custom_node_run_queue Number of Erlang processes on the node waiting for a scheduler
+# TYPE rabbitmq_custom_node_run_queue gauge
+rabbitmq_custom_node_run_queue{node="rabbit@a"} 3
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_node_gc_run_rate",
+		"rabbitmq_custom_node_gc_runs_total",
+		"rabbitmq_custom_node_run_queue",
+	); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_node_gc_bytes_reclaimed_rate"); n != 0 {
+		t.Errorf("Expected no reclaim rate when the broker reports none, got %d", n)
+	}
+}
@@ -0,0 +1,22 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// collectNodeRuntimeMetrics exports the garbage collection and scheduler
+// statistics of a running node's Erlang runtime
+func (c *Collector) collectNodeRuntimeMetrics(ch chan<- prometheus.Metric, node rabbitmq.Node) {
+	emitCounter(ch, c.metrics.NodeGCRuns, float64(node.GCNum), node.Name)
+	emitCounter(ch, c.metrics.NodeGCBytesReclaimed, float64(node.GCBytesReclaimed), node.Name)
+	emitCounter(ch, c.metrics.NodeContextSwitches, float64(node.ContextSwitches), node.Name)
+	emitGauge(ch, c.metrics.NodeRunQueue, float64(node.RunQueue), node.Name)
+	if node.GCNumDetails != nil {
+		emitGauge(ch, c.metrics.NodeGCRunRate, node.GCNumDetails.Rate, node.Name)
+	}
+	if node.GCBytesReclaimedDetails != nil {
+		emitGauge(ch, c.metrics.NodeGCBytesReclaimedRate, node.GCBytesReclaimedDetails.Rate, node.Name)
+	}
+}
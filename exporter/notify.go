@@ -0,0 +1,237 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/notifier"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// DefaultNotifierRepeatInterval is how often firing alerts are resent to an
+// Alertmanager, which resolves alerts it stops hearing about
+const DefaultNotifierRepeatInterval = time.Minute
+
+// Names of the alerts the notifier sends
+const (
+	AlertQueueDepth       = "RabbitMQQueueDepth"
+	AlertDeadLetterGrowth = "RabbitMQDeadLetterQueueGrowing"
+	AlertConsumerStarved  = "RabbitMQQueueConsumersStarved"
+)
+
+// NotifierConfig configures the alerts the exporter sends itself, for sites
+// without a Prometheus
+type NotifierConfig struct {
+	notifier.Config `mapstructure:",squash"`
+	// For is how long a condition must hold before its alert fires
+	For time.Duration `mapstructure:"for"`
+	// RepeatInterval is how often firing alerts are resent. Zero never
+	// resends to a webhook and uses DefaultNotifierRepeatInterval for an
+	// Alertmanager.
+	RepeatInterval time.Duration `mapstructure:"repeat_interval"`
+	// Labels are added to every alert, e.g. to name the site
+	Labels map[string]string `mapstructure:"labels"`
+}
+
+// AlertSender delivers a batch of alerts
+type AlertSender interface {
+	Send(ctx context.Context, alerts []notifier.Alert) error
+}
+
+// notifiedAlert is an alert whose condition holds, pending until it has held
+// for the configured duration
+type notifiedAlert struct {
+	alert    notifier.Alert
+	firing   bool
+	lastSent time.Time
+}
+
+// AlertNotifier evaluates queue depth, dead letter growth and consumer
+// starvation after every collection and sends an alert when a condition
+// starts or stops holding. Alerts already sent aren't sent again until
+// RepeatInterval has passed. Sending happens on a background worker so
+// collection is never blocked on the receiver.
+type AlertNotifier struct {
+	sender      AlertSender
+	hold        time.Duration
+	repeat      time.Duration
+	labels      map[string]string
+	queueLabels []string
+	active      map[string]*notifiedAlert
+	lastDepth   map[string]int64
+	batches     chan []notifier.Alert
+	done        chan struct{}
+}
+
+// NewAlertNotifier creates a notifier whose alerts name the queue the way
+// the metric naming scheme does, so they line up with the exporter's series
+// in Alertmanager routes and inhibitions
+func NewAlertNotifier(sender AlertSender, cfg NotifierConfig, naming string) *AlertNotifier {
+	repeat := cfg.RepeatInterval
+	if repeat <= 0 && cfg.Format != notifier.FormatWebhook {
+		repeat = DefaultNotifierRepeatInterval
+	}
+
+	n := &AlertNotifier{
+		sender:      sender,
+		hold:        cfg.For,
+		repeat:      repeat,
+		labels:      cfg.Labels,
+		queueLabels: metrics.QueueLabelNames(naming),
+		active:      make(map[string]*notifiedAlert),
+		lastDepth:   make(map[string]int64),
+		batches:     make(chan []notifier.Alert, 16),
+		done:        make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// WithAlertNotifier sends alerts through n after every collection
+func WithAlertNotifier(n *AlertNotifier) CollectorOption {
+	return func(c *Collector) {
+		c.alertNotifier = n
+	}
+}
+
+// Evaluate derives the alert conditions of a fresh queue snapshot and
+// notifies the ones that changed. Depth alerts fire per severity tier like
+// queue_depth_alert. A dead letter queue is growing while it holds more
+// messages than at the previous collection, so its alert resolves once the
+// queue stops growing, and any other queue is starved while it has ready
+// messages and no consumers.
+func (n *AlertNotifier) Evaluate(queues []rabbitmq.Queue, thresholds *DepthThresholdMatcher, tiers []SeverityTier, isDeadLetter func(rabbitmq.Queue) bool, now time.Time) {
+	tierLabels := make(map[string]map[string]string, len(tiers))
+	for _, tier := range tiers {
+		tierLabels[tier.Severity] = tier.Labels
+	}
+	var conditions []notifier.Alert
+	add := func(queue rabbitmq.Queue, name, severity, summary, description string) {
+		labels := make(map[string]string, len(n.labels)+5)
+		for k, v := range n.labels {
+			labels[k] = v
+		}
+		for k, v := range tierLabels[severity] {
+			labels[k] = v
+		}
+		labels["alertname"] = name
+		labels["severity"] = severity
+		for _, name := range n.queueLabels {
+			labels[name] = queue.Name
+		}
+		labels["vhost"] = queue.Vhost
+		conditions = append(conditions, notifier.Alert{
+			Labels:      labels,
+			Annotations: map[string]string{"summary": summary, "description": description},
+		})
+	}
+
+	lastDepth := make(map[string]int64, len(n.lastDepth))
+	for _, queue := range queues {
+		depth := thresholds.ForQueue(queue)
+		for _, tier := range tiers {
+			threshold, ok := tier.DepthThreshold(depth)
+			if !ok || queue.Messages <= threshold {
+				continue
+			}
+			add(queue, AlertQueueDepth, tier.Severity,
+				fmt.Sprintf("Queue depth above %s threshold", tier.Severity),
+				fmt.Sprintf("Queue %s in %s has %d messages (%s at %d)", queue.Name, queue.Vhost, queue.Messages, tier.Severity, threshold))
+		}
+
+		if isDeadLetter(queue) {
+			key := queueKey(queue)
+			lastDepth[key] = queue.Messages
+			if previous, seen := n.lastDepth[key]; seen && queue.Messages > previous {
+				add(queue, AlertDeadLetterGrowth, SeverityWarning,
+					"Dead letter queue is growing",
+					fmt.Sprintf("Dead letter queue %s in %s has %d messages, %d more than at the previous collection", queue.Name, queue.Vhost, queue.Messages, queue.Messages-previous))
+			}
+			continue
+		}
+
+		if !queue.IsStreamQueue() && queue.MessagesReady > 0 && queue.Consumers == 0 {
+			add(queue, AlertConsumerStarved, SeverityCritical,
+				"Queue has messages but no consumers",
+				fmt.Sprintf("Queue %s in %s has %d ready messages and no consumers", queue.Name, queue.Vhost, queue.MessagesReady))
+		}
+	}
+	n.lastDepth = lastDepth
+
+	n.observe(conditions, now)
+}
+
+// observe compares the conditions holding at now with the active alerts and
+// queues the alerts that fire, are due to be resent or have resolved
+func (n *AlertNotifier) observe(conditions []notifier.Alert, now time.Time) {
+	var batch []notifier.Alert
+	holding := make(map[string]bool, len(conditions))
+
+	for _, condition := range conditions {
+		fingerprint := condition.Fingerprint()
+		holding[fingerprint] = true
+
+		active := n.active[fingerprint]
+		if active == nil {
+			condition.StartsAt = now
+			active = &notifiedAlert{alert: condition}
+			n.active[fingerprint] = active
+		} else {
+			// Keep the description current, e.g. the message count
+			active.alert.Annotations = condition.Annotations
+		}
+
+		switch {
+		case !active.firing && now.Sub(active.alert.StartsAt) >= n.hold:
+			active.firing = true
+		case active.firing && n.repeat > 0 && now.Sub(active.lastSent) >= n.repeat:
+		default:
+			continue
+		}
+		active.lastSent = now
+		batch = append(batch, active.alert)
+	}
+
+	for fingerprint, active := range n.active {
+		if holding[fingerprint] {
+			continue
+		}
+		delete(n.active, fingerprint)
+		if active.firing {
+			resolved := active.alert
+			resolved.EndsAt = now
+			batch = append(batch, resolved)
+		}
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+	select {
+	case n.batches <- batch:
+	default:
+		log.Printf("Dropping %d alert notifications, queue full", len(batch))
+	}
+}
+
+func (n *AlertNotifier) run() {
+	defer close(n.done)
+
+	for batch := range n.batches {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := n.sender.Send(ctx, batch)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to send %d alert notifications: %v", len(batch), err)
+		}
+	}
+}
+
+// Close flushes queued notifications and stops the worker
+func (n *AlertNotifier) Close() {
+	close(n.batches)
+	<-n.done
+}
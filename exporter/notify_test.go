@@ -0,0 +1,164 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/notifier"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// recordingSender keeps every batch it is asked to send
+type recordingSender struct {
+	mu      sync.Mutex
+	batches [][]notifier.Alert
+}
+
+func (s *recordingSender) Send(ctx context.Context, alerts []notifier.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, alerts)
+	return nil
+}
+
+func TestAlertNotifier_Evaluate(t *testing.T) {
+	sender := &recordingSender{}
+	n := NewAlertNotifier(sender, NotifierConfig{
+		Config:         notifier.Config{Format: notifier.FormatWebhook},
+		For:            time.Minute,
+		RepeatInterval: 10 * time.Minute,
+		Labels:         map[string]string{"site": "edge-01"},
+	}, metrics.NamingNative)
+	thresholds, _ := NewDepthThresholdMatcher(nil, DepthThresholds{Warning: 100, Critical: 1000})
+	tiers, _ := NewSeverityTiers([]SeverityTierConfig{
+		{Severity: "warning"},
+		{Severity: "critical", Labels: map[string]string{"pager": "oncall"}},
+	})
+	isDeadLetter := func(q rabbitmq.Queue) bool { return q.Name == "orders.dlq" }
+
+	evaluate := func(at time.Duration, orders, dlq, consumers int64) {
+		n.Evaluate([]rabbitmq.Queue{
+			{Name: "orders", Vhost: "/", Messages: orders, MessagesReady: orders, Consumers: consumers},
+			{Name: "orders.dlq", Vhost: "/", Messages: dlq, MessagesReady: dlq},
+		}, thresholds, tiers, isDeadLetter, time.Unix(0, 0).Add(at))
+	}
+
+	evaluate(0, 0, 5, 1)                          // baseline, nothing holds
+	evaluate(30*time.Second, 200, 7, 1)           // depth warning and DLQ growth pending
+	evaluate(90*time.Second, 200, 8, 1)           // both fire
+	evaluate(2*time.Minute, 200, 9, 1)            // already sent, deduplicated
+	evaluate(3*time.Minute, 50, 9, 0)             // depth resolves, DLQ stopped growing, starvation pending
+	evaluate(4*time.Minute, 50, 9, 0)             // starvation fires
+	evaluate(15*time.Minute, 50, 9, 0)            // repeat interval passed
+	evaluate(15*time.Minute+time.Second, 0, 0, 0) // starvation resolves
+	n.Close()
+
+	type sent struct {
+		name     string
+		resolved bool
+	}
+	want := [][]sent{
+		{{AlertQueueDepth, false}, {AlertDeadLetterGrowth, false}},
+		{{AlertQueueDepth, true}, {AlertDeadLetterGrowth, true}},
+		{{AlertConsumerStarved, false}},
+		{{AlertConsumerStarved, false}},
+		{{AlertConsumerStarved, true}},
+	}
+	if len(sender.batches) != len(want) {
+		t.Fatalf("Expected %d batches, got %d: %+v", len(want), len(sender.batches), sender.batches)
+	}
+	for i, batch := range sender.batches {
+		got := make(map[sent]bool)
+		for _, alert := range batch {
+			got[sent{alert.Labels["alertname"], alert.Resolved()}] = true
+		}
+		if len(got) != len(want[i]) {
+			t.Errorf("Batch %d: expected %v, got %+v", i, want[i], batch)
+			continue
+		}
+		for _, w := range want[i] {
+			if !got[w] {
+				t.Errorf("Batch %d: expected %v, got %+v", i, w, batch)
+			}
+		}
+	}
+
+	depth := sender.batches[0][0]
+	if depth.Labels["alertname"] != AlertQueueDepth {
+		depth = sender.batches[0][1]
+	}
+	if depth.Labels["severity"] != "warning" || depth.Labels["site"] != "edge-01" || depth.Labels["queue_name"] != "orders" {
+		t.Errorf("Unexpected depth alert labels: %v", depth.Labels)
+	}
+	if !depth.StartsAt.Equal(time.Unix(30, 0)) {
+		t.Errorf("Expected the alert to start when its condition first held, got %v", depth.StartsAt)
+	}
+	if starved := sender.batches[2][0]; starved.Labels["pager"] != "oncall" {
+		t.Errorf("Expected the critical tier's labels on starvation alerts, got %v", starved.Labels)
+	}
+}
+
+func TestNewAlertNotifier_AlertmanagerRepeat(t *testing.T) {
+	n := NewAlertNotifier(&recordingSender{}, NotifierConfig{}, metrics.NamingNative)
+	defer n.Close()
+	if n.repeat != DefaultNotifierRepeatInterval {
+		t.Errorf("Expected Alertmanager alerts to be resent every %v, got %v", DefaultNotifierRepeatInterval, n.repeat)
+	}
+}
+
+func TestAlertNotifier_QueueLabelNaming(t *testing.T) {
+	starved := []rabbitmq.Queue{{Name: "orders", Vhost: "/", MessagesReady: 3}}
+	thresholds, _ := NewDepthThresholdMatcher(nil, DepthThresholds{Warning: 100, Critical: 1000})
+	noDeadLetters := func(rabbitmq.Queue) bool { return false }
+
+	for _, tc := range []struct {
+		naming string
+		want   []string
+		absent string
+	}{
+		{metrics.NamingKbudde, []string{"queue"}, "queue_name"},
+		{metrics.NamingBoth, []string{"queue", "queue_name"}, ""},
+	} {
+		sender := &recordingSender{}
+		n := NewAlertNotifier(sender, NotifierConfig{}, tc.naming)
+		n.Evaluate(starved, thresholds, DefaultSeverityTiers(), noDeadLetters, time.Now())
+		n.Close()
+
+		if len(sender.batches) != 1 || len(sender.batches[0]) != 1 {
+			t.Fatalf("%s: expected a single starvation alert, got %+v", tc.naming, sender.batches)
+		}
+		labels := sender.batches[0][0].Labels
+		for _, name := range tc.want {
+			if labels[name] != "orders" {
+				t.Errorf("%s: expected the queue in label %s, got %v", tc.naming, name, labels)
+			}
+		}
+		if _, ok := labels[tc.absent]; tc.absent != "" && ok {
+			t.Errorf("%s: expected no %s label, got %v", tc.naming, tc.absent, labels)
+		}
+	}
+}
+
+func TestCollector_AlertNotifier(t *testing.T) {
+	sender := &recordingSender{}
+	n := NewAlertNotifier(sender, NotifierConfig{}, metrics.NamingNative)
+	client := &exportertest.Client{Queues: []rabbitmq.Queue{
+		{Name: "orders", Vhost: "/", MessagesReady: 3},
+		{Name: "orders.dlq", Vhost: "/", MessagesReady: 3},
+	}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithAlertNotifier(n))
+	defer collector.Stop()
+	collector.collectQueueData()
+	n.Close()
+
+	if len(sender.batches) != 1 || len(sender.batches[0]) != 1 {
+		t.Fatalf("Expected a single starvation alert, got %+v", sender.batches)
+	}
+	if alert := sender.batches[0][0]; alert.Labels["alertname"] != AlertConsumerStarved || alert.Labels["queue_name"] != "orders" {
+		t.Errorf("Expected orders to be starved and the DLQ to be left out, got %v", alert.Labels)
+	}
+}
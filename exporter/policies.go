@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// Policy kinds as reported in the kind label
+const (
+	PolicyKindPolicy         = "policy"
+	PolicyKindOperatorPolicy = "operator_policy"
+)
+
+// policyQueueCount is the number of queues a policy currently applies to
+type policyQueueCount struct {
+	Vhost  string
+	Name   string
+	Kind   string
+	Queues int
+}
+
+// policyLists holds the policies listed by the management API
+type policyLists struct {
+	policies         []rabbitmq.Policy
+	operatorPolicies []rabbitmq.Policy
+}
+
+// fetchPolicies lists policies and operator policies. It returns false when
+// either request fails, so the caller can keep the previous lists.
+func (c *Collector) fetchPolicies(ctx context.Context) (policyLists, bool) {
+	var lists policyLists
+	var err error
+
+	lists.policies, err = c.client.GetPolicies(ctx)
+	if err != nil {
+		log.Printf("Failed to fetch policies: %v", err)
+		return lists, false
+	}
+	lists.operatorPolicies, err = c.client.GetOperatorPolicies(ctx)
+	if err != nil {
+		log.Printf("Failed to fetch operator policies: %v", err)
+		return lists, false
+	}
+	return lists, true
+}
+
+// countPolicyQueues counts the queues each policy applies to. Listed
+// policies that match no queue are reported with a count of zero, which is
+// what a misapplied policy looks like after a definition import.
+func countPolicyQueues(queues []rabbitmq.Queue, lists policyLists) []policyQueueCount {
+	type key struct{ vhost, name, kind string }
+	counts := make(map[key]int)
+
+	for _, kp := range []struct {
+		kind     string
+		policies []rabbitmq.Policy
+	}{
+		{PolicyKindPolicy, lists.policies},
+		{PolicyKindOperatorPolicy, lists.operatorPolicies},
+	} {
+		for _, p := range kp.policies {
+			if p.AppliesToQueues() {
+				counts[key{p.Vhost, p.Name, kp.kind}] += 0
+			}
+		}
+	}
+
+	for _, q := range queues {
+		if q.Policy != "" {
+			counts[key{q.Vhost, q.Policy, PolicyKindPolicy}]++
+		}
+		if q.OperatorPolicy != "" {
+			counts[key{q.Vhost, q.OperatorPolicy, PolicyKindOperatorPolicy}]++
+		}
+	}
+
+	result := make([]policyQueueCount, 0, len(counts))
+	for k, n := range counts {
+		result = append(result, policyQueueCount{Vhost: k.vhost, Name: k.name, Kind: k.kind, Queues: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Vhost != result[j].Vhost {
+			return result[i].Vhost < result[j].Vhost
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	return result
+}
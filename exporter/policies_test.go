@@ -0,0 +1,97 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCountPolicyQueues(t *testing.T) {
+	queues := []rabbitmq.Queue{
+		{Name: "orders.a", Vhost: "/", Policy: "orders-ha", OperatorPolicy: "limits"},
+		{Name: "orders.b", Vhost: "/", Policy: "orders-ha"},
+		{Name: "legacy", Vhost: "/"},
+	}
+	lists := policyLists{
+		policies: []rabbitmq.Policy{
+			{Name: "orders-ha", Vhost: "/", ApplyTo: "queues"},
+			{Name: "imported", Vhost: "/", ApplyTo: "all"},
+			{Name: "exchange-only", Vhost: "/", ApplyTo: "exchanges"},
+		},
+		operatorPolicies: []rabbitmq.Policy{
+			{Name: "limits", Vhost: "/", ApplyTo: "queues"},
+		},
+	}
+
+	expected := []policyQueueCount{
+		{Vhost: "/", Name: "imported", Kind: PolicyKindPolicy, Queues: 0},
+		{Vhost: "/", Name: "limits", Kind: PolicyKindOperatorPolicy, Queues: 1},
+		{Vhost: "/", Name: "orders-ha", Kind: PolicyKindPolicy, Queues: 2},
+	}
+	if got := countPolicyQueues(queues, lists); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestCollector_Policies(t *testing.T) {
+	var policyRequests int
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues":
+			w.Write([]byte(`[
+				{"name":"orders","vhost":"/","policy":"orders-ha","operator_policy":"limits"},
+				{"name":"legacy","vhost":"/"}
+			]`))
+		case "/api/policies":
+			policyRequests++
+			w.Write([]byte(`[{"name":"orders-ha","vhost":"/","pattern":"^orders","apply-to":"queues"},{"name":"stale","vhost":"/","pattern":"^gone","apply-to":"all"}]`))
+		case "/api/operator-policies":
+			w.Write([]byte(`[{"name":"limits","vhost":"/","pattern":".*","apply-to":"queues"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	groups := CollectGroups{Queues: true, Policies: true}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithCollectGroups(groups))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	if policyRequests == 0 {
+		t.Fatal("Expected policies to be fetched")
+	}
+
+	expected := `
+# HELP rabbitmq_custom_policy_queues Number of queues the policy applies to, by kind (policy or operator_policy)
+# TYPE rabbitmq_custom_policy_queues gauge
+rabbitmq_custom_policy_queues{kind="operator_policy",policy="limits",vhost="/"} 1
+rabbitmq_custom_policy_queues{kind="policy",policy="orders-ha",vhost="/"} 1
+rabbitmq_custom_policy_queues{kind="policy",policy="stale",vhost="/"} 0
+# HELP rabbitmq_custom_queue_policy_info Policy and operator policy applied to the queue, always 1. Labels are empty when none applies.
+# TYPE rabbitmq_custom_queue_policy_info gauge
+rabbitmq_custom_queue_policy_info{operator_policy="",policy="",queue_name="legacy",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_policy_info{operator_policy="limits",policy="orders-ha",queue_name="orders",type="classic",vhost="/"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_policy_queues", "rabbitmq_custom_queue_policy_info"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_PoliciesDisabledByDefault(t *testing.T) {
+	collector, _ := newTestCollector(t, `[{"name":"orders","vhost":"/","policy":"orders-ha"}]`)
+
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_policy_info", "rabbitmq_custom_policy_queues"); n != 0 {
+		t.Errorf("Expected no policy metrics without collect.policies, got %d", n)
+	}
+}
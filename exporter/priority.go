@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// collectPriorityMetrics exports the priority levels of a priority queue,
+// and its backlog per level when the broker reports it. The broker lists
+// empty levels too, so a starved lane shows as a series that keeps rising.
+func (c *Collector) collectPriorityMetrics(ch chan<- prometheus.Metric, queue rabbitmq.Queue, labels []string) {
+	maxPriority, ok := queue.MaxPriority()
+	if !ok {
+		return
+	}
+	emitGauge(ch, c.metrics.QueueMaxPriority, float64(maxPriority), labels...)
+
+	if queue.BackingQueueStatus == nil || queue.BackingQueueStatus.PriorityLengths == nil {
+		return
+	}
+	for priority, ready := range queue.BackingQueueStatus.PriorityLengths {
+		emitGauge(ch, c.metrics.QueueMessagesByPriority, float64(ready), append(labels, strconv.Itoa(priority))...)
+	}
+}
@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_PriorityMetrics(t *testing.T) {
+	var queues []rabbitmq.Queue
+	if err := json.Unmarshal([]byte(`[
+		{"name":"jobs","vhost":"/","messages_ready":120,"arguments":{"x-max-priority":2},
+		 "backing_queue_status":{"mode":"default","len":120,"priority_lengths":{"0":0,"1":20,"2":100}}},
+		{"name":"restarting","vhost":"/","arguments":{"x-max-priority":"5"},"backing_queue_status":[]},
+		{"name":"plain","vhost":"/","arguments":{},"backing_queue_status":{"mode":"default","len":0}}
+	]`), &queues); err != nil {
+		t.Fatalf("Expected queues to unmarshal, got %v", err)
+	}
+	client := &exportertest.Client{Queues: queues}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour)
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_max_priority Highest message priority the queue supports, from its x-max-priority argument
+# TYPE rabbitmq_custom_queue_max_priority gauge
+rabbitmq_custom_queue_max_priority{queue_name="jobs",type="classic",vhost="/"} 2
+rabbitmq_custom_queue_max_priority{queue_name="restarting",type="classic",vhost="/"} 5
+# HELP rabbitmq_custom_queue_messages_ready_by_priority Number of messages ready for delivery in a priority queue, by priority level
+# TYPE rabbitmq_custom_queue_messages_ready_by_priority gauge
+rabbitmq_custom_queue_messages_ready_by_priority{priority="0",queue_name="jobs",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_messages_ready_by_priority{priority="1",queue_name="jobs",type="classic",vhost="/"} 20
+rabbitmq_custom_queue_messages_ready_by_priority{priority="2",queue_name="jobs",type="classic",vhost="/"} 100
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_max_priority", "rabbitmq_custom_queue_messages_ready_by_priority"); err != nil {
+		t.Error(err)
+	}
+}
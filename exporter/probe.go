@@ -0,0 +1,236 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// maxProbeClients bounds the client cache when URL targets are allowed
+const maxProbeClients = 64
+
+// probeTimeoutOffset leaves Prometheus time to receive the response before
+// its scrape timeout expires
+const probeTimeoutOffset = 500 * time.Millisecond
+
+// ProbeConfig configures the multi-target /probe endpoint
+type ProbeConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// AllowURLTargets lets ?target= be a management API URL that isn't listed
+	// in Targets. URL targets use the credentials in the URL, or the
+	// exporter's own when it has none.
+	AllowURLTargets bool          `mapstructure:"allow_url_targets"`
+	Targets         []ProbeTarget `mapstructure:"targets"`
+}
+
+// ProbeTarget is a named cluster that can be probed with ?target=<name>
+type ProbeTarget struct {
+	Name     string `mapstructure:"name"`
+	URL      string `mapstructure:"url"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Timeout and Timeouts override the exporter's own for this cluster
+	Timeout  time.Duration     `mapstructure:"timeout"`
+	Timeouts rabbitmq.Timeouts `mapstructure:"timeouts"`
+}
+
+// Prober scrapes a RabbitMQ cluster on demand for each /probe request, the
+// way blackbox_exporter probes targets. Queue metrics are built the same way
+// as in the background collector, into a registry owned by the request.
+type Prober struct {
+	cfg        ProbeConfig
+	defaults   ProbeTarget
+	timeout    time.Duration
+	clientOpts []rabbitmq.ClientOption
+	template   *Collector
+
+	mu      sync.Mutex
+	clients map[string]*rabbitmq.Client
+}
+
+// NewProber creates a prober. defaults holds the credentials for URL targets
+// that don't carry their own; collectorOpts configure queue thresholds and
+// dead letter detection like the main collector's.
+func NewProber(cfg ProbeConfig, defaults ProbeTarget, timeout time.Duration, m *metrics.Metrics, clientOpts []rabbitmq.ClientOption, collectorOpts ...CollectorOption) (*Prober, error) {
+	seen := make(map[string]bool)
+	for _, target := range cfg.Targets {
+		if target.Name == "" || target.URL == "" {
+			return nil, fmt.Errorf("probe targets need a name and url")
+		}
+		if seen[target.Name] {
+			return nil, fmt.Errorf("duplicate probe target %q", target.Name)
+		}
+		if err := target.Timeouts.Validate(); err != nil {
+			return nil, fmt.Errorf("probe target %q: %w", target.Name, err)
+		}
+		seen[target.Name] = true
+	}
+
+	return &Prober{
+		cfg:        cfg,
+		defaults:   defaults,
+		timeout:    timeout,
+		clientOpts: clientOpts,
+		template:   newCollector(nil, m, 0, collectorOpts...),
+		clients:    make(map[string]*rabbitmq.Client),
+	}, nil
+}
+
+// resolve maps a ?target= value to a configured target or, when allowed, a
+// management API URL
+func (p *Prober) resolve(target string) (ProbeTarget, error) {
+	for _, t := range p.cfg.Targets {
+		if t.Name == target {
+			return t, nil
+		}
+	}
+
+	if !p.cfg.AllowURLTargets {
+		return ProbeTarget{}, fmt.Errorf("unknown probe target %q", target)
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ProbeTarget{}, fmt.Errorf("probe target %q is neither a configured target nor an http(s) URL", target)
+	}
+
+	resolved := ProbeTarget{Name: target, Username: p.defaults.Username, Password: p.defaults.Password}
+	if u.User != nil {
+		resolved.Username = u.User.Username()
+		resolved.Password, _ = u.User.Password()
+		resolved.Name = RedactURL(target)
+		u.User = nil
+	}
+	resolved.URL = u.String()
+	return resolved, nil
+}
+
+// client returns the cached client for a target so circuit breakers and
+// keep-alive connections survive between probes
+func (p *Prober) client(target ProbeTarget) *rabbitmq.Client {
+	key := target.URL + "\x00" + target.Username
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[key]; ok {
+		return client
+	}
+	if len(p.clients) >= maxProbeClients {
+		for k, client := range p.clients {
+			client.Close()
+			delete(p.clients, k)
+		}
+	}
+
+	opts := append(p.clientOpts[:len(p.clientOpts):len(p.clientOpts)], rabbitmq.WithTimeouts(target.Timeouts))
+	client := rabbitmq.NewClient(target.URL, target.Username, target.Password, p.targetTimeout(target), opts...)
+	p.clients[key] = client
+	return client
+}
+
+func (p *Prober) targetTimeout(target ProbeTarget) time.Duration {
+	if target.Timeout > 0 {
+		return target.Timeout
+	}
+	return p.timeout
+}
+
+// probeTimeout honours the scrape timeout Prometheus sends with each request
+func (p *Prober) probeTimeout(r *http.Request, target ProbeTarget) time.Duration {
+	timeout := p.targetTimeout(target)
+	if header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); header != "" {
+		if seconds, err := strconv.ParseFloat(header, 64); err == nil && seconds > 0 {
+			scrapeTimeout := time.Duration(seconds*float64(time.Second)) - probeTimeoutOffset
+			if scrapeTimeout > 0 && scrapeTimeout < timeout {
+				timeout = scrapeTimeout
+			}
+		}
+	}
+	return timeout
+}
+
+func (p *Prober) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	targetParam := r.URL.Query().Get("target")
+	if targetParam == "" {
+		http.Error(w, "target parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	target, err := p.resolve(targetParam)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), p.probeTimeout(r, target))
+	defer cancel()
+
+	namespace := p.template.metrics.Namespace()
+	probeSuccess := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "probe_success",
+		Help:      "Whether the probe could list the target's queues (1 = success, 0 = failure)",
+	})
+	probeDuration := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "probe_duration_seconds",
+		Help:      "How long the probe took to list the target's queues",
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(probeSuccess, probeDuration)
+
+	start := time.Now()
+	queues, err := p.client(target).GetQueues(ctx)
+	probeDuration.Set(time.Since(start).Seconds())
+
+	if err != nil {
+		log.Printf("Probe of %s failed: %v", target.Name, err)
+	} else {
+		probeSuccess.Set(1)
+		registry.MustRegister(probeCollector{c: p.template, queues: queues})
+	}
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// Close releases the connections of every cached client
+func (p *Prober) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, client := range p.clients {
+		client.Close()
+		delete(p.clients, key)
+	}
+}
+
+// probeCollector exposes the queue metrics of a single probe
+type probeCollector struct {
+	c      *Collector
+	queues []rabbitmq.Queue
+}
+
+func (p probeCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range p.c.metrics.QueueDescs() {
+		ch <- desc
+	}
+}
+
+func (p probeCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, queue := range p.queues {
+		p.c.collectQueueMetrics(ch, queue)
+	}
+}
@@ -0,0 +1,159 @@
+package exporter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+func newProbeBroker(t *testing.T, username, password, queuesJSON string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != username || pass != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/queues" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(queuesJSON))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func probe(t *testing.T, prober *Prober, target string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	prober.ServeHTTP(rec, httptest.NewRequest("GET", "/probe?target="+url.QueryEscape(target), nil))
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+func TestProber_NamedTarget(t *testing.T) {
+	broker := newProbeBroker(t, "monitor", "pw", `[{"name":"orders","vhost":"/","messages":7}]`)
+
+	prober, err := NewProber(ProbeConfig{
+		Enabled: true,
+		Targets: []ProbeTarget{{Name: "eu", URL: broker.URL, Username: "monitor", Password: "pw"}},
+	}, ProbeTarget{Username: "guest", Password: "guest"}, time.Second, metrics.NewMetrics(), nil)
+	if err != nil {
+		t.Fatalf("NewProber: %v", err)
+	}
+	defer prober.Close()
+
+	code, body := probe(t, prober, "eu")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", code, body)
+	}
+	for _, want := range []string{
+		"rabbitmq_custom_probe_success 1",
+		`rabbitmq_custom_queue_messages_ready{queue_name="orders",type="classic",vhost="/"} 0`,
+		`rabbitmq_custom_queue_messages{queue_name="orders"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Probe output missing %s:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "rabbitmq_custom_up") {
+		t.Error("Probe output shouldn't include the exporter's own metrics")
+	}
+}
+
+func TestProber_FailedTarget(t *testing.T) {
+	broker := newProbeBroker(t, "monitor", "pw", `[]`)
+
+	prober, err := NewProber(ProbeConfig{
+		Enabled: true,
+		Targets: []ProbeTarget{{Name: "eu", URL: broker.URL, Username: "monitor", Password: "wrong"}},
+	}, ProbeTarget{}, time.Second, metrics.NewMetrics(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prober.Close()
+
+	code, body := probe(t, prober, "eu")
+	if code != http.StatusOK {
+		t.Fatalf("Expected a failed probe to still return 200, got %d", code)
+	}
+	if !strings.Contains(body, "rabbitmq_custom_probe_success 0") {
+		t.Errorf("Expected probe_success 0:\n%s", body)
+	}
+}
+
+func TestProber_URLTargets(t *testing.T) {
+	broker := newProbeBroker(t, "monitor", "pw", `[{"name":"orders","vhost":"/"}]`)
+	m := metrics.NewMetrics()
+
+	closed, err := NewProber(ProbeConfig{Enabled: true}, ProbeTarget{}, time.Second, m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := probe(t, closed, broker.URL); code != http.StatusBadRequest {
+		t.Errorf("Expected URL targets to be rejected by default, got %d", code)
+	}
+	if code, _ := probe(t, closed, ""); code != http.StatusBadRequest {
+		t.Errorf("Expected missing target to be rejected, got %d", code)
+	}
+
+	open, err := NewProber(ProbeConfig{Enabled: true, AllowURLTargets: true},
+		ProbeTarget{Username: "monitor", Password: "pw"}, time.Second, m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer open.Close()
+
+	if _, body := probe(t, open, broker.URL); !strings.Contains(body, "rabbitmq_custom_probe_success 1") {
+		t.Errorf("Expected URL target with default credentials to succeed:\n%s", body)
+	}
+
+	withCreds := strings.Replace(broker.URL, "http://", "http://monitor:pw@", 1)
+	open.defaults = ProbeTarget{Username: "guest", Password: "guest"}
+	if _, body := probe(t, open, withCreds); !strings.Contains(body, "rabbitmq_custom_probe_success 1") {
+		t.Errorf("Expected URL credentials to be used:\n%s", body)
+	}
+
+	if code, _ := probe(t, open, "ftp://example.com"); code != http.StatusBadRequest {
+		t.Errorf("Expected non-http URL to be rejected, got %d", code)
+	}
+}
+
+func TestNewProber_InvalidTargets(t *testing.T) {
+	if _, err := NewProber(ProbeConfig{Targets: []ProbeTarget{{Name: "eu"}}}, ProbeTarget{}, time.Second, nil, nil); err == nil {
+		t.Error("Expected error for target without url")
+	}
+	dup := []ProbeTarget{{Name: "eu", URL: "http://a"}, {Name: "eu", URL: "http://b"}}
+	if _, err := NewProber(ProbeConfig{Targets: dup}, ProbeTarget{}, time.Second, nil, nil); err == nil {
+		t.Error("Expected error for duplicate target names")
+	}
+	badTimeouts := []ProbeTarget{{Name: "eu", URL: "http://a", Timeouts: rabbitmq.Timeouts{Endpoints: map[string]time.Duration{"exchanges": time.Second}}}}
+	if _, err := NewProber(ProbeConfig{Targets: badTimeouts}, ProbeTarget{}, time.Second, nil, nil); err == nil {
+		t.Error("Expected error for a timeout on an unknown endpoint")
+	}
+}
+
+func TestProber_Timeout(t *testing.T) {
+	prober := &Prober{timeout: 10 * time.Second}
+
+	r := httptest.NewRequest("GET", "/probe", nil)
+	if got := prober.probeTimeout(r, ProbeTarget{}); got != 10*time.Second {
+		t.Errorf("Expected configured timeout, got %v", got)
+	}
+	if got := prober.probeTimeout(r, ProbeTarget{Timeout: 60 * time.Second}); got != 60*time.Second {
+		t.Errorf("Expected the target's own timeout, got %v", got)
+	}
+
+	r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "5")
+	if got := prober.probeTimeout(r, ProbeTarget{}); got != 5*time.Second-probeTimeoutOffset {
+		t.Errorf("Expected scrape timeout minus offset, got %v", got)
+	}
+}
@@ -0,0 +1,50 @@
+package exporter
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// protocolCount is the number of connections using one protocol, with or
+// without TLS
+type protocolCount struct {
+	Protocol    string
+	TLS         bool
+	Connections int
+}
+
+// countProtocols counts connections by protocol, as the broker names it
+// (e.g. "AMQP 0-9-1", "MQTT 3.1.1", "Web STOMP 1.2"), and by whether they
+// use TLS, ordered by protocol with plaintext first
+func countProtocols(connections []rabbitmq.Connection) []protocolCount {
+	type key struct {
+		protocol string
+		tls      bool
+	}
+	counts := make(map[key]int)
+	for i := range connections {
+		counts[key{connections[i].Protocol, connections[i].SSL}]++
+	}
+
+	result := make([]protocolCount, 0, len(counts))
+	for k, n := range counts {
+		result = append(result, protocolCount{Protocol: k.protocol, TLS: k.tls, Connections: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Protocol != result[j].Protocol {
+			return result[i].Protocol < result[j].Protocol
+		}
+		return !result[i].TLS && result[j].TLS
+	})
+	return result
+}
+
+func (c *Collector) collectProtocolMetrics(ch chan<- prometheus.Metric, counts []protocolCount) {
+	for _, p := range counts {
+		emitGauge(ch, c.metrics.ConnectionsByProtocol, float64(p.Connections), p.Protocol, strconv.FormatBool(p.TLS))
+	}
+}
@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+const (
+	DefaultQueueDiffMaxEntries = 100
+	queueDiffWindow            = time.Minute
+)
+
+// QueueDiff describes how the queue set changed between two collections
+type QueueDiff struct {
+	Added       []string
+	Removed     []string
+	Transitions []string
+}
+
+func (d QueueDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Transitions) == 0
+}
+
+// QueueDiffLogger logs concise diffs of queue topology between collections.
+// Summary lines are always logged; individual entries are limited to
+// maxEntries per minute so large churn doesn't flood the logs.
+type QueueDiffLogger struct {
+	maxEntries int
+
+	initialized bool
+	previous    map[string]rabbitmq.QueueState
+
+	windowStart  time.Time
+	windowLogged int
+}
+
+func NewQueueDiffLogger(maxEntries int) *QueueDiffLogger {
+	if maxEntries <= 0 {
+		maxEntries = DefaultQueueDiffMaxEntries
+	}
+	return &QueueDiffLogger{maxEntries: maxEntries}
+}
+
+// queueKey identifies a queue across snapshots as name@vhost
+func queueKey(q rabbitmq.Queue) string {
+	return q.Name + "@" + q.Vhost
+}
+
+// Diff computes the change from the previous snapshot and records queues as
+// the new baseline. The first snapshot only establishes a baseline.
+func (l *QueueDiffLogger) Diff(queues []rabbitmq.Queue) QueueDiff {
+	current := make(map[string]rabbitmq.QueueState, len(queues))
+	for _, q := range queues {
+		current[queueKey(q)] = q.GetQueueState()
+	}
+
+	var diff QueueDiff
+	if l.initialized {
+		for key, state := range current {
+			prev, ok := l.previous[key]
+			if !ok {
+				diff.Added = append(diff.Added, key)
+			} else if prev != state {
+				diff.Transitions = append(diff.Transitions, fmt.Sprintf("%s %s->%s", key, prev, state))
+			}
+		}
+		for key := range l.previous {
+			if _, ok := current[key]; !ok {
+				diff.Removed = append(diff.Removed, key)
+			}
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Transitions)
+
+	l.previous = current
+	l.initialized = true
+	return diff
+}
+
+// Log computes the diff for queues and writes it to the debug log
+func (l *QueueDiffLogger) Log(queues []rabbitmq.Queue, now time.Time) {
+	diff := l.Diff(queues)
+	if diff.Empty() {
+		return
+	}
+
+	debugf("Queue changes: +%d added, -%d removed, ~%d state transitions",
+		len(diff.Added), len(diff.Removed), len(diff.Transitions))
+
+	if now.Sub(l.windowStart) >= queueDiffWindow {
+		l.windowStart = now
+		l.windowLogged = 0
+	}
+
+	suppressed := 0
+	emit := func(prefix string, entries []string) {
+		for _, entry := range entries {
+			if l.windowLogged >= l.maxEntries {
+				suppressed++
+				continue
+			}
+			debugf("  %s %s", prefix, entry)
+			l.windowLogged++
+		}
+	}
+	emit("+", diff.Added)
+	emit("-", diff.Removed)
+	emit("~", diff.Transitions)
+
+	if suppressed > 0 {
+		debugf("  ... %d more queue changes suppressed (limit %d per minute)", suppressed, l.maxEntries)
+	}
+}
@@ -0,0 +1,50 @@
+package exporter
+
+import (
+	"testing"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+func TestQueueDiffLogger_Diff(t *testing.T) {
+	logger := NewQueueDiffLogger(0)
+
+	baseline := []rabbitmq.Queue{
+		{Name: "orders", Vhost: "/", Consumers: 1, Messages: 5},
+		{Name: "payments", Vhost: "/"},
+	}
+	if diff := logger.Diff(baseline); !diff.Empty() {
+		t.Fatalf("Expected empty diff for baseline, got %+v", diff)
+	}
+
+	next := []rabbitmq.Queue{
+		{Name: "orders", Vhost: "/"},
+		{Name: "invoices", Vhost: "billing"},
+	}
+	diff := logger.Diff(next)
+
+	if len(diff.Added) != 1 || diff.Added[0] != "invoices@billing" {
+		t.Errorf("Expected invoices@billing to be added, got %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "payments@/" {
+		t.Errorf("Expected payments@/ to be removed, got %v", diff.Removed)
+	}
+	if len(diff.Transitions) != 1 || diff.Transitions[0] != "orders@/ active->idle" {
+		t.Errorf("Expected orders to transition from active to idle, got %v", diff.Transitions)
+	}
+
+	if diff := logger.Diff(next); !diff.Empty() {
+		t.Errorf("Expected empty diff for unchanged snapshot, got %+v", diff)
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	defer SetLogLevel(LogLevelInfo)
+
+	if err := SetLogLevel("DEBUG"); err != nil || !debugLogging {
+		t.Errorf("Expected debug logging to be enabled, got err=%v", err)
+	}
+	if err := SetLogLevel("verbose"); err == nil {
+		t.Error("Expected error for invalid log level")
+	}
+}
@@ -0,0 +1,89 @@
+package exporter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedQueueLabels are the labels the exporter already puts on per-queue
+// metrics, which extracted labels must not shadow
+var reservedQueueLabels = map[string]bool{
+	"queue_name": true,
+	"vhost":      true,
+	"type":       true,
+	"state":      true,
+	"node":       true,
+	"severity":   true,
+
+	// queue_info
+	"durable":          true,
+	"auto_delete":      true,
+	"exclusive":        true,
+	"policy":           true,
+	"max_length":       true,
+	"max_length_bytes": true,
+	"message_ttl":      true,
+	"overflow":         true,
+}
+
+// QueueLabeler extracts extra labels from queue names using the named
+// capture groups of a regex, e.g. ^(?P<team>[^.]+)\.(?P<service>[^.]+)\.
+type QueueLabeler struct {
+	pattern *regexp.Regexp
+	names   []string
+	groups  []int
+}
+
+func NewQueueLabeler(pattern string) (*QueueLabeler, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid queue label regex %q: %w", pattern, err)
+	}
+
+	l := &QueueLabeler{pattern: re}
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("queue label regex group %q is not a valid label name", name)
+		}
+		if reservedQueueLabels[name] {
+			return nil, fmt.Errorf("queue label regex group %q clashes with a built-in label", name)
+		}
+		l.names = append(l.names, name)
+		l.groups = append(l.groups, i)
+	}
+	if len(l.names) == 0 {
+		return nil, fmt.Errorf("queue label regex %q has no named capture groups", pattern)
+	}
+	return l, nil
+}
+
+// Names returns the extracted label names in the order of their groups
+func (l *QueueLabeler) Names() []string {
+	if l == nil {
+		return nil
+	}
+	return l.names
+}
+
+// Values returns the label values for a queue name, in the order of Names.
+// Queues that don't match get empty values.
+func (l *QueueLabeler) Values(queueName string) []string {
+	if l == nil {
+		return nil
+	}
+	values := make([]string, len(l.groups))
+	match := l.pattern.FindStringSubmatch(queueName)
+	if match == nil {
+		return values
+	}
+	for i, group := range l.groups {
+		values[i] = match[group]
+	}
+	return values
+}
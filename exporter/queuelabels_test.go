@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueueLabeler(t *testing.T) {
+	labeler, err := NewQueueLabeler(`^(?P<team>[^.]+)\.(?P<service>[^.]+)\.`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := labeler.Names(); !reflect.DeepEqual(got, []string{"team", "service"}) {
+		t.Errorf("Expected team and service, got %v", got)
+	}
+	if got := labeler.Values("payments.billing.invoices"); !reflect.DeepEqual(got, []string{"payments", "billing"}) {
+		t.Errorf("Expected payments/billing, got %v", got)
+	}
+	if got := labeler.Values("legacy"); !reflect.DeepEqual(got, []string{"", ""}) {
+		t.Errorf("Expected empty values for a non-matching queue, got %v", got)
+	}
+
+	var none *QueueLabeler
+	if none.Names() != nil || none.Values("orders") != nil {
+		t.Error("Expected a nil labeler to add no labels")
+	}
+}
+
+func TestNewQueueLabeler_Invalid(t *testing.T) {
+	for _, pattern := range []string{
+		`(`,
+		`^([^.]+)\.`,
+		`^(?P<vhost>[^.]+)\.`,
+		`^(?P<__team>[^.]+)\.`,
+		`^(?P<1team>[^.]+)\.`,
+	} {
+		if _, err := NewQueueLabeler(pattern); err == nil {
+			t.Errorf("Expected %q to be rejected", pattern)
+		}
+	}
+}
+
+func TestCollector_QueueLabels(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"name":"payments.billing.invoices","vhost":"/","type":"quorum","consumers":2,"members":["a"],"online":["a"],"leader":"a"},
+			{"name":"legacy","vhost":"/","consumers":1}
+		]`))
+	}))
+	defer rabbit.Close()
+
+	labeler, err := NewQueueLabeler(`^(?P<team>[^.]+)\.(?P<service>[^.]+)\.`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetricsWithNamespace(metrics.DefaultNamespace, labeler.Names()...), time.Hour, WithQueueLabels(labeler))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_consumers Number of consumers connected to the queue
+# TYPE rabbitmq_custom_queue_consumers gauge
+rabbitmq_custom_queue_consumers{queue_name="legacy",service="",team="",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_consumers{queue_name="payments.billing.invoices",service="billing",team="payments",type="quorum",vhost="/"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_queue_consumers"); err != nil {
+		t.Error(err)
+	}
+
+	// Metrics with their own labels after the base ones must still line up
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+	if _, err := registry.Gather(); err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package exporter
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// OtherQueueName is the queue_name of the series that aggregates the queues
+// beyond max_queues_per_vhost. RabbitMQ refuses to declare queues under the
+// reserved amq. prefix and only generates amq.gen- names itself, so no real
+// queue shares the aggregate's series.
+const OtherQueueName = "amq.exporter.other"
+
+// WithMaxQueuesPerVhost exports only the max deepest queues of each vhost
+// individually and sums the rest into OtherQueueName series
+func WithMaxQueuesPerVhost(max int) CollectorOption {
+	return func(c *Collector) {
+		c.maxQueuesPerVhost = max
+	}
+}
+
+// queueSelection is the outcome of choosing which queues get per-queue series
+type queueSelection struct {
+	individual []rabbitmq.Queue
+	// other holds one aggregate per vhost and queue type
+	other []rabbitmq.Queue
+	// aggregated counts the queues summed into other, per vhost
+	aggregated map[string]int
+}
+
+// selectQueues drops queues in rollup-only vhosts and then applies the
+// per-vhost queue limit. Individual queues keep their listing order.
+func (c *Collector) selectQueues(queues []rabbitmq.Queue) queueSelection {
+	if c.rollupOnly != nil {
+		filtered := make([]rabbitmq.Queue, 0, len(queues))
+		for _, q := range queues {
+			if !c.rollupOnly.Match(q.Vhost) {
+				filtered = append(filtered, q)
+			}
+		}
+		queues = filtered
+	}
+	if c.maxQueuesPerVhost <= 0 {
+		return queueSelection{individual: queues}
+	}
+
+	byVhost := make(map[string][]int)
+	for i, q := range queues {
+		byVhost[q.Vhost] = append(byVhost[q.Vhost], i)
+	}
+
+	sel := queueSelection{aggregated: make(map[string]int, len(byVhost))}
+	keep := make([]bool, len(queues))
+	for vhost, indexes := range byVhost {
+		// Deepest first, by name among equals so the selection is stable
+		// across collections
+		sort.Slice(indexes, func(a, b int) bool {
+			qa, qb := &queues[indexes[a]], &queues[indexes[b]]
+			if qa.Messages != qb.Messages {
+				return qa.Messages > qb.Messages
+			}
+			return qa.Name < qb.Name
+		})
+		if len(indexes) > c.maxQueuesPerVhost {
+			sel.aggregated[vhost] = len(indexes) - c.maxQueuesPerVhost
+			indexes = indexes[:c.maxQueuesPerVhost]
+		} else {
+			sel.aggregated[vhost] = 0
+		}
+		for _, i := range indexes {
+			keep[i] = true
+		}
+	}
+
+	sel.individual = make([]rabbitmq.Queue, 0, len(queues))
+	others := make(map[[2]string]*rabbitmq.Queue)
+	for i, q := range queues {
+		if keep[i] {
+			sel.individual = append(sel.individual, q)
+			continue
+		}
+
+		key := [2]string{q.Vhost, q.GetQueueType()}
+		other := others[key]
+		if other == nil {
+			other = &rabbitmq.Queue{
+				Name:         OtherQueueName,
+				Vhost:        q.Vhost,
+				Type:         q.GetQueueType(),
+				MessageStats: &rabbitmq.MessageStats{},
+			}
+			others[key] = other
+		}
+		addToOther(other, q)
+	}
+	for _, other := range others {
+		sel.other = append(sel.other, *other)
+	}
+	sort.Slice(sel.other, func(i, j int) bool {
+		if sel.other[i].Vhost != sel.other[j].Vhost {
+			return sel.other[i].Vhost < sel.other[j].Vhost
+		}
+		return sel.other[i].Type < sel.other[j].Type
+	})
+	return sel
+}
+
+func addToOther(other *rabbitmq.Queue, q rabbitmq.Queue) {
+	other.Messages += q.Messages
+	other.MessagesReady += q.MessagesReady
+	other.MessagesUnacknowledged += q.MessagesUnacknowledged
+	other.Consumers += q.Consumers
+	other.Memory += q.Memory
+	other.MessageBytes += q.MessageBytes
+	other.MessageBytesReady += q.MessageBytesReady
+	other.MessageBytesUnacknowledged += q.MessageBytesUnacknowledged
+	other.MessagesRAM += q.MessagesRAM
+	other.MessagesPersistent += q.MessagesPersistent
+
+	stats := other.MessageStats
+	stats.PublishDetails = addRate(stats.PublishDetails, q.GetPublishRate())
+	stats.DeliverDetails = addRate(stats.DeliverDetails, q.GetDeliverRate())
+	stats.AckDetails = addRate(stats.AckDetails, q.GetAckRate())
+	stats.RedeliverDetails = addRate(stats.RedeliverDetails, q.GetRedeliverRate())
+}
+
+func addRate(details *rabbitmq.RateDetails, rate float64) *rabbitmq.RateDetails {
+	if details == nil {
+		details = &rabbitmq.RateDetails{}
+	}
+	details.Rate += rate
+	return details
+}
+
+// collectOtherQueueMetrics emits the metrics that still mean something when
+// summed over many queues. Configuration, health and alerting series would
+// describe no real queue, so they are left out.
+func (c *Collector) collectOtherQueueMetrics(ch chan<- prometheus.Metric, other rabbitmq.Queue) {
+	labels := append([]string{other.Name, other.Vhost, other.Type}, c.queueLabels.Values(other.Name)...)
+	labels = labels[:len(labels):len(labels)]
+
+	emitGauge(ch, c.metrics.QueueMessages, float64(other.Messages), append(labels, string(other.GetQueueState()))...)
+	emitGauge(ch, c.metrics.QueueMessagesReady, float64(other.MessagesReady), labels...)
+	emitGauge(ch, c.metrics.QueueMessagesUnacknowledged, float64(other.MessagesUnacknowledged), labels...)
+
+	emitGauge(ch, c.metrics.QueueMemoryBytes, float64(other.Memory), labels...)
+	emitGauge(ch, c.metrics.QueueMessageBytes, float64(other.MessageBytes), labels...)
+	emitGauge(ch, c.metrics.QueueMessageBytesReady, float64(other.MessageBytesReady), labels...)
+	emitGauge(ch, c.metrics.QueueMessageBytesUnacknowledged, float64(other.MessageBytesUnacknowledged), labels...)
+	emitGauge(ch, c.metrics.QueueMessagesRAM, float64(other.MessagesRAM), labels...)
+	emitGauge(ch, c.metrics.QueueMessagesPersistent, float64(other.MessagesPersistent), labels...)
+	emitGauge(ch, c.metrics.QueueMessagePublishRate, other.GetPublishRate(), labels...)
+	emitGauge(ch, c.metrics.QueueMessageDeliverRate, other.GetDeliverRate(), labels...)
+	emitGauge(ch, c.metrics.QueueMessageAckRate, other.GetAckRate(), labels...)
+	emitGauge(ch, c.metrics.QueueMessageRedeliverRate, other.GetRedeliverRate(), labels...)
+
+	emitGauge(ch, c.metrics.QueueConsumers, float64(other.Consumers), labels...)
+}
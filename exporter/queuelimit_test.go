@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_MaxQueuesPerVhost(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues":
+			w.Write([]byte(`[
+				{"name":"orders","vhost":"/","messages":50,"consumers":2},
+				{"name":"worker-1","vhost":"/","messages":3,"consumers":1,"message_stats":{"publish_details":{"rate":1.5}}},
+				{"name":"worker-2","vhost":"/","messages":7,"consumers":1,"message_stats":{"publish_details":{"rate":2}}},
+				{"name":"worker-3","vhost":"/","messages":10,"consumers":1},
+				{"name":"payments","vhost":"payments","messages":1,"type":"quorum"}
+			]`))
+		case "/api/nodes":
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithMaxQueuesPerVhost(2))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_consumers Number of consumers connected to the queue
+# TYPE rabbitmq_custom_queue_consumers gauge
+rabbitmq_custom_queue_consumers{queue_name="amq.exporter.other",type="classic",vhost="/"} 2
+rabbitmq_custom_queue_consumers{queue_name="orders",type="classic",vhost="/"} 2
+rabbitmq_custom_queue_consumers{queue_name="payments",type="quorum",vhost="payments"} 0
+rabbitmq_custom_queue_consumers{queue_name="worker-3",type="classic",vhost="/"} 1
+# HELP rabbitmq_custom_queue_message_publish_rate Message publish rate per second
+# TYPE rabbitmq_custom_queue_message_publish_rate gauge
+rabbitmq_custom_queue_message_publish_rate{queue_name="amq.exporter.other",type="classic",vhost="/"} 3.5
+rabbitmq_custom_queue_message_publish_rate{queue_name="orders",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_message_publish_rate{queue_name="payments",type="quorum",vhost="payments"} 0
+rabbitmq_custom_queue_message_publish_rate{queue_name="worker-3",type="classic",vhost="/"} 0
+# HELP rabbitmq_custom_vhost_queues Number of queues in the vhost
+# TYPE rabbitmq_custom_vhost_queues gauge
+rabbitmq_custom_vhost_queues{vhost="/"} 4
+rabbitmq_custom_vhost_queues{vhost="payments"} 1
+# HELP rabbitmq_custom_vhost_aggregated_queues Number of queues beyond max_queues_per_vhost that are only exported as part of the aggregate series
+# TYPE rabbitmq_custom_vhost_aggregated_queues gauge
+rabbitmq_custom_vhost_aggregated_queues{vhost="/"} 2
+rabbitmq_custom_vhost_aggregated_queues{vhost="payments"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_consumers",
+		"rabbitmq_custom_queue_message_publish_rate",
+		"rabbitmq_custom_vhost_queues",
+		"rabbitmq_custom_vhost_aggregated_queues"); err != nil {
+		t.Error(err)
+	}
+
+	// The aggregate describes no real queue, so it has no health series
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_info"); n != 3 {
+		t.Errorf("Expected info for the 3 individual queues only, got %d", n)
+	}
+}
+
+func TestCollector_MaxQueuesPerVhostQueueNamedOther(t *testing.T) {
+	// A queue may be named like the aggregate of earlier releases
+	client := &exportertest.Client{Queues: []rabbitmq.Queue{
+		{Name: "_other", Vhost: "/", Messages: 50, Consumers: 1},
+		{Name: "worker-1", Vhost: "/", Messages: 3, Consumers: 2},
+		{Name: "worker-2", Vhost: "/", Messages: 7, Consumers: 3},
+	}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithMaxQueuesPerVhost(1))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_consumers Number of consumers connected to the queue
+# TYPE rabbitmq_custom_queue_consumers gauge
+rabbitmq_custom_queue_consumers{queue_name="_other",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_consumers{queue_name="amq.exporter.other",type="classic",vhost="/"} 5
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_queue_consumers"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces secrets wherever the exporter shows its configuration
+// or broker data
+const Redacted = "REDACTED"
+
+// RedactURL masks the password of s if it is a URL with credentials, e.g. a
+// shovel or federation upstream URI
+func RedactURL(s string) string {
+	if !strings.Contains(s, "://") || !strings.Contains(s, "@") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+	u.User = url.UserPassword(u.User.Username(), Redacted)
+	return u.String()
+}
@@ -0,0 +1,12 @@
+package exporter
+
+import (
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// Reconfigure swaps the rules a config reload can change. Collections
+// already in flight finish with the old rules.
+func (c *Collector) Reconfigure(thresholds *DepthThresholdMatcher, deadLetter *rabbitmq.DeadLetterRules) {
+	c.depthThresholds.Store(thresholds)
+	c.deadLetter.Store(deadLetter)
+}
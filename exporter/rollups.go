@@ -0,0 +1,128 @@
+package exporter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// vhostRollup sums the queues of a vhost
+type vhostRollup struct {
+	Vhost                  string
+	Queues                 int
+	Messages               int64
+	MessagesReady          int64
+	MessagesUnacknowledged int64
+	Consumers              int64
+	PublishRate            float64
+	DeliverRate            float64
+
+	// Queues and messages that don't survive a restart or their last
+	// consumer or connection going away
+	AutoDeleteQueues   int
+	AutoDeleteMessages int64
+	ExclusiveQueues    int
+	ExclusiveMessages  int64
+	NonDurableQueues   int
+	NonDurableMessages int64
+}
+
+// rollupVhosts sums queue statistics per vhost, ordered by vhost
+func rollupVhosts(queues []rabbitmq.Queue) []vhostRollup {
+	byVhost := make(map[string]*vhostRollup)
+	for i := range queues {
+		q := &queues[i]
+		r := byVhost[q.Vhost]
+		if r == nil {
+			r = &vhostRollup{Vhost: q.Vhost}
+			byVhost[q.Vhost] = r
+		}
+		r.Queues++
+		r.Messages += q.Messages
+		r.MessagesReady += q.MessagesReady
+		r.MessagesUnacknowledged += q.MessagesUnacknowledged
+		r.Consumers += int64(q.Consumers)
+		r.PublishRate += q.GetPublishRate()
+		r.DeliverRate += q.GetDeliverRate()
+		if q.AutoDelete {
+			r.AutoDeleteQueues++
+			r.AutoDeleteMessages += q.Messages
+		}
+		if q.Exclusive {
+			r.ExclusiveQueues++
+			r.ExclusiveMessages += q.Messages
+		}
+		if !q.Durable {
+			r.NonDurableQueues++
+			r.NonDurableMessages += q.Messages
+		}
+	}
+
+	rollups := make([]vhostRollup, 0, len(byVhost))
+	for _, r := range byVhost {
+		rollups = append(rollups, *r)
+	}
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i].Vhost < rollups[j].Vhost })
+	return rollups
+}
+
+// VhostMatcher matches vhost names against a list of regular expressions
+type VhostMatcher struct {
+	patterns []*regexp.Regexp
+}
+
+func NewVhostMatcher(patterns []string) (*VhostMatcher, error) {
+	m := &VhostMatcher{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vhost pattern %q: %w", p, err)
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+// Match reports whether any pattern matches vhost. A nil matcher matches
+// nothing.
+func (m *VhostMatcher) Match(vhost string) bool {
+	if m == nil {
+		return false
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(vhost) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRollupOnlyVhosts exports queues in matching vhosts only through the
+// per-vhost rollups
+func WithRollupOnlyVhosts(matcher *VhostMatcher) CollectorOption {
+	return func(c *Collector) {
+		c.rollupOnly = matcher
+	}
+}
+
+func (c *Collector) collectVhostRollups(ch chan<- prometheus.Metric, rollups []vhostRollup) {
+	for _, r := range rollups {
+		emitGauge(ch, c.metrics.VhostQueues, float64(r.Queues), r.Vhost)
+		emitGauge(ch, c.metrics.VhostMessages, float64(r.Messages), r.Vhost)
+		emitGauge(ch, c.metrics.VhostMessagesReady, float64(r.MessagesReady), r.Vhost)
+		emitGauge(ch, c.metrics.VhostMessagesUnacknowledged, float64(r.MessagesUnacknowledged), r.Vhost)
+		emitGauge(ch, c.metrics.VhostConsumers, float64(r.Consumers), r.Vhost)
+		emitGauge(ch, c.metrics.VhostMessagePublishRate, r.PublishRate, r.Vhost)
+		emitGauge(ch, c.metrics.VhostMessageDeliverRate, r.DeliverRate, r.Vhost)
+		emitGauge(ch, c.metrics.VhostQueuesByFlag, float64(r.AutoDeleteQueues), r.Vhost, "auto_delete")
+		emitGauge(ch, c.metrics.VhostQueuesByFlag, float64(r.ExclusiveQueues), r.Vhost, "exclusive")
+		emitGauge(ch, c.metrics.VhostQueuesByFlag, float64(r.NonDurableQueues), r.Vhost, "non_durable")
+		emitGauge(ch, c.metrics.VhostMessagesByFlag, float64(r.AutoDeleteMessages), r.Vhost, "auto_delete")
+		emitGauge(ch, c.metrics.VhostMessagesByFlag, float64(r.ExclusiveMessages), r.Vhost, "exclusive")
+		emitGauge(ch, c.metrics.VhostMessagesByFlag, float64(r.NonDurableMessages), r.Vhost, "non_durable")
+	}
+}
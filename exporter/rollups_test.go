@@ -0,0 +1,138 @@
+package exporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const rollupQueuesJSON = `[
+	{"name":"orders","vhost":"/","durable":true,"messages":15,"messages_ready":10,"messages_unacknowledged":5,"consumers":2,
+		"message_stats":{"publish_details":{"rate":4},"deliver_details":{"rate":3}}},
+	{"name":"billing","vhost":"/","messages":5,"messages_ready":5,"consumers":1,
+		"message_stats":{"publish_details":{"rate":1.5}}},
+	{"name":"amq.gen-1","vhost":"rpc","auto_delete":true,"exclusive":true,"messages":1,"messages_ready":1},
+	{"name":"amq.gen-2","vhost":"rpc","consumers":1}
+]`
+
+func TestRollupVhosts(t *testing.T) {
+	var queues []rabbitmq.Queue
+	if err := json.Unmarshal([]byte(rollupQueuesJSON), &queues); err != nil {
+		t.Fatalf("Failed to parse queues: %v", err)
+	}
+
+	rollups := rollupVhosts(queues)
+	expected := []vhostRollup{
+		{Vhost: "/", Queues: 2, Messages: 20, MessagesReady: 15, MessagesUnacknowledged: 5, Consumers: 3, PublishRate: 5.5, DeliverRate: 3,
+			NonDurableQueues: 1, NonDurableMessages: 5},
+		{Vhost: "rpc", Queues: 2, Messages: 1, MessagesReady: 1, Consumers: 1,
+			AutoDeleteQueues: 1, AutoDeleteMessages: 1, ExclusiveQueues: 1, ExclusiveMessages: 1, NonDurableQueues: 2, NonDurableMessages: 1},
+	}
+	if len(rollups) != len(expected) {
+		t.Fatalf("Expected %d rollups, got %+v", len(expected), rollups)
+	}
+	for i := range expected {
+		if rollups[i] != expected[i] {
+			t.Errorf("Expected rollup %+v, got %+v", expected[i], rollups[i])
+		}
+	}
+}
+
+func TestVhostMatcher(t *testing.T) {
+	matcher, err := NewVhostMatcher([]string{"^rpc$", "^tmp-"})
+	if err != nil {
+		t.Fatalf("NewVhostMatcher failed: %v", err)
+	}
+	for vhost, expected := range map[string]bool{"rpc": true, "tmp-42": true, "/": false, "rpc-prod": false} {
+		if got := matcher.Match(vhost); got != expected {
+			t.Errorf("Match(%q) = %v, expected %v", vhost, got, expected)
+		}
+	}
+
+	var none *VhostMatcher
+	if none.Match("rpc") {
+		t.Error("Expected a nil matcher to match nothing")
+	}
+
+	if _, err := NewVhostMatcher([]string{"("}); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
+
+func TestCollector_VhostRollups(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues":
+			w.Write([]byte(rollupQueuesJSON))
+		case "/api/nodes":
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer rabbit.Close()
+
+	matcher, err := NewVhostMatcher([]string{"^rpc$"})
+	if err != nil {
+		t.Fatalf("NewVhostMatcher failed: %v", err)
+	}
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithRollupOnlyVhosts(matcher))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_vhost_messages Total number of messages in the vhost's queues
+# TYPE rabbitmq_custom_vhost_messages gauge
+rabbitmq_custom_vhost_messages{vhost="/"} 20
+rabbitmq_custom_vhost_messages{vhost="rpc"} 1
+# HELP rabbitmq_custom_vhost_message_publish_rate Rate of messages published to the vhost's queues
+# TYPE rabbitmq_custom_vhost_message_publish_rate gauge
+rabbitmq_custom_vhost_message_publish_rate{vhost="/"} 5.5
+rabbitmq_custom_vhost_message_publish_rate{vhost="rpc"} 0
+# HELP rabbitmq_custom_vhost_queues Number of queues in the vhost
+# TYPE rabbitmq_custom_vhost_queues gauge
+rabbitmq_custom_vhost_queues{vhost="/"} 2
+rabbitmq_custom_vhost_queues{vhost="rpc"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_vhost_messages", "rabbitmq_custom_vhost_message_publish_rate", "rabbitmq_custom_vhost_queues"); err != nil {
+		t.Error(err)
+	}
+
+	expected = `
+# HELP rabbitmq_custom_vhost_queues_by_flag Number of the vhost's queues that are auto_delete, exclusive or non_durable
+# TYPE rabbitmq_custom_vhost_queues_by_flag gauge
+rabbitmq_custom_vhost_queues_by_flag{flag="auto_delete",vhost="/"} 0
+rabbitmq_custom_vhost_queues_by_flag{flag="auto_delete",vhost="rpc"} 1
+rabbitmq_custom_vhost_queues_by_flag{flag="exclusive",vhost="/"} 0
+rabbitmq_custom_vhost_queues_by_flag{flag="exclusive",vhost="rpc"} 1
+rabbitmq_custom_vhost_queues_by_flag{flag="non_durable",vhost="/"} 1
+rabbitmq_custom_vhost_queues_by_flag{flag="non_durable",vhost="rpc"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_vhost_queues_by_flag"); err != nil {
+		t.Error(err)
+	}
+
+	// Queues in rollup-only vhosts get no per-queue series
+	expected = `
+# HELP rabbitmq_custom_queue_consumers Number of consumers connected to the queue
+# TYPE rabbitmq_custom_queue_consumers gauge
+rabbitmq_custom_queue_consumers{queue_name="billing",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_consumers{queue_name="orders",type="classic",vhost="/"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rabbitmq_custom_queue_consumers"); err != nil {
+		t.Error(err)
+	}
+
+	if n := len(collector.Snapshot().Queues); n != 4 {
+		t.Errorf("Expected the snapshot to keep all 4 queues, got %d", n)
+	}
+}
@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// WithCollectionJitter spreads background collections out, so exporters
+// started together don't all hit the management API at the same instant.
+// The first collection starts at a random point within the first scrape
+// interval and every later one is up to jitter early or late.
+func WithCollectionJitter(jitter time.Duration) CollectorOption {
+	return func(c *Collector) {
+		if jitter > 0 {
+			c.jitter = jitter
+		}
+	}
+}
+
+// ValidateCollectionJitter checks that jitter leaves every interval
+// positive
+func ValidateCollectionJitter(jitter, scrapeInterval time.Duration) error {
+	if jitter < 0 || jitter >= scrapeInterval {
+		return fmt.Errorf("collection_jitter must be between 0 and scrape_interval (%v), got %v", scrapeInterval, jitter)
+	}
+	return nil
+}
+
+// firstCollection returns the delay before the first background collection
+func (c *Collector) firstCollection() time.Duration {
+	if c.jitter <= 0 {
+		return c.scrapeInterval
+	}
+	return time.Duration(c.random() * float64(c.scrapeInterval))
+}
+
+// nextCollection returns the point on the collection schedule after last.
+// Like a ticker, it keeps to the schedule rather than waiting a full interval
+// after a slow collection. Slots a slow collection has overrun are skipped
+// rather than run back to back, and counted.
+func (c *Collector) nextCollection(last, now time.Time) (time.Time, int) {
+	next := last.Add(c.scrapeInterval)
+	skipped := 0
+	for !next.After(now) {
+		next = next.Add(c.scrapeInterval)
+		skipped++
+	}
+	return next, skipped
+}
+
+// afterBackoff moves next past until, when the broker asked the client to
+// hold off with Retry-After, and returns how many slots that skipped
+func (c *Collector) afterBackoff(next, until time.Time) (time.Time, int) {
+	if !next.Before(until) {
+		return next, 0
+	}
+	later, skipped := c.nextCollection(next, until)
+	return later, skipped + 1
+}
+
+// jitterOffset returns how far to move a collection from its point on the
+// schedule. The schedule itself doesn't move, so jitter doesn't accumulate.
+func (c *Collector) jitterOffset() time.Duration {
+	if c.jitter <= 0 {
+		return 0
+	}
+	return time.Duration((2*c.random() - 1) * float64(c.jitter))
+}
+
+func (c *Collector) random() float64 {
+	if c.randFloat != nil {
+		return c.randFloat()
+	}
+	return rand.Float64()
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_SkipIfBusy(t *testing.T) {
+	collector, m := newTestCollector(t, `[{"name":"orders","vhost":"/","messages":5}]`)
+	snapshot := collector.snapshotID
+
+	collector.collecting.Store(1)
+	collector.collectQueueData()
+	if collector.snapshotID != snapshot {
+		t.Error("Expected the overlapping collection not to run")
+	}
+	if got := testutil.ToFloat64(m.CollectionsSkippedTotal.WithLabelValues(skipReasonBusy)); got != 1 {
+		t.Errorf("Expected one busy skip, got %v", got)
+	}
+
+	collector.collecting.Store(0)
+	collector.collectQueueData()
+	if collector.snapshotID != snapshot+1 {
+		t.Error("Expected the collection to run once the previous one finished")
+	}
+}
+
+func TestCollector_CollectionSchedule(t *testing.T) {
+	c := &Collector{scrapeInterval: 10 * time.Second}
+	start := time.Unix(1000, 0)
+
+	if got := c.firstCollection(); got != 10*time.Second {
+		t.Errorf("Expected the first collection after one interval without jitter, got %v", got)
+	}
+	if got := c.jitterOffset(); got != 0 {
+		t.Errorf("Expected no offset without jitter, got %v", got)
+	}
+	if got, skipped := c.nextCollection(start, start.Add(2*time.Second)); !got.Equal(start.Add(10*time.Second)) || skipped != 0 {
+		t.Errorf("Expected the next interval, got %v with %d skipped", got.Sub(start), skipped)
+	}
+	// A collection that overran two intervals skips them
+	if got, skipped := c.nextCollection(start, start.Add(25*time.Second)); !got.Equal(start.Add(30*time.Second)) || skipped != 2 {
+		t.Errorf("Expected two overdue collections to be skipped, got %v with %d skipped", got.Sub(start), skipped)
+	}
+
+	WithCollectionJitter(2 * time.Second)(c)
+	random := 0.25
+	c.randFloat = func() float64 { return random }
+
+	if got := c.firstCollection(); got != 2500*time.Millisecond {
+		t.Errorf("Expected the first collection staggered within the interval, got %v", got)
+	}
+	if got := c.jitterOffset(); got != -time.Second {
+		t.Errorf("Expected a collection 1s early, got %v", got)
+	}
+	random = 1
+	if got := c.jitterOffset(); got != 2*time.Second {
+		t.Errorf("Expected a collection 2s late, got %v", got)
+	}
+}
+
+func TestValidateCollectionJitter(t *testing.T) {
+	tests := []struct {
+		jitter time.Duration
+		valid  bool
+	}{
+		{0, true},
+		{3 * time.Second, true},
+		{-time.Second, false},
+		{15 * time.Second, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateCollectionJitter(tt.jitter, 15*time.Second); (err == nil) != tt.valid {
+			t.Errorf("validateCollectionJitter(%v) = %v, expected valid %v", tt.jitter, err, tt.valid)
+		}
+	}
+}
+
+func TestCollector_AfterBackoff(t *testing.T) {
+	c := &Collector{scrapeInterval: 10 * time.Second}
+	next := time.Unix(1000, 0)
+
+	if got, skipped := c.afterBackoff(next, next.Add(-time.Second)); !got.Equal(next) || skipped != 0 {
+		t.Errorf("Expected an expired backoff to keep the schedule, got %v with %d skipped", got.Sub(next), skipped)
+	}
+	// A 25s Retry-After covers the next collection and the two after it
+	if got, skipped := c.afterBackoff(next, next.Add(25*time.Second)); !got.Equal(next.Add(30*time.Second)) || skipped != 3 {
+		t.Errorf("Expected the first collection after the backoff, got %v with %d skipped", got.Sub(next), skipped)
+	}
+}
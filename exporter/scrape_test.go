@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+	"rabbitmq-exporter/rabbitmq/rabbitmqtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// scrapeFake registers a collector reading from server in a fresh
+// registry, warms it and returns the /metrics exposition
+func scrapeFake(t *testing.T, server *rabbitmqtest.Server, timeout time.Duration, opts ...CollectorOption) string {
+	t.Helper()
+
+	client := rabbitmq.NewClient(server.URL, rabbitmqtest.DefaultUsername, rabbitmqtest.DefaultPassword, timeout)
+	t.Cleanup(client.Close)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, opts...)
+	t.Cleanup(collector.Stop)
+	collector.Warm(5 * time.Second)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	metricsServer := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	t.Cleanup(metricsServer.Close)
+
+	resp, err := http.Get(metricsServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 from /metrics, got %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func expectSeries(t *testing.T, exposition string, series ...string) {
+	t.Helper()
+	for _, line := range series {
+		if !strings.Contains(exposition, line+"\n") {
+			t.Errorf("Expected series %q in:\n%s", line, exposition)
+		}
+	}
+}
+
+func TestScrape_FakeBroker(t *testing.T) {
+	server := rabbitmqtest.NewServer()
+	defer server.Close()
+	server.SetQueues(
+		rabbitmq.Queue{Name: "orders", Vhost: "/", Type: "quorum", Messages: 12, MessagesReady: 10, MessagesUnacknowledged: 2, Consumers: 3},
+		rabbitmq.Queue{Name: "billing", Vhost: "payments", Messages: 4, MessagesReady: 4},
+	)
+	server.SetNodes(
+		rabbitmq.Node{Name: "rabbit@a", Running: true},
+		rabbitmq.Node{Name: "rabbit@b", Running: true, MemAlarm: true},
+	)
+
+	exposition := scrapeFake(t, server, time.Second)
+
+	expectSeries(t, exposition,
+		`rabbitmq_custom_up 1`,
+		`rabbitmq_custom_queue_messages_ready{queue_name="orders",type="quorum",vhost="/"} 10`,
+		`rabbitmq_custom_queue_messages_ready{queue_name="billing",type="classic",vhost="payments"} 4`,
+		`rabbitmq_custom_queue_consumers{queue_name="orders",type="quorum",vhost="/"} 3`,
+		`rabbitmq_custom_vhost_queues{vhost="payments"} 1`,
+		`rabbitmq_custom_node_mem_alarm{node="rabbit@b"} 1`,
+	)
+}
+
+func TestScrape_FakeBrokerErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*rabbitmqtest.Server)
+		errorType string
+	}{
+		{
+			name: "server error",
+			configure: func(s *rabbitmqtest.Server) {
+				s.Fail(rabbitmq.EndpointQueues, http.StatusInternalServerError, "boom")
+			},
+			errorType: rabbitmq.ErrorTypeServerError,
+		},
+		{
+			name:      "bad credentials",
+			configure: func(s *rabbitmqtest.Server) { s.SetCredentials("exporter", "secret") },
+			errorType: rabbitmq.ErrorTypeAuth,
+		},
+		{
+			name:      "slow queues endpoint",
+			configure: func(s *rabbitmqtest.Server) { s.SetLatency(rabbitmq.EndpointQueues, time.Second) },
+			errorType: rabbitmq.ErrorTypeTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rabbitmqtest.NewServer()
+			defer server.Close()
+			server.SetQueues(rabbitmq.Queue{Name: "orders", Vhost: "/", Messages: 12})
+			tt.configure(server)
+
+			exposition := scrapeFake(t, server, 50*time.Millisecond)
+
+			expectSeries(t, exposition,
+				`rabbitmq_custom_up 0`,
+				`rabbitmq_custom_scrape_errors_total{error_type="`+tt.errorType+`"} 1`,
+			)
+			if strings.Contains(exposition, `queue_name="orders"`) {
+				t.Error("Expected queue series to be withheld after a failed collection")
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package exporter
+
+import (
+	"fmt"
+)
+
+// Severities whose depth thresholds come from queue_depth_thresholds and
+// x-exporter-depth-* arguments rather than from their tier
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// SeverityTierConfig describes a severity level of the depth and
+// utilisation alerts. Depth may not be set on the warning and critical
+// tiers, and utilisation of those tiers defaults to UtilisationWarning and
+// UtilisationCritical. Labels are added to generated alerting rules of this
+// severity.
+type SeverityTierConfig struct {
+	Severity    string            `mapstructure:"severity"`
+	Depth       int64             `mapstructure:"depth"`
+	Utilisation float64           `mapstructure:"utilisation"`
+	Labels      map[string]string `mapstructure:"labels"`
+}
+
+// SeverityTier is a validated severity level. A zero Depth or Utilisation
+// means the tier has no alert of that kind.
+type SeverityTier struct {
+	Severity    string
+	Depth       int64
+	Utilisation float64
+	Labels      map[string]string
+}
+
+// DefaultSeverityTiers returns the warning and critical tiers used when no
+// tiers are configured
+func DefaultSeverityTiers() []SeverityTier {
+	return []SeverityTier{
+		{Severity: SeverityWarning, Utilisation: UtilisationWarning},
+		{Severity: SeverityCritical, Utilisation: UtilisationCritical},
+	}
+}
+
+// NewSeverityTiers validates configs, in the order their alerts are
+// exported. No configs give DefaultSeverityTiers.
+func NewSeverityTiers(configs []SeverityTierConfig) ([]SeverityTier, error) {
+	if len(configs) == 0 {
+		return DefaultSeverityTiers(), nil
+	}
+
+	tiers := make([]SeverityTier, 0, len(configs))
+	seen := make(map[string]bool, len(configs))
+	for i, cfg := range configs {
+		if cfg.Severity == "" {
+			return nil, fmt.Errorf("severity tier %d has no severity", i)
+		}
+		if seen[cfg.Severity] {
+			return nil, fmt.Errorf("severity tier %q is defined more than once", cfg.Severity)
+		}
+		seen[cfg.Severity] = true
+
+		if cfg.Depth < 0 {
+			return nil, fmt.Errorf("severity tier %q: depth must not be negative", cfg.Severity)
+		}
+		if cfg.Utilisation < 0 || cfg.Utilisation > 1 {
+			return nil, fmt.Errorf("severity tier %q: utilisation must be between 0 and 1", cfg.Severity)
+		}
+		if _, ok := cfg.Labels["severity"]; ok {
+			return nil, fmt.Errorf("severity tier %q: labels must not set severity", cfg.Severity)
+		}
+
+		tier := SeverityTier{Severity: cfg.Severity, Depth: cfg.Depth, Utilisation: cfg.Utilisation, Labels: cfg.Labels}
+		switch cfg.Severity {
+		case SeverityWarning, SeverityCritical:
+			if cfg.Depth != 0 {
+				return nil, fmt.Errorf("severity tier %q: depth is set by queue_depth_thresholds", cfg.Severity)
+			}
+			if tier.Utilisation == 0 {
+				tier.Utilisation = UtilisationWarning
+				if cfg.Severity == SeverityCritical {
+					tier.Utilisation = UtilisationCritical
+				}
+			}
+		}
+		tiers = append(tiers, tier)
+	}
+
+	return tiers, nil
+}
+
+// DepthThreshold returns the depth above which the tier's alert fires for a
+// queue with the given thresholds, and false when the tier has no depth
+// alert
+func (t SeverityTier) DepthThreshold(depth DepthThresholds) (int64, bool) {
+	switch t.Severity {
+	case SeverityWarning:
+		return depth.Warning, true
+	case SeverityCritical:
+		return depth.Critical, true
+	}
+	return t.Depth, t.Depth > 0
+}
+
+// WithSeverityTiers sets the severity levels of the depth and utilisation
+// alerts. Without it the collector uses DefaultSeverityTiers.
+func WithSeverityTiers(tiers []SeverityTier) CollectorOption {
+	return func(c *Collector) {
+		c.severityTiers = tiers
+	}
+}
@@ -0,0 +1,110 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewSeverityTiers(t *testing.T) {
+	tiers, err := NewSeverityTiers([]SeverityTierConfig{
+		{Severity: "info", Depth: 500},
+		{Severity: "warning"},
+		{Severity: "critical", Utilisation: 0.05},
+		{Severity: "page", Depth: 100000, Utilisation: 0.001},
+	})
+	if err != nil {
+		t.Fatalf("NewSeverityTiers: %v", err)
+	}
+	if tiers[1].Utilisation != UtilisationWarning || tiers[2].Utilisation != 0.05 {
+		t.Errorf("Expected default warning and configured critical utilisation, got %+v", tiers)
+	}
+	if tiers[0].Utilisation != 0 {
+		t.Errorf("Expected no utilisation alert for info, got %v", tiers[0].Utilisation)
+	}
+
+	depth := DepthThresholds{Warning: 10, Critical: 20}
+	for i, want := range []int64{500, 10, 20, 100000} {
+		if got, ok := tiers[i].DepthThreshold(depth); !ok || got != want {
+			t.Errorf("Tier %s: expected depth %d, got %d (%v)", tiers[i].Severity, want, got, ok)
+		}
+	}
+
+	if defaults, _ := NewSeverityTiers(nil); len(defaults) != 2 {
+		t.Errorf("Expected warning and critical tiers by default, got %+v", defaults)
+	}
+}
+
+func TestNewSeverityTiers_Invalid(t *testing.T) {
+	for name, configs := range map[string][]SeverityTierConfig{
+		"no severity":       {{Depth: 10}},
+		"duplicate":         {{Severity: "page", Depth: 10}, {Severity: "page", Depth: 20}},
+		"negative depth":    {{Severity: "page", Depth: -1}},
+		"utilisation > 1":   {{Severity: "page", Utilisation: 2}},
+		"warning depth":     {{Severity: "warning", Depth: 10}},
+		"severity in label": {{Severity: "page", Labels: map[string]string{"severity": "p1"}}},
+	} {
+		if _, err := NewSeverityTiers(configs); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCollector_SeverityTiers(t *testing.T) {
+	tiers, err := NewSeverityTiers([]SeverityTierConfig{
+		{Severity: "info", Depth: 100},
+		{Severity: "critical"},
+		{Severity: "page", Depth: 5000, Utilisation: 0.5},
+	})
+	if err != nil {
+		t.Fatalf("NewSeverityTiers: %v", err)
+	}
+	client := &exportertest.Client{Queues: []rabbitmq.Queue{
+		{Name: "orders", Vhost: "/", Messages: 2000, ConsumerUtilisation: 0.2},
+	}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithSeverityTiers(tiers))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_depth_alert Queue depth alert indicator (1 if depth > threshold, 0 otherwise)
+# TYPE rabbitmq_custom_queue_depth_alert gauge
+rabbitmq_custom_queue_depth_alert{queue_name="orders",severity="critical",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_depth_alert{queue_name="orders",severity="info",type="classic",vhost="/"} 1
+rabbitmq_custom_queue_depth_alert{queue_name="orders",severity="page",type="classic",vhost="/"} 0
+# HELP rabbitmq_custom_queue_utilization_alert Queue utilization alert indicator (1 if utilization < threshold, 0 otherwise)
+# TYPE rabbitmq_custom_queue_utilization_alert gauge
+rabbitmq_custom_queue_utilization_alert{queue_name="orders",severity="critical",type="classic",vhost="/"} 0
+rabbitmq_custom_queue_utilization_alert{queue_name="orders",severity="page",type="classic",vhost="/"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_depth_alert", "rabbitmq_custom_queue_utilization_alert"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_HealthMetricsDisabled(t *testing.T) {
+	client := &exportertest.Client{Queues: []rabbitmq.Queue{{Name: "orders", Vhost: "/", Messages: 20000}}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithHealthMetrics(false))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	for _, name := range []string{
+		"rabbitmq_custom_queue_health_score",
+		"rabbitmq_custom_queue_depth_alert",
+		"rabbitmq_custom_queue_utilization_alert",
+	} {
+		if n := testutil.CollectAndCount(collector, name); n != 0 {
+			t.Errorf("Expected no %s series with health metrics off, got %d", name, n)
+		}
+	}
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_queue_messages"); n != 1 {
+		t.Errorf("Expected queue metrics to be unaffected, got %d series", n)
+	}
+}
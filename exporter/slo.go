@@ -0,0 +1,160 @@
+package exporter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+const DefaultDepthSLOWindow = 24 * time.Hour
+
+// DepthSLOConfig describes a critical queue whose depth should stay at or
+// under Threshold. A zero Threshold uses the queue's critical depth
+// threshold.
+type DepthSLOConfig struct {
+	Vhost     string        `mapstructure:"vhost"`
+	Queue     string        `mapstructure:"queue"`
+	Threshold int64         `mapstructure:"threshold"`
+	Window    time.Duration `mapstructure:"window"`
+}
+
+// DepthSLOStatus reports how often a queue stayed within its threshold over
+// its window
+type DepthSLOStatus struct {
+	Vhost   string
+	Queue   string
+	Samples int
+	Ratio   float64
+}
+
+type depthSample struct {
+	at     time.Time
+	within bool
+}
+
+type depthSLOState struct {
+	config  DepthSLOConfig
+	samples []depthSample
+}
+
+// DepthSLOTracker records, for each configured queue, whether its depth was
+// within threshold at every collection, and keeps the samples of a rolling
+// window
+type DepthSLOTracker struct {
+	mu   sync.Mutex
+	slos []*depthSLOState
+}
+
+func NewDepthSLOTracker(configs []DepthSLOConfig) (*DepthSLOTracker, error) {
+	t := &DepthSLOTracker{}
+
+	seen := make(map[string]bool, len(configs))
+	for _, cfg := range configs {
+		if cfg.Queue == "" {
+			return nil, fmt.Errorf("depth SLO in vhost %q has no queue name", cfg.Vhost)
+		}
+		if cfg.Threshold < 0 {
+			return nil, fmt.Errorf("depth SLO for queue %q: threshold must not be negative", cfg.Queue)
+		}
+		if cfg.Vhost == "" {
+			cfg.Vhost = "/"
+		}
+		if cfg.Window <= 0 {
+			cfg.Window = DefaultDepthSLOWindow
+		}
+		// Both entries would export the same series
+		key := cfg.Queue + "@" + cfg.Vhost
+		if seen[key] {
+			return nil, fmt.Errorf("depth SLO for queue %q in vhost %q is defined more than once", cfg.Queue, cfg.Vhost)
+		}
+		seen[key] = true
+		t.slos = append(t.slos, &depthSLOState{config: cfg})
+	}
+
+	return t, nil
+}
+
+// WithDepthSLOs enables depth SLO tracking for the tracker's queues
+func WithDepthSLOs(tracker *DepthSLOTracker) CollectorOption {
+	return func(c *Collector) {
+		c.depthSLOs = tracker
+	}
+}
+
+// Observe records a sample for every configured queue in a fresh queue
+// snapshot and drops samples that have left the window. Queues missing
+// from the snapshot get no sample.
+func (t *DepthSLOTracker) Observe(queues []rabbitmq.Queue, thresholds *DepthThresholdMatcher, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, slo := range t.slos {
+		slo.trim(now)
+
+		var queue *rabbitmq.Queue
+		for i := range queues {
+			if queues[i].Name == slo.config.Queue && queues[i].Vhost == slo.config.Vhost {
+				queue = &queues[i]
+				break
+			}
+		}
+		if queue == nil {
+			continue
+		}
+
+		threshold := slo.config.Threshold
+		if threshold == 0 {
+			threshold = thresholds.ForQueue(*queue).Critical
+		}
+		slo.samples = append(slo.samples, depthSample{at: now, within: queue.Messages <= threshold})
+	}
+}
+
+// Status returns the ratio of every configured queue as of now. Queues with
+// no samples in their window have a Ratio of 0.
+func (t *DepthSLOTracker) Status(now time.Time) []DepthSLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]DepthSLOStatus, 0, len(t.slos))
+	for _, slo := range t.slos {
+		slo.trim(now)
+
+		within := 0
+		for _, sample := range slo.samples {
+			if sample.within {
+				within++
+			}
+		}
+		status := DepthSLOStatus{Vhost: slo.config.Vhost, Queue: slo.config.Queue, Samples: len(slo.samples)}
+		if status.Samples > 0 {
+			status.Ratio = float64(within) / float64(status.Samples)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// trim drops the samples taken before the start of the window ending at now
+func (s *depthSLOState) trim(now time.Time) {
+	start := now.Add(-s.config.Window)
+	i := 0
+	for i < len(s.samples) && s.samples[i].at.Before(start) {
+		i++
+	}
+	s.samples = s.samples[i:]
+}
+
+func (c *Collector) collectDepthSLOMetrics(ch chan<- prometheus.Metric) {
+	for _, status := range c.depthSLOs.Status(time.Now()) {
+		emitGauge(ch, c.metrics.DepthSLOSamples, float64(status.Samples), status.Queue, status.Vhost)
+		// Without samples there is no ratio to report
+		if status.Samples > 0 {
+			emitGauge(ch, c.metrics.DepthSLORatio, status.Ratio, status.Queue, status.Vhost)
+		}
+	}
+}
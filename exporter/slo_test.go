@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDepthSLOTracker(t *testing.T) {
+	tracker, err := NewDepthSLOTracker([]DepthSLOConfig{
+		{Queue: "orders", Threshold: 100, Window: time.Hour},
+		{Queue: "jobs"},
+	})
+	if err != nil {
+		t.Fatalf("Expected tracker to be created, got %v", err)
+	}
+	thresholds, _ := NewDepthThresholdMatcher(nil, DepthThresholds{Warning: 10, Critical: 50})
+
+	start := time.Now()
+	for i, depth := range []int64{10, 200, 100, 300} {
+		tracker.Observe([]rabbitmq.Queue{
+			{Name: "orders", Vhost: "/", Messages: depth},
+			{Name: "jobs", Vhost: "/", Messages: depth},
+		}, thresholds, start.Add(time.Duration(i)*10*time.Minute))
+	}
+
+	statuses := tracker.Status(start.Add(30 * time.Minute))
+	if orders := statuses[0]; orders.Samples != 4 || orders.Ratio != 0.5 {
+		t.Errorf("Expected orders within 100 in 2 of 4 samples, got %+v", orders)
+	}
+	// jobs falls back to the critical depth threshold of 50
+	if jobs := statuses[1]; jobs.Samples != 4 || jobs.Ratio != 0.25 {
+		t.Errorf("Expected jobs within 50 in 1 of 4 samples, got %+v", jobs)
+	}
+
+	// The first two samples leave the window
+	if orders := tracker.Status(start.Add(75 * time.Minute))[0]; orders.Samples != 2 || orders.Ratio != 0.5 {
+		t.Errorf("Expected 2 samples left in the window, got %+v", orders)
+	}
+
+	// A missing queue records no sample
+	tracker.Observe(nil, thresholds, start.Add(80*time.Minute))
+	if orders := tracker.Status(start.Add(2 * time.Hour))[0]; orders.Samples != 0 || orders.Ratio != 0 {
+		t.Errorf("Expected no samples once the window passed, got %+v", orders)
+	}
+}
+
+func TestNewDepthSLOTracker_Invalid(t *testing.T) {
+	if _, err := NewDepthSLOTracker([]DepthSLOConfig{{Vhost: "/"}}); err == nil {
+		t.Error("Expected error for depth SLO without queue name")
+	}
+	if _, err := NewDepthSLOTracker([]DepthSLOConfig{{Queue: "orders", Threshold: -1}}); err == nil {
+		t.Error("Expected error for negative depth SLO threshold")
+	}
+	// The default vhost makes these the same queue
+	if _, err := NewDepthSLOTracker([]DepthSLOConfig{{Queue: "orders", Window: time.Hour}, {Vhost: "/", Queue: "orders"}}); err == nil {
+		t.Error("Expected error for a queue with two depth SLOs")
+	}
+}
+
+func TestCollector_DepthSLOMetrics(t *testing.T) {
+	tracker, err := NewDepthSLOTracker([]DepthSLOConfig{
+		{Queue: "orders", Threshold: 100},
+		{Queue: "missing"},
+	})
+	if err != nil {
+		t.Fatalf("NewDepthSLOTracker: %v", err)
+	}
+	client := &exportertest.Client{Queues: []rabbitmq.Queue{{Name: "orders", Vhost: "/", Messages: 50}}}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithDepthSLOs(tracker))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	client.SetQueues([]rabbitmq.Queue{{Name: "orders", Vhost: "/", Messages: 150}})
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_queue_depth_slo_ratio Fraction of collections within the SLO window in which the queue's depth stayed at or under its threshold
+# TYPE rabbitmq_custom_queue_depth_slo_ratio gauge
+rabbitmq_custom_queue_depth_slo_ratio{queue_name="orders",vhost="/"} 0.5
+# HELP rabbitmq_custom_queue_depth_slo_samples Number of collections within the SLO window that the depth SLO ratio is based on
+# TYPE rabbitmq_custom_queue_depth_slo_samples gauge
+rabbitmq_custom_queue_depth_slo_samples{queue_name="missing",vhost="/"} 0
+rabbitmq_custom_queue_depth_slo_samples{queue_name="orders",vhost="/"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_queue_depth_slo_ratio", "rabbitmq_custom_queue_depth_slo_samples"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// Snapshot is the JSON view of one collection cycle. SnapshotID matches the
+// rabbitmq_custom_snapshot_id gauge exposed alongside the same data.
+type Snapshot struct {
+	SnapshotID uint64          `json:"snapshot_id"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Valid      bool            `json:"valid"`
+	Error      string          `json:"error,omitempty"`
+	Queues     []QueueSnapshot `json:"queues"`
+}
+
+// QueueSnapshot is the JSON view of a single queue
+type QueueSnapshot struct {
+	Name                   string  `json:"name"`
+	Vhost                  string  `json:"vhost"`
+	Type                   string  `json:"type"`
+	State                  string  `json:"state"`
+	Messages               int64   `json:"messages"`
+	MessagesReady          int64   `json:"messages_ready"`
+	MessagesUnacknowledged int64   `json:"messages_unacknowledged"`
+	Consumers              int64   `json:"consumers"`
+	PublishRate            float64 `json:"publish_rate"`
+	DeliverRate            float64 `json:"deliver_rate"`
+}
+
+func newQueueSnapshot(q rabbitmq.Queue) QueueSnapshot {
+	s := QueueSnapshot{
+		Name:                   q.Name,
+		Vhost:                  q.Vhost,
+		Type:                   q.GetQueueType(),
+		State:                  string(q.GetQueueState()),
+		Messages:               q.Messages,
+		MessagesReady:          q.MessagesReady,
+		MessagesUnacknowledged: q.MessagesUnacknowledged,
+		Consumers:              q.Consumers,
+	}
+	if q.MessageStats != nil {
+		if q.MessageStats.PublishDetails != nil {
+			s.PublishRate = q.MessageStats.PublishDetails.Rate
+		}
+		if q.MessageStats.DeliverDetails != nil {
+			s.DeliverRate = q.MessageStats.DeliverDetails.Rate
+		}
+	}
+	return s
+}
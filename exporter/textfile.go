@@ -0,0 +1,86 @@
+package exporter
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const textfileName = "rabbitmq_exporter.prom"
+
+// OutputConfig configures outputs other than the /metrics endpoint
+type OutputConfig struct {
+	// TextfileDir is a node_exporter textfile collector directory the
+	// exposition is written to after every collection
+	TextfileDir string `mapstructure:"textfile_dir"`
+	// TextfileOnly disables the HTTP server for hosts that can't open a port
+	TextfileOnly bool `mapstructure:"textfile_only"`
+}
+
+// TextfileWriter atomically writes the exposition of a gatherer to a .prom
+// file from a background worker; Notify never blocks
+type TextfileWriter struct {
+	path     string
+	gatherer prometheus.Gatherer
+
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func NewTextfileWriter(dir string, gatherer prometheus.Gatherer) (*TextfileWriter, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("textfile directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("textfile directory %s is not a directory", dir)
+	}
+
+	w := &TextfileWriter{
+		path:     filepath.Join(dir, textfileName),
+		gatherer: gatherer,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Path returns the file the writer writes to
+func (w *TextfileWriter) Path() string {
+	return w.path
+}
+
+// Write gathers and writes the exposition. The file is written to a
+// temporary name and renamed so the textfile collector never reads a
+// partial file.
+func (w *TextfileWriter) Write() error {
+	return prometheus.WriteToTextfile(w.path, w.gatherer)
+}
+
+// Notify schedules a write, coalescing requests made while one is pending
+func (w *TextfileWriter) Notify() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *TextfileWriter) run() {
+	defer close(w.done)
+
+	for range w.notify {
+		if err := w.Write(); err != nil {
+			log.Printf("Failed to write textfile %s: %v", w.path, err)
+		}
+	}
+}
+
+// Close waits for a pending write and stops the worker
+func (w *TextfileWriter) Close() {
+	close(w.notify)
+	<-w.done
+}
@@ -0,0 +1,133 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTextfileWriter_Write(t *testing.T) {
+	dir := t.TempDir()
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rabbitmq_custom_queue_messages", Help: "test"}, []string{"queue_name"})
+	gauge.WithLabelValues("orders").Set(42)
+	registry.MustRegister(gauge)
+
+	w, err := NewTextfileWriter(dir, registry)
+	if err != nil {
+		t.Fatalf("NewTextfileWriter: %v", err)
+	}
+	defer w.Close()
+
+	if err := w.Write(); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, textfileName))
+	if err != nil {
+		t.Fatalf("Failed to read textfile: %v", err)
+	}
+	if !strings.Contains(string(data), `rabbitmq_custom_queue_messages{queue_name="orders"} 42`) {
+		t.Errorf("Textfile missing sample:\n%s", data)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only %s in the directory, found %d entries", textfileName, len(entries))
+	}
+}
+
+func TestTextfileWriter_NotifyAndClose(t *testing.T) {
+	dir := t.TempDir()
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "rabbitmq_custom_up", Help: "test"})
+	gauge.Set(1)
+	registry.MustRegister(gauge)
+
+	w, err := NewTextfileWriter(dir, registry)
+	if err != nil {
+		t.Fatalf("NewTextfileWriter: %v", err)
+	}
+	w.Notify()
+	w.Notify()
+	w.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, textfileName))
+	if err != nil {
+		t.Fatalf("Expected textfile to be written before Close returned: %v", err)
+	}
+	if !strings.Contains(string(data), "rabbitmq_custom_up 1") {
+		t.Errorf("Textfile missing sample:\n%s", data)
+	}
+}
+
+func TestNewTextfileWriter_InvalidDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "not-a-dir")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewTextfileWriter(file, prometheus.NewRegistry()); err == nil {
+		t.Error("Expected error for a path that is not a directory")
+	}
+	if _, err := NewTextfileWriter(filepath.Join(dir, "missing"), prometheus.NewRegistry()); err == nil {
+		t.Error("Expected error for a missing directory")
+	}
+}
+
+func TestTextfileWriter_Canaries(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/queues" {
+			w.Write([]byte(`[{"name":"orders.canary","vhost":"/","messages":3}]`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer rabbit.Close()
+
+	tracker, err := NewCanaryTracker([]CanaryConfig{{Pipeline: "orders", Queue: "orders.canary", MaxSilence: time.Minute}}, time.Now())
+	if err != nil {
+		t.Fatalf("NewCanaryTracker: %v", err)
+	}
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour, WithCanaries(tracker))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	// Nothing scrapes /metrics, as with output.textfile_only
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(snapshotCollector{c: collector})
+
+	dir := t.TempDir()
+	w, err := NewTextfileWriter(dir, registry)
+	if err != nil {
+		t.Fatalf("NewTextfileWriter: %v", err)
+	}
+	defer w.Close()
+	if err := w.Write(); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, textfileName))
+	if err != nil {
+		t.Fatalf("Failed to read textfile: %v", err)
+	}
+	if !strings.Contains(string(data), `rabbitmq_custom_canary_producer_alive{pipeline="orders",queue_name="orders.canary",vhost="/"} 1`) {
+		t.Errorf("Textfile missing canary liveness:\n%s", data)
+	}
+}
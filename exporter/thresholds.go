@@ -0,0 +1,140 @@
+package exporter
+
+import (
+	"fmt"
+	"regexp"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+const (
+	DefaultDepthWarning  = 1000
+	DefaultDepthCritical = 10000
+)
+
+// Consumer utilisation below which a queue is flagged and loses health score
+const (
+	UtilisationWarning  = 0.1
+	UtilisationCritical = 0.01
+)
+
+// Health score cutoffs used by the generated alerting rules
+const (
+	HealthScoreWarning  = 50
+	HealthScoreCritical = 25
+)
+
+// Max-length ratios at which the generated rules warn that a queue is about
+// to overflow
+const (
+	SaturationWarning  = 0.8
+	SaturationCritical = 0.95
+)
+
+// MaxDrainSeconds caps the estimated drain time, which is unbounded for
+// queues that nothing is being delivered from
+const MaxDrainSeconds = 7 * 24 * 60 * 60
+
+// Queue arguments that let queue owners override depth thresholds themselves
+const (
+	DepthWarningArgument  = "x-exporter-depth-warning"
+	DepthCriticalArgument = "x-exporter-depth-critical"
+)
+
+// DepthThresholdConfig maps a queue name pattern to depth alert thresholds
+type DepthThresholdConfig struct {
+	Pattern  string `mapstructure:"pattern"`
+	Warning  int64  `mapstructure:"warning"`
+	Critical int64  `mapstructure:"critical"`
+}
+
+// DepthThresholds holds the queue depth at which warning and critical alerts fire
+type DepthThresholds struct {
+	Warning  int64
+	Critical int64
+}
+
+type depthThresholdRule struct {
+	pattern    *regexp.Regexp
+	thresholds DepthThresholds
+}
+
+// DepthThresholdMatcher resolves depth thresholds for a queue by name. Rules are
+// evaluated in order and the first matching pattern wins.
+type DepthThresholdMatcher struct {
+	rules    []depthThresholdRule
+	defaults DepthThresholds
+}
+
+func NewDepthThresholdMatcher(configs []DepthThresholdConfig, defaults DepthThresholds) (*DepthThresholdMatcher, error) {
+	m := &DepthThresholdMatcher{defaults: defaults}
+
+	for i, cfg := range configs {
+		re, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid queue depth threshold pattern %q: %w", cfg.Pattern, err)
+		}
+
+		thresholds := DepthThresholds{Warning: cfg.Warning, Critical: cfg.Critical}
+		if thresholds.Warning <= 0 {
+			thresholds.Warning = defaults.Warning
+		}
+		if thresholds.Critical <= 0 {
+			thresholds.Critical = defaults.Critical
+		}
+		if thresholds.Warning > thresholds.Critical {
+			return nil, fmt.Errorf("queue depth threshold %d (%q): warning %d exceeds critical %d",
+				i, cfg.Pattern, thresholds.Warning, thresholds.Critical)
+		}
+
+		m.rules = append(m.rules, depthThresholdRule{pattern: re, thresholds: thresholds})
+	}
+
+	return m, nil
+}
+
+// For returns the thresholds that apply to the named queue
+func (m *DepthThresholdMatcher) For(queueName string) DepthThresholds {
+	for _, rule := range m.rules {
+		if rule.pattern.MatchString(queueName) {
+			return rule.thresholds
+		}
+	}
+	return m.defaults
+}
+
+// DepthThresholdPattern is a configured rule: queues whose names match
+// Pattern get Thresholds
+type DepthThresholdPattern struct {
+	Pattern    string
+	Thresholds DepthThresholds
+}
+
+// Patterns returns the configured rules in the order they are evaluated
+func (m *DepthThresholdMatcher) Patterns() []DepthThresholdPattern {
+	patterns := make([]DepthThresholdPattern, len(m.rules))
+	for i, rule := range m.rules {
+		patterns[i] = DepthThresholdPattern{Pattern: rule.pattern.String(), Thresholds: rule.thresholds}
+	}
+	return patterns
+}
+
+// Defaults returns the thresholds of queues no rule matches
+func (m *DepthThresholdMatcher) Defaults() DepthThresholds {
+	return m.defaults
+}
+
+// ForQueue returns the thresholds for a queue, letting the queue's own
+// x-exporter-depth-* arguments override the configured rules
+func (m *DepthThresholdMatcher) ForQueue(queue rabbitmq.Queue) DepthThresholds {
+	thresholds := m.For(queue.Name)
+
+	if warning, ok := queue.GetIntArgument(DepthWarningArgument); ok && warning > 0 {
+		thresholds.Warning = warning
+	}
+	if critical, ok := queue.GetIntArgument(DepthCriticalArgument); ok && critical > 0 {
+		thresholds.Critical = critical
+	}
+
+	return thresholds
+}
@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"testing"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+func TestDepthThresholdMatcher_For(t *testing.T) {
+	defaults := DepthThresholds{Warning: DefaultDepthWarning, Critical: DefaultDepthCritical}
+	matcher, err := NewDepthThresholdMatcher([]DepthThresholdConfig{
+		{Pattern: `^orders\.`, Warning: 5000, Critical: 50000},
+		{Pattern: `^batch\.`, Critical: 200000},
+	}, defaults)
+	if err != nil {
+		t.Fatalf("Expected matcher to be created, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		queue    string
+		expected DepthThresholds
+	}{
+		{
+			name:     "Matching pattern",
+			queue:    "orders.created",
+			expected: DepthThresholds{Warning: 5000, Critical: 50000},
+		},
+		{
+			name:     "Partial override inherits defaults",
+			queue:    "batch.import",
+			expected: DepthThresholds{Warning: DefaultDepthWarning, Critical: 200000},
+		},
+		{
+			name:     "No matching pattern",
+			queue:    "payments",
+			expected: defaults,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matcher.For(tt.queue)
+			if result != tt.expected {
+				t.Errorf("Expected thresholds to be %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestNewDepthThresholdMatcher_Invalid(t *testing.T) {
+	defaults := DepthThresholds{Warning: DefaultDepthWarning, Critical: DefaultDepthCritical}
+
+	if _, err := NewDepthThresholdMatcher([]DepthThresholdConfig{{Pattern: "("}}, defaults); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+
+	if _, err := NewDepthThresholdMatcher([]DepthThresholdConfig{{Pattern: ".*", Warning: 100, Critical: 10}}, defaults); err == nil {
+		t.Error("Expected error when warning exceeds critical")
+	}
+}
+
+func TestDepthThresholdMatcher_ForQueue(t *testing.T) {
+	matcher, err := NewDepthThresholdMatcher([]DepthThresholdConfig{
+		{Pattern: `^orders\.`, Warning: 5000, Critical: 50000},
+	}, DepthThresholds{Warning: DefaultDepthWarning, Critical: DefaultDepthCritical})
+	if err != nil {
+		t.Fatalf("Expected matcher to be created, got %v", err)
+	}
+
+	queue := rabbitmq.Queue{
+		Name: "orders.created",
+		Arguments: map[string]interface{}{
+			DepthWarningArgument:  float64(200),
+			DepthCriticalArgument: "800",
+		},
+	}
+	expected := DepthThresholds{Warning: 200, Critical: 800}
+	if result := matcher.ForQueue(queue); result != expected {
+		t.Errorf("Expected thresholds to be %+v, got %+v", expected, result)
+	}
+
+	queue.Arguments = map[string]interface{}{DepthCriticalArgument: "not-a-number"}
+	expected = DepthThresholds{Warning: 5000, Critical: 50000}
+	if result := matcher.ForQueue(queue); result != expected {
+		t.Errorf("Expected invalid argument to be ignored, got %+v", result)
+	}
+}
@@ -0,0 +1,60 @@
+package exporter
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// vhostPublishing sums the publishing stats of a vhost's channels
+type vhostPublishing struct {
+	Vhost                  string
+	ConfirmRate            float64
+	ReturnedUnroutableRate float64
+	DroppedUnroutableRate  float64
+}
+
+// UnroutableRate reports the rate of messages that reached no queue
+func (p vhostPublishing) UnroutableRate() float64 {
+	return p.ReturnedUnroutableRate + p.DroppedUnroutableRate
+}
+
+// rollupChannels sums channel rates per vhost, ordered by vhost. Counters
+// aren't summed, since they would go down whenever a channel closes.
+func rollupChannels(channels []rabbitmq.Channel) []vhostPublishing {
+	byVhost := make(map[string]*vhostPublishing)
+	for i := range channels {
+		channel := &channels[i]
+		p := byVhost[channel.Vhost]
+		if p == nil {
+			p = &vhostPublishing{Vhost: channel.Vhost}
+			byVhost[channel.Vhost] = p
+		}
+		p.ConfirmRate += channel.GetConfirmRate()
+		p.ReturnedUnroutableRate += channel.GetReturnUnroutableRate()
+		p.DroppedUnroutableRate += channel.GetDropUnroutableRate()
+	}
+
+	publishing := make([]vhostPublishing, 0, len(byVhost))
+	for _, p := range byVhost {
+		publishing = append(publishing, *p)
+	}
+	sort.Slice(publishing, func(i, j int) bool { return publishing[i].Vhost < publishing[j].Vhost })
+	return publishing
+}
+
+func (c *Collector) collectPublishingMetrics(ch chan<- prometheus.Metric, publishing []vhostPublishing) {
+	for _, p := range publishing {
+		emitGauge(ch, c.metrics.VhostMessageConfirmRate, p.ConfirmRate, p.Vhost)
+		emitGauge(ch, c.metrics.VhostMessageUnroutableRate, p.ReturnedUnroutableRate, p.Vhost, "returned")
+		emitGauge(ch, c.metrics.VhostMessageUnroutableRate, p.DroppedUnroutableRate, p.Vhost, "dropped")
+
+		alert := 0.0
+		if p.UnroutableRate() > 0 {
+			alert = 1.0
+		}
+		emitGauge(ch, c.metrics.VhostUnroutableAlert, alert, p.Vhost)
+	}
+}
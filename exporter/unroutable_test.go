@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_Unroutable(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues", "/api/nodes":
+			w.Write([]byte(`[]`))
+		case "/api/channels":
+			w.Write([]byte(`[
+				{"name":"app-1","vhost":"/","message_stats":{"confirm_details":{"rate":10},"return_unroutable_details":{"rate":0.5}}},
+				{"name":"app-2","vhost":"/","message_stats":{"confirm_details":{"rate":5},"drop_unroutable_details":{"rate":2}}},
+				{"name":"billing","vhost":"payments","message_stats":{"confirm_details":{"rate":3}}},
+				{"name":"idle","vhost":"payments"}
+			]`))
+		}
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour,
+		WithCollectGroups(CollectGroups{Queues: true, Channels: true}))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_vhost_message_confirm_rate Rate of publisher confirms sent on the vhost's channels
+# TYPE rabbitmq_custom_vhost_message_confirm_rate gauge
+rabbitmq_custom_vhost_message_confirm_rate{vhost="/"} 15
+rabbitmq_custom_vhost_message_confirm_rate{vhost="payments"} 3
+# HELP rabbitmq_custom_vhost_message_unroutable_rate Rate of published messages that matched no queue, by outcome (returned to a mandatory publisher or dropped)
+# TYPE rabbitmq_custom_vhost_message_unroutable_rate gauge
+rabbitmq_custom_vhost_message_unroutable_rate{outcome="dropped",vhost="/"} 2
+rabbitmq_custom_vhost_message_unroutable_rate{outcome="dropped",vhost="payments"} 0
+rabbitmq_custom_vhost_message_unroutable_rate{outcome="returned",vhost="/"} 0.5
+rabbitmq_custom_vhost_message_unroutable_rate{outcome="returned",vhost="payments"} 0
+# HELP rabbitmq_custom_vhost_unroutable_alert Whether messages published in the vhost are currently unroutable (1 = alert)
+# TYPE rabbitmq_custom_vhost_unroutable_alert gauge
+rabbitmq_custom_vhost_unroutable_alert{vhost="/"} 1
+rabbitmq_custom_vhost_unroutable_alert{vhost="payments"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_vhost_message_confirm_rate",
+		"rabbitmq_custom_vhost_message_unroutable_rate",
+		"rabbitmq_custom_vhost_unroutable_alert"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_UnroutableNeedsChannels(t *testing.T) {
+	collector, _ := newTestCollector(t, `[]`)
+
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_vhost_unroutable_alert"); n != 0 {
+		t.Errorf("Expected no unroutable series without the channels group, got %d", n)
+	}
+}
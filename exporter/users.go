@@ -0,0 +1,80 @@
+package exporter
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// userActivity sums the connections and channels of one user and
+// application, the client-provided connection name
+type userActivity struct {
+	User        string
+	Application string
+	Connections int
+	Channels    int64
+	ReceiveRate float64
+	SendRate    float64
+	PublishRate float64
+}
+
+// rollupUsers sums connections, and channels when they were listed, per user
+// and application, ordered by user and application. Channels are matched to
+// their connection to find the application; those whose connection isn't
+// listed count towards an empty application.
+func rollupUsers(connections []rabbitmq.Connection, channels []rabbitmq.Channel) []userActivity {
+	type key struct{ user, application string }
+	byKey := make(map[key]*userActivity)
+	get := func(user, application string) *userActivity {
+		k := key{user, application}
+		a := byKey[k]
+		if a == nil {
+			a = &userActivity{User: user, Application: application}
+			byKey[k] = a
+		}
+		return a
+	}
+
+	applications := make(map[string]string, len(connections))
+	for i := range connections {
+		conn := &connections[i]
+		application := conn.ClientProperties.ConnectionName
+		applications[conn.Name] = application
+
+		a := get(conn.User, application)
+		a.Connections++
+		a.Channels += conn.Channels
+		a.ReceiveRate += conn.GetReceiveRate()
+		a.SendRate += conn.GetSendRate()
+	}
+	for i := range channels {
+		channel := &channels[i]
+		get(channel.User, applications[channel.ConnectionDetails.Name]).PublishRate += channel.GetPublishRate()
+	}
+
+	activity := make([]userActivity, 0, len(byKey))
+	for _, a := range byKey {
+		activity = append(activity, *a)
+	}
+	sort.Slice(activity, func(i, j int) bool {
+		if activity[i].User != activity[j].User {
+			return activity[i].User < activity[j].User
+		}
+		return activity[i].Application < activity[j].Application
+	})
+	return activity
+}
+
+func (c *Collector) collectUserMetrics(ch chan<- prometheus.Metric, activity []userActivity, withChannels bool) {
+	for _, a := range activity {
+		emitGauge(ch, c.metrics.UserConnections, float64(a.Connections), a.User, a.Application)
+		emitGauge(ch, c.metrics.UserChannels, float64(a.Channels), a.User, a.Application)
+		emitGauge(ch, c.metrics.UserReceiveBytesRate, a.ReceiveRate, a.User, a.Application)
+		emitGauge(ch, c.metrics.UserSendBytesRate, a.SendRate, a.User, a.Application)
+		if withChannels {
+			emitGauge(ch, c.metrics.UserPublishRate, a.PublishRate, a.User, a.Application)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter/exportertest"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_UserMetrics(t *testing.T) {
+	client := &exportertest.Client{
+		Connections: []rabbitmq.Connection{
+			{Name: "c1", User: "billing", Channels: 2, ClientProperties: rabbitmq.ClientProperties{ConnectionName: "invoicer"},
+				RecvOctDetails: &rabbitmq.RateDetails{Rate: 100}, SendOctDetails: &rabbitmq.RateDetails{Rate: 10}},
+			{Name: "c2", User: "billing", Channels: 1, ClientProperties: rabbitmq.ClientProperties{ConnectionName: "invoicer"},
+				RecvOctDetails: &rabbitmq.RateDetails{Rate: 50}},
+			{Name: "c3", User: "admin", Channels: 1},
+		},
+		Channels: []rabbitmq.Channel{
+			{Name: "c1 (1)", User: "billing", ConnectionDetails: rabbitmq.ConnectionDetails{Name: "c1"},
+				MessageStats: &rabbitmq.ChannelMessageStats{PublishDetails: &rabbitmq.RateDetails{Rate: 4}}},
+			{Name: "c2 (1)", User: "billing", ConnectionDetails: rabbitmq.ConnectionDetails{Name: "c2"},
+				MessageStats: &rabbitmq.ChannelMessageStats{PublishDetails: &rabbitmq.RateDetails{Rate: 1}}},
+			{Name: "c3 (1)", User: "admin", ConnectionDetails: rabbitmq.ConnectionDetails{Name: "c3"}},
+		},
+	}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour,
+		WithCollectGroups(CollectGroups{Connections: true, Channels: true}))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	expected := `
+# HELP rabbitmq_custom_user_connections Number of open connections of the user, by application (client-provided connection name)
+# TYPE rabbitmq_custom_user_connections gauge
+rabbitmq_custom_user_connections{application="",user="admin"} 1
+rabbitmq_custom_user_connections{application="invoicer",user="billing"} 2
+# HELP rabbitmq_custom_user_channels Number of channels open on the user's connections, by application
+# TYPE rabbitmq_custom_user_channels gauge
+rabbitmq_custom_user_channels{application="",user="admin"} 1
+rabbitmq_custom_user_channels{application="invoicer",user="billing"} 3
+# HELP rabbitmq_custom_user_receive_bytes_rate Rate in bytes per second received from the user's connections, by application
+# TYPE rabbitmq_custom_user_receive_bytes_rate gauge
+rabbitmq_custom_user_receive_bytes_rate{application="",user="admin"} 0
+rabbitmq_custom_user_receive_bytes_rate{application="invoicer",user="billing"} 150
+# HELP rabbitmq_custom_user_message_publish_rate Rate of messages published on the user's channels, by application
+# TYPE rabbitmq_custom_user_message_publish_rate gauge
+rabbitmq_custom_user_message_publish_rate{application="",user="admin"} 0
+rabbitmq_custom_user_message_publish_rate{application="invoicer",user="billing"} 5
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rabbitmq_custom_user_connections", "rabbitmq_custom_user_channels",
+		"rabbitmq_custom_user_receive_bytes_rate", "rabbitmq_custom_user_message_publish_rate"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_connections_by_protocol"); n != 1 {
+		t.Errorf("Expected 1 protocol series, got %d", n)
+	}
+}
+
+func TestCollector_UserMetricsWithoutChannels(t *testing.T) {
+	client := &exportertest.Client{
+		Connections: []rabbitmq.Connection{{Name: "c1", User: "billing", Channels: 2}},
+	}
+	collector := NewCollector(client, metrics.NewMetrics(), time.Hour,
+		WithCollectGroups(CollectGroups{Connections: true}))
+	defer collector.Stop()
+	collector.collectQueueData()
+
+	if got := client.Calls("GetChannels"); got != 0 {
+		t.Errorf("Expected no channel listing without collect.channels, got %d", got)
+	}
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_user_connections"); n != 1 {
+		t.Errorf("Expected 1 connection series, got %d", n)
+	}
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_user_message_publish_rate"); n != 0 {
+		t.Errorf("Expected no publish rate without collect.channels, got %d", n)
+	}
+
+	client.SetError("GetConnections", errors.New("connection refused"))
+	collector.collectQueueData()
+	if n := testutil.CollectAndCount(collector, "rabbitmq_custom_user_connections"); n != 0 {
+		t.Errorf("Expected user series to be left out after a failed listing, got %d", n)
+	}
+}
+
+func TestCountProtocols(t *testing.T) {
+	counts := countProtocols([]rabbitmq.Connection{
+		{Protocol: "MQTT 3.1.1", SSL: true},
+		{Protocol: "AMQP 0-9-1", SSL: true},
+		{Protocol: "AMQP 0-9-1"},
+		{Protocol: "AMQP 0-9-1", SSL: true},
+	})
+	expected := []protocolCount{
+		{Protocol: "AMQP 0-9-1", TLS: false, Connections: 1},
+		{Protocol: "AMQP 0-9-1", TLS: true, Connections: 2},
+		{Protocol: "MQTT 3.1.1", TLS: true, Connections: 1},
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, counts)
+	}
+}
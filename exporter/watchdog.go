@@ -0,0 +1,78 @@
+package exporter
+
+import (
+	"log"
+	"time"
+)
+
+// DefaultWatchdogIntervals is how many scrape intervals may pass without a
+// completed collection before the watchdog restarts background collection
+const DefaultWatchdogIntervals = 5
+
+// WithWatchdog sets how many scrape intervals may pass without a completed
+// collection, successful or not, before the background loop is restarted.
+// Non-positive values keep DefaultWatchdogIntervals.
+func WithWatchdog(intervals int) CollectorOption {
+	return func(c *Collector) {
+		if intervals > 0 {
+			c.watchdogIntervals = intervals
+		}
+	}
+}
+
+// WatchdogTimeout never cuts off a collection that is still within its
+// collection timeout
+func (c *Collector) WatchdogTimeout() time.Duration {
+	timeout := time.Duration(c.watchdogIntervals) * c.scrapeInterval
+	if minimum := c.collectionTimeout + c.scrapeInterval; timeout < minimum {
+		timeout = minimum
+	}
+	return timeout
+}
+
+// finishCollection records that collection id has returned, unless the
+// watchdog already gave up on it
+func (c *Collector) finishCollection(id uint64) {
+	if c.collecting.CompareAndSwap(id, 0) {
+		c.lastCompleted.Store(time.Now().UnixNano())
+	}
+}
+
+// watchdog checks once per scrape interval that collections still complete
+func (c *Collector) watchdog() {
+	ticker := time.NewTicker(c.scrapeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case now := <-ticker.C:
+			c.checkWatchdog(now)
+		}
+	}
+}
+
+// checkWatchdog restarts background collection when none has completed
+// within the watchdog timeout, typically because a request or hook is stuck.
+// The stuck collection is cancelled and its results, should it ever return,
+// are discarded. It reports whether it restarted collection.
+func (c *Collector) checkWatchdog(now time.Time) bool {
+	last := time.Unix(0, c.lastCompleted.Load())
+	if now.Sub(last) < c.WatchdogTimeout() {
+		return false
+	}
+
+	log.Printf("Watchdog: no background collection has completed since %s; restarting background collection", last.Format(time.RFC3339))
+	c.metrics.CollectionWatchdogRestarts.Inc()
+
+	c.loopMu.Lock()
+	if c.cancelCollection != nil {
+		c.cancelCollection()
+	}
+	c.loopMu.Unlock()
+	c.collecting.Store(0)
+
+	c.startBackgroundCollection()
+	return true
+}
@@ -0,0 +1,107 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_WatchdogRestartsStuckCollection(t *testing.T) {
+	var queueRequests, nodeRequests int32
+	stuck := make(chan struct{})
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues":
+			if atomic.AddInt32(&queueRequests, 1) == 1 {
+				w.Write([]byte(`[{"name":"stale","vhost":"/"}]`))
+				return
+			}
+			w.Write([]byte(`[{"name":"fresh","vhost":"/"}]`))
+		case "/api/nodes":
+			// The first collection hangs here until it's cancelled
+			if atomic.AddInt32(&nodeRequests, 1) == 1 {
+				close(stuck)
+				<-r.Context().Done()
+				return
+			}
+			w.Write([]byte(`[]`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer rabbit.Close()
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Minute)
+	m := metrics.NewMetrics()
+	collector := NewCollector(client, m, time.Hour, WithCollectionTimeout(time.Minute))
+
+	if collector.checkWatchdog(time.Now()) {
+		t.Fatal("Expected no restart right after start")
+	}
+
+	stuckDone := make(chan struct{})
+	go func() {
+		defer close(stuckDone)
+		collector.collectQueueData()
+	}()
+	<-stuck
+
+	if !collector.checkWatchdog(time.Now().Add(collector.WatchdogTimeout())) {
+		t.Fatal("Expected the watchdog to restart the stuck collection")
+	}
+	if got := testutil.ToFloat64(m.CollectionWatchdogRestarts); got != 1 {
+		t.Errorf("Expected one watchdog restart, got %v", got)
+	}
+
+	collector.collectQueueData()
+	completed := collector.lastCompleted.Load()
+
+	select {
+	case <-stuckDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stuck collection to be cancelled")
+	}
+
+	collector.mu.RLock()
+	queues := collector.cachedQueues
+	collector.mu.RUnlock()
+	if len(queues) != 1 || queues[0].Name != "fresh" {
+		t.Errorf("Expected the stuck collection's late result to be discarded, got %v", queues)
+	}
+	if got := testutil.ToFloat64(m.Up); got != 1 {
+		t.Errorf("Expected up to stay 1, got %v", got)
+	}
+	if got := collector.lastCompleted.Load(); got != completed {
+		t.Error("Expected the abandoned collection not to count as completed")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		collector.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Stop to return after a watchdog restart")
+	}
+}
+
+func TestCollector_WatchdogTimeout(t *testing.T) {
+	c := &Collector{scrapeInterval: 15 * time.Second, collectionTimeout: 30 * time.Second, watchdogIntervals: DefaultWatchdogIntervals}
+	if got := c.WatchdogTimeout(); got != 75*time.Second {
+		t.Errorf("Expected five intervals, got %v", got)
+	}
+
+	WithWatchdog(2)(c)
+	if got := c.WatchdogTimeout(); got != 45*time.Second {
+		t.Errorf("Expected the collection timeout plus an interval, got %v", got)
+	}
+}
@@ -0,0 +1,103 @@
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Config describes the Grafana instance annotations are pushed to
+type Config struct {
+	URL          string        `mapstructure:"url"`
+	APIKey       string        `mapstructure:"api_key"`
+	DashboardUID string        `mapstructure:"dashboard_uid"`
+	Tags         []string      `mapstructure:"tags"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+}
+
+// Enabled reports whether annotation pushing has been configured
+func (c Config) Enabled() bool {
+	return c.URL != ""
+}
+
+// Annotation is a single event annotation
+type Annotation struct {
+	Time time.Time
+	Text string
+	Tags []string
+}
+
+type annotationRequest struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+// Client creates annotations through the Grafana HTTP API
+type Client struct {
+	baseURL      string
+	apiKey       string
+	dashboardUID string
+	tags         []string
+	httpClient   *http.Client
+}
+
+func NewClient(cfg Config) *Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &Client{
+		baseURL:      strings.TrimRight(cfg.URL, "/"),
+		apiKey:       cfg.APIKey,
+		dashboardUID: cfg.DashboardUID,
+		tags:         cfg.Tags,
+		httpClient:   &http.Client{Timeout: timeout},
+	}
+}
+
+// CreateAnnotation posts an annotation, adding the configured tags to those of
+// the annotation itself
+func (c *Client) CreateAnnotation(ctx context.Context, annotation Annotation) error {
+	tags := append(append([]string{}, c.tags...), annotation.Tags...)
+
+	payload, err := json.Marshal(annotationRequest{
+		DashboardUID: c.dashboardUID,
+		Time:         annotation.Time.UnixMilli(),
+		Tags:         tags,
+		Text:         annotation.Text,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal annotation: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/annotations", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create annotation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("annotation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("annotation request failed with HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_CreateAnnotation(t *testing.T) {
+	var received annotationRequest
+	var authHeader string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/annotations" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		authHeader = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"id":1,"message":"Annotation added"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{URL: server.URL + "/", APIKey: "token", DashboardUID: "abc", Tags: []string{"prod"}})
+	at := time.UnixMilli(1700000000000)
+
+	err := client.CreateAnnotation(context.Background(), Annotation{Time: at, Text: "Node down", Tags: []string{"node_down"}})
+	if err != nil {
+		t.Fatalf("Expected annotation to be created, got %v", err)
+	}
+
+	if authHeader != "Bearer token" {
+		t.Errorf("Expected bearer authorization, got '%s'", authHeader)
+	}
+	if received.Time != 1700000000000 || received.Text != "Node down" || received.DashboardUID != "abc" {
+		t.Errorf("Unexpected annotation payload: %+v", received)
+	}
+	if len(received.Tags) != 2 || received.Tags[0] != "prod" || received.Tags[1] != "node_down" {
+		t.Errorf("Expected configured and event tags, got %v", received.Tags)
+	}
+}
+
+func TestClient_CreateAnnotationError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{URL: server.URL})
+	if err := client.CreateAnnotation(context.Background(), Annotation{Time: time.Now(), Text: "x"}); err == nil {
+		t.Error("Expected error for non-200 response")
+	}
+}
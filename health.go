@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// readinessChecker is the part of *exporter.Collector /readyz needs
+type readinessChecker interface {
+	Readiness() error
+}
+
+// collectionTracker is the part of *exporter.Collector /health needs
+type collectionTracker interface {
+	LastCollection() (time.Time, error)
+}
+
+// livezHandler answers as long as the process can serve HTTP
+func livezHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+// readyzHandler answers 200 while the collector holds a recent snapshot
+func readyzHandler(collector readinessChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := collector.Readiness(); err != nil {
+			http.Error(w, "Not ready: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}
+}
+
+// healthCache answers /health from the collector's last collection while it
+// is at most maxStaleness old. Past that, for example while collection is
+// stuck, it runs the live check, and shares the result with every request
+// until that goes stale too.
+type healthCache struct {
+	collector    collectionTracker
+	check        func(context.Context) error
+	maxStaleness time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+func newHealthCache(collector collectionTracker, check func(context.Context) error, maxStaleness time.Duration) *healthCache {
+	return &healthCache{collector: collector, check: check, maxStaleness: maxStaleness}
+}
+
+func (h *healthCache) status(ctx context.Context) error {
+	if at, err := h.collector.LastCollection(); !at.IsZero() && time.Since(at) <= h.maxStaleness {
+		return err
+	}
+
+	// Holding the lock across the check keeps concurrent requests down to
+	// one live call
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.checked.IsZero() && time.Since(h.checked) <= h.maxStaleness {
+		return h.err
+	}
+	// A client hanging up must not leave a failure cached for everyone else
+	h.err = h.check(context.WithoutCancel(ctx))
+	h.checked = time.Now()
+	return h.err
+}
+
+func healthHandler(h *healthCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h.status(r.Context()); err != nil {
+			http.Error(w, "Health check failed", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readinessFunc lets a function stand in for the collector's readiness
+type readinessFunc func() error
+
+func (f readinessFunc) Readiness() error { return f() }
+
+func TestReadyzHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		readiness error
+		expected  int
+		body      string
+	}{
+		{name: "Ready", expected: http.StatusOK, body: "OK"},
+		{
+			name:      "Not ready",
+			readiness: errors.New("last collection failed: connection refused"),
+			expected:  http.StatusServiceUnavailable,
+			body:      "Not ready: last collection failed: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := readyzHandler(readinessFunc(func() error { return tt.readiness }))
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("Expected body to contain %q, got %q", tt.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestLivezHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	livezHandler(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+}
+
+// fakeCollection stands in for the collector's last collection
+type fakeCollection struct {
+	at  time.Time
+	err error
+}
+
+func (f *fakeCollection) LastCollection() (time.Time, error) { return f.at, f.err }
+
+func TestHealthCache(t *testing.T) {
+	collector := &fakeCollection{at: time.Now()}
+
+	var checks int
+	checkErr := errors.New("connection refused")
+	health := newHealthCache(collector, func(ctx context.Context) error {
+		checks++
+		return checkErr
+	}, time.Minute)
+	handler := healthHandler(health)
+
+	get := func() int {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		return rec.Code
+	}
+
+	// A recent successful collection answers without a live check
+	for i := 0; i < 3; i++ {
+		if code := get(); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+	}
+	if checks != 0 {
+		t.Fatalf("Expected no live checks while the collection is recent, got %d", checks)
+	}
+
+	// So does a recent failed one
+	collector.err = errors.New("queues: 503")
+	if code := get(); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 after a failed collection, got %d", code)
+	}
+	if checks != 0 {
+		t.Fatalf("Expected no live checks while the collection is recent, got %d", checks)
+	}
+
+	// Once the collection is stale, one live check serves every request
+	// until it goes stale too
+	collector.at = time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		if code := get(); code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503 from the live check, got %d", code)
+		}
+	}
+	if checks != 1 {
+		t.Fatalf("Expected 1 live check, got %d", checks)
+	}
+
+	health.mu.Lock()
+	health.checked = time.Now().Add(-time.Hour)
+	health.mu.Unlock()
+	checkErr = nil
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("Expected status 200 from a fresh live check, got %d", code)
+	}
+	if checks != 2 {
+		t.Fatalf("Expected 2 live checks, got %d", checks)
+	}
+}
@@ -0,0 +1,157 @@
+//go:build integration
+
+// Package integration provides a dockerized RabbitMQ broker for end-to-end
+// tests of the exporter. It is only built with the integration build tag.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/testcontainers/testcontainers-go/modules/rabbitmq"
+)
+
+const (
+	DefaultImage    = "rabbitmq:3.13-management-alpine"
+	DefaultUsername = "exporter"
+	DefaultPassword = "exporter"
+)
+
+// Broker is a running RabbitMQ container with an open AMQP connection used to
+// create queues, publish messages and attach consumers
+type Broker struct {
+	ManagementURL string
+	AMQPURL       string
+	Username      string
+	Password      string
+
+	container *rabbitmq.RabbitMQContainer
+	conn      *amqp.Connection
+}
+
+// Start launches a RabbitMQ container with the management plugin enabled and
+// waits until both the AMQP and management listeners accept connections
+func Start(ctx context.Context) (*Broker, error) {
+	container, err := rabbitmq.Run(ctx, DefaultImage,
+		rabbitmq.WithAdminUsername(DefaultUsername),
+		rabbitmq.WithAdminPassword(DefaultPassword),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start RabbitMQ container: %w", err)
+	}
+
+	b := &Broker{
+		Username:  DefaultUsername,
+		Password:  DefaultPassword,
+		container: container,
+	}
+
+	if b.ManagementURL, err = container.HttpURL(ctx); err != nil {
+		b.Terminate(ctx)
+		return nil, fmt.Errorf("failed to get management URL: %w", err)
+	}
+	if b.AMQPURL, err = container.AmqpURL(ctx); err != nil {
+		b.Terminate(ctx)
+		return nil, fmt.Errorf("failed to get AMQP URL: %w", err)
+	}
+	if b.conn, err = amqp.Dial(b.AMQPURL); err != nil {
+		b.Terminate(ctx)
+		return nil, fmt.Errorf("failed to connect over AMQP: %w", err)
+	}
+
+	return b, nil
+}
+
+// Terminate closes the AMQP connection and removes the container
+func (b *Broker) Terminate(ctx context.Context) error {
+	if b.conn != nil {
+		b.conn.Close()
+	}
+	return b.container.Terminate(ctx)
+}
+
+// DeclareQueue declares a durable queue with the given arguments, e.g.
+// x-queue-type=quorum
+func (b *Broker) DeclareQueue(name string, args amqp.Table) error {
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	if _, err := ch.QueueDeclare(name, true, false, false, false, args); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", name, err)
+	}
+	return nil
+}
+
+// Publish sends count persistent messages to queue through the default exchange
+func (b *Broker) Publish(ctx context.Context, queue string, count int) error {
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	for i := 0; i < count; i++ {
+		err := ch.PublishWithContext(ctx, "", queue, false, false, amqp.Publishing{
+			DeliveryMode: amqp.Persistent,
+			ContentType:  "text/plain",
+			Body:         []byte(fmt.Sprintf("message %d", i)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", queue, err)
+		}
+	}
+	return nil
+}
+
+// Consume attaches a consumer that never acknowledges deliveries, so
+// messages it receives stay unacknowledged. The returned function cancels
+// the consumer.
+func (b *Broker) Consume(queue string, prefetch int) (func(), error) {
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to consume from %s: %w", queue, err)
+	}
+	go func() {
+		for range deliveries {
+		}
+	}()
+
+	return func() { ch.Close() }, nil
+}
+
+// Eventually polls cond until it returns true or the timeout expires. The
+// management API aggregates queue statistics periodically, so assertions on
+// collected values need to wait for the broker to catch up.
+func Eventually(ctx context.Context, timeout time.Duration, cond func() bool) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if cond() {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
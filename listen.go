@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+const unixAddressPrefix = "unix://"
+
+// parseListenAddress splits listen_address into the network and address
+// net.Listen takes. "unix://" addresses name a socket path, anything else is
+// a TCP host:port.
+func parseListenAddress(address string) (network, addr string, err error) {
+	if path, ok := strings.CutPrefix(address, unixAddressPrefix); ok {
+		if path == "" {
+			return "", "", fmt.Errorf("listen_address %q has no socket path", address)
+		}
+		return "unix", path, nil
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return "", "", fmt.Errorf("invalid listen_address %q: %w", address, err)
+	}
+	return "tcp", address, nil
+}
+
+// listen opens the HTTP listener for address. A socket file left behind by
+// an earlier run that didn't shut down cleanly is removed first; any other
+// file at that path is an error.
+func listen(address string) (net.Listener, error) {
+	network, addr, err := parseListenAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		if info, err := os.Lstat(addr); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				return nil, fmt.Errorf("listen_address %s exists and is not a socket", addr)
+			}
+			if err := os.Remove(addr); err != nil {
+				return nil, fmt.Errorf("removing stale socket: %w", err)
+			}
+		}
+	}
+	return net.Listen(network, addr)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseListenAddress(t *testing.T) {
+	tests := []struct {
+		address     string
+		network     string
+		addr        string
+		expectError bool
+	}{
+		{address: ":9419", network: "tcp", addr: ":9419"},
+		{address: "127.0.0.1:9419", network: "tcp", addr: "127.0.0.1:9419"},
+		{address: "[::1]:9419", network: "tcp", addr: "[::1]:9419"},
+		{address: "unix:///var/run/exporter.sock", network: "unix", addr: "/var/run/exporter.sock"},
+		{address: "unix://", expectError: true},
+		{address: "9419", expectError: true},
+		{address: "localhost", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			network, addr, err := parseListenAddress(tt.address)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got %s %s", network, addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if network != tt.network || addr != tt.addr {
+				t.Errorf("Expected %s %s, got %s %s", tt.network, tt.addr, network, addr)
+			}
+		})
+	}
+}
+
+func TestListen_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exporter.sock")
+
+	// A socket left behind by an earlier run is replaced
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listen("unix://" + path)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListen_RefusesNonSocketFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exporter.sock")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if listener, err := listen("unix://" + path); err == nil {
+		listener.Close()
+		t.Fatal("Expected an error for a regular file at the socket path")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the file to be left alone: %v", err)
+	}
+}
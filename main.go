@@ -2,16 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
+	"rabbitmq-exporter/amqpprobe"
+	"rabbitmq-exporter/eventexchange"
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/grafana"
 	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/notifier"
+	"rabbitmq-exporter/otlp"
 	"rabbitmq-exporter/rabbitmq"
+	"rabbitmq-exporter/sshtunnel"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -19,26 +30,27 @@ import (
 	"github.com/spf13/viper"
 )
 
-type Config struct {
-	RabbitMQURL      string        `mapstructure:"rabbitmq_url"`
-	RabbitMQUsername string        `mapstructure:"rabbitmq_username"`
-	RabbitMQPassword string        `mapstructure:"rabbitmq_password"`
-	ScrapeInterval   time.Duration `mapstructure:"scrape_interval"`
-	ListenPort       int           `mapstructure:"listen_port"`
-	Timeout          time.Duration `mapstructure:"timeout"`
-}
-
 const (
-	DefaultRabbitMQURL      = "http://localhost:15672"
-	DefaultRabbitMQUsername = "guest"
-	DefaultRabbitMQPassword = "guest"
-	DefaultScrapeInterval   = 15 * time.Second
-	DefaultListenPort       = 9419
-	DefaultTimeout          = 10 * time.Second
+	DefaultRabbitMQURL       = "http://localhost:15672"
+	DefaultRabbitMQUsername  = "guest"
+	DefaultRabbitMQPassword  = "guest"
+	DefaultScrapeInterval    = 15 * time.Second
+	DefaultListenPort        = 9419
+	DefaultTimeout           = 10 * time.Second
+	DefaultLogLevel          = exporter.LogLevelInfo
+	DefaultStateSaveInterval = time.Minute
+	DefaultShutdownTimeout   = 30 * time.Second
+	DefaultMetricNamespace   = metrics.DefaultNamespace
+	DefaultMetricNaming      = metrics.NamingNative
+
+	DefaultCircuitBreakerMaxFailures  = rabbitmq.DefaultCircuitBreakerMaxFailures
+	DefaultCircuitBreakerResetTimeout = rabbitmq.DefaultCircuitBreakerResetTimeout
+	DefaultMaxConcurrentRequests      = rabbitmq.DefaultMaxConcurrentRequests
+	DefaultMaxRetryAfter              = rabbitmq.DefaultMaxRetryAfter
 )
 
 var (
-	config  Config
+	config  exporter.Config
 	rootCmd = &cobra.Command{
 		Use:   "rabbitmq-exporter",
 		Short: "A custom RabbitMQ Prometheus exporter",
@@ -55,14 +67,55 @@ func init() {
 	rootCmd.Flags().String("password", DefaultRabbitMQPassword, "RabbitMQ password")
 	rootCmd.Flags().Duration("scrape-interval", DefaultScrapeInterval, "Scrape interval")
 	rootCmd.Flags().Int("port", DefaultListenPort, "Listen port")
+	rootCmd.Flags().String("listen-address", "", "Address to listen on, host:port or unix:///path/to.sock (overrides --port)")
 	rootCmd.Flags().Duration("timeout", DefaultTimeout, "Request timeout")
+	rootCmd.Flags().String("log-level", DefaultLogLevel, "Log level (info or debug)")
+	rootCmd.Flags().String("metric-namespace", DefaultMetricNamespace, "Prefix for all exported metric names")
+	rootCmd.Flags().String("metric-naming", DefaultMetricNaming, "Metric naming scheme: native, kbudde (kbudde/rabbitmq_exporter names) or both")
+	rootCmd.Flags().String("profile", "", "Settings preset for the cluster size: small, medium or huge")
+	rootCmd.Flags().Int("circuit-breaker-max-failures", DefaultCircuitBreakerMaxFailures, "Consecutive failures that open an endpoint's circuit breaker")
+	rootCmd.Flags().Duration("circuit-breaker-reset-timeout", DefaultCircuitBreakerResetTimeout, "How long an open circuit breaker rejects requests")
+	rootCmd.Flags().String("admin-username", "", "Username for admin endpoints (admin endpoints disabled if empty)")
+	rootCmd.Flags().String("admin-password", "", "Password for admin endpoints")
+	rootCmd.Flags().Bool("enable-pprof", false, "Serve net/http/pprof profiles under /debug/pprof/")
+	rootCmd.Flags().Bool("runtime-metrics", true, "Export Go runtime and process metrics")
+	rootCmd.Flags().String("output.textfile-dir", "", "Directory to write a node_exporter textfile (.prom) to after every collection")
+	rootCmd.Flags().Bool("output.textfile-only", false, "Only write the textfile output and don't start the HTTP server")
+	rootCmd.Flags().String("state-file", "", "Path to persist counter state across restarts (disabled if empty)")
+	rootCmd.Flags().Duration("state-save-interval", DefaultStateSaveInterval, "How often to persist counter state")
 
 	viper.BindPFlag("rabbitmq_url", rootCmd.Flags().Lookup("rabbitmq-url"))
 	viper.BindPFlag("rabbitmq_username", rootCmd.Flags().Lookup("username"))
 	viper.BindPFlag("rabbitmq_password", rootCmd.Flags().Lookup("password"))
 	viper.BindPFlag("scrape_interval", rootCmd.Flags().Lookup("scrape-interval"))
 	viper.BindPFlag("listen_port", rootCmd.Flags().Lookup("port"))
+	viper.BindPFlag("listen_address", rootCmd.Flags().Lookup("listen-address"))
 	viper.BindPFlag("timeout", rootCmd.Flags().Lookup("timeout"))
+	viper.BindPFlag("log_level", rootCmd.Flags().Lookup("log-level"))
+	viper.BindPFlag("metric_namespace", rootCmd.Flags().Lookup("metric-namespace"))
+	viper.BindPFlag("metric_naming", rootCmd.Flags().Lookup("metric-naming"))
+	viper.BindPFlag("profile", rootCmd.Flags().Lookup("profile"))
+	viper.BindPFlag("circuit_breaker_max_failures", rootCmd.Flags().Lookup("circuit-breaker-max-failures"))
+	viper.BindPFlag("circuit_breaker_reset_timeout", rootCmd.Flags().Lookup("circuit-breaker-reset-timeout"))
+	viper.BindPFlag("admin_username", rootCmd.Flags().Lookup("admin-username"))
+	viper.BindPFlag("admin_password", rootCmd.Flags().Lookup("admin-password"))
+	viper.BindPFlag("enable_pprof", rootCmd.Flags().Lookup("enable-pprof"))
+	viper.BindPFlag("runtime_metrics", rootCmd.Flags().Lookup("runtime-metrics"))
+	viper.BindPFlag("output.textfile_dir", rootCmd.Flags().Lookup("output.textfile-dir"))
+	viper.BindPFlag("output.textfile_only", rootCmd.Flags().Lookup("output.textfile-only"))
+	viper.BindPFlag("state_file", rootCmd.Flags().Lookup("state-file"))
+	viper.BindPFlag("state_save_interval", rootCmd.Flags().Lookup("state-save-interval"))
+
+	viper.SetDefault("collect.queues", exporter.DefaultCollectGroups.Queues)
+	viper.SetDefault("collect.nodes", exporter.DefaultCollectGroups.Nodes)
+	viper.SetDefault("collect.overview", exporter.DefaultCollectGroups.Overview)
+	viper.SetDefault("collect.exchanges", exporter.DefaultCollectGroups.Exchanges)
+	viper.SetDefault("collect.connections", exporter.DefaultCollectGroups.Connections)
+	viper.SetDefault("collect.policies", exporter.DefaultCollectGroups.Policies)
+	viper.SetDefault("collect.bindings", exporter.DefaultCollectGroups.Bindings)
+	viper.SetDefault("collect.channels", exporter.DefaultCollectGroups.Channels)
+	viper.SetDefault("collect.consumers", exporter.DefaultCollectGroups.Consumers)
+	viper.SetDefault("health_metrics", true)
 
 	viper.SetEnvPrefix("RABBITMQ_EXPORTER")
 	viper.AutomaticEnv()
@@ -80,82 +133,613 @@ func main() {
 	}
 }
 
-func run(cmd *cobra.Command, args []string) error {
-	if configFile, _ := cmd.Flags().GetString("config"); configFile != "" {
+// readConfigFile loads configFile, or config.yaml from the search path when
+// empty, into the global viper instance. A missing default file is not an
+// error.
+func readConfigFile(configFile string) error {
+	if configFile != "" {
 		viper.SetConfigFile(configFile)
 		if err := viper.ReadInConfig(); err != nil {
 			return fmt.Errorf("failed to read config file %s: %w", configFile, err)
 		}
 		log.Printf("Using config file: %s", configFile)
+		return nil
+	}
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		log.Printf("No config file found, using defaults and command line flags")
 	} else {
-		if err := viper.ReadInConfig(); err != nil {
-			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-				return fmt.Errorf("failed to read config file: %w", err)
-			}
-			log.Printf("No config file found, using defaults and command line flags")
-		} else {
-			log.Printf("Using config file: %s", viper.ConfigFileUsed())
+		log.Printf("Using config file: %s", viper.ConfigFileUsed())
+	}
+	return nil
+}
+
+// loadConfig reads the config file, applies the selected profile and fills
+// in defaults. With strict set, config keys that don't map to a setting are
+// rejected.
+func loadConfig(configFile string, strict bool) (exporter.Config, error) {
+	var cfg exporter.Config
+
+	if err := readConfigFile(configFile); err != nil {
+		return cfg, err
+	}
+
+	if err := applyProfile(viper.GetViper(), viper.GetString("profile")); err != nil {
+		return cfg, err
+	}
+
+	unmarshal := viper.Unmarshal
+	if strict {
+		unmarshal = viper.UnmarshalExact
+	}
+	if err := unmarshal(&cfg); err != nil {
+		return cfg, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	if cfg.RabbitMQURL == "" {
+		cfg.RabbitMQURL = DefaultRabbitMQURL
+	}
+	if cfg.RabbitMQUsername == "" {
+		cfg.RabbitMQUsername = DefaultRabbitMQUsername
+	}
+	if cfg.RabbitMQPassword == "" {
+		cfg.RabbitMQPassword = DefaultRabbitMQPassword
+	}
+	if cfg.ScrapeInterval == 0 {
+		cfg.ScrapeInterval = DefaultScrapeInterval
+	}
+	if err := exporter.ValidateCollectionJitter(cfg.CollectionJitter, cfg.ScrapeInterval); err != nil {
+		return cfg, err
+	}
+	if cfg.WatchdogIntervals < 0 {
+		return cfg, fmt.Errorf("watchdog_intervals must not be negative")
+	}
+	if cfg.WatchdogIntervals == 0 {
+		cfg.WatchdogIntervals = exporter.DefaultWatchdogIntervals
+	}
+	if cfg.ListenPort == 0 {
+		cfg.ListenPort = DefaultListenPort
+	}
+	if cfg.ListenAddress == "" {
+		cfg.ListenAddress = fmt.Sprintf(":%d", cfg.ListenPort)
+	}
+	if _, _, err := parseListenAddress(cfg.ListenAddress); err != nil {
+		return cfg, err
+	}
+	if cfg.AdminListenAddress != "" {
+		if _, _, err := parseListenAddress(cfg.AdminListenAddress); err != nil {
+			return cfg, fmt.Errorf("admin_listen_address: %w", err)
 		}
 	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.CollectionTimeout <= 0 {
+		cfg.CollectionTimeout = exporter.DefaultCollectionTimeout
+	}
+	if cfg.WarmupTimeout <= 0 {
+		cfg.WarmupTimeout = cfg.CollectionTimeout
+	}
+	if cfg.IgnoreQueueArgument == "" {
+		cfg.IgnoreQueueArgument = exporter.DefaultIgnoreArgument
+	}
+	if cfg.CacheMaxAge < 0 {
+		return cfg, fmt.Errorf("cache_max_age must not be negative")
+	}
+	if cfg.CacheMaxAge == 0 {
+		cfg.CacheMaxAge = 2 * cfg.ScrapeInterval
+	}
+	if cfg.HealthMaxStaleness < 0 {
+		return cfg, fmt.Errorf("health_max_staleness must not be negative")
+	}
+	if cfg.HealthMaxStaleness == 0 {
+		cfg.HealthMaxStaleness = 2 * cfg.ScrapeInterval
+	}
+	if cfg.ShutdownTimeout < 0 {
+		return cfg, fmt.Errorf("shutdown_timeout must not be negative")
+	}
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if err := cfg.Timeouts.Validate(); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxConcurrentRequests < 0 {
+		return cfg, fmt.Errorf("max_concurrent_requests must not be negative")
+	}
+	if cfg.MaxConcurrentRequests == 0 {
+		cfg.MaxConcurrentRequests = DefaultMaxConcurrentRequests
+	}
+	if cfg.CircuitBreakerMaxFailures <= 0 {
+		cfg.CircuitBreakerMaxFailures = DefaultCircuitBreakerMaxFailures
+	}
+	if cfg.CircuitBreakerResetTimeout <= 0 {
+		cfg.CircuitBreakerResetTimeout = DefaultCircuitBreakerResetTimeout
+	}
+	if cfg.MaxRetryAfter < 0 {
+		return cfg, fmt.Errorf("max_retry_after must not be negative")
+	}
+	if cfg.MaxRetryAfter == 0 {
+		cfg.MaxRetryAfter = DefaultMaxRetryAfter
+	}
+	if cfg.MsgRatesAge > 0 && cfg.MsgRatesIncr == 0 {
+		cfg.MsgRatesIncr = cfg.MsgRatesAge
+	}
+	if err := rabbitmq.ValidateMessageRates(cfg.MsgRatesAge, cfg.MsgRatesIncr); err != nil {
+		return cfg, err
+	}
+	if cfg.StateSaveInterval == 0 {
+		cfg.StateSaveInterval = DefaultStateSaveInterval
+	}
+	if cfg.MetricNamespace == "" {
+		cfg.MetricNamespace = DefaultMetricNamespace
+	}
+	if err := metrics.ValidateNamespace(cfg.MetricNamespace); err != nil {
+		return cfg, err
+	}
+	if cfg.MetricNaming == "" {
+		cfg.MetricNaming = DefaultMetricNaming
+	}
+	if err := metrics.ValidateNaming(cfg.MetricNaming); err != nil {
+		return cfg, err
+	}
+	if cfg.Output.TextfileOnly && cfg.Output.TextfileDir == "" {
+		return cfg, fmt.Errorf("output.textfile_only requires output.textfile_dir")
+	}
+	return cfg, nil
+}
+
+// newRabbitMQClient builds the management API client, routing it through
+// the SSH tunnel when one is configured. cleanup releases both.
+func newRabbitMQClient(cfg exporter.Config, opts ...rabbitmq.ClientOption) (client *rabbitmq.Client, cleanup func(), err error) {
+	clientOpts := []rabbitmq.ClientOption{
+		rabbitmq.WithCircuitBreaker(cfg.CircuitBreakerMaxFailures, cfg.CircuitBreakerResetTimeout),
+		rabbitmq.WithMaxRetryAfter(cfg.MaxRetryAfter),
+		rabbitmq.WithMessageRates(cfg.MsgRatesAge, cfg.MsgRatesIncr),
+		rabbitmq.WithTimeouts(cfg.Timeouts),
+		rabbitmq.WithMaxConcurrentRequests(cfg.MaxConcurrentRequests),
+		rabbitmq.WithHeaders(cfg.RequestHeaders),
+	}
+	clientOpts = append(clientOpts, opts...)
 
-	if err := viper.Unmarshal(&config); err != nil {
-		return fmt.Errorf("failed to unmarshal config: %w", err)
+	var tunnel *sshtunnel.Tunnel
+	if cfg.SSHTunnel.Enabled() {
+		tunnel, err = sshtunnel.New(cfg.SSHTunnel)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to configure ssh tunnel: %w", err)
+		}
+		clientOpts = append(clientOpts, rabbitmq.WithDialContext(tunnel.DialContext))
 	}
 
-	if config.RabbitMQURL == "" {
-		config.RabbitMQURL = DefaultRabbitMQURL
+	client = rabbitmq.NewClient(cfg.RabbitMQURL, cfg.RabbitMQUsername, cfg.RabbitMQPassword, cfg.Timeout, clientOpts...)
+	cleanup = func() {
+		client.Close()
+		if tunnel != nil {
+			tunnel.Close()
+		}
 	}
-	if config.RabbitMQUsername == "" {
-		config.RabbitMQUsername = DefaultRabbitMQUsername
+	return client, cleanup, nil
+}
+
+// exposedGatherer applies the configured naming scheme and cluster label to
+// metrics on their way out
+func exposedGatherer(gatherer prometheus.Gatherer, cfg exporter.Config, cluster *exporter.ClusterIdentity) (prometheus.Gatherer, error) {
+	gatherer, err := metrics.NewNamingGatherer(gatherer, cfg.MetricNamespace, cfg.MetricNaming)
+	if err != nil {
+		return nil, err
 	}
-	if config.RabbitMQPassword == "" {
-		config.RabbitMQPassword = DefaultRabbitMQPassword
+	if cluster != nil {
+		gatherer = metrics.NewLabelGatherer(gatherer, exporter.ClusterLabelName, cluster.Name)
 	}
-	if config.ScrapeInterval == 0 {
-		config.ScrapeInterval = DefaultScrapeInterval
+	return gatherer, nil
+}
+
+// connectionError adds a hint for the most common misconfiguration to a
+// failed health check
+func connectionError(err error) error {
+	if errors.Is(err, rabbitmq.ErrUnauthorized) {
+		return fmt.Errorf("RabbitMQ rejected the configured credentials (the user needs the monitoring tag): %w", err)
 	}
-	if config.ListenPort == 0 {
-		config.ListenPort = DefaultListenPort
+	return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("config")
+	var err error
+	config, err = loadConfig(configFile, false)
+	if err != nil {
+		return err
 	}
-	if config.Timeout == 0 {
-		config.Timeout = DefaultTimeout
+
+	if err := exporter.SetLogLevel(config.LogLevel); err != nil {
+		return err
 	}
 
-	log.Printf("Starting RabbitMQ Exporter")
+	log.Printf("Starting RabbitMQ Exporter %s (commit %s)", Version, buildCommit())
 	log.Printf("Configuration:")
 	log.Printf("  RabbitMQ URL: %s", config.RabbitMQURL)
 	log.Printf("  Username: %s", config.RabbitMQUsername)
 	log.Printf("  Scrape Interval: %v", config.ScrapeInterval)
-	log.Printf("  Listen Port: %d", config.ListenPort)
-	log.Printf("  Timeout: %v", config.Timeout)
+	if config.CollectionJitter > 0 {
+		log.Printf("  Collection Jitter: %v", config.CollectionJitter)
+	}
+	log.Printf("  Listen Address: %s", config.ListenAddress)
+	log.Printf("  Timeout: %v (collection %v)", config.Timeout, config.CollectionTimeout)
+	for endpoint, timeout := range config.Timeouts.Endpoints {
+		log.Printf("  Timeout for %s: %v", endpoint, timeout)
+	}
+	log.Printf("  Max Concurrent Requests: %d", config.MaxConcurrentRequests)
+	if len(config.RequestHeaders) > 0 {
+		names := make([]string, 0, len(config.RequestHeaders))
+		for name := range config.RequestHeaders {
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+		sort.Strings(names)
+		log.Printf("  Request Headers: %s", strings.Join(names, ", "))
+	}
+	log.Printf("  Circuit Breaker: %d failures, %v reset", config.CircuitBreakerMaxFailures, config.CircuitBreakerResetTimeout)
+	log.Printf("  Max Retry-After: %v", config.MaxRetryAfter)
+	if config.MsgRatesAge > 0 {
+		log.Printf("  Message Rates: averaged over %v, sampled every %v", config.MsgRatesAge, config.MsgRatesIncr)
+	}
+	log.Printf("  Metric Groups: %s", strings.Join(config.Collect.Enabled(), ", "))
+	if !config.HealthMetrics {
+		log.Printf("  Health Metrics: disabled")
+	}
+	if config.MetricNamespace != DefaultMetricNamespace {
+		log.Printf("  Metric Namespace: %s", config.MetricNamespace)
+	}
+	if config.MetricNaming != DefaultMetricNaming {
+		log.Printf("  Metric Naming: %s", config.MetricNaming)
+	}
+	if config.Profile != "" {
+		log.Printf("  Profile: %s", config.Profile)
+	}
+	if exporter.DebugLogging() {
+		log.Printf("  Log Level: %s", exporter.LogLevelDebug)
+	}
+	if config.StateFile != "" {
+		log.Printf("  State File: %s", config.StateFile)
+	}
+	if len(config.CanaryQueues) > 0 {
+		log.Printf("  Canary Queues: %d", len(config.CanaryQueues))
+	}
+	if len(config.DepthSLOs) > 0 {
+		log.Printf("  Depth SLOs: %d", len(config.DepthSLOs))
+	}
+	if len(config.SeverityTiers) > 0 {
+		severities := make([]string, len(config.SeverityTiers))
+		for i, tier := range config.SeverityTiers {
+			severities[i] = tier.Severity
+		}
+		log.Printf("  Severity Tiers: %s", strings.Join(severities, ", "))
+	}
+	if len(config.RollupOnlyVhosts) > 0 {
+		log.Printf("  Rollup-only Vhosts: %s", strings.Join(config.RollupOnlyVhosts, ", "))
+	}
+	if config.MaxQueuesPerVhost > 0 {
+		log.Printf("  Max Queues per Vhost: %d", config.MaxQueuesPerVhost)
+	}
+	if config.ExpectedNodes > 0 {
+		log.Printf("  Expected Nodes: %d", config.ExpectedNodes)
+	}
+	log.Printf("  Ignore Queue Argument: %s", config.IgnoreQueueArgument)
+	if config.AnomalyDetection.Enabled {
+		log.Printf("  Depth Anomaly Detection: enabled")
+	}
+	if len(config.AlivenessVhosts) > 0 {
+		log.Printf("  Aliveness Vhosts: %s", strings.Join(config.AlivenessVhosts, ", "))
+	}
+	if checks := config.HealthChecks.Checks(); len(checks) > 0 {
+		log.Printf("  Health Checks: %s", strings.Join(checks, ", "))
+	}
+	if config.SSHTunnel.Enabled() {
+		log.Printf("  SSH Tunnel: %s@%s", config.SSHTunnel.User, config.SSHTunnel.Host)
+	}
+	if config.GrafanaAnnotations.Enabled() {
+		log.Printf("  Grafana Annotations: %s", config.GrafanaAnnotations.URL)
+	}
+	if config.Notifier.Enabled() {
+		log.Printf("  Alert Notifier: %s", config.Notifier.URL)
+	}
+	if config.OTLP.Enabled() {
+		log.Printf("  OTLP Endpoint: %s", config.OTLP.Endpoint)
+	}
+	if config.Output.TextfileDir != "" {
+		log.Printf("  Textfile Directory: %s", config.Output.TextfileDir)
+	}
+	if len(config.QueueDepthThresholds) > 0 {
+		log.Printf("  Queue Depth Threshold Rules: %d", len(config.QueueDepthThresholds))
+	}
+
+	depthThresholds, err := exporter.NewDepthThresholdMatcher(config.QueueDepthThresholds, exporter.DepthThresholds{
+		Warning:  exporter.DefaultDepthWarning,
+		Critical: exporter.DefaultDepthCritical,
+	})
+	if err != nil {
+		return err
+	}
+
+	deadLetterRules, err := rabbitmq.NewDeadLetterRules(config.DeadLetter.Patterns, config.DeadLetter.Exchanges)
+	if err != nil {
+		return err
+	}
+
+	canaries, err := exporter.NewCanaryTracker(config.CanaryQueues, time.Now())
+	if err != nil {
+		return err
+	}
+
+	depthSLOs, err := exporter.NewDepthSLOTracker(config.DepthSLOs)
+	if err != nil {
+		return err
+	}
 
-	client := rabbitmq.NewClient(config.RabbitMQURL, config.RabbitMQUsername, config.RabbitMQPassword, config.Timeout)
-	defer client.Close()
+	severityTiers, err := exporter.NewSeverityTiers(config.SeverityTiers)
+	if err != nil {
+		return err
+	}
+
+	rollupOnly, err := exporter.NewVhostMatcher(config.RollupOnlyVhosts)
+	if err != nil {
+		return err
+	}
+
+	var depthBaselines *exporter.DepthBaselines
+	if config.AnomalyDetection.Enabled {
+		depthBaselines, err = exporter.NewDepthBaselines(config.AnomalyDetection, config.ScrapeInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	var queueLabels *exporter.QueueLabeler
+	if config.QueueLabelRegex != "" {
+		queueLabels, err = exporter.NewQueueLabeler(config.QueueLabelRegex)
+		if err != nil {
+			return err
+		}
+		log.Printf("Queue name labels: %s", strings.Join(queueLabels.Names(), ", "))
+	}
+
+	exporterMetrics := metrics.NewMetricsWithNamespace(config.MetricNamespace, queueLabels.Names()...)
+	exporterMetrics.SetBuildInfo(Version, buildCommit(), runtime.Version())
+	exporterMetrics.SetConfigInfo(configInfoValues(config, viper.ConfigFileUsed())...)
+
+	client, closeClient, err := newRabbitMQClient(config, rabbitmq.WithRequestObserver(exporterMetrics.ObserveAPIRequest))
+	if err != nil {
+		return err
+	}
+	defer closeClient()
 
 	if err := client.HealthCheck(context.Background()); err != nil {
-		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return connectionError(err)
 	}
 	log.Printf("Successfully connected to RabbitMQ")
 
-	metrics := metrics.NewMetrics()
+	var clusterIdentity *exporter.ClusterIdentity
+	if config.ClusterLabel.Enabled {
+		clusterIdentity = exporter.NewClusterIdentity(config.ClusterLabel)
+		if err := clusterIdentity.Detect(context.Background(), client); err != nil {
+			log.Printf("Warning: %v; metrics have no cluster label", err)
+		} else {
+			log.Printf("Cluster label: %s", clusterIdentity.Name())
+		}
+	}
+
+	if config.StateFile != "" {
+		stateStore := NewStateStore(config.StateFile, exporterMetrics, config.StateSaveInterval)
+		if err := stateStore.Load(); err != nil {
+			return err
+		}
+		stateStore.Start()
+		defer stateStore.Stop()
+	}
+
+	collectorOpts := []exporter.CollectorOption{
+		exporter.WithDepthThresholds(depthThresholds),
+		exporter.WithDeadLetterRules(deadLetterRules),
+		exporter.WithCollectGroups(config.Collect),
+		exporter.WithCollectionTimeout(config.CollectionTimeout),
+		exporter.WithMaxCacheAge(config.CacheMaxAge),
+		exporter.WithCollectionJitter(config.CollectionJitter),
+		exporter.WithWatchdog(config.WatchdogIntervals),
+		exporter.WithBacklogGrowthCollections(config.BacklogGrowthCollections),
+		exporter.WithSeverityTiers(severityTiers),
+		exporter.WithHealthMetrics(config.HealthMetrics),
+		exporter.WithQueueLabels(queueLabels),
+		exporter.WithIgnoreArgument(config.IgnoreQueueArgument),
+	}
+	if len(config.CanaryQueues) > 0 {
+		collectorOpts = append(collectorOpts, exporter.WithCanaries(canaries))
+	}
+	if len(config.DepthSLOs) > 0 {
+		collectorOpts = append(collectorOpts, exporter.WithDepthSLOs(depthSLOs))
+	}
+	if len(config.RollupOnlyVhosts) > 0 {
+		collectorOpts = append(collectorOpts, exporter.WithRollupOnlyVhosts(rollupOnly))
+	}
+	if config.MaxQueuesPerVhost > 0 {
+		collectorOpts = append(collectorOpts, exporter.WithMaxQueuesPerVhost(config.MaxQueuesPerVhost))
+	}
+	if config.ExpectedNodes > 0 {
+		collectorOpts = append(collectorOpts, exporter.WithExpectedNodes(config.ExpectedNodes))
+	}
+	if depthBaselines != nil {
+		collectorOpts = append(collectorOpts, exporter.WithDepthBaselines(depthBaselines))
+	}
+	if len(config.AlivenessVhosts) > 0 {
+		collectorOpts = append(collectorOpts, exporter.WithAlivenessVhosts(config.AlivenessVhosts))
+	}
+	if checks := config.HealthChecks.Checks(); len(checks) > 0 {
+		collectorOpts = append(collectorOpts, exporter.WithHealthChecks(checks))
+	}
+
+	if exporter.DebugLogging() {
+		collectorOpts = append(collectorOpts, exporter.WithQueueDiffLogging(exporter.NewQueueDiffLogger(exporter.DefaultQueueDiffMaxEntries)))
+	}
+	if config.GrafanaAnnotations.Enabled() {
+		sink := exporter.NewGrafanaEventSink(grafana.NewClient(config.GrafanaAnnotations.Config))
+		defer sink.Close()
+		detector := exporter.NewEventDetector(config.GrafanaAnnotations.MassQueueDeletionThreshold)
+		collectorOpts = append(collectorOpts, exporter.WithEvents(detector, sink))
+	}
+	if config.Notifier.Enabled() {
+		alertClient, err := notifier.NewClient(config.Notifier.Config)
+		if err != nil {
+			return err
+		}
+		alertNotifier := exporter.NewAlertNotifier(alertClient, config.Notifier, config.MetricNaming)
+		defer alertNotifier.Close()
+		collectorOpts = append(collectorOpts, exporter.WithAlertNotifier(alertNotifier))
+	}
+
+	// The OTLP pipeline reads the collector's snapshot through its own
+	// registry, which is populated once the collector exists
+	var otlpRegistry *prometheus.Registry
+	if config.OTLP.Enabled() {
+		otlpRegistry = prometheus.NewRegistry()
+		otlpExporter, err := otlp.New(context.Background(), config.OTLP, otlpRegistry, Version)
+		if err != nil {
+			return fmt.Errorf("failed to configure OTLP export: %w", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			otlpExporter.Close(ctx)
+		}()
+		collectorOpts = append(collectorOpts, exporter.WithCollectionHook(otlpExporter.Notify))
+		log.Printf("OTLP export enabled (%s)", config.OTLP.Endpoint)
+	}
+
+	// The textfile holds only the exporter's own metrics: node_exporter
+	// already exports go_* and process_* for the host
+	var textfileRegistry *prometheus.Registry
+	if config.Output.TextfileDir != "" {
+		textfileRegistry = prometheus.NewRegistry()
+		textfileGatherer, err := exposedGatherer(textfileRegistry, config, clusterIdentity)
+		if err != nil {
+			return err
+		}
+		textfileWriter, err := exporter.NewTextfileWriter(config.Output.TextfileDir, textfileGatherer)
+		if err != nil {
+			return fmt.Errorf("failed to configure textfile output: %w", err)
+		}
+		defer textfileWriter.Close()
+		collectorOpts = append(collectorOpts, exporter.WithCollectionHook(textfileWriter.Notify))
+		log.Printf("Textfile output enabled (%s)", textfileWriter.Path())
+	}
 
-	collector := NewCollector(client, metrics, config.ScrapeInterval)
+	collector := exporter.NewCollector(client, exporterMetrics, config.ScrapeInterval, collectorOpts...)
 	defer collector.Stop()
 
-	prometheus.MustRegister(collector)
+	// Serving before the first collection would expose no queue metrics at
+	// all, which looks like every queue vanished
+	if collector.Warm(config.WarmupTimeout) {
+		log.Printf("Initial collection complete")
+	} else {
+		log.Printf("Warning: initial collection did not succeed within %v; queue metrics appear once a collection succeeds", config.WarmupTimeout)
+	}
+
+	if config.AMQPProbe.Enabled() {
+		var prober *amqpprobe.Prober
+		prober, err = amqpprobe.New(config.AMQPProbe, func(roundTrip time.Duration, err error) {
+			if err != nil {
+				log.Printf("AMQP probe failed: %v", err)
+			}
+			exporterMetrics.ObserveAMQPProbe(prober.Vhost(), roundTrip, err)
+		})
+		if err != nil {
+			return err
+		}
+		prober.Start()
+		defer prober.Stop()
+		log.Printf("AMQP probe enabled (vhost %s)", prober.Vhost())
+	}
+
+	if config.EventExchange.Enabled() {
+		consumer, err := eventexchange.New(config.EventExchange, brokerEventMetrics{metrics: exporterMetrics})
+		if err != nil {
+			return err
+		}
+		consumer.Start()
+		defer consumer.Stop()
+		log.Printf("Consuming broker events from %s", eventexchange.Exchange)
+	}
+
+	if otlpRegistry != nil {
+		otlpRegistry.MustRegister(collector.SnapshotCollector())
+	}
+	if textfileRegistry != nil {
+		textfileRegistry.MustRegister(collector.SnapshotCollector())
+	}
+
+	registry := newRegistry(config.RuntimeMetrics)
+	registry.MustRegister(collector)
 
 	mux := http.NewServeMux()
 
-	mux.Handle("/metrics", promhttp.Handler())
+	var gatherer prometheus.Gatherer = registry
+	if config.MetricTransition.Enabled {
+		renames := append(append([]metrics.Rename{}, metrics.Renames...), config.MetricTransition.Renames...)
+		gatherer = metrics.NewDeprecationGatherer(gatherer, renames, config.MetricTransition.Sunset)
+		log.Printf("Metric transition mode enabled for %d renamed metrics", len(renames))
+	}
+	gatherer, err = exposedGatherer(gatherer, config, clusterIdentity)
+	if err != nil {
+		return err
+	}
+
+	mux.Handle("/metrics", promhttp.InstrumentMetricHandler(
+		registry,
+		metricsHandler(gatherer),
+	))
+
+	mux.HandleFunc("/health", healthHandler(newHealthCache(collector, client.HealthCheck, config.HealthMaxStaleness)))
+
+	reloader := newConfigReloader(configFile, config, collector, exporterMetrics, client, clusterIdentity)
+	mux.HandleFunc("/config", configHandler(reloader.Config))
+	mux.HandleFunc("/livez", livezHandler)
+	mux.HandleFunc("/readyz", readyzHandler(collector))
+
+	registerAPIHandlers(mux, collector)
 
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		if err := client.HealthCheck(r.Context()); err != nil {
-			http.Error(w, "Health check failed", http.StatusServiceUnavailable)
-			return
+	if config.Probe.Enabled {
+		prober, err := exporter.NewProber(config.Probe,
+			exporter.ProbeTarget{Username: config.RabbitMQUsername, Password: config.RabbitMQPassword},
+			config.Timeout, exporterMetrics,
+			[]rabbitmq.ClientOption{
+				rabbitmq.WithCircuitBreaker(config.CircuitBreakerMaxFailures, config.CircuitBreakerResetTimeout),
+				rabbitmq.WithMaxRetryAfter(config.MaxRetryAfter),
+				rabbitmq.WithTimeouts(config.Timeouts),
+				rabbitmq.WithMaxConcurrentRequests(config.MaxConcurrentRequests),
+				rabbitmq.WithHeaders(config.RequestHeaders),
+			},
+			exporter.WithDepthThresholds(depthThresholds), exporter.WithDeadLetterRules(deadLetterRules), exporter.WithQueueLabels(queueLabels))
+		if err != nil {
+			return err
 		}
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+		defer prober.Close()
+		mux.Handle("/probe", prober)
+		log.Printf("Probe endpoint enabled for %d targets", len(config.Probe.Targets))
+	}
+
+	adminMux := mux
+	if config.AdminListenAddress != "" {
+		adminMux = http.NewServeMux()
+	}
+
+	if config.EnablePprof {
+		registerPprofHandlers(adminMux, config)
+		log.Printf("pprof endpoints enabled under /debug/pprof/")
+	}
+
+	if registerAdminHandlers(adminMux, config, collector, reloader) {
+		log.Printf("Admin endpoints enabled")
+	}
 
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
@@ -171,6 +755,12 @@ func run(cmd *cobra.Command, args []string) error {
     <ul>
         <li><a href="/metrics">Metrics</a> - Prometheus metrics endpoint</li>
         <li><a href="/health">Health</a> - Health check endpoint</li>
+        <li><a href="/livez">Liveness</a> - Process is up</li>
+        <li><a href="/readyz">Readiness</a> - A recent collection is cached</li>
+        <li><a href="/config">Config</a> - Effective configuration, secrets redacted</li>
+        <li><a href="/api/v1/snapshot">Snapshot</a> - JSON view of the latest collection</li>
+        <li><a href="/api/v1/cardinality">Cardinality</a> - Where exported series come from</li>
+        <li>/probe?target=&lt;name&gt; - Scrape another cluster on demand (when enabled)</li>
     </ul>
 </body>
 </html>
@@ -178,28 +768,76 @@ func run(cmd *cobra.Command, args []string) error {
 	})
 
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", config.ListenPort),
 		Handler: mux,
 	}
 
-	go func() {
-		log.Printf("Starting HTTP server on port %d", config.ListenPort)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("HTTP server error: %v", err)
+	if config.Output.TextfileOnly {
+		log.Printf("HTTP server disabled, writing textfile output only")
+	} else {
+		listener, err := listen(config.ListenAddress)
+		if err != nil {
+			return err
 		}
-	}()
+		go func() {
+			log.Printf("Starting HTTP server on %s", config.ListenAddress)
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("HTTP server error: %v", err)
+			}
+		}()
+	}
+
+	// The admin server runs even with textfile_only, so reloads and
+	// refreshes stay available
+	var adminServer *http.Server
+	if adminMux != mux {
+		adminServer = &http.Server{Handler: adminMux}
+		listener, err := listen(config.AdminListenAddress)
+		if err != nil {
+			return err
+		}
+		go func() {
+			log.Printf("Starting admin HTTP server on %s", config.AdminListenAddress)
+			if err := adminServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Admin HTTP server error: %v", err)
+			}
+		}()
+	}
+
+	sdNotifier := newSystemdNotifier()
+	sdNotifierStop := make(chan struct{})
+	if sdNotifier != nil {
+		go sdNotifier.run(collector, sdNotifierStop)
+		if sdNotifier.watchdog > 0 {
+			log.Printf("systemd watchdog enabled, pinging while collections succeed within %v", collector.WatchdogTimeout())
+		}
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Printf("Shutting down server...")
+	if sdNotifier != nil {
+		close(sdNotifierStop)
+		sdNotifier.notify("STOPPING=1")
+	}
+
+	// Stop collecting first: that cancels management API calls in flight,
+	// and scrapes during the drain are answered from the cache
+	collector.Stop()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+	if !config.Output.TextfileOnly {
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Server shutdown error: %v", err)
+		}
+	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			log.Printf("Admin server shutdown error: %v", err)
+		}
 	}
 
 	log.Printf("Server stopped")
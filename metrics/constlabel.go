@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// labelGatherer adds a label whose value is looked up on every gather
+type labelGatherer struct {
+	inner prometheus.Gatherer
+	name  string
+	value func() string
+}
+
+// NewLabelGatherer wraps a gatherer so that every series gets the label
+// name set to value(). Unlike const labels the value may change at runtime.
+// Series that already carry the label keep theirs, and an empty value adds
+// nothing.
+func NewLabelGatherer(inner prometheus.Gatherer, name string, value func() string) prometheus.Gatherer {
+	return &labelGatherer{inner: inner, name: name, value: value}
+}
+
+func (g *labelGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.inner.Gather()
+	value := g.value()
+	if value == "" {
+		return families, err
+	}
+
+	for _, f := range families {
+		for _, m := range f.Metric {
+			if hasLabel(m, g.name) {
+				continue
+			}
+			m.Label = append(m.Label, &dto.LabelPair{
+				Name:  proto.String(g.name),
+				Value: proto.String(value),
+			})
+			sort.Slice(m.Label, func(i, j int) bool {
+				return m.Label[i].GetName() < m.Label[j].GetName()
+			})
+		}
+	}
+	return families, err
+}
+
+func hasLabel(m *dto.Metric, name string) bool {
+	for _, lp := range m.Label {
+		if lp.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
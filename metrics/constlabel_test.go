@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestLabelGatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "up_test", Help: "Test gauge"})
+	labelled := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "labelled_test", Help: "Test gauge"}, []string{"cluster"})
+	registry.MustRegister(up, labelled)
+	labelled.WithLabelValues("other").Set(1)
+
+	value := "rabbit@prod"
+	gatherer := NewLabelGatherer(registry, "cluster", func() string { return value })
+
+	clusterOf := func() map[string]string {
+		families, err := gatherer.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		clusters := make(map[string]string)
+		for _, f := range families {
+			for _, lp := range f.Metric[0].Label {
+				if lp.GetName() == "cluster" {
+					clusters[f.GetName()] = lp.GetValue()
+				}
+			}
+		}
+		return clusters
+	}
+
+	clusters := clusterOf()
+	if clusters["up_test"] != "rabbit@prod" {
+		t.Errorf("Expected the cluster label to be added, got %v", clusters)
+	}
+	if clusters["labelled_test"] != "other" {
+		t.Errorf("Expected an existing cluster label to be kept, got %v", clusters)
+	}
+
+	value = "rabbit@staging"
+	if clusters := clusterOf(); clusters["up_test"] != "rabbit@staging" {
+		t.Errorf("Expected the label to follow the value, got %v", clusters)
+	}
+
+	value = ""
+	if _, ok := clusterOf()["up_test"]; ok {
+		t.Error("Expected no label for an empty value")
+	}
+}
@@ -1,239 +1,1263 @@
 package metrics
 
 import (
+	"fmt"
+	"regexp"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Metrics struct {
-	QueueMessages               *prometheus.GaugeVec
-	QueueMessagesReady          *prometheus.GaugeVec
-	QueueMessagesUnacknowledged *prometheus.GaugeVec
+	QueueMessages               *prometheus.Desc
+	QueueMessagesReady          *prometheus.Desc
+	QueueMessagesUnacknowledged *prometheus.Desc
+
+	QueueMemoryBytes                *prometheus.Desc
+	QueueMessageBytes               *prometheus.Desc
+	QueueMessageBytesReady          *prometheus.Desc
+	QueueMessageBytesUnacknowledged *prometheus.Desc
+	QueueMessagesRAM                *prometheus.Desc
+	QueueMessagesPersistent         *prometheus.Desc
+
+	QueueMaxLengthRatio      *prometheus.Desc
+	QueueMaxLengthBytesRatio *prometheus.Desc
+
+	QueueMessagePublishRate   *prometheus.Desc
+	QueueMessageDeliverRate   *prometheus.Desc
+	QueueMessageAckRate       *prometheus.Desc
+	QueueMessageRedeliverRate *prometheus.Desc
+
+	QueueMessagesPublishedTotal    *prometheus.Desc
+	QueueMessagesDeliveredTotal    *prometheus.Desc
+	QueueMessagesAcknowledgedTotal *prometheus.Desc
+	QueueMessagesRedeliveredTotal  *prometheus.Desc
+	QueueMessagesGetTotal          *prometheus.Desc
+
+	QueueEstimatedDrainSeconds *prometheus.Desc
+	QueueIdleSeconds           *prometheus.Desc
+	QueueAckRatio              *prometheus.Desc
+	QueueRedeliveryRatio       *prometheus.Desc
+	QueueNetGrowthRate         *prometheus.Desc
+	QueueBacklogGrowing        *prometheus.Desc
+
+	QueueConsumers           *prometheus.Desc
+	QueueConsumerUtilisation *prometheus.Desc
+	QueueConsumerCapacity    *prometheus.Desc
+
+	QueueState        *prometheus.Desc
+	QueueIsDeadLetter *prometheus.Desc
+	QueueInfo         *prometheus.Desc
+	QueuePolicyInfo   *prometheus.Desc
+	QueueNodeInfo     *prometheus.Desc
+	QueueModeInfo     *prometheus.Desc
+	PolicyQueues      *prometheus.Desc
+	QueueBindings     *prometheus.Desc
+	QueueUnbound      *prometheus.Desc
+
+	QueueLeaderChangesTotal *prometheus.Desc
+
+	QueueMaxPriority        *prometheus.Desc
+	QueueMessagesByPriority *prometheus.Desc
+
+	QueueConsumersByAckMode         *prometheus.Desc
+	QueueConsumerPrefetch           *prometheus.Desc
+	QueueSingleActiveConsumerActive *prometheus.Desc
+
+	QueueQuorumLeader          *prometheus.Desc
+	QueueQuorumMembers         *prometheus.Desc
+	QueueQuorumOnlineMembers   *prometheus.Desc
+	QueueQuorumUnderReplicated *prometheus.Desc
+	QueueQuorumOpenFiles       *prometheus.Desc
+
+	QueueStreamCommittedOffset *prometheus.Desc
+	QueueStreamReaders         *prometheus.Desc
+	QueueStreamSegments        *prometheus.Desc
+
+	QueueHealthScore      *prometheus.Desc
+	QueueDepthAlert       *prometheus.Desc
+	QueueUtilizationAlert *prometheus.Desc
+	QueueDepthZScore      *prometheus.Desc
+	QueueDepthAnomaly     *prometheus.Desc
+
+	ClusterQueues                 *prometheus.Desc
+	ClusterExchanges              *prometheus.Desc
+	ClusterConnections            *prometheus.Desc
+	ClusterChannels               *prometheus.Desc
+	ClusterConsumers              *prometheus.Desc
+	ClusterMessages               *prometheus.Desc
+	ClusterMessagesReady          *prometheus.Desc
+	ClusterMessagesUnacknowledged *prometheus.Desc
+	ClusterMessagePublishRate     *prometheus.Desc
+	ClusterMessageDeliverRate     *prometheus.Desc
+	ClusterMessageAckRate         *prometheus.Desc
+	ClusterMessageRedeliverRate   *prometheus.Desc
+	ClusterMessageConfirmRate     *prometheus.Desc
+	ClusterChurnRate              *prometheus.Desc
+
+	ClusterMessagesPublishedTotal          *prometheus.Desc
+	ClusterMessagesDeliveredTotal          *prometheus.Desc
+	ClusterMessagesAcknowledgedTotal       *prometheus.Desc
+	ClusterMessagesRedeliveredTotal        *prometheus.Desc
+	ClusterMessagesGetTotal                *prometheus.Desc
+	ClusterMessagesConfirmedTotal          *prometheus.Desc
+	ClusterMessagesReturnedUnroutableTotal *prometheus.Desc
+
+	VhostQueues                 *prometheus.Desc
+	VhostMessages               *prometheus.Desc
+	VhostMessagesReady          *prometheus.Desc
+	VhostMessagesUnacknowledged *prometheus.Desc
+	VhostConsumers              *prometheus.Desc
+	VhostMessagePublishRate     *prometheus.Desc
+	VhostMessageDeliverRate     *prometheus.Desc
+	VhostQueuesByFlag           *prometheus.Desc
+	VhostMessagesByFlag         *prometheus.Desc
+	VhostAggregatedQueues       *prometheus.Desc
+	VhostMessageConfirmRate     *prometheus.Desc
+	VhostMessageUnroutableRate  *prometheus.Desc
+	VhostUnroutableAlert        *prometheus.Desc
+	QueueDepthDistribution      *prometheus.Desc
 
-	QueueMessagePublishRate   *prometheus.GaugeVec
-	QueueMessageDeliverRate   *prometheus.GaugeVec
-	QueueMessageAckRate       *prometheus.GaugeVec
-	QueueMessageRedeliverRate *prometheus.GaugeVec
+	UserConnections       *prometheus.Desc
+	UserChannels          *prometheus.Desc
+	UserReceiveBytesRate  *prometheus.Desc
+	UserSendBytesRate     *prometheus.Desc
+	UserPublishRate       *prometheus.Desc
+	ConnectionsByProtocol *prometheus.Desc
 
-	QueueConsumers           *prometheus.GaugeVec
-	QueueConsumerUtilisation *prometheus.GaugeVec
-	QueueConsumerCapacity    *prometheus.GaugeVec
+	NodeMemAlarm      *prometheus.Desc
+	NodeDiskFreeAlarm *prometheus.Desc
+	NodePartitions    *prometheus.Desc
+	NodeQueues        *prometheus.Desc
 
-	QueueState        *prometheus.GaugeVec
-	QueueIsDeadLetter *prometheus.GaugeVec
+	ClusterNodesRunning        *prometheus.Desc
+	ClusterNodesExpected       *prometheus.Desc
+	ClusterNodeJoinsTotal      *prometheus.Desc
+	ClusterNodeDeparturesTotal *prometheus.Desc
 
-	QueueHealthScore      *prometheus.GaugeVec
-	QueueDepthAlert       *prometheus.GaugeVec
-	QueueUtilizationAlert *prometheus.GaugeVec
+	DepthSLORatio   *prometheus.Desc
+	DepthSLOSamples *prometheus.Desc
 
-	ScrapeDurationSeconds prometheus.Gauge
-	ScrapeErrorsTotal     *prometheus.CounterVec
+	NodeIOOperations           *prometheus.Desc
+	NodeIOBytes                *prometheus.Desc
+	NodeIOOperationRate        *prometheus.Desc
+	NodeIOAverageTimeSeconds   *prometheus.Desc
+	NodeMnesiaTransactions     *prometheus.Desc
+	NodeMessageStoreOperations *prometheus.Desc
 
-	CircuitBreakerState    *prometheus.GaugeVec
-	CircuitBreakerFailures *prometheus.CounterVec
+	NodeGCRuns               *prometheus.Desc
+	NodeGCRunRate            *prometheus.Desc
+	NodeGCBytesReclaimed     *prometheus.Desc
+	NodeGCBytesReclaimedRate *prometheus.Desc
+	NodeContextSwitches      *prometheus.Desc
+	NodeRunQueue             *prometheus.Desc
+
+	AlivenessSuccess         *prometheus.Desc
+	AlivenessDurationSeconds *prometheus.Desc
+	HealthCheckPassed        *prometheus.Desc
+
+	CanarySecondsSinceChange *prometheus.GaugeVec
+	CanaryProducerAlive      *prometheus.GaugeVec
+
+	AMQPProbeSuccess          *prometheus.GaugeVec
+	AMQPProbeRoundTripSeconds *prometheus.GaugeVec
+
+	BrokerEventsTotal      *prometheus.CounterVec
+	EventExchangeConnected *prometheus.GaugeVec
+
+	ScrapeDurationSeconds     prometheus.Gauge
+	ScrapeErrorsTotal         *prometheus.CounterVec
+	SnapshotID                prometheus.Gauge
+	CollectionDurationSeconds prometheus.Histogram
+	CacheAgeSeconds           prometheus.Gauge
+	CacheStale                prometheus.Gauge
+	CollectionPhaseSeconds    *prometheus.GaugeVec
+
+	Up                         prometheus.Gauge
+	LastScrapeTimestampSeconds prometheus.Gauge
+	CollectionsSkippedTotal    *prometheus.CounterVec
+	CollectionWatchdogRestarts prometheus.Counter
+	BuildInfo                  *prometheus.GaugeVec
+	ConfigInfo                 *prometheus.GaugeVec
+
+	CircuitBreakerState        *prometheus.GaugeVec
+	CircuitBreakerFailures     *prometheus.CounterVec
+	CircuitBreakerManualResets *prometheus.CounterVec
+
+	APIRequestDurationSeconds *prometheus.HistogramVec
+
+	namespace string
+}
+
+// Namespace returns the prefix of the metric names
+func (m *Metrics) Namespace() string {
+	return m.namespace
+}
+
+// DefaultNamespace prefixes every metric name unless configured otherwise
+const DefaultNamespace = "rabbitmq_custom"
+
+var namespacePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ValidateNamespace reports whether namespace can prefix metric names
+func ValidateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid metric namespace %q: must match %s", namespace, namespacePattern)
+	}
+	return nil
 }
 
+// NewMetrics creates the exporter's metrics under DefaultNamespace
 func NewMetrics() *Metrics {
-	return &Metrics{
+	return NewMetricsWithNamespace(DefaultNamespace)
+}
+
+// NewMetricsWithNamespace creates the exporter's metrics with every name
+// prefixed by namespace instead of rabbitmq_custom, e.g. rmq_queue_messages.
+// extraQueueLabels are added to every per-queue metric after the queue_name,
+// vhost and type labels.
+func NewMetricsWithNamespace(namespace string, extraQueueLabels ...string) *Metrics {
+	name := func(suffix string) string {
+		return prometheus.BuildFQName(namespace, "", suffix)
+	}
+	queueLabels := func(labels ...string) []string {
+		all := append([]string{"queue_name", "vhost", "type"}, extraQueueLabels...)
+		return append(all, labels...)
+	}
+
+	m := &Metrics{
 		// Queue message counts
-		QueueMessages: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_messages",
-				Help: "Total number of messages in the queue",
-			},
-			[]string{"queue_name", "vhost", "state"},
+		QueueMessages: prometheus.NewDesc(
+			name("queue_messages"),
+			"Total number of messages in the queue",
+			queueLabels("state"), nil,
+		),
+		QueueMessagesReady: prometheus.NewDesc(
+			name("queue_messages_ready"),
+			"Number of messages ready to be delivered",
+			queueLabels(), nil,
+		),
+		QueueMessagesUnacknowledged: prometheus.NewDesc(
+			name("queue_messages_unacknowledged"),
+			"Number of messages that have been delivered but not yet acknowledged",
+			queueLabels(), nil,
+		),
+
+		// Memory and message bytes
+		QueueMemoryBytes: prometheus.NewDesc(
+			name("queue_memory_bytes"),
+			"Bytes of memory consumed by the queue process, including stack, heap and internal structures",
+			queueLabels(), nil,
+		),
+		QueueMessageBytes: prometheus.NewDesc(
+			name("queue_message_bytes"),
+			"Sum of the size of all message bodies in the queue",
+			queueLabels(), nil,
+		),
+		QueueMessageBytesReady: prometheus.NewDesc(
+			name("queue_message_bytes_ready"),
+			"Sum of the size of message bodies ready to be delivered",
+			queueLabels(), nil,
+		),
+		QueueMessageBytesUnacknowledged: prometheus.NewDesc(
+			name("queue_message_bytes_unacknowledged"),
+			"Sum of the size of message bodies delivered but not yet acknowledged",
+			queueLabels(), nil,
+		),
+		QueueMessagesRAM: prometheus.NewDesc(
+			name("queue_messages_ram"),
+			"Number of messages held in RAM",
+			queueLabels(), nil,
+		),
+		QueueMessagesPersistent: prometheus.NewDesc(
+			name("queue_messages_persistent"),
+			"Number of persistent messages in the queue",
+			queueLabels(), nil,
+		),
+
+		// Max-length saturation
+		QueueMaxLengthRatio: prometheus.NewDesc(
+			name("queue_max_length_ratio"),
+			"Ready messages as a fraction of the queue's max-length limit, from arguments or policy",
+			queueLabels(), nil,
+		),
+		QueueMaxLengthBytesRatio: prometheus.NewDesc(
+			name("queue_max_length_bytes_ratio"),
+			"Ready message bytes as a fraction of the queue's max-length-bytes limit, from arguments or policy",
+			queueLabels(), nil,
+		),
+
+		// Message rates (per second)
+		QueueMessagePublishRate: prometheus.NewDesc(
+			name("queue_message_publish_rate"),
+			"Message publish rate per second",
+			queueLabels(), nil,
+		),
+		QueueMessageDeliverRate: prometheus.NewDesc(
+			name("queue_message_deliver_rate"),
+			"Message delivery rate per second",
+			queueLabels(), nil,
+		),
+		QueueMessageAckRate: prometheus.NewDesc(
+			name("queue_message_ack_rate"),
+			"Message acknowledgment rate per second",
+			queueLabels(), nil,
+		),
+		QueueMessageRedeliverRate: prometheus.NewDesc(
+			name("queue_message_redeliver_rate"),
+			"Message redelivery rate per second",
+			queueLabels(), nil,
+		),
+
+		// Cumulative message counters, straight from the broker
+		QueueMessagesPublishedTotal: prometheus.NewDesc(
+			name("queue_messages_published_total"),
+			"Total number of messages published to the queue",
+			queueLabels(), nil,
+		),
+		QueueMessagesDeliveredTotal: prometheus.NewDesc(
+			name("queue_messages_delivered_total"),
+			"Total number of messages delivered to consumers from the queue",
+			queueLabels(), nil,
+		),
+		QueueMessagesAcknowledgedTotal: prometheus.NewDesc(
+			name("queue_messages_acknowledged_total"),
+			"Total number of messages acknowledged by consumers of the queue",
+			queueLabels(), nil,
+		),
+		QueueMessagesRedeliveredTotal: prometheus.NewDesc(
+			name("queue_messages_redelivered_total"),
+			"Total number of messages redelivered from the queue",
+			queueLabels(), nil,
+		),
+		QueueMessagesGetTotal: prometheus.NewDesc(
+			name("queue_messages_get_total"),
+			"Total number of messages fetched from the queue with basic.get in manual acknowledgement mode",
+			queueLabels(), nil,
+		),
+
+		QueueEstimatedDrainSeconds: prometheus.NewDesc(
+			name("queue_estimated_drain_seconds"),
+			"Estimated time to deliver the ready messages at the current delivery rate, capped at 7 days",
+			queueLabels(), nil,
+		),
+		QueueIdleSeconds: prometheus.NewDesc(
+			name("queue_idle_seconds"),
+			"Seconds since the queue last saw activity, from idle_since. Only exported while the queue is idle.",
+			queueLabels(), nil,
+		),
+		QueueAckRatio: prometheus.NewDesc(
+			name("queue_ack_ratio"),
+			"Ack rate as a fraction of the deliver rate, capped at 1. 1 while nothing is delivered.",
+			queueLabels(), nil,
+		),
+		QueueRedeliveryRatio: prometheus.NewDesc(
+			name("queue_redelivery_ratio"),
+			"Redeliver rate as a fraction of the deliver rate, capped at 1. 0 while nothing is delivered.",
+			queueLabels(), nil,
+		),
+		QueueNetGrowthRate: prometheus.NewDesc(
+			name("queue_net_growth_rate"),
+			"Messages published per second minus messages consumed per second, with or without acks; positive while the backlog grows",
+			queueLabels(), nil,
+		),
+		QueueBacklogGrowing: prometheus.NewDesc(
+			name("queue_backlog_growing"),
+			"Whether the queue's net growth rate has been positive for backlog_growth_collections consecutive collections (1 = growing)",
+			queueLabels(), nil,
+		),
+
+		// Consumer metrics
+		QueueConsumers: prometheus.NewDesc(
+			name("queue_consumers"),
+			"Number of consumers connected to the queue",
+			queueLabels(), nil,
+		),
+		QueueConsumerUtilisation: prometheus.NewDesc(
+			name("queue_consumer_utilisation"),
+			"Consumer utilisation as a percentage (0-1)",
+			queueLabels(), nil,
+		),
+		QueueConsumerCapacity: prometheus.NewDesc(
+			name("queue_consumer_capacity"),
+			"Fraction of time the queue can deliver to consumers immediately (0-1), falling back to utilisation on brokers before 3.8",
+			queueLabels(), nil,
+		),
+
+		// Queue state indicators
+		QueueState: prometheus.NewDesc(
+			name("queue_state"),
+			"Queue state indicator (1 for current state, 0 otherwise)",
+			queueLabels("state"), nil,
+		),
+		QueueIsDeadLetter: prometheus.NewDesc(
+			name("queue_is_dead_letter"),
+			"Indicates if the queue is a dead letter queue (1 if true, 0 if false)",
+			queueLabels(), nil,
+		),
+		QueueInfo: prometheus.NewDesc(
+			name("queue_info"),
+			"Queue configuration, always 1. Argument labels are empty when the argument isn't set.",
+			queueLabels("durable", "auto_delete", "exclusive", "policy", "max_length", "max_length_bytes", "message_ttl", "overflow"), nil,
+		),
+		QueuePolicyInfo: prometheus.NewDesc(
+			name("queue_policy_info"),
+			"Policy and operator policy applied to the queue, always 1. Labels are empty when none applies.",
+			queueLabels("policy", "operator_policy"), nil,
+		),
+		QueueNodeInfo: prometheus.NewDesc(
+			name("queue_node_info"),
+			"Node hosting the queue, always 1: the node of a classic queue, or the leader of a quorum queue or stream",
+			queueLabels("node"), nil,
+		),
+		QueueModeInfo: prometheus.NewDesc(
+			name("queue_mode_info"),
+			"Mode a classic queue runs in (default or lazy), always 1, whether set by argument or policy",
+			queueLabels("mode"), nil,
+		),
+		QueueLeaderChangesTotal: prometheus.NewDesc(
+			name("queue_leader_changes_total"),
+			"Number of times the node hosting the queue changed since the exporter started, e.g. on failover or rebalancing",
+			queueLabels(), nil,
+		),
+
+		// Priority queues
+		QueueMaxPriority: prometheus.NewDesc(
+			name("queue_max_priority"),
+			"Highest message priority the queue supports, from its x-max-priority argument",
+			queueLabels(), nil,
+		),
+		QueueMessagesByPriority: prometheus.NewDesc(
+			name("queue_messages_ready_by_priority"),
+			"Number of messages ready for delivery in a priority queue, by priority level",
+			queueLabels("priority"), nil,
+		),
+
+		// Consumer details
+		QueueConsumersByAckMode: prometheus.NewDesc(
+			name("queue_consumers_by_ack_mode"),
+			"Number of consumers of the queue by acknowledgement mode: manual, or auto for consumers that don't ack",
+			queueLabels("ack_mode"), nil,
+		),
+		QueueConsumerPrefetch: prometheus.NewDesc(
+			name("queue_consumer_prefetch"),
+			"Distribution of the prefetch limits of the queue's consumers. Unlimited prefetch (0) only counts towards +Inf and the count.",
+			queueLabels(), nil,
+		),
+		QueueSingleActiveConsumerActive: prometheus.NewDesc(
+			name("queue_single_active_consumer_active"),
+			"Whether a single active consumer queue has a consumer receiving messages (1) or none (0)",
+			queueLabels(), nil,
+		),
+		QueueBindings: prometheus.NewDesc(
+			name("queue_bindings"),
+			"Number of bindings to the queue, excluding the implicit default exchange binding",
+			queueLabels(), nil,
+		),
+		QueueUnbound: prometheus.NewDesc(
+			name("queue_unbound"),
+			"Indicates if the queue has no bindings besides the default exchange (1 if true, 0 if false)",
+			queueLabels(), nil,
+		),
+		PolicyQueues: prometheus.NewDesc(
+			name("policy_queues"),
+			"Number of queues the policy applies to, by kind (policy or operator_policy)",
+			[]string{"vhost", "policy", "kind"}, nil,
+		),
+
+		// Quorum queue replication
+		QueueQuorumLeader: prometheus.NewDesc(
+			name("queue_quorum_leader"),
+			"Node currently leading the quorum queue (1 for the leader node)",
+			queueLabels("node"), nil,
+		),
+		QueueQuorumMembers: prometheus.NewDesc(
+			name("queue_quorum_members"),
+			"Number of configured quorum queue members",
+			queueLabels(), nil,
+		),
+		QueueQuorumOnlineMembers: prometheus.NewDesc(
+			name("queue_quorum_online_members"),
+			"Number of quorum queue members currently online",
+			queueLabels(), nil,
+		),
+		QueueQuorumUnderReplicated: prometheus.NewDesc(
+			name("queue_quorum_under_replicated"),
+			"Indicates if fewer quorum queue members are online than configured (1 if true, 0 if false)",
+			queueLabels(), nil,
+		),
+		QueueQuorumOpenFiles: prometheus.NewDesc(
+			name("queue_quorum_open_files"),
+			"Number of open files held by the quorum queue on each member node",
+			queueLabels("node"), nil,
+		),
+
+		// Stream queues
+		QueueStreamCommittedOffset: prometheus.NewDesc(
+			name("queue_stream_committed_offset"),
+			"Last committed offset of the stream",
+			queueLabels(), nil,
+		),
+		QueueStreamReaders: prometheus.NewDesc(
+			name("queue_stream_readers"),
+			"Number of readers attached to the stream",
+			queueLabels(), nil,
+		),
+		QueueStreamSegments: prometheus.NewDesc(
+			name("queue_stream_segments"),
+			"Number of segment files backing the stream",
+			queueLabels(), nil,
+		),
+
+		// Queue health indicators
+		QueueHealthScore: prometheus.NewDesc(
+			name("queue_health_score"),
+			"Queue health score (0-100, higher is better)",
+			queueLabels(), nil,
+		),
+		QueueDepthAlert: prometheus.NewDesc(
+			name("queue_depth_alert"),
+			"Queue depth alert indicator (1 if depth > threshold, 0 otherwise)",
+			queueLabels("severity"), nil,
+		),
+		QueueUtilizationAlert: prometheus.NewDesc(
+			name("queue_utilization_alert"),
+			"Queue utilization alert indicator (1 if utilization < threshold, 0 otherwise)",
+			queueLabels("severity"), nil,
+		),
+		QueueDepthZScore: prometheus.NewDesc(
+			name("queue_depth_zscore"),
+			"Standard deviations between the queue's depth and its rolling baseline",
+			queueLabels(), nil,
+		),
+		QueueDepthAnomaly: prometheus.NewDesc(
+			name("queue_depth_anomaly"),
+			"Queue depth anomaly indicator (1 if the depth z-score exceeds the anomaly threshold, 0 otherwise)",
+			queueLabels(), nil,
+		),
+
+		// Cluster overview
+		ClusterQueues: prometheus.NewDesc(
+			name("cluster_queues"),
+			"Number of queues in the cluster",
+			nil, nil,
+		),
+		ClusterExchanges: prometheus.NewDesc(
+			name("cluster_exchanges"),
+			"Number of exchanges in the cluster",
+			nil, nil,
+		),
+		ClusterConnections: prometheus.NewDesc(
+			name("cluster_connections"),
+			"Number of client connections to the cluster",
+			nil, nil,
+		),
+		ClusterChannels: prometheus.NewDesc(
+			name("cluster_channels"),
+			"Number of channels open on the cluster",
+			nil, nil,
+		),
+		ClusterConsumers: prometheus.NewDesc(
+			name("cluster_consumers"),
+			"Number of consumers in the cluster",
+			nil, nil,
+		),
+		ClusterMessages: prometheus.NewDesc(
+			name("cluster_messages"),
+			"Total number of messages in all queues",
+			nil, nil,
+		),
+		ClusterMessagesReady: prometheus.NewDesc(
+			name("cluster_messages_ready"),
+			"Number of messages ready to be delivered across all queues",
+			nil, nil,
+		),
+		ClusterMessagesUnacknowledged: prometheus.NewDesc(
+			name("cluster_messages_unacknowledged"),
+			"Number of unacknowledged messages across all queues",
+			nil, nil,
+		),
+		ClusterMessagePublishRate: prometheus.NewDesc(
+			name("cluster_message_publish_rate"),
+			"Rate of messages published to the cluster",
+			nil, nil,
+		),
+		ClusterMessageDeliverRate: prometheus.NewDesc(
+			name("cluster_message_deliver_rate"),
+			"Rate of messages delivered to consumers or fetched with basic.get across the cluster",
+			nil, nil,
+		),
+		ClusterMessageAckRate: prometheus.NewDesc(
+			name("cluster_message_ack_rate"),
+			"Rate of messages acknowledged across the cluster",
+			nil, nil,
+		),
+		ClusterMessageRedeliverRate: prometheus.NewDesc(
+			name("cluster_message_redeliver_rate"),
+			"Rate of messages redelivered across the cluster",
+			nil, nil,
+		),
+		ClusterMessageConfirmRate: prometheus.NewDesc(
+			name("cluster_message_confirm_rate"),
+			"Rate of publisher confirms sent by the cluster",
+			nil, nil,
+		),
+		ClusterChurnRate: prometheus.NewDesc(
+			name("cluster_churn_rate"),
+			"Rate at which connections, channels and queues are created and closed, by object and event",
+			[]string{"object", "event"}, nil,
+		),
+		ClusterMessagesPublishedTotal: prometheus.NewDesc(
+			name("cluster_messages_published_total"),
+			"Total number of messages published to the cluster",
+			nil, nil,
+		),
+		ClusterMessagesDeliveredTotal: prometheus.NewDesc(
+			name("cluster_messages_delivered_total"),
+			"Total number of messages delivered to consumers or fetched with basic.get across the cluster",
+			nil, nil,
+		),
+		ClusterMessagesAcknowledgedTotal: prometheus.NewDesc(
+			name("cluster_messages_acknowledged_total"),
+			"Total number of messages acknowledged across the cluster",
+			nil, nil,
 		),
-		QueueMessagesReady: prometheus.NewGaugeVec(
+		ClusterMessagesRedeliveredTotal: prometheus.NewDesc(
+			name("cluster_messages_redelivered_total"),
+			"Total number of messages redelivered across the cluster",
+			nil, nil,
+		),
+		ClusterMessagesGetTotal: prometheus.NewDesc(
+			name("cluster_messages_get_total"),
+			"Total number of messages fetched with basic.get in manual acknowledgement mode across the cluster",
+			nil, nil,
+		),
+		ClusterMessagesConfirmedTotal: prometheus.NewDesc(
+			name("cluster_messages_confirmed_total"),
+			"Total number of publisher confirms sent by the cluster",
+			nil, nil,
+		),
+		ClusterMessagesReturnedUnroutableTotal: prometheus.NewDesc(
+			name("cluster_messages_returned_unroutable_total"),
+			"Total number of mandatory messages returned to publishers as unroutable",
+			nil, nil,
+		),
+
+		// Per-vhost rollups
+		VhostQueues: prometheus.NewDesc(
+			name("vhost_queues"),
+			"Number of queues in the vhost",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessages: prometheus.NewDesc(
+			name("vhost_messages"),
+			"Total number of messages in the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessagesReady: prometheus.NewDesc(
+			name("vhost_messages_ready"),
+			"Number of messages ready to be delivered in the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessagesUnacknowledged: prometheus.NewDesc(
+			name("vhost_messages_unacknowledged"),
+			"Number of unacknowledged messages in the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostConsumers: prometheus.NewDesc(
+			name("vhost_consumers"),
+			"Number of consumers on the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessagePublishRate: prometheus.NewDesc(
+			name("vhost_message_publish_rate"),
+			"Rate of messages published to the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessageDeliverRate: prometheus.NewDesc(
+			name("vhost_message_deliver_rate"),
+			"Rate of messages delivered from the vhost's queues",
+			[]string{"vhost"}, nil,
+		),
+		VhostQueuesByFlag: prometheus.NewDesc(
+			name("vhost_queues_by_flag"),
+			"Number of the vhost's queues that are auto_delete, exclusive or non_durable",
+			[]string{"vhost", "flag"}, nil,
+		),
+		VhostMessagesByFlag: prometheus.NewDesc(
+			name("vhost_messages_by_flag"),
+			"Number of messages in the vhost's auto_delete, exclusive or non_durable queues",
+			[]string{"vhost", "flag"}, nil,
+		),
+
+		VhostAggregatedQueues: prometheus.NewDesc(
+			name("vhost_aggregated_queues"),
+			"Number of queues beyond max_queues_per_vhost that are only exported as part of the aggregate series",
+			[]string{"vhost"}, nil,
+		),
+
+		// Publishing, summed over the vhost's channels
+		VhostMessageConfirmRate: prometheus.NewDesc(
+			name("vhost_message_confirm_rate"),
+			"Rate of publisher confirms sent on the vhost's channels",
+			[]string{"vhost"}, nil,
+		),
+		VhostMessageUnroutableRate: prometheus.NewDesc(
+			name("vhost_message_unroutable_rate"),
+			"Rate of published messages that matched no queue, by outcome (returned to a mandatory publisher or dropped)",
+			[]string{"vhost", "outcome"}, nil,
+		),
+		VhostUnroutableAlert: prometheus.NewDesc(
+			name("vhost_unroutable_alert"),
+			"Whether messages published in the vhost are currently unroutable (1 = alert)",
+			[]string{"vhost"}, nil,
+		),
+
+		// Connections and channels per user and application
+		UserConnections: prometheus.NewDesc(
+			name("user_connections"),
+			"Number of open connections of the user, by application (client-provided connection name)",
+			[]string{"user", "application"}, nil,
+		),
+		UserChannels: prometheus.NewDesc(
+			name("user_channels"),
+			"Number of channels open on the user's connections, by application",
+			[]string{"user", "application"}, nil,
+		),
+		UserReceiveBytesRate: prometheus.NewDesc(
+			name("user_receive_bytes_rate"),
+			"Rate in bytes per second received from the user's connections, by application",
+			[]string{"user", "application"}, nil,
+		),
+		UserSendBytesRate: prometheus.NewDesc(
+			name("user_send_bytes_rate"),
+			"Rate in bytes per second sent to the user's connections, by application",
+			[]string{"user", "application"}, nil,
+		),
+		UserPublishRate: prometheus.NewDesc(
+			name("user_message_publish_rate"),
+			"Rate of messages published on the user's channels, by application",
+			[]string{"user", "application"}, nil,
+		),
+		ConnectionsByProtocol: prometheus.NewDesc(
+			name("connections_by_protocol"),
+			"Number of open connections by protocol and whether they use TLS",
+			[]string{"protocol", "tls"}, nil,
+		),
+
+		// Queue depth histogram
+		QueueDepthDistribution: prometheus.NewDesc(
+			name("queue_depth_distribution"),
+			"Distribution of queue depths in messages across all queues except streams",
+			nil, nil,
+		),
+
+		// Node alarms
+		NodeMemAlarm: prometheus.NewDesc(
+			name("node_mem_alarm"),
+			"Whether the node's memory alarm is set, blocking publishers (1 = alarm)",
+			[]string{"node"}, nil,
+		),
+		NodeDiskFreeAlarm: prometheus.NewDesc(
+			name("node_disk_free_alarm"),
+			"Whether the node's free disk space alarm is set, blocking publishers (1 = alarm)",
+			[]string{"node"}, nil,
+		),
+		NodePartitions: prometheus.NewDesc(
+			name("node_partitions"),
+			"Number of cluster peers the node has detected a network partition with",
+			[]string{"node"}, nil,
+		),
+		NodeQueues: prometheus.NewDesc(
+			name("node_queues"),
+			"Number of queues hosted by the node: classic queues living on it, and quorum queues and streams it leads",
+			[]string{"node"}, nil,
+		),
+
+		// Cluster membership
+		ClusterNodesRunning: prometheus.NewDesc(
+			name("cluster_nodes_running"),
+			"Number of cluster nodes listed as running",
+			nil, nil,
+		),
+		ClusterNodesExpected: prometheus.NewDesc(
+			name("cluster_nodes_expected"),
+			"Number of running nodes the cluster is expected to have, from expected_nodes",
+			nil, nil,
+		),
+		ClusterNodeJoinsTotal: prometheus.NewDesc(
+			name("cluster_node_joins_total"),
+			"Number of times a node joined the cluster or started running again since the exporter started",
+			nil, nil,
+		),
+		ClusterNodeDeparturesTotal: prometheus.NewDesc(
+			name("cluster_node_departures_total"),
+			"Number of times a node left the cluster or stopped running since the exporter started",
+			nil, nil,
+		),
+
+		// Depth SLOs
+		DepthSLORatio: prometheus.NewDesc(
+			name("queue_depth_slo_ratio"),
+			"Fraction of collections within the SLO window in which the queue's depth stayed at or under its threshold",
+			[]string{"queue_name", "vhost"}, nil,
+		),
+		DepthSLOSamples: prometheus.NewDesc(
+			name("queue_depth_slo_samples"),
+			"Number of collections within the SLO window that the depth SLO ratio is based on",
+			[]string{"queue_name", "vhost"}, nil,
+		),
+
+		// Node I/O and message store
+		NodeIOOperations: prometheus.NewDesc(
+			name("node_io_operations_total"),
+			"Total file I/O operations of the node by operation (read, write, sync, seek)",
+			[]string{"node", "operation"}, nil,
+		),
+		NodeIOBytes: prometheus.NewDesc(
+			name("node_io_bytes_total"),
+			"Total bytes read and written by the node's file I/O, by operation",
+			[]string{"node", "operation"}, nil,
+		),
+		NodeIOOperationRate: prometheus.NewDesc(
+			name("node_io_operation_rate"),
+			"Rate of file I/O operations per second of the node by operation (read, write, sync)",
+			[]string{"node", "operation"}, nil,
+		),
+		NodeIOAverageTimeSeconds: prometheus.NewDesc(
+			name("node_io_average_time_seconds"),
+			"Average duration of the node's recent file I/O operations by operation (read, write, sync)",
+			[]string{"node", "operation"}, nil,
+		),
+		NodeMnesiaTransactions: prometheus.NewDesc(
+			name("node_mnesia_transactions_total"),
+			"Total Mnesia schema database transactions of the node by storage (ram, disk)",
+			[]string{"node", "storage"}, nil,
+		),
+		NodeMessageStoreOperations: prometheus.NewDesc(
+			name("node_message_store_operations_total"),
+			"Total reads and writes of the node's queue index and message store, by store (queue_index, message_store) and operation",
+			[]string{"node", "store", "operation"}, nil,
+		),
+
+		// Erlang runtime
+		NodeGCRuns: prometheus.NewDesc(
+			name("node_gc_runs_total"),
+			"Total garbage collections run by the node's Erlang runtime",
+			[]string{"node"}, nil,
+		),
+		NodeGCRunRate: prometheus.NewDesc(
+			name("node_gc_run_rate"),
+			"Rate of garbage collections per second on the node",
+			[]string{"node"}, nil,
+		),
+		NodeGCBytesReclaimed: prometheus.NewDesc(
+			name("node_gc_bytes_reclaimed_total"),
+			"Total bytes of memory reclaimed by garbage collection on the node",
+			[]string{"node"}, nil,
+		),
+		NodeGCBytesReclaimedRate: prometheus.NewDesc(
+			name("node_gc_bytes_reclaimed_rate"),
+			"Rate in bytes per second of memory reclaimed by garbage collection on the node",
+			[]string{"node"}, nil,
+		),
+		NodeContextSwitches: prometheus.NewDesc(
+			name("node_context_switches_total"),
+			"Total Erlang process context switches on the node",
+			[]string{"node"}, nil,
+		),
+		NodeRunQueue: prometheus.NewDesc(
+			name("node_run_queue"),
+			"Number of Erlang processes on the node waiting for a scheduler",
+			[]string{"node"}, nil,
+		),
+
+		// Aliveness tests
+		AlivenessSuccess: prometheus.NewDesc(
+			name("aliveness_success"),
+			"Whether the last aliveness test, which publishes and consumes a message, succeeded in the vhost (1 = success)",
+			[]string{"vhost"}, nil,
+		),
+		AlivenessDurationSeconds: prometheus.NewDesc(
+			name("aliveness_duration_seconds"),
+			"Duration of the last aliveness test in the vhost",
+			[]string{"vhost"}, nil,
+		),
+		HealthCheckPassed: prometheus.NewDesc(
+			name("health_check_passed"),
+			"Whether the last run of the management API health check passed (1 = passed)",
+			[]string{"check"}, nil,
+		),
+
+		// Producer canaries
+		CanarySecondsSinceChange: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_messages_ready",
-				Help: "Number of messages ready to be delivered",
+				Name: name("canary_seconds_since_change"),
+				Help: "Seconds since the canary queue last showed producer activity",
 			},
-			[]string{"queue_name", "vhost"},
+			[]string{"pipeline", "queue_name", "vhost"},
 		),
-		QueueMessagesUnacknowledged: prometheus.NewGaugeVec(
+		CanaryProducerAlive: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_messages_unacknowledged",
-				Help: "Number of messages that have been delivered but not yet acknowledged",
+				Name: name("canary_producer_alive"),
+				Help: "Indicates if the pipeline producer published within its max silence window (1 if alive, 0 otherwise)",
 			},
-			[]string{"queue_name", "vhost"},
+			[]string{"pipeline", "queue_name", "vhost"},
 		),
 
-		// Message rates (per second)
-		QueueMessagePublishRate: prometheus.NewGaugeVec(
+		// AMQP probe
+		AMQPProbeSuccess: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_message_publish_rate",
-				Help: "Message publish rate per second",
+				Name: name("amqp_probe_success"),
+				Help: "Whether the last AMQP probe published and consumed its message in time (1 = success)",
 			},
-			[]string{"queue_name", "vhost"},
+			[]string{"vhost"},
 		),
-		QueueMessageDeliverRate: prometheus.NewGaugeVec(
+		AMQPProbeRoundTripSeconds: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_message_deliver_rate",
-				Help: "Message delivery rate per second",
+				Name: name("amqp_probe_round_trip_seconds"),
+				Help: "Time from publishing the last successful AMQP probe message to consuming it",
 			},
-			[]string{"queue_name", "vhost"},
+			[]string{"vhost"},
 		),
-		QueueMessageAckRate: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_message_ack_rate",
-				Help: "Message acknowledgment rate per second",
+
+		// Event exchange
+		BrokerEventsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: name("broker_events_total"),
+				Help: "Events consumed from the event exchange, by routing key such as queue.created",
 			},
-			[]string{"queue_name", "vhost"},
+			[]string{"event"},
 		),
-		QueueMessageRedeliverRate: prometheus.NewGaugeVec(
+		EventExchangeConnected: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_message_redeliver_rate",
-				Help: "Message redelivery rate per second",
+				Name: name("event_exchange_connected"),
+				Help: "Whether the exporter is subscribed to the event exchange (1 = connected)",
 			},
-			[]string{"queue_name", "vhost"},
+			[]string{"exchange"},
 		),
 
-		// Consumer metrics
-		QueueConsumers: prometheus.NewGaugeVec(
+		// Health metrics
+		ScrapeDurationSeconds: prometheus.NewGauge(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_consumers",
-				Help: "Number of consumers connected to the queue",
+				Name: name("scrape_duration_seconds"),
+				Help: "Duration of the last scrape in seconds",
 			},
-			[]string{"queue_name", "vhost"},
 		),
-		QueueConsumerUtilisation: prometheus.NewGaugeVec(
+		CacheAgeSeconds: prometheus.NewGauge(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_consumer_utilisation",
-				Help: "Consumer utilisation as a percentage (0-1)",
+				Name: name("cache_age_seconds"),
+				Help: "Age of the snapshot queue and cluster metrics are served from, or time since startup before the first one",
 			},
-			[]string{"queue_name", "vhost"},
 		),
-		QueueConsumerCapacity: prometheus.NewGaugeVec(
+		CacheStale: prometheus.NewGauge(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_consumer_capacity",
-				Help: "Consumer capacity as a percentage (0-1)",
+				Name: name("cache_stale"),
+				Help: "Whether queue and cluster series were withheld from the last scrape because the snapshot was older than cache_max_age or the last collection failed (1) or not (0)",
 			},
-			[]string{"queue_name", "vhost"},
 		),
-
-		// Queue state indicators
-		QueueState: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_state",
-				Help: "Queue state indicator (1 for current state, 0 otherwise)",
+		CollectionDurationSeconds: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    name("collection_duration_seconds"),
+				Help:    "Duration of background collections from the management API, including failed ones",
+				Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
 			},
-			[]string{"queue_name", "vhost", "state"},
 		),
-		QueueIsDeadLetter: prometheus.NewGaugeVec(
+		CollectionPhaseSeconds: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_is_dead_letter",
-				Help: "Indicates if the queue is a dead letter queue (1 if true, 0 if false)",
+				Name: name("collection_phase_seconds"),
+				Help: "Time the last collection spent waiting on management API requests (phase=\"fetch\"), decoding their responses (phase=\"decode\") and updating the snapshot metrics are served from (phase=\"update\"). Requests run concurrently, so fetch and decode add up across them.",
 			},
-			[]string{"queue_name", "vhost"},
+			[]string{"phase"},
 		),
-
-		// Queue health indicators
-		QueueHealthScore: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_health_score",
-				Help: "Queue health score (0-100, higher is better)",
+		ScrapeErrorsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: name("scrape_errors_total"),
+				Help: "Total number of scrape errors",
 			},
-			[]string{"queue_name", "vhost"},
+			[]string{"error_type"},
 		),
-		QueueDepthAlert: prometheus.NewGaugeVec(
+		SnapshotID: prometheus.NewGauge(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_depth_alert",
-				Help: "Queue depth alert indicator (1 if depth > threshold, 0 otherwise)",
+				Name: name("snapshot_id"),
+				Help: "Sequence number of the collection the exposed queue metrics were taken from",
 			},
-			[]string{"queue_name", "vhost", "severity"},
 		),
-		QueueUtilizationAlert: prometheus.NewGaugeVec(
+		Up: prometheus.NewGauge(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_queue_utilization_alert",
-				Help: "Queue utilization alert indicator (1 if utilization < threshold, 0 otherwise)",
+				Name: name("up"),
+				Help: "Whether the most recent background collection from the management API succeeded (1) or failed (0)",
 			},
-			[]string{"queue_name", "vhost", "severity"},
 		),
-
-		// Health metrics
-		ScrapeDurationSeconds: prometheus.NewGauge(
+		LastScrapeTimestampSeconds: prometheus.NewGauge(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_scrape_duration_seconds",
-				Help: "Duration of the last scrape in seconds",
+				Name: name("last_scrape_timestamp_seconds"),
+				Help: "Unix timestamp of the last successful background collection",
 			},
 		),
-		ScrapeErrorsTotal: prometheus.NewCounterVec(
+		CollectionsSkippedTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "rabbitmq_custom_scrape_errors_total",
-				Help: "Total number of scrape errors",
+				Name: name("collections_skipped_total"),
+				Help: "Background collections skipped because the previous one was still running (reason=\"busy\"), had overrun their slot (reason=\"overdue\") or fell within a Retry-After pause (reason=\"backoff\")",
+			},
+			[]string{"reason"},
+		),
+		CollectionWatchdogRestarts: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: name("collection_watchdog_restarts_total"),
+				Help: "Times the watchdog restarted background collection because no collection had completed in time",
 			},
-			[]string{"error_type"},
+		),
+		BuildInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: name("exporter_build_info"),
+				Help: "Exporter build information; the value is always 1",
+			},
+			[]string{"version", "commit", "go_version"},
+		),
+		ConfigInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: name("exporter_config_info"),
+				Help: "Settings the exporter loaded, with config_hash identifying the whole effective configuration; the value is always 1",
+			},
+			ConfigInfoLabels,
 		),
 
 		// Circuit breaker metrics
 		CircuitBreakerState: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "rabbitmq_custom_circuit_breaker_state",
+				Name: name("circuit_breaker_state"),
 				Help: "Circuit breaker state (0=closed, 1=open, 2=half-open)",
 			},
 			[]string{"endpoint"},
 		),
 		CircuitBreakerFailures: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "rabbitmq_custom_circuit_breaker_failures_total",
+				Name: name("circuit_breaker_failures_total"),
 				Help: "Total number of circuit breaker failures",
 			},
 			[]string{"endpoint"},
 		),
+		CircuitBreakerManualResets: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: name("circuit_breaker_manual_resets_total"),
+				Help: "Total number of manual circuit breaker resets via the admin API",
+			},
+			[]string{"endpoint"},
+		),
+
+		// Management API metrics
+		APIRequestDurationSeconds: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    name("api_request_duration_seconds"),
+				Help:    "Duration of management API requests by endpoint and HTTP status code (code=\"error\" when no response was received)",
+				Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30},
+			},
+			[]string{"endpoint", "code"},
+		),
 	}
+	m.namespace = namespace
+	return m
 }
 
-// GetAllCollectors returns all metrics as collectors for consistent iteration
+// GetAllCollectors returns the metrics that keep state between scrapes
 func (m *Metrics) GetAllCollectors() []prometheus.Collector {
 	return []prometheus.Collector{
-		m.QueueMessages,
-		m.QueueMessagesReady,
-		m.QueueMessagesUnacknowledged,
-		m.QueueMessagePublishRate,
-		m.QueueMessageDeliverRate,
-		m.QueueMessageAckRate,
-		m.QueueMessageRedeliverRate,
-		m.QueueConsumers,
-		m.QueueConsumerUtilisation,
-		m.QueueConsumerCapacity,
-		m.QueueState,
-		m.QueueIsDeadLetter,
-		m.QueueHealthScore,
-		m.QueueDepthAlert,
-		m.QueueUtilizationAlert,
+		m.CanarySecondsSinceChange,
+		m.CanaryProducerAlive,
+		m.AMQPProbeSuccess,
+		m.AMQPProbeRoundTripSeconds,
+		m.BrokerEventsTotal,
+		m.EventExchangeConnected,
 		m.ScrapeDurationSeconds,
+		m.CollectionDurationSeconds,
+		m.CollectionPhaseSeconds,
+		m.CacheAgeSeconds,
+		m.CacheStale,
 		m.ScrapeErrorsTotal,
+		m.SnapshotID,
+		m.Up,
+		m.LastScrapeTimestampSeconds,
+		m.CollectionsSkippedTotal,
+		m.CollectionWatchdogRestarts,
+		m.BuildInfo,
+		m.ConfigInfo,
 		m.CircuitBreakerState,
 		m.CircuitBreakerFailures,
+		m.CircuitBreakerManualResets,
+		m.APIRequestDurationSeconds,
 	}
 }
 
-// GetQueueCollectors returns only queue-related metrics for reset operations
-func (m *Metrics) GetQueueCollectors() []prometheus.Collector {
-	return []prometheus.Collector{
+// ObserveAPIRequest records the duration of a management API request
+func (m *Metrics) ObserveAPIRequest(endpoint, code string, duration time.Duration) {
+	m.APIRequestDurationSeconds.WithLabelValues(endpoint, code).Observe(duration.Seconds())
+}
+
+// ObserveAMQPProbe records the outcome of an AMQP probe run. Failed runs keep
+// the previous round trip.
+func (m *Metrics) ObserveAMQPProbe(vhost string, roundTrip time.Duration, err error) {
+	if err != nil {
+		m.AMQPProbeSuccess.WithLabelValues(vhost).Set(0)
+		return
+	}
+	m.AMQPProbeSuccess.WithLabelValues(vhost).Set(1)
+	m.AMQPProbeRoundTripSeconds.WithLabelValues(vhost).Set(roundTrip.Seconds())
+}
+
+// SetBuildInfo records the running exporter build
+func (m *Metrics) SetBuildInfo(version, commit, goVersion string) {
+	m.BuildInfo.Reset()
+	m.BuildInfo.WithLabelValues(version, commit, goVersion).Set(1)
+}
+
+// ConfigInfoLabels are the labels of exporter_config_info. Secrets are never
+// among them.
+var ConfigInfoLabels = []string{
+	"config_file", "config_hash", "rabbitmq_url", "scrape_interval", "timeout",
+	"collection_timeout", "metric_naming", "profile", "collect", "max_concurrent_requests",
+}
+
+// SetConfigInfo records the loaded configuration, with values in
+// ConfigInfoLabels order
+func (m *Metrics) SetConfigInfo(values ...string) {
+	m.ConfigInfo.Reset()
+	m.ConfigInfo.WithLabelValues(values...).Set(1)
+}
+
+// QueueDescs returns the descriptors of the per-queue metrics, which are
+// built as const metrics from the cached queue snapshot on every scrape
+func (m *Metrics) QueueDescs() []*prometheus.Desc {
+	return []*prometheus.Desc{
 		m.QueueMessages,
 		m.QueueMessagesReady,
 		m.QueueMessagesUnacknowledged,
+		m.QueueMemoryBytes,
+		m.QueueMessageBytes,
+		m.QueueMessageBytesReady,
+		m.QueueMessageBytesUnacknowledged,
+		m.QueueMessagesRAM,
+		m.QueueMessagesPersistent,
+		m.QueueMaxLengthRatio,
+		m.QueueMaxLengthBytesRatio,
 		m.QueueMessagePublishRate,
 		m.QueueMessageDeliverRate,
 		m.QueueMessageAckRate,
 		m.QueueMessageRedeliverRate,
+		m.QueueMessagesPublishedTotal,
+		m.QueueMessagesDeliveredTotal,
+		m.QueueMessagesAcknowledgedTotal,
+		m.QueueMessagesRedeliveredTotal,
+		m.QueueMessagesGetTotal,
+		m.QueueEstimatedDrainSeconds,
+		m.QueueIdleSeconds,
+		m.QueueAckRatio,
+		m.QueueRedeliveryRatio,
+		m.QueueNetGrowthRate,
+		m.QueueBacklogGrowing,
 		m.QueueConsumers,
 		m.QueueConsumerUtilisation,
 		m.QueueConsumerCapacity,
 		m.QueueState,
 		m.QueueIsDeadLetter,
+		m.QueueInfo,
+		m.QueuePolicyInfo,
+		m.QueueNodeInfo,
+		m.QueueModeInfo,
+		m.QueueLeaderChangesTotal,
+		m.QueueMaxPriority,
+		m.QueueMessagesByPriority,
+		m.QueueConsumersByAckMode,
+		m.QueueConsumerPrefetch,
+		m.QueueSingleActiveConsumerActive,
+		m.QueueBindings,
+		m.QueueUnbound,
+		m.QueueQuorumLeader,
+		m.QueueQuorumMembers,
+		m.QueueQuorumOnlineMembers,
+		m.QueueQuorumUnderReplicated,
+		m.QueueQuorumOpenFiles,
+		m.QueueStreamCommittedOffset,
+		m.QueueStreamReaders,
+		m.QueueStreamSegments,
 		m.QueueHealthScore,
 		m.QueueDepthAlert,
 		m.QueueUtilizationAlert,
+		m.QueueDepthZScore,
+		m.QueueDepthAnomaly,
 	}
 }
 
-// ResetQueueMetrics resets all queue-related metrics to zero
-func (m *Metrics) ResetQueueMetrics() {
-	collectors := m.GetQueueCollectors()
-	for _, collector := range collectors {
-		if gaugeVec, ok := collector.(*prometheus.GaugeVec); ok {
-			gaugeVec.Reset()
-		}
+// ClusterDescs returns the descriptors of the cluster-wide metrics, which
+// are built from the cached snapshot like the queue metrics
+func (m *Metrics) ClusterDescs() []*prometheus.Desc {
+	return []*prometheus.Desc{
+		m.PolicyQueues,
+		m.ClusterQueues,
+		m.ClusterExchanges,
+		m.ClusterConnections,
+		m.ClusterChannels,
+		m.ClusterConsumers,
+		m.ClusterMessages,
+		m.ClusterMessagesReady,
+		m.ClusterMessagesUnacknowledged,
+		m.ClusterMessagePublishRate,
+		m.ClusterMessageDeliverRate,
+		m.ClusterMessageAckRate,
+		m.ClusterMessageRedeliverRate,
+		m.ClusterMessageConfirmRate,
+		m.ClusterChurnRate,
+		m.ClusterMessagesPublishedTotal,
+		m.ClusterMessagesDeliveredTotal,
+		m.ClusterMessagesAcknowledgedTotal,
+		m.ClusterMessagesRedeliveredTotal,
+		m.ClusterMessagesGetTotal,
+		m.ClusterMessagesConfirmedTotal,
+		m.ClusterMessagesReturnedUnroutableTotal,
+		m.VhostQueues,
+		m.VhostMessages,
+		m.VhostMessagesReady,
+		m.VhostMessagesUnacknowledged,
+		m.VhostConsumers,
+		m.VhostMessagePublishRate,
+		m.VhostMessageDeliverRate,
+		m.VhostQueuesByFlag,
+		m.VhostMessagesByFlag,
+		m.VhostAggregatedQueues,
+		m.VhostMessageConfirmRate,
+		m.VhostMessageUnroutableRate,
+		m.VhostUnroutableAlert,
+		m.QueueDepthDistribution,
+		m.UserConnections,
+		m.UserChannels,
+		m.UserReceiveBytesRate,
+		m.UserSendBytesRate,
+		m.UserPublishRate,
+		m.ConnectionsByProtocol,
+		m.NodeMemAlarm,
+		m.NodeDiskFreeAlarm,
+		m.NodePartitions,
+		m.NodeQueues,
+		m.ClusterNodesRunning,
+		m.ClusterNodesExpected,
+		m.ClusterNodeJoinsTotal,
+		m.ClusterNodeDeparturesTotal,
+		m.DepthSLORatio,
+		m.DepthSLOSamples,
+		m.NodeIOOperations,
+		m.NodeIOBytes,
+		m.NodeIOOperationRate,
+		m.NodeIOAverageTimeSeconds,
+		m.NodeMnesiaTransactions,
+		m.NodeMessageStoreOperations,
+		m.NodeGCRuns,
+		m.NodeGCRunRate,
+		m.NodeGCBytesReclaimed,
+		m.NodeGCBytesReclaimedRate,
+		m.NodeContextSwitches,
+		m.NodeRunQueue,
+		m.AlivenessSuccess,
+		m.AlivenessDurationSeconds,
+		m.HealthCheckPassed,
 	}
 }
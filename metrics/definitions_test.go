@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNewMetricsWithNamespace(t *testing.T) {
+	m := NewMetricsWithNamespace("rmq")
+	if m.Namespace() != "rmq" {
+		t.Errorf("Expected namespace rmq, got %s", m.Namespace())
+	}
+
+	for _, desc := range m.QueueDescs() {
+		if !strings.Contains(desc.String(), `fqName: "rmq_`) {
+			t.Errorf("Expected rmq_ prefix, got %s", desc)
+		}
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(m.GetAllCollectors()...)
+	m.Up.Set(1)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, mf := range families {
+		if !strings.HasPrefix(mf.GetName(), "rmq_") {
+			t.Errorf("Expected rmq_ prefix, got %s", mf.GetName())
+		}
+	}
+}
+
+func TestValidateNamespace(t *testing.T) {
+	for _, ns := range []string{"rabbitmq_custom", "rmq", "_private", "team2"} {
+		if err := ValidateNamespace(ns); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", ns, err)
+		}
+	}
+	for _, ns := range []string{"", "2fast", "rabbit-mq", "rmq:", "rabbit mq"} {
+		if err := ValidateNamespace(ns); err == nil {
+			t.Errorf("Expected %q to be rejected", ns)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Rename records a metric whose exposed name or labels changed between
+// exporter versions
+type Rename struct {
+	// Old is the previous metric name, exported during the transition
+	Old string `mapstructure:"old"`
+	// New is the current metric name
+	New string `mapstructure:"new"`
+	// Labels maps current label names to their previous names
+	Labels map[string]string `mapstructure:"labels"`
+	// Sunset is the date after which the old name is no longer exported
+	Sunset string `mapstructure:"sunset"`
+}
+
+// Renames lists the metric renames made in this release. Entries are kept for
+// one release cycle so transition mode can export the old names.
+var Renames = []Rename{}
+
+// deprecationGatherer re-exports renamed metric families under their old
+// names with a deprecated="true" label
+type deprecationGatherer struct {
+	inner   prometheus.Gatherer
+	renames map[string]Rename
+}
+
+// NewDeprecationGatherer wraps a gatherer so that every family listed in
+// renames is also exported under its old name. Renames without a sunset date
+// use defaultSunset.
+func NewDeprecationGatherer(inner prometheus.Gatherer, renames []Rename, defaultSunset string) prometheus.Gatherer {
+	byNew := make(map[string]Rename, len(renames))
+	for _, r := range renames {
+		if r.Old == "" || r.New == "" || r.Old == r.New {
+			continue
+		}
+		if r.Sunset == "" {
+			r.Sunset = defaultSunset
+		}
+		byNew[r.New] = r
+	}
+	return &deprecationGatherer{inner: inner, renames: byNew}
+}
+
+func (g *deprecationGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.inner.Gather()
+	if len(g.renames) == 0 {
+		return families, err
+	}
+
+	existing := make(map[string]bool, len(families))
+	for _, f := range families {
+		existing[f.GetName()] = true
+	}
+
+	out := families
+	for _, f := range families {
+		rename, ok := g.renames[f.GetName()]
+		if !ok || existing[rename.Old] {
+			continue
+		}
+		out = append(out, deprecatedFamily(f, rename))
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].GetName() < out[j].GetName() })
+	return out, err
+}
+
+func deprecatedFamily(f *dto.MetricFamily, rename Rename) *dto.MetricFamily {
+	help := fmt.Sprintf("%s (DEPRECATED: renamed to %s", f.GetHelp(), rename.New)
+	if rename.Sunset != "" {
+		help += ", removed after " + rename.Sunset
+	}
+	help += ")"
+
+	family := &dto.MetricFamily{
+		Name: proto.String(rename.Old),
+		Help: proto.String(help),
+		Type: f.Type,
+	}
+
+	for _, m := range f.Metric {
+		metric := proto.Clone(m).(*dto.Metric)
+		for _, lp := range metric.Label {
+			if old, ok := rename.Labels[lp.GetName()]; ok {
+				lp.Name = proto.String(old)
+			}
+		}
+		metric.Label = append(metric.Label, &dto.LabelPair{
+			Name:  proto.String("deprecated"),
+			Value: proto.String("true"),
+		})
+		sort.Slice(metric.Label, func(i, j int) bool {
+			return metric.Label[i].GetName() < metric.Label[j].GetName()
+		})
+		family.Metric = append(family.Metric, metric)
+	}
+
+	return family
+}
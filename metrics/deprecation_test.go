@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDeprecationGatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rmq_queue_messages",
+		Help: "Total number of messages in the queue",
+	}, []string{"queue"})
+	registry.MustRegister(gauge)
+	gauge.WithLabelValues("orders").Set(42)
+
+	gatherer := NewDeprecationGatherer(registry, []Rename{
+		{Old: "rabbitmq_custom_queue_messages", New: "rmq_queue_messages", Labels: map[string]string{"queue": "queue_name"}},
+		{Old: "unused_old", New: "unused_new"},
+	}, "2026-01-01")
+
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatalf("Expected gather to succeed, got %v", err)
+	}
+	if len(families) != 2 {
+		t.Fatalf("Expected 2 metric families, got %d", len(families))
+	}
+
+	old := families[0]
+	if old.GetName() != "rabbitmq_custom_queue_messages" {
+		t.Fatalf("Expected families to be sorted with the old name first, got %s", old.GetName())
+	}
+	if !strings.Contains(old.GetHelp(), "DEPRECATED") || !strings.Contains(old.GetHelp(), "2026-01-01") {
+		t.Errorf("Expected help text to mention deprecation and sunset, got %q", old.GetHelp())
+	}
+
+	labels := map[string]string{}
+	for _, lp := range old.Metric[0].Label {
+		labels[lp.GetName()] = lp.GetValue()
+	}
+	if labels["queue_name"] != "orders" || labels["deprecated"] != "true" {
+		t.Errorf("Expected renamed and deprecated labels, got %v", labels)
+	}
+	if old.Metric[0].GetGauge().GetValue() != 42 {
+		t.Errorf("Expected value 42, got %v", old.Metric[0].GetGauge().GetValue())
+	}
+
+	current := families[1]
+	if len(current.Metric[0].Label) != 1 || current.Metric[0].Label[0].GetName() != "queue" {
+		t.Errorf("Expected the current family to be unchanged, got %v", current.Metric[0].Label)
+	}
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"regexp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// labelFilterGatherer drops series whose labels don't match the filters
+type labelFilterGatherer struct {
+	inner   prometheus.Gatherer
+	filters map[string]*regexp.Regexp
+}
+
+// NewLabelFilterGatherer wraps a gatherer so that only series matching every
+// filter are exported. A filter applies only to series that carry its label;
+// series without it, such as the exporter's own metrics, are kept. Families
+// left without series are dropped.
+func NewLabelFilterGatherer(inner prometheus.Gatherer, filters map[string]*regexp.Regexp) prometheus.Gatherer {
+	return &labelFilterGatherer{inner: inner, filters: filters}
+}
+
+func (g *labelFilterGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.inner.Gather()
+	if len(g.filters) == 0 {
+		return families, err
+	}
+
+	filtered := families[:0]
+	for _, f := range families {
+		kept := f.Metric[:0]
+		for _, m := range f.Metric {
+			if g.matches(m) {
+				kept = append(kept, m)
+			}
+		}
+		if len(kept) > 0 {
+			f.Metric = kept
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered, err
+}
+
+func (g *labelFilterGatherer) matches(m *dto.Metric) bool {
+	for _, label := range m.GetLabel() {
+		if re, ok := g.filters[label.GetName()]; ok && !re.MatchString(label.GetValue()) {
+			return false
+		}
+	}
+	return true
+}
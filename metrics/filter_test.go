@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestLabelFilterGatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	messages := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "queue_messages", Help: "test"}, []string{"queue_name", "vhost"})
+	messages.WithLabelValues("orders.created", "prod").Set(1)
+	messages.WithLabelValues("orders.created", "staging").Set(2)
+	messages.WithLabelValues("payments", "prod").Set(3)
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "up", Help: "test"})
+	other := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "staging_only", Help: "test"}, []string{"vhost"})
+	other.WithLabelValues("staging").Set(1)
+	registry.MustRegister(messages, up, other)
+
+	g := NewLabelFilterGatherer(registry, map[string]*regexp.Regexp{
+		"vhost":      regexp.MustCompile(`^(?:prod)$`),
+		"queue_name": regexp.MustCompile(`^(?:orders\..*)$`),
+	})
+
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	byName := make(map[string]int)
+	for _, f := range families {
+		byName[f.GetName()] = len(f.Metric)
+	}
+
+	if byName["queue_messages"] != 1 {
+		t.Errorf("Expected one matching queue series, got %d", byName["queue_messages"])
+	}
+	if byName["up"] != 1 {
+		t.Error("Expected series without filtered labels to be kept")
+	}
+	if _, ok := byName["staging_only"]; ok {
+		t.Error("Expected families without matching series to be dropped")
+	}
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Naming schemes select the names metrics are exposed under
+const (
+	// NamingNative exposes the exporter's own names
+	NamingNative = "native"
+	// NamingKbudde exposes kbudde/rabbitmq_exporter names where an equivalent
+	// exists, and native names for everything else
+	NamingKbudde = "kbudde"
+	// NamingBoth exposes mapped metrics under both names
+	NamingBoth = "both"
+)
+
+// NameMapping maps one of the exporter's metrics to the name it is exposed
+// under by another exporter
+type NameMapping struct {
+	// Suffix is the metric name without the namespace
+	Suffix string
+	// Name is the exposed name
+	Name string
+	// Labels maps the exporter's label names to the exposed ones
+	Labels map[string]string
+}
+
+var kbuddeQueueLabels = map[string]string{"queue_name": "queue"}
+
+// KbuddeNames maps the exporter's metrics to their kbudde/rabbitmq_exporter
+// equivalents. Rates have no counterpart there, since kbudde exports
+// counters, so they keep their native names; the message counters map.
+var KbuddeNames = []NameMapping{
+	{Suffix: "up", Name: "rabbitmq_up"},
+	{Suffix: "queue_messages", Name: "rabbitmq_queue_messages", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_messages_ready", Name: "rabbitmq_queue_messages_ready", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_messages_unacknowledged", Name: "rabbitmq_queue_messages_unacknowledged", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_messages_ram", Name: "rabbitmq_queue_messages_ram", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_messages_persistent", Name: "rabbitmq_queue_messages_persistent", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_message_bytes", Name: "rabbitmq_queue_message_bytes", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_message_bytes_ready", Name: "rabbitmq_queue_message_bytes_ready", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_message_bytes_unacknowledged", Name: "rabbitmq_queue_message_bytes_unacknowledged", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_memory_bytes", Name: "rabbitmq_queue_memory", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_consumers", Name: "rabbitmq_queue_consumers", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_consumer_utilisation", Name: "rabbitmq_queue_consumer_utilisation", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_messages_published_total", Name: "rabbitmq_queue_messages_published_total", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_messages_delivered_total", Name: "rabbitmq_queue_messages_delivered_total", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_messages_acknowledged_total", Name: "rabbitmq_queue_messages_ack_total", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_messages_redelivered_total", Name: "rabbitmq_queue_messages_redelivered_total", Labels: kbuddeQueueLabels},
+	{Suffix: "queue_messages_get_total", Name: "rabbitmq_queue_messages_get_total", Labels: kbuddeQueueLabels},
+}
+
+// ValidateNaming checks that scheme is a known naming scheme
+func ValidateNaming(scheme string) error {
+	switch scheme {
+	case NamingNative, NamingKbudde, NamingBoth:
+		return nil
+	}
+	return fmt.Errorf("invalid metric naming %q (expected %s, %s or %s)", scheme, NamingNative, NamingKbudde, NamingBoth)
+}
+
+// QueueLabelNames returns the names the queue label is exposed under by
+// scheme, both of them under NamingBoth
+func QueueLabelNames(scheme string) []string {
+	switch scheme {
+	case NamingKbudde:
+		return []string{kbuddeQueueLabels["queue_name"]}
+	case NamingBoth:
+		return []string{"queue_name", kbuddeQueueLabels["queue_name"]}
+	}
+	return []string{"queue_name"}
+}
+
+// namingGatherer exposes metric families under mapped names
+type namingGatherer struct {
+	inner        prometheus.Gatherer
+	mappings     map[string]NameMapping
+	keepOriginal bool
+}
+
+// NewNamingGatherer wraps a gatherer so that metrics are exposed according
+// to scheme. Metric names are looked up under namespace. The native scheme
+// returns inner unchanged.
+func NewNamingGatherer(inner prometheus.Gatherer, namespace, scheme string) (prometheus.Gatherer, error) {
+	if err := ValidateNaming(scheme); err != nil {
+		return nil, err
+	}
+	if scheme == NamingNative {
+		return inner, nil
+	}
+
+	byName := make(map[string]NameMapping, len(KbuddeNames))
+	for _, m := range KbuddeNames {
+		byName[prometheus.BuildFQName(namespace, "", m.Suffix)] = m
+	}
+	return &namingGatherer{inner: inner, mappings: byName, keepOriginal: scheme == NamingBoth}, nil
+}
+
+func (g *namingGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.inner.Gather()
+
+	existing := make(map[string]bool, len(families))
+	for _, f := range families {
+		existing[f.GetName()] = true
+	}
+
+	out := make([]*dto.MetricFamily, 0, len(families))
+	for _, f := range families {
+		mapping, ok := g.mappings[f.GetName()]
+		// A family already exposed under the mapped name, e.g. when the
+		// namespace is "rabbitmq", is left alone rather than duplicated
+		if !ok || existing[mapping.Name] {
+			out = append(out, f)
+			continue
+		}
+		if g.keepOriginal {
+			out = append(out, f)
+		}
+		out = append(out, mappedFamily(f, mapping))
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].GetName() < out[j].GetName() })
+	return out, err
+}
+
+func mappedFamily(f *dto.MetricFamily, mapping NameMapping) *dto.MetricFamily {
+	family := &dto.MetricFamily{
+		Name: proto.String(mapping.Name),
+		Help: f.Help,
+		Type: f.Type,
+	}
+
+	for _, m := range f.Metric {
+		metric := proto.Clone(m).(*dto.Metric)
+		for _, lp := range metric.Label {
+			if name, ok := mapping.Labels[lp.GetName()]; ok {
+				lp.Name = proto.String(name)
+			}
+		}
+		sort.Slice(metric.Label, func(i, j int) bool {
+			return metric.Label[i].GetName() < metric.Label[j].GetName()
+		})
+		family.Metric = append(family.Metric, metric)
+	}
+
+	return family
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func namingTestRegistry(t *testing.T) *prometheus.Registry {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	messages := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rabbitmq_custom_queue_messages_ready",
+		Help: "Number of messages ready to be delivered",
+	}, []string{"queue_name", "vhost"})
+	rate := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rabbitmq_custom_queue_message_publish_rate",
+		Help: "Rate of messages published to the queue",
+	}, []string{"queue_name", "vhost"})
+	registry.MustRegister(messages, rate)
+	messages.WithLabelValues("orders", "/").Set(7)
+	rate.WithLabelValues("orders", "/").Set(1.5)
+	return registry
+}
+
+func familyNames(t *testing.T, gatherer prometheus.Gatherer) map[string]bool {
+	t.Helper()
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	names := make(map[string]bool, len(families))
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	return names
+}
+
+func TestNamingGatherer_Kbudde(t *testing.T) {
+	gatherer, err := NewNamingGatherer(namingTestRegistry(t), DefaultNamespace, NamingKbudde)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(families) != 2 {
+		t.Fatalf("Expected 2 families, got %d", len(families))
+	}
+
+	var ready bool
+	for _, f := range families {
+		switch f.GetName() {
+		case "rabbitmq_queue_messages_ready":
+			ready = true
+			labels := map[string]string{}
+			for _, lp := range f.Metric[0].Label {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			if labels["queue"] != "orders" || labels["vhost"] != "/" {
+				t.Errorf("Expected queue and vhost labels, got %v", labels)
+			}
+			if _, ok := labels["queue_name"]; ok {
+				t.Error("Expected queue_name to be renamed")
+			}
+			if f.Metric[0].GetGauge().GetValue() != 7 {
+				t.Errorf("Expected value 7, got %v", f.Metric[0].GetGauge().GetValue())
+			}
+		case "rabbitmq_custom_queue_message_publish_rate":
+			// Unmapped metrics keep their native names
+		default:
+			t.Errorf("Unexpected family %s", f.GetName())
+		}
+	}
+	if !ready {
+		t.Error("Expected rabbitmq_queue_messages_ready")
+	}
+}
+
+func TestNamingGatherer_Both(t *testing.T) {
+	gatherer, err := NewNamingGatherer(namingTestRegistry(t), DefaultNamespace, NamingBoth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := familyNames(t, gatherer)
+	for _, name := range []string{
+		"rabbitmq_queue_messages_ready",
+		"rabbitmq_custom_queue_messages_ready",
+		"rabbitmq_custom_queue_message_publish_rate",
+	} {
+		if !names[name] {
+			t.Errorf("Expected %s, got %v", name, names)
+		}
+	}
+}
+
+func TestNamingGatherer_Native(t *testing.T) {
+	registry := namingTestRegistry(t)
+	gatherer, err := NewNamingGatherer(registry, DefaultNamespace, NamingNative)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gatherer != prometheus.Gatherer(registry) {
+		t.Error("Expected the native scheme to return the inner gatherer")
+	}
+}
+
+func TestNamingGatherer_Invalid(t *testing.T) {
+	if _, err := NewNamingGatherer(prometheus.NewRegistry(), DefaultNamespace, "classic"); err == nil {
+		t.Error("Expected an unknown scheme to be rejected")
+	}
+}
+
+func TestNamingGatherer_KbuddeCounters(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	acked := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rabbitmq_custom_queue_messages_acknowledged_total",
+		Help: "Total number of messages acknowledged",
+	}, []string{"queue_name", "vhost"})
+	registry.MustRegister(acked)
+	acked.WithLabelValues("orders", "/").Add(3)
+
+	gatherer, err := NewNamingGatherer(registry, DefaultNamespace, NamingKbudde)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := familyNames(t, gatherer)
+	if !names["rabbitmq_queue_messages_ack_total"] || len(names) != 1 {
+		t.Errorf("Expected the counter under its kbudde name, got %v", names)
+	}
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// CounterState is the serializable form of the exporter's persistent counters
+type CounterState struct {
+	Counters map[string][]CounterSample `json:"counters"`
+}
+
+// CounterSample is a single labelled counter value
+type CounterSample struct {
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// persistentCounters returns the counters that survive exporter restarts, keyed
+// by a stable identifier that does not depend on the exposed metric name
+func (m *Metrics) persistentCounters() map[string]*prometheus.CounterVec {
+	return map[string]*prometheus.CounterVec{
+		"scrape_errors_total":                 m.ScrapeErrorsTotal,
+		"circuit_breaker_failures_total":      m.CircuitBreakerFailures,
+		"circuit_breaker_manual_resets_total": m.CircuitBreakerManualResets,
+		"broker_events_total":                 m.BrokerEventsTotal,
+		"collections_skipped_total":           m.CollectionsSkippedTotal,
+	}
+}
+
+// SnapshotCounters captures the current value of every persistent counter
+func (m *Metrics) SnapshotCounters() CounterState {
+	state := CounterState{Counters: make(map[string][]CounterSample)}
+
+	for key, vec := range m.persistentCounters() {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			vec.Collect(ch)
+			close(ch)
+		}()
+
+		samples := []CounterSample{}
+		for metric := range ch {
+			var pb dto.Metric
+			if err := metric.Write(&pb); err != nil || pb.Counter == nil {
+				continue
+			}
+			labels := make(map[string]string, len(pb.Label))
+			for _, lp := range pb.Label {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			samples = append(samples, CounterSample{Labels: labels, Value: pb.Counter.GetValue()})
+		}
+		state.Counters[key] = samples
+	}
+
+	return state
+}
+
+// RestoreCounters adds previously persisted values back onto the persistent
+// counters. Samples for unknown counters or with mismatched labels are skipped.
+func (m *Metrics) RestoreCounters(state CounterState) {
+	counters := m.persistentCounters()
+	for key, samples := range state.Counters {
+		vec, ok := counters[key]
+		if !ok {
+			continue
+		}
+		for _, sample := range samples {
+			if sample.Value <= 0 {
+				continue
+			}
+			counter, err := vec.GetMetricWith(prometheus.Labels(sample.Labels))
+			if err != nil {
+				continue
+			}
+			counter.Add(sample.Value)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package notifier sends alerts to an Alertmanager or to a generic webhook,
+// for sites where no Prometheus evaluates the exporter's metrics.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Formats of the requests a Client sends
+const (
+	// FormatAlertmanager posts alerts to the Alertmanager v2 API
+	FormatAlertmanager = "alertmanager"
+	// FormatWebhook posts alerts in the payload Alertmanager sends to its
+	// webhook receivers
+	FormatWebhook = "webhook"
+)
+
+// Config describes where alerts are sent
+type Config struct {
+	URL     string            `mapstructure:"url"`
+	Format  string            `mapstructure:"format"`
+	Headers map[string]string `mapstructure:"headers"`
+	Timeout time.Duration     `mapstructure:"timeout"`
+}
+
+// Enabled reports whether a notifier has been configured
+func (c Config) Enabled() bool {
+	return c.URL != ""
+}
+
+// Alert is a firing or resolved alert. Its labels identify it.
+type Alert struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	StartsAt    time.Time
+	// EndsAt is set once the alert is resolved
+	EndsAt time.Time
+}
+
+// Resolved reports whether the alert has ended
+func (a Alert) Resolved() bool {
+	return !a.EndsAt.IsZero()
+}
+
+// Fingerprint identifies the alert by its sorted labels
+func (a Alert) Fingerprint() string {
+	names := make([]string, 0, len(a.Labels))
+	for name := range a.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(a.Labels[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// alertmanagerAlert is an alert in the Alertmanager v2 API
+type alertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      *time.Time        `json:"endsAt,omitempty"`
+}
+
+// webhookMessage is the payload Alertmanager sends to webhook receivers
+type webhookMessage struct {
+	Version  string         `json:"version"`
+	Status   string         `json:"status"`
+	Receiver string         `json:"receiver"`
+	Alerts   []webhookAlert `json:"alerts"`
+}
+
+type webhookAlert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+// Client sends alerts over HTTP
+type Client struct {
+	url        string
+	format     string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+func NewClient(cfg Config) (*Client, error) {
+	format := cfg.Format
+	if format == "" {
+		format = FormatAlertmanager
+	}
+	if format != FormatAlertmanager && format != FormatWebhook {
+		return nil, fmt.Errorf("unknown notifier format %q, want %q or %q", cfg.Format, FormatAlertmanager, FormatWebhook)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	url := strings.TrimRight(cfg.URL, "/")
+	if format == FormatAlertmanager {
+		url += "/api/v2/alerts"
+	}
+	return &Client{
+		url:        url,
+		format:     format,
+		headers:    cfg.Headers,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Format returns the format of the requests the client sends
+func (c *Client) Format() string {
+	return c.format
+}
+
+// Send posts alerts in a single request
+func (c *Client) Send(ctx context.Context, alerts []Alert) error {
+	var payload []byte
+	var err error
+	if c.format == FormatWebhook {
+		payload, err = json.Marshal(newWebhookMessage(alerts))
+	} else {
+		payload, err = json.Marshal(newAlertmanagerAlerts(alerts))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal alerts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("notification request failed with HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func newAlertmanagerAlerts(alerts []Alert) []alertmanagerAlert {
+	out := make([]alertmanagerAlert, len(alerts))
+	for i, a := range alerts {
+		out[i] = alertmanagerAlert{Labels: a.Labels, Annotations: a.Annotations, StartsAt: a.StartsAt}
+		if a.Resolved() {
+			endsAt := a.EndsAt
+			out[i].EndsAt = &endsAt
+		}
+	}
+	return out
+}
+
+func newWebhookMessage(alerts []Alert) webhookMessage {
+	msg := webhookMessage{Version: "4", Status: "resolved", Receiver: "rabbitmq-exporter"}
+	for _, a := range alerts {
+		status := "resolved"
+		if !a.Resolved() {
+			status = "firing"
+			msg.Status = "firing"
+		}
+		msg.Alerts = append(msg.Alerts, webhookAlert{
+			Status:      status,
+			Labels:      a.Labels,
+			Annotations: a.Annotations,
+			StartsAt:    a.StartsAt,
+			EndsAt:      a.EndsAt,
+		})
+	}
+	return msg
+}
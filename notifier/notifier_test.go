@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_SendAlertmanager(t *testing.T) {
+	var received []map[string]interface{}
+	var authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/alerts" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		authHeader = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL + "/", Headers: map[string]string{"Authorization": "Bearer token"}})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	start := time.Unix(1700000000, 0).UTC()
+	err = client.Send(context.Background(), []Alert{
+		{Labels: map[string]string{"alertname": "A"}, StartsAt: start},
+		{Labels: map[string]string{"alertname": "B"}, StartsAt: start, EndsAt: start.Add(time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("Expected alerts to be sent, got %v", err)
+	}
+
+	if authHeader != "Bearer token" {
+		t.Errorf("Expected configured headers, got authorization '%s'", authHeader)
+	}
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 alerts, got %v", received)
+	}
+	if _, ok := received[0]["endsAt"]; ok {
+		t.Errorf("Expected no endsAt on a firing alert, got %v", received[0])
+	}
+	if received[1]["endsAt"] != "2023-11-14T22:14:20Z" {
+		t.Errorf("Expected endsAt on a resolved alert, got %v", received[1])
+	}
+}
+
+func TestClient_SendWebhook(t *testing.T) {
+	var received webhookMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/hook" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL + "/hook", Format: FormatWebhook})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	now := time.Now()
+	err = client.Send(context.Background(), []Alert{
+		{Labels: map[string]string{"alertname": "A"}, StartsAt: now, EndsAt: now},
+	})
+	if err != nil {
+		t.Fatalf("Expected alerts to be sent, got %v", err)
+	}
+	if received.Status != "resolved" || len(received.Alerts) != 1 || received.Alerts[0].Status != "resolved" {
+		t.Errorf("Expected a resolved webhook message, got %+v", received)
+	}
+}
+
+func TestClient_SendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad alerts", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(Config{URL: server.URL})
+	if err := client.Send(context.Background(), []Alert{{Labels: map[string]string{"alertname": "A"}}}); err == nil {
+		t.Error("Expected error for non-2xx response")
+	}
+}
+
+func TestNewClient_InvalidFormat(t *testing.T) {
+	if _, err := NewClient(Config{URL: "http://localhost", Format: "slack"}); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestAlert_Fingerprint(t *testing.T) {
+	a := Alert{Labels: map[string]string{"alertname": "A", "queue_name": "orders"}}
+	b := Alert{Labels: map[string]string{"queue_name": "orders", "alertname": "A"}, Annotations: map[string]string{"summary": "x"}}
+	c := Alert{Labels: map[string]string{"alertname": "A", "queue_name": "jobs"}}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("Expected alerts with the same labels to share a fingerprint")
+	}
+	if a.Fingerprint() == c.Fingerprint() {
+		t.Error("Expected alerts with different labels to differ")
+	}
+}
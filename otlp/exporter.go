@@ -0,0 +1,163 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	prometheusbridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// Config describes the OTLP collector metrics are pushed to
+type Config struct {
+	// Endpoint is host:port, or a full URL such as https://collector:4318/v1/metrics
+	Endpoint string            `mapstructure:"endpoint"`
+	Protocol string            `mapstructure:"protocol"`
+	Insecure bool              `mapstructure:"insecure"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Timeout  time.Duration     `mapstructure:"timeout"`
+}
+
+// Enabled reports whether OTLP export has been configured
+func (c Config) Enabled() bool {
+	return c.Endpoint != ""
+}
+
+// Exporter pushes the metrics of a Prometheus gatherer to an OTLP collector.
+// Pushes run on a background worker; Notify never blocks.
+type Exporter struct {
+	exporter sdkmetric.Exporter
+	producer sdkmetric.Producer
+	resource *resource.Resource
+	timeout  time.Duration
+
+	notify chan struct{}
+	done   chan struct{}
+}
+
+// New creates an exporter for cfg that converts the metrics of gatherer.
+// serviceVersion is reported as the service.version resource attribute.
+func New(ctx context.Context, cfg Config, gatherer prometheus.Gatherer, serviceVersion string) (*Exporter, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	exporter, err := newMetricExporter(ctx, cfg, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", "rabbitmq-exporter"),
+		attribute.String("service.version", serviceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OTLP resource: %w", err)
+	}
+
+	e := &Exporter{
+		exporter: exporter,
+		producer: prometheusbridge.NewMetricProducer(prometheusbridge.WithGatherer(gatherer)),
+		resource: res,
+		timeout:  timeout,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+func newMetricExporter(ctx context.Context, cfg Config, timeout time.Duration) (sdkmetric.Exporter, error) {
+	isURL := strings.Contains(cfg.Endpoint, "://")
+
+	switch strings.ToLower(cfg.Protocol) {
+	case "", ProtocolGRPC:
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithTimeout(timeout)}
+		if isURL {
+			opts = append(opts, otlpmetricgrpc.WithEndpointURL(cfg.Endpoint))
+		} else {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	case ProtocolHTTP:
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithTimeout(timeout)}
+		if isURL {
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(cfg.Endpoint))
+		} else {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("invalid OTLP protocol %q (expected grpc or http)", cfg.Protocol)
+	}
+}
+
+// Push converts the current metrics and exports them synchronously
+func (e *Exporter) Push(ctx context.Context) error {
+	scopeMetrics, err := e.producer.Produce(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to convert metrics: %w", err)
+	}
+
+	return e.exporter.Export(ctx, &metricdata.ResourceMetrics{
+		Resource:     e.resource,
+		ScopeMetrics: scopeMetrics,
+	})
+}
+
+// Notify schedules a push. Notifications that arrive while a push is already
+// pending are coalesced.
+func (e *Exporter) Notify() {
+	select {
+	case e.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+
+	for range e.notify {
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		err := e.Push(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to push OTLP metrics: %v", err)
+		}
+	}
+}
+
+// Close waits for a pending push and shuts down the underlying exporter
+func (e *Exporter) Close(ctx context.Context) error {
+	close(e.notify)
+	<-e.done
+	return e.exporter.Shutdown(ctx)
+}
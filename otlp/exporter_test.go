@@ -0,0 +1,98 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestExporter_PushHTTP(t *testing.T) {
+	received := make(chan *collectormetrics.ExportMetricsServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Scope-OrgID"); got != "tenant-a" {
+			t.Errorf("Expected tenant header, got %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		req := &collectormetrics.ExportMetricsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			t.Errorf("Failed to decode OTLP request: %v", err)
+		}
+		received <- req
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rabbitmq_custom_queue_messages", Help: "test"}, []string{"queue_name"})
+	gauge.WithLabelValues("orders").Set(42)
+	registry.MustRegister(gauge)
+
+	cfg := Config{
+		Endpoint: server.URL + "/v1/metrics",
+		Protocol: ProtocolHTTP,
+		Headers:  map[string]string{"X-Scope-OrgID": "tenant-a"},
+		Timeout:  time.Second,
+	}
+	exporter, err := New(context.Background(), cfg, registry, "test")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer exporter.Close(context.Background())
+
+	exporter.Notify()
+
+	select {
+	case req := <-received:
+		rm := req.GetResourceMetrics()
+		if len(rm) != 1 || len(rm[0].GetScopeMetrics()) != 1 {
+			t.Fatalf("Unexpected resource metrics: %v", rm)
+		}
+		metrics := rm[0].GetScopeMetrics()[0].GetMetrics()
+		if len(metrics) != 1 || metrics[0].GetName() != "rabbitmq_custom_queue_messages" {
+			t.Fatalf("Unexpected metrics: %v", metrics)
+		}
+		if v := metrics[0].GetGauge().GetDataPoints()[0].GetAsDouble(); v != 42 {
+			t.Errorf("Expected gauge value 42, got %v", v)
+		}
+
+		found := false
+		for _, attr := range rm[0].GetResource().GetAttributes() {
+			if attr.GetKey() == "service.name" && attr.GetValue().GetStringValue() == "rabbitmq-exporter" {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("Expected service.name resource attribute")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for OTLP push")
+	}
+}
+
+func TestNew_InvalidProtocol(t *testing.T) {
+	_, err := New(context.Background(), Config{Endpoint: "localhost:4317", Protocol: "udp"}, prometheus.NewRegistry(), "test")
+	if err == nil || !strings.Contains(err.Error(), "invalid OTLP protocol") {
+		t.Errorf("Expected invalid protocol error, got %v", err)
+	}
+}
+
+func TestConfig_Enabled(t *testing.T) {
+	if (Config{}).Enabled() {
+		t.Error("Expected empty config to be disabled")
+	}
+	if !(Config{Endpoint: "localhost:4317"}).Enabled() {
+		t.Error("Expected config with endpoint to be enabled")
+	}
+}
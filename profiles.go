@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Profile is a named preset of settings tuned for a cluster size. Profile
+// settings are applied as defaults, so values from flags, the environment or
+// the config file still take precedence.
+type Profile struct {
+	Description string
+	Settings    map[string]interface{}
+}
+
+// Profiles lists the built-in presets selectable with --profile
+var Profiles = map[string]Profile{
+	"small": {
+		Description: "up to a few hundred queues; frequent collection",
+		Settings: map[string]interface{}{
+			"scrape_interval":               15 * time.Second,
+			"timeout":                       10 * time.Second,
+			"state_save_interval":           time.Minute,
+			"circuit_breaker_max_failures":  5,
+			"circuit_breaker_reset_timeout": 30 * time.Second,
+			"grafana_annotations.mass_queue_deletion_threshold": 20,
+		},
+	},
+	"medium": {
+		Description: "a few thousand queues; relaxed collection interval",
+		Settings: map[string]interface{}{
+			"scrape_interval":               30 * time.Second,
+			"timeout":                       20 * time.Second,
+			"state_save_interval":           2 * time.Minute,
+			"circuit_breaker_max_failures":  5,
+			"circuit_breaker_reset_timeout": time.Minute,
+			"grafana_annotations.mass_queue_deletion_threshold": 100,
+		},
+	},
+	"huge": {
+		Description: "tens of thousands of queues; slow collection and generous timeouts",
+		Settings: map[string]interface{}{
+			"scrape_interval":               60 * time.Second,
+			"timeout":                       30 * time.Second,
+			"state_save_interval":           5 * time.Minute,
+			"circuit_breaker_max_failures":  3,
+			"circuit_breaker_reset_timeout": 3 * time.Minute,
+			"grafana_annotations.mass_queue_deletion_threshold": 1000,
+		},
+	},
+}
+
+// profileNames returns the built-in profile names in sorted order
+func profileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile registers the settings of the named profile as defaults on v.
+// An empty name leaves the built-in defaults untouched.
+func applyProfile(v *viper.Viper, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	profile, ok := Profiles[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (expected one of %s)", name, strings.Join(profileNames(), ", "))
+	}
+
+	for key, value := range profile.Settings {
+		v.SetDefault(key, value)
+	}
+	return nil
+}
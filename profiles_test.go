@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"rabbitmq-exporter/exporter"
+)
+
+func TestApplyProfile(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader("timeout: 5s\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyProfile(v, "huge"); err != nil {
+		t.Fatalf("applyProfile failed: %v", err)
+	}
+
+	if got := v.GetDuration("scrape_interval"); got != 60*time.Second {
+		t.Errorf("Expected profile scrape interval 60s, got %v", got)
+	}
+	if got := v.GetDuration("timeout"); got != 5*time.Second {
+		t.Errorf("Expected config file timeout 5s to override the profile, got %v", got)
+	}
+	if got := v.GetInt("grafana_annotations.mass_queue_deletion_threshold"); got != 1000 {
+		t.Errorf("Expected profile mass deletion threshold 1000, got %d", got)
+	}
+
+	var cfg exporter.Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ScrapeInterval != 60*time.Second || cfg.GrafanaAnnotations.MassQueueDeletionThreshold != 1000 {
+		t.Errorf("Unexpected unmarshaled config: %+v", cfg)
+	}
+}
+
+func TestApplyProfile_Unknown(t *testing.T) {
+	err := applyProfile(viper.New(), "gigantic")
+	if err == nil || !strings.Contains(err.Error(), "huge, medium, small") {
+		t.Errorf("Expected unknown profile error listing profiles, got %v", err)
+	}
+	if err := applyProfile(viper.New(), ""); err != nil {
+		t.Errorf("Expected no error without a profile, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package rabbitmq
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCircuitBreakerMaxFailures  = 5
+	DefaultCircuitBreakerResetTimeout = 60 * time.Second
+)
+
+// Management API endpoints, each guarded by its own circuit breaker
+const (
+	EndpointQueues           = "queues"
+	EndpointNodes            = "nodes"
+	EndpointBindings         = "bindings"
+	EndpointOverview         = "overview"
+	EndpointPolicies         = "policies"
+	EndpointOperatorPolicies = "operator_policies"
+	EndpointAliveness        = "aliveness"
+	EndpointHealthChecks     = "health_checks"
+	EndpointChannels         = "channels"
+	EndpointConsumers        = "consumers"
+	EndpointConnections      = "connections"
+)
+
+var endpointPaths = map[string]string{
+	EndpointQueues:           "/api/queues",
+	EndpointNodes:            "/api/nodes",
+	EndpointBindings:         "/api/bindings",
+	EndpointOverview:         "/api/overview",
+	EndpointPolicies:         "/api/policies",
+	EndpointOperatorPolicies: "/api/operator-policies",
+	EndpointAliveness:        "/api/aliveness-test",
+	EndpointHealthChecks:     "/api/health/checks",
+	EndpointChannels:         "/api/channels",
+	EndpointConsumers:        "/api/consumers",
+	EndpointConnections:      "/api/connections",
+}
+
+// BreakerStatus is a point-in-time view of one endpoint's circuit breaker
+type BreakerStatus struct {
+	Open bool
+	// Failures is the number of consecutive failures since the last success
+	Failures int
+	// TotalFailures counts every failure since the client was created
+	TotalFailures uint64
+	LastFailure   time.Time
+}
+
+// circuitBreaker stops requests to an endpoint after maxFailures consecutive
+// failures and allows them again once resetTimeout has passed
+type circuitBreaker struct {
+	mu           sync.Mutex
+	maxFailures  int
+	resetTimeout time.Duration
+
+	open          bool
+	failures      int
+	totalFailures uint64
+	lastFailure   time.Time
+}
+
+func newCircuitBreaker(maxFailures int, resetTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{maxFailures: maxFailures, resetTimeout: resetTimeout}
+}
+
+// isOpen reports whether requests should be rejected, closing the breaker
+// once the reset timeout has passed
+func (b *circuitBreaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return false
+	}
+
+	if time.Since(b.lastFailure) > b.resetTimeout {
+		b.open = false
+		b.failures = 0
+		return false
+	}
+
+	return true
+}
+
+func (b *circuitBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.totalFailures++
+	b.lastFailure = time.Now()
+
+	if b.failures >= b.maxFailures {
+		b.open = true
+	}
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.open = false
+}
+
+func (b *circuitBreaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return BreakerStatus{
+		Open:          b.open,
+		Failures:      b.failures,
+		TotalFailures: b.totalFailures,
+		LastFailure:   b.lastFailure,
+	}
+}
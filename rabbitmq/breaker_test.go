@@ -0,0 +1,76 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := newCircuitBreaker(2, 50*time.Millisecond)
+
+	b.recordFailure()
+	if b.isOpen() {
+		t.Fatal("Expected breaker to stay closed below the failure threshold")
+	}
+	b.recordFailure()
+	if !b.isOpen() {
+		t.Fatal("Expected breaker to open at the failure threshold")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if b.isOpen() {
+		t.Error("Expected breaker to close after the reset timeout")
+	}
+
+	status := b.status()
+	if status.Failures != 0 || status.TotalFailures != 2 {
+		t.Errorf("Unexpected status after reset: %+v", status)
+	}
+}
+
+func TestClient_PerEndpointCircuitBreakers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/bindings" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second, WithCircuitBreaker(2, time.Hour))
+
+	for i := 0; i < 2; i++ {
+		client.GetBindings(context.Background())
+	}
+	if _, err := client.GetBindings(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected bindings breaker to be open, got %v", err)
+	}
+	if _, err := client.GetQueues(context.Background()); err != nil {
+		t.Errorf("Expected queues endpoint to be unaffected, got %v", err)
+	}
+
+	statuses := client.CircuitBreakerStatus()
+	if !statuses[EndpointBindings].Open || statuses[EndpointQueues].Open {
+		t.Errorf("Unexpected breaker statuses: %+v", statuses)
+	}
+	if open, failures, _ := client.GetCircuitBreakerStatus(); !open || failures != 2 {
+		t.Errorf("Expected aggregate status open with 2 failures, got %v %d", open, failures)
+	}
+
+	client.ResetCircuitBreaker()
+	if client.CircuitBreakerStatus()[EndpointBindings].Open {
+		t.Error("Expected reset to close the bindings breaker")
+	}
+}
+
+func TestWithCircuitBreaker_Defaults(t *testing.T) {
+	client := NewClient("", "", "", 0, WithCircuitBreaker(0, 0))
+	if client.maxFailures != DefaultCircuitBreakerMaxFailures || client.resetTimeout != DefaultCircuitBreakerResetTimeout {
+		t.Errorf("Expected defaults to be kept, got %d/%v", client.maxFailures, client.resetTimeout)
+	}
+}
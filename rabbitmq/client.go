@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,20 +19,211 @@ type Client struct {
 	username   string
 	password   string
 	httpClient *http.Client
-	mu         sync.RWMutex
 
-	// Circuit breaker state
-	failureCount    int
-	lastFailureTime time.Time
-	circuitOpen     bool
+	// Circuit breakers, one per management API endpoint
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+
+	// observeRequest, when set, is told the duration of every request
+	observeRequest RequestObserver
+
+	// requests holds a slot for every request in flight, bounding how many
+	// run concurrently
+	requests chan struct{}
+
+	// headers are added to every request, after the client's own
+	headers http.Header
+
+	// backoffUntil is when the broker's last Retry-After expires, in Unix
+	// nanoseconds. Requests fail fast until then.
+	backoffUntil  atomic.Int64
+	maxRetryAfter time.Duration
+
+	// ratesQuery asks for rates averaged over a window, e.g.
+	// "msg_rates_age=60&msg_rates_incr=60"
+	ratesQuery string
 
 	// Configuration
-	maxFailures    int
-	resetTimeout   time.Duration
-	requestTimeout time.Duration
+	maxFailures        int
+	resetTimeout       time.Duration
+	requestTimeout     time.Duration
+	dialTimeout        time.Duration
+	healthCheckTimeout time.Duration
+	endpointTimeouts   map[string]time.Duration
+}
+
+const (
+	DefaultDialTimeout           = 30 * time.Second
+	DefaultMaxConcurrentRequests = 4
+	// DefaultMaxRetryAfter caps how long a Retry-After header can pause
+	// requests, so a bogus value can't stop collection for hours
+	DefaultMaxRetryAfter = 5 * time.Minute
+)
+
+// Timeouts refines the request timeout passed to NewClient. Zero values keep
+// the defaults.
+type Timeouts struct {
+	// Dial bounds establishing a connection to the management API
+	Dial time.Duration `mapstructure:"dial"`
+	// ResponseHeader bounds the wait for response headers once a request
+	// has been sent. By default only the request timeout applies.
+	ResponseHeader time.Duration `mapstructure:"response_header"`
+	// HealthCheck replaces the request timeout for HealthCheck
+	HealthCheck time.Duration `mapstructure:"health_check"`
+	// Endpoints replaces the request timeout per endpoint, e.g. for the
+	// queues endpoint of a large cluster
+	Endpoints map[string]time.Duration `mapstructure:"endpoints"`
+}
+
+// Validate rejects negative timeouts and unknown endpoint names
+func (t Timeouts) Validate() error {
+	if t.Dial < 0 || t.ResponseHeader < 0 || t.HealthCheck < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	for endpoint, timeout := range t.Endpoints {
+		if _, ok := endpointPaths[endpoint]; !ok {
+			return fmt.Errorf("unknown endpoint %q in timeouts", endpoint)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("timeout for endpoint %s must not be negative", endpoint)
+		}
+	}
+	return nil
+}
+
+// ClientOption customizes a Client at construction time
+type ClientOption func(*Client)
+
+// WithDialContext replaces the dialer used for management API connections,
+// e.g. to route requests through an SSH tunnel. Proxy environment variables
+// are ignored from then on: the dialer already knows how to reach the broker.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return func(c *Client) {
+		if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
+			transport.DialContext = dial
+			transport.Proxy = nil
+		}
+	}
+}
+
+// WithHeaders adds headers to every management API request, e.g. the
+// identity or token an authenticating proxy in front of the broker expects.
+// They replace the client's own headers of the same name, Authorization
+// included.
+func WithHeaders(headers map[string]string) ClientOption {
+	return func(c *Client) {
+		for name, value := range headers {
+			if c.headers == nil {
+				c.headers = make(http.Header)
+			}
+			c.headers.Set(name, value)
+		}
+	}
+}
+
+// RequestObserver receives the duration of a management API request. code
+// is the HTTP status code, or "error" when no response was received.
+type RequestObserver func(endpoint, code string, duration time.Duration)
+
+// WithRequestObserver reports every management API request, including
+// retries, to observe
+func WithRequestObserver(observe RequestObserver) ClientOption {
+	return func(c *Client) {
+		c.observeRequest = observe
+	}
+}
+
+// WithCircuitBreaker sets how many consecutive failures open an endpoint's
+// circuit breaker and how long it stays open. Non-positive values keep the
+// defaults.
+func WithCircuitBreaker(maxFailures int, resetTimeout time.Duration) ClientOption {
+	return func(c *Client) {
+		if maxFailures > 0 {
+			c.maxFailures = maxFailures
+		}
+		if resetTimeout > 0 {
+			c.resetTimeout = resetTimeout
+		}
+	}
+}
+
+// WithMaxConcurrentRequests bounds how many management API requests the
+// client has in flight at once. Further requests wait for a free slot. A
+// non-positive value keeps the default.
+func WithMaxConcurrentRequests(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.requests = make(chan struct{}, n)
+		}
+	}
+}
+
+// ValidateMessageRates checks a message rate window: both durations must be
+// whole seconds and the increment must fit in the window
+func ValidateMessageRates(age, incr time.Duration) error {
+	if age <= 0 {
+		return nil
+	}
+	if age%time.Second != 0 || incr%time.Second != 0 {
+		return fmt.Errorf("msg_rates_age and msg_rates_incr must be whole seconds, got %v and %v", age, incr)
+	}
+	if incr <= 0 || incr > age {
+		return fmt.Errorf("msg_rates_incr must be between 1s and msg_rates_age (%v), got %v", age, incr)
+	}
+	return nil
 }
 
-func NewClient(baseURL, username, password string, timeout time.Duration) *Client {
+// WithMessageRates makes the management API average message rates over the
+// last age, sampled every incr, instead of reporting the latest sample. The
+// averages replace the rates on queues, channels and the overview. A
+// non-positive age keeps the broker's default.
+func WithMessageRates(age, incr time.Duration) ClientOption {
+	return func(c *Client) {
+		if age <= 0 {
+			return
+		}
+		if incr <= 0 {
+			incr = age
+		}
+		c.ratesQuery = fmt.Sprintf("msg_rates_age=%d&msg_rates_incr=%d", int64(age/time.Second), int64(incr/time.Second))
+	}
+}
+
+// WithMaxRetryAfter caps how long a Retry-After header on a 429 or 503
+// response pauses requests. A non-positive value keeps
+// DefaultMaxRetryAfter.
+func WithMaxRetryAfter(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d > 0 {
+			c.maxRetryAfter = d
+		}
+	}
+}
+
+// WithTimeouts applies the non-zero timeouts in t. Endpoint timeouts are
+// added to those set by earlier options.
+func WithTimeouts(t Timeouts) ClientOption {
+	return func(c *Client) {
+		if t.Dial > 0 {
+			c.dialTimeout = t.Dial
+		}
+		if t.ResponseHeader > 0 {
+			if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
+				transport.ResponseHeaderTimeout = t.ResponseHeader
+			}
+		}
+		if t.HealthCheck > 0 {
+			c.healthCheckTimeout = t.HealthCheck
+		}
+		for endpoint, timeout := range t.Endpoints {
+			if timeout > 0 {
+				c.endpointTimeouts[endpoint] = timeout
+			}
+		}
+	}
+}
+
+func NewClient(baseURL, username, password string, timeout time.Duration, opts ...ClientOption) *Client {
 	if baseURL == "" {
 		baseURL = "http://localhost:15672"
 	}
@@ -43,6 +238,8 @@ func NewClient(baseURL, username, password string, timeout time.Duration) *Clien
 	}
 
 	transport := &http.Transport{
+		// Honors HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+		Proxy:               http.ProxyFromEnvironment,
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 50,
 		IdleConnTimeout:     90 * time.Second,
@@ -51,170 +248,566 @@ func NewClient(baseURL, username, password string, timeout time.Duration) *Clien
 		DisableCompression: true,
 		DisableKeepAlives:  false,
 
-		ResponseHeaderTimeout: 30 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-		TLSHandshakeTimeout: 10 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
 	}
 
-	return &Client{
-		baseURL:        baseURL,
-		username:       username,
-		password:       password,
-		httpClient:     &http.Client{Timeout: timeout, Transport: transport},
-		maxFailures:    5,
-		resetTimeout:   60 * time.Second,
-		requestTimeout: timeout,
+	// Requests are bounded by a context per attempt rather than
+	// http.Client.Timeout, so endpoint timeouts can exceed the default
+	c := &Client{
+		baseURL:            baseURL,
+		username:           username,
+		password:           password,
+		httpClient:         &http.Client{Transport: transport},
+		requests:           make(chan struct{}, DefaultMaxConcurrentRequests),
+		breakers:           make(map[string]*circuitBreaker),
+		maxFailures:        DefaultCircuitBreakerMaxFailures,
+		resetTimeout:       DefaultCircuitBreakerResetTimeout,
+		requestTimeout:     timeout,
+		dialTimeout:        DefaultDialTimeout,
+		healthCheckTimeout: timeout,
+		endpointTimeouts:   make(map[string]time.Duration),
+		maxRetryAfter:      DefaultMaxRetryAfter,
 	}
-}
-
-func (c *Client) isCircuitOpen() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	if !c.circuitOpen {
-		return false
+	// Reads dialTimeout when dialling, so WithTimeouts applies in any order
+	// and WithDialContext can still replace the dialer
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialer := net.Dialer{Timeout: c.dialTimeout, KeepAlive: 30 * time.Second}
+		return dialer.DialContext(ctx, network, addr)
 	}
 
-	if time.Since(c.lastFailureTime) > c.resetTimeout {
-		c.mu.RUnlock()
-		c.mu.Lock()
-		c.circuitOpen = false
-		c.failureCount = 0
-		c.mu.Unlock()
-		c.mu.RLock()
-		return false
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	return true
+	return c
 }
 
-func (c *Client) recordFailure() {
+// breaker returns the circuit breaker guarding endpoint, creating it on
+// first use
+func (c *Client) breaker(endpoint string) *circuitBreaker {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.failureCount++
-	c.lastFailureTime = time.Now()
+	b, ok := c.breakers[endpoint]
+	if !ok {
+		b = newCircuitBreaker(c.maxFailures, c.resetTimeout)
+		c.breakers[endpoint] = b
+	}
+	return b
+}
 
-	if c.failureCount >= c.maxFailures {
-		c.circuitOpen = true
+// getJSON issues a GET against a management API path and decodes the JSON
+// response into out, retrying transport errors once and feeding the
+// endpoint's circuit breaker. endpoint also names the payload in error
+// messages.
+func (c *Client) getJSON(ctx context.Context, path, endpoint string, out interface{}) error {
+	breaker := c.breaker(endpoint)
+	body, err := c.get(ctx, path, endpoint, breaker)
+	if err != nil {
+		return err
 	}
+
+	start := time.Now()
+	err = json.Unmarshal(body, out)
+	addDecodeTime(ctx, time.Since(start))
+	if err != nil {
+		breaker.recordFailure()
+		return fmt.Errorf("failed to unmarshal %s: %w: %w", endpoint, ErrDecode, err)
+	}
+
+	breaker.recordSuccess()
+	return nil
 }
 
-func (c *Client) recordSuccess() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// GetRaw returns the undecoded response of a management API endpoint, e.g.
+// EndpointQueues, for troubleshooting dumps
+func (c *Client) GetRaw(ctx context.Context, endpoint string) ([]byte, error) {
+	path, ok := endpointPaths[endpoint]
+	if !ok {
+		return nil, fmt.Errorf("unknown endpoint %q", endpoint)
+	}
 
-	c.failureCount = 0
-	c.circuitOpen = false
+	breaker := c.breaker(endpoint)
+	body, err := c.get(ctx, path, endpoint, breaker)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(body) {
+		breaker.recordFailure()
+		return nil, fmt.Errorf("%w: invalid JSON from %s", ErrDecode, endpoint)
+	}
+
+	breaker.recordSuccess()
+	return body, nil
 }
 
-func (c *Client) GetQueues(ctx context.Context) ([]Queue, error) {
-	if c.isCircuitOpen() {
-		return nil, fmt.Errorf("circuit breaker is open - too many recent failures")
+// get fetches path and returns the body of a 200 response. Failures are
+// recorded on breaker; success is left to the caller, which still has to
+// decode the body. A 429 or 503 with Retry-After is the broker asking for a
+// pause rather than failing, so it isn't recorded.
+func (c *Client) get(ctx context.Context, path, endpoint string, breaker *circuitBreaker) ([]byte, error) {
+	status, body, retryAfter, err := c.fetch(ctx, path, endpoint, breaker)
+	if err != nil {
+		return nil, err
 	}
 
-	url := fmt.Sprintf("%s/api/queues", c.baseURL)
+	if status != http.StatusOK {
+		apiErr := newAPIError(status, body)
+		apiErr.RetryAfter = retryAfter
+		if retryAfter == 0 {
+			breaker.recordFailure()
+		}
+		return nil, apiErr
+	}
+
+	return body, nil
+}
+
+// addHeaders applies the headers set with WithHeaders
+func (c *Client) addHeaders(req *http.Request) {
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+}
+
+// fetch issues a GET against path and returns the response status and body
+// whatever the status, along with the pause a 429 or 503 asked for. Only
+// failures to get a response are recorded on breaker.
+func (c *Client) fetch(ctx context.Context, path, endpoint string, breaker *circuitBreaker) (int, []byte, time.Duration, error) {
+	if err := c.checkBackoff(); err != nil {
+		return 0, nil, 0, err
+	}
+	if breaker.isOpen() {
+		return 0, nil, 0, ErrCircuitOpen
+	}
+
+	url := c.baseURL + path
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		c.recordFailure()
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		breaker.recordFailure()
+		return 0, nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Connection", "keep-alive")
+	c.addHeaders(req)
 
 	var resp *http.Response
 	var lastErr error
+	var start time.Time
+	timeout := c.timeout(endpoint)
 
 	for attempt := 0; attempt < 2; attempt++ {
-		resp, err = c.httpClient.Do(req)
+		// Waiting for a slot is bounded by ctx alone and isn't the
+		// endpoint's fault, so it doesn't feed the breaker
+		if err := c.acquire(ctx); err != nil {
+			return 0, nil, 0, classifyTransportError(err)
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		start = time.Now()
+		resp, err = c.httpClient.Do(req.WithContext(attemptCtx))
 		if err != nil {
-			lastErr = fmt.Errorf("request failed (attempt %d): %w", attempt+1, err)
+			cancel()
+			c.release()
+			c.observe(endpoint, "error", time.Since(start))
+			addRequestTime(ctx, time.Since(start))
+			lastErr = fmt.Errorf("request failed (attempt %d): %w", attempt+1, classifyTransportError(err))
 
 			if attempt < 1 {
 				backoff := time.Duration(attempt+1) * 500 * time.Millisecond
 				select {
 				case <-ctx.Done():
-					return nil, ctx.Err()
+					breaker.recordFailure()
+					return 0, nil, 0, classifyTransportError(ctx.Err())
 				case <-time.After(backoff):
 					continue
 				}
 			}
 			continue
 		}
+		// The attempt's deadline and slot also cover reading the body
+		defer cancel()
+		defer c.release()
 		break
 	}
 
 	if resp == nil {
-		c.recordFailure()
-		return nil, lastErr
+		breaker.recordFailure()
+		return 0, nil, 0, lastErr
 	}
 	defer resp.Body.Close()
+	retryAfter := c.noteRetryAfter(resp)
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	c.observe(endpoint, strconv.Itoa(resp.StatusCode), time.Since(start))
+	addRequestTime(ctx, time.Since(start))
 	if err != nil {
-		c.recordFailure()
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		breaker.recordFailure()
+		return 0, nil, 0, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		c.recordFailure()
-		var apiErr APIError
-		if json.Unmarshal(body, &apiErr) == nil {
-			return nil, &apiErr
+	return resp.StatusCode, body, retryAfter, nil
+}
+
+// noteRetryAfter pauses requests for as long as a 429 or 503 response's
+// Retry-After header asks, up to maxRetryAfter, and returns the pause
+func (c *Client) noteRetryAfter(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		return 0
+	}
+	if wait > c.maxRetryAfter {
+		wait = c.maxRetryAfter
+	}
+	until := time.Now().Add(wait).UnixNano()
+	for {
+		current := c.backoffUntil.Load()
+		if current >= until || c.backoffUntil.CompareAndSwap(current, until) {
+			return wait
 		}
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
 	}
+}
 
-	var queues []Queue
-	if err := json.Unmarshal(body, &queues); err != nil {
-		c.recordFailure()
-		return nil, fmt.Errorf("failed to unmarshal queues: %w", err)
+// parseRetryAfter reads a Retry-After value, either delay seconds or an
+// HTTP date. A date in the past asks for no pause.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil || !date.After(now) {
+		return 0, false
+	}
+	return date.Sub(now), true
+}
+
+// checkBackoff fails fast while a Retry-After pause is in effect
+func (c *Client) checkBackoff() error {
+	if wait := time.Until(c.BackoffUntil()); wait > 0 {
+		return fmt.Errorf("%w: broker asked to retry in %v", ErrRateLimited, wait.Round(time.Second))
+	}
+	return nil
+}
+
+// BackoffUntil returns when the broker's last Retry-After pause ends. It is
+// in the past when no pause is in effect.
+func (c *Client) BackoffUntil() time.Time {
+	return time.Unix(0, c.backoffUntil.Load())
+}
+
+// acquire waits for a request slot
+func (c *Client) acquire(ctx context.Context) error {
+	select {
+	case c.requests <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) release() {
+	<-c.requests
+}
+
+type requestTimeKey struct{}
+
+// WithRequestTime returns a context whose requests add the time spent on
+// them to *d. Waiting for a request slot and retry backoff aren't counted, so
+// *d reflects the broker rather than the client's own queueing. Requests on
+// the context must not run concurrently.
+func WithRequestTime(ctx context.Context, d *time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeKey{}, d)
+}
+
+func addRequestTime(ctx context.Context, elapsed time.Duration) {
+	if d, ok := ctx.Value(requestTimeKey{}).(*time.Duration); ok {
+		*d += elapsed
+	}
+	if t, ok := ctx.Value(timingsKey{}).(*Timings); ok {
+		t.fetch.Add(int64(elapsed))
+	}
+}
+
+func addDecodeTime(ctx context.Context, elapsed time.Duration) {
+	if t, ok := ctx.Value(timingsKey{}).(*Timings); ok {
+		t.decode.Add(int64(elapsed))
+	}
+}
+
+type timingsKey struct{}
+
+// Timings adds up the time requests spend waiting on the broker and
+// decoding its responses. Unlike WithRequestTime it may be shared by
+// concurrent requests, so the totals can exceed the wall-clock time.
+type Timings struct {
+	fetch  atomic.Int64
+	decode atomic.Int64
+}
+
+// WithTimings returns a context whose requests add their timings to t
+func WithTimings(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, timingsKey{}, t)
+}
+
+// Fetch returns the time spent on requests, excluding waits for a request
+// slot and retry backoff
+func (t *Timings) Fetch() time.Duration {
+	return time.Duration(t.fetch.Load())
+}
+
+// Decode returns the time spent decoding JSON responses
+func (t *Timings) Decode() time.Duration {
+	return time.Duration(t.decode.Load())
+}
+
+// ratesPath returns the path of an endpoint that reports message rates,
+// with the configured rate window applied
+func (c *Client) ratesPath(endpoint string) string {
+	if c.ratesQuery == "" {
+		return endpointPaths[endpoint]
+	}
+	return endpointPaths[endpoint] + "?" + c.ratesQuery
+}
+
+// timeout returns the request timeout for endpoint
+func (c *Client) timeout(endpoint string) time.Duration {
+	if timeout, ok := c.endpointTimeouts[endpoint]; ok {
+		return timeout
 	}
+	return c.requestTimeout
+}
 
-	c.recordSuccess()
+func (c *Client) GetQueues(ctx context.Context) ([]Queue, error) {
+	var queues []Queue
+	if err := c.getJSON(ctx, c.ratesPath(EndpointQueues), EndpointQueues, &queues); err != nil {
+		return nil, err
+	}
 	return queues, nil
 }
 
+func (c *Client) GetNodes(ctx context.Context) ([]Node, error) {
+	var nodes []Node
+	if err := c.getJSON(ctx, endpointPaths[EndpointNodes], EndpointNodes, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func (c *Client) GetBindings(ctx context.Context) ([]Binding, error) {
+	var bindings []Binding
+	if err := c.getJSON(ctx, endpointPaths[EndpointBindings], EndpointBindings, &bindings); err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+func (c *Client) GetChannels(ctx context.Context) ([]Channel, error) {
+	var channels []Channel
+	if err := c.getJSON(ctx, c.ratesPath(EndpointChannels), EndpointChannels, &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (c *Client) GetConnections(ctx context.Context) ([]Connection, error) {
+	var connections []Connection
+	if err := c.getJSON(ctx, c.ratesPath(EndpointConnections), EndpointConnections, &connections); err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
+func (c *Client) GetConsumers(ctx context.Context) ([]Consumer, error) {
+	var consumers []Consumer
+	if err := c.getJSON(ctx, endpointPaths[EndpointConsumers], EndpointConsumers, &consumers); err != nil {
+		return nil, err
+	}
+	return consumers, nil
+}
+
+func (c *Client) GetOverview(ctx context.Context) (*Overview, error) {
+	var overview Overview
+	if err := c.getJSON(ctx, c.ratesPath(EndpointOverview), EndpointOverview, &overview); err != nil {
+		return nil, err
+	}
+	return &overview, nil
+}
+
+func (c *Client) GetPolicies(ctx context.Context) ([]Policy, error) {
+	var policies []Policy
+	if err := c.getJSON(ctx, endpointPaths[EndpointPolicies], EndpointPolicies, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (c *Client) GetOperatorPolicies(ctx context.Context) ([]Policy, error) {
+	var policies []Policy
+	if err := c.getJSON(ctx, endpointPaths[EndpointOperatorPolicies], EndpointOperatorPolicies, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// AlivenessTest has the broker declare a test queue in vhost, publish a
+// message to it and consume it again. It fails unless the broker reports
+// status "ok".
+func (c *Client) AlivenessTest(ctx context.Context, vhost string) error {
+	var result struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	path := endpointPaths[EndpointAliveness] + "/" + url.PathEscape(vhost)
+	if err := c.getJSON(ctx, path, EndpointAliveness, &result); err != nil {
+		return err
+	}
+	if result.Status != "ok" {
+		return fmt.Errorf("broker reported status %q: %s", result.Status, result.Reason)
+	}
+	return nil
+}
+
+// HealthCheckResult is the outcome of a management API health check
+type HealthCheckResult struct {
+	Passed bool
+	// Reason explains a failed check
+	Reason string
+}
+
+// RunHealthCheck calls /api/health/checks/<check>, e.g. "alarms" or
+// "port-listener/5672". A failing check is a result rather than an error:
+// the broker answered, so the endpoint's circuit breaker isn't affected.
+func (c *Client) RunHealthCheck(ctx context.Context, check string) (HealthCheckResult, error) {
+	breaker := c.breaker(EndpointHealthChecks)
+	status, body, _, err := c.fetch(ctx, endpointPaths[EndpointHealthChecks]+"/"+check, EndpointHealthChecks, breaker)
+	if err != nil {
+		return HealthCheckResult{}, err
+	}
+
+	switch status {
+	case http.StatusOK:
+		breaker.recordSuccess()
+		return HealthCheckResult{Passed: true}, nil
+	case http.StatusServiceUnavailable:
+		breaker.recordSuccess()
+		return HealthCheckResult{Reason: newAPIError(status, body).Reason}, nil
+	default:
+		breaker.recordFailure()
+		return HealthCheckResult{}, newAPIError(status, body)
+	}
+}
+
 func (c *Client) HealthCheck(ctx context.Context) error {
-	if c.isCircuitOpen() {
-		return fmt.Errorf("circuit breaker is open - too many recent failures")
+	if err := c.checkBackoff(); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	breaker := c.breaker(EndpointOverview)
+	if breaker.isOpen() {
+		return ErrCircuitOpen
 	}
 
-	url := fmt.Sprintf("%s/api/overview", c.baseURL)
+	ctx, cancel := context.WithTimeout(ctx, c.healthCheckTimeout)
+	defer cancel()
+
+	url := c.baseURL + endpointPaths[EndpointOverview]
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		c.recordFailure()
+		breaker.recordFailure()
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
 
 	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set("Accept", "application/json")
+	c.addHeaders(req)
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		c.recordFailure()
-		return fmt.Errorf("health check failed: %w", err)
+		c.observe(EndpointOverview, "error", time.Since(start))
+		breaker.recordFailure()
+		return fmt.Errorf("health check failed: %w", classifyTransportError(err))
 	}
 	defer resp.Body.Close()
+	c.observe(EndpointOverview, strconv.Itoa(resp.StatusCode), time.Since(start))
 
 	if resp.StatusCode != http.StatusOK {
-		c.recordFailure()
-		return fmt.Errorf("health check failed with status: %d", resp.StatusCode)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := newAPIError(resp.StatusCode, body)
+		apiErr.RetryAfter = c.noteRetryAfter(resp)
+		if apiErr.RetryAfter == 0 {
+			breaker.recordFailure()
+		}
+		return fmt.Errorf("health check failed: %w", apiErr)
 	}
 
-	c.recordSuccess()
+	breaker.recordSuccess()
 	return nil
 }
 
+func (c *Client) observe(endpoint, code string, duration time.Duration) {
+	if c.observeRequest != nil {
+		c.observeRequest(endpoint, code, duration)
+	}
+}
+
+// GetCircuitBreakerStatus summarizes all endpoint breakers: open if any is
+// open, the highest consecutive failure count and the most recent failure
 func (c *Client) GetCircuitBreakerStatus() (bool, int, time.Time) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.circuitOpen, c.failureCount, c.lastFailureTime
+	var open bool
+	var failures int
+	var lastFailure time.Time
+	for _, status := range c.CircuitBreakerStatus() {
+		open = open || status.Open
+		if status.Failures > failures {
+			failures = status.Failures
+		}
+		if status.LastFailure.After(lastFailure) {
+			lastFailure = status.LastFailure
+		}
+	}
+	return open, failures, lastFailure
+}
+
+// CircuitBreakerStatus returns the state of every endpoint breaker that has
+// been used, keyed by endpoint
+func (c *Client) CircuitBreakerStatus() map[string]BreakerStatus {
+	c.mu.Lock()
+	breakers := make(map[string]*circuitBreaker, len(c.breakers))
+	for endpoint, b := range c.breakers {
+		breakers[endpoint] = b
+	}
+	c.mu.Unlock()
+
+	statuses := make(map[string]BreakerStatus, len(breakers))
+	for endpoint, b := range breakers {
+		statuses[endpoint] = b.status()
+	}
+	return statuses
+}
+
+// ResetCircuitBreaker closes every endpoint's circuit breaker and clears the
+// failure counts
+func (c *Client) ResetCircuitBreaker() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, b := range c.breakers {
+		b.recordSuccess()
+	}
 }
 
 func (c *Client) Close() {
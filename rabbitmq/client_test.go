@@ -1,6 +1,14 @@
 package rabbitmq
 
 import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -20,8 +28,8 @@ func TestNewClient(t *testing.T) {
 		t.Errorf("Expected password to be 'guest', got '%s'", client.password)
 	}
 
-	if client.httpClient.Timeout != 10*time.Second {
-		t.Errorf("Expected timeout to be 10s, got '%v'", client.httpClient.Timeout)
+	if client.timeout(EndpointQueues) != 10*time.Second {
+		t.Errorf("Expected timeout to be 10s, got '%v'", client.timeout(EndpointQueues))
 	}
 }
 
@@ -137,6 +145,41 @@ func TestQueue_GetQueueState(t *testing.T) {
 			},
 			expected: QueueStateBlocked,
 		},
+		{
+			name: "Flow-controlled queue - broker state wins",
+			queue: Queue{
+				Name:      "flow_queue",
+				State:     "flow",
+				Consumers: 2,
+				Messages:  10,
+				MessageStats: &MessageStats{
+					DeliverDetails: &RateDetails{Rate: 5.0},
+				},
+			},
+			expected: QueueStateFlow,
+		},
+		{
+			name: "Crashed queue - reported as down",
+			queue: Queue{
+				Name:  "crashed_queue",
+				State: "crashed",
+			},
+			expected: QueueStateDown,
+		},
+		{
+			name: "Running queue - falls back to the rates",
+			queue: Queue{
+				Name:      "running_queue",
+				State:     "running",
+				Consumers: 1,
+				Messages:  15,
+				MessageStats: &MessageStats{
+					DeliverDetails: &RateDetails{Rate: 0.0},
+					PublishDetails: &RateDetails{Rate: 5.0},
+				},
+			},
+			expected: QueueStateBlocked,
+		},
 	}
 
 	for _, tt := range tests {
@@ -149,6 +192,21 @@ func TestQueue_GetQueueState(t *testing.T) {
 	}
 }
 
+func TestQueue_GetConsumerCapacity(t *testing.T) {
+	var queue Queue
+	if err := json.Unmarshal([]byte(`{"consumer_utilisation":0.5,"consumer_capacity":0.75}`), &queue); err != nil {
+		t.Fatalf("Failed to unmarshal queue: %v", err)
+	}
+	if got := queue.GetConsumerCapacity(); got != 0.75 {
+		t.Errorf("Expected the reported capacity 0.75, got %v", got)
+	}
+
+	legacy := Queue{ConsumerUtilisation: 0.5}
+	if got := legacy.GetConsumerCapacity(); got != 0.5 {
+		t.Errorf("Expected to fall back to utilisation 0.5, got %v", got)
+	}
+}
+
 func TestQueue_GetPublishRate(t *testing.T) {
 	queue := Queue{
 		Name: "test_queue",
@@ -190,3 +248,689 @@ func TestQueue_GetAckRate(t *testing.T) {
 		t.Errorf("Expected GetAckRate() to be %f, got %f", expected, queue.GetAckRate())
 	}
 }
+
+func TestQueue_GetIntArgument(t *testing.T) {
+	queue := Queue{
+		Arguments: map[string]interface{}{
+			"x-max-length": float64(1000),
+			"x-string":     "42",
+			"x-invalid":    "abc",
+			"x-bool":       true,
+		},
+	}
+
+	tests := []struct {
+		name          string
+		argument      string
+		expectedValue int64
+		expectedOK    bool
+	}{
+		{name: "JSON number", argument: "x-max-length", expectedValue: 1000, expectedOK: true},
+		{name: "Numeric string", argument: "x-string", expectedValue: 42, expectedOK: true},
+		{name: "Invalid string", argument: "x-invalid", expectedOK: false},
+		{name: "Unsupported type", argument: "x-bool", expectedOK: false},
+		{name: "Missing argument", argument: "x-missing", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := queue.GetIntArgument(tt.argument)
+			if ok != tt.expectedOK || value != tt.expectedValue {
+				t.Errorf("Expected GetIntArgument() to be (%d, %v), got (%d, %v)", tt.expectedValue, tt.expectedOK, value, ok)
+			}
+		})
+	}
+}
+
+func TestQueue_GetArgumentString(t *testing.T) {
+	queue := Queue{
+		Arguments: map[string]interface{}{
+			"x-max-length":  float64(1000),
+			"x-message-ttl": float64(60000),
+			"x-overflow":    "reject-publish",
+			"x-single":      true,
+			"x-null":        nil,
+		},
+	}
+
+	for argument, expected := range map[string]string{
+		"x-max-length":  "1000",
+		"x-message-ttl": "60000",
+		"x-overflow":    "reject-publish",
+		"x-single":      "true",
+		"x-null":        "",
+		"x-missing":     "",
+	} {
+		if got := queue.GetArgumentString(argument); got != expected {
+			t.Errorf("GetArgumentString(%q) = %q, want %q", argument, got, expected)
+		}
+	}
+}
+
+func TestQueue_MaxLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		queue    Queue
+		expected int64
+		ok       bool
+	}{
+		{name: "No limit", queue: Queue{}},
+		{name: "Argument", queue: Queue{Arguments: map[string]interface{}{"x-max-length": float64(1000)}}, expected: 1000, ok: true},
+		{name: "Policy", queue: Queue{EffectivePolicyDefinition: map[string]interface{}{"max-length": float64(500)}}, expected: 500, ok: true},
+		{
+			name: "Lower of argument and policy",
+			queue: Queue{
+				Arguments:                 map[string]interface{}{"x-max-length": float64(1000)},
+				EffectivePolicyDefinition: map[string]interface{}{"max-length": float64(2000)},
+			},
+			expected: 1000, ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, ok := tt.queue.MaxLength()
+			if limit != tt.expected || ok != tt.ok {
+				t.Errorf("Expected MaxLength() to be (%d, %v), got (%d, %v)", tt.expected, tt.ok, limit, ok)
+			}
+		})
+	}
+
+	queue := Queue{EffectivePolicyDefinition: map[string]interface{}{"max-length-bytes": "1048576"}}
+	if limit, ok := queue.MaxLengthBytes(); !ok || limit != 1048576 {
+		t.Errorf("Expected MaxLengthBytes() to read the policy, got (%d, %v)", limit, ok)
+	}
+}
+
+func TestQueue_QuorumReplication(t *testing.T) {
+	tests := []struct {
+		name            string
+		queue           Queue
+		quorum          bool
+		underReplicated bool
+	}{
+		{
+			name: "Healthy quorum queue",
+			queue: Queue{
+				Type:    "quorum",
+				Members: []string{"rabbit@a", "rabbit@b", "rabbit@c"},
+				Online:  []string{"rabbit@a", "rabbit@b", "rabbit@c"},
+			},
+			quorum: true,
+		},
+		{
+			name: "Under-replicated quorum queue",
+			queue: Queue{
+				Type:    "quorum",
+				Members: []string{"rabbit@a", "rabbit@b", "rabbit@c"},
+				Online:  []string{"rabbit@a"},
+			},
+			quorum:          true,
+			underReplicated: true,
+		},
+		{
+			name: "Quorum queue from arguments",
+			queue: Queue{
+				Arguments: map[string]interface{}{"x-queue-type": "quorum"},
+			},
+			quorum: true,
+		},
+		{
+			name:  "Classic queue",
+			queue: Queue{Type: "classic"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.queue.IsQuorumQueue(); result != tt.quorum {
+				t.Errorf("Expected IsQuorumQueue() to be %v, got %v", tt.quorum, result)
+			}
+			if result := tt.queue.IsUnderReplicated(); result != tt.underReplicated {
+				t.Errorf("Expected IsUnderReplicated() to be %v, got %v", tt.underReplicated, result)
+			}
+		})
+	}
+}
+
+func TestQueue_GetQueueType(t *testing.T) {
+	tests := []struct {
+		name     string
+		queue    Queue
+		expected string
+	}{
+		{name: "Broker-reported type", queue: Queue{Type: "stream"}, expected: QueueTypeStream},
+		{name: "Type from arguments", queue: Queue{Arguments: map[string]interface{}{"x-queue-type": "quorum"}}, expected: QueueTypeQuorum},
+		{name: "Default classic", queue: Queue{Arguments: map[string]interface{}{}}, expected: QueueTypeClassic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.queue.GetQueueType(); result != tt.expected {
+				t.Errorf("Expected GetQueueType() to be %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestQueue_QueueMode(t *testing.T) {
+	lazyPolicy := map[string]interface{}{"queue-mode": "lazy"}
+	tests := []struct {
+		name     string
+		queue    Queue
+		expected string
+	}{
+		{name: "Reported by the queue", queue: Queue{BackingQueueStatus: &BackingQueueStatus{Mode: "lazy"}}, expected: QueueModeLazy},
+		{name: "Reported mode wins over policy", queue: Queue{BackingQueueStatus: &BackingQueueStatus{Mode: "default"}, EffectivePolicyDefinition: lazyPolicy}, expected: QueueModeDefault},
+		{name: "Argument", queue: Queue{Arguments: map[string]interface{}{"x-queue-mode": "lazy"}}, expected: QueueModeLazy},
+		{name: "Policy", queue: Queue{EffectivePolicyDefinition: lazyPolicy}, expected: QueueModeLazy},
+		{name: "Default", queue: Queue{}, expected: QueueModeDefault},
+		{name: "Quorum queue", queue: Queue{Type: QueueTypeQuorum, EffectivePolicyDefinition: lazyPolicy}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.queue.QueueMode(); result != tt.expected {
+				t.Errorf("Expected QueueMode() to be %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestQueue_UnmarshalIdleSince(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected time.Time
+	}{
+		{
+			name:     "RFC 3339",
+			payload:  `{"name":"orders","idle_since":"2024-03-05T09:07:44.221+01:00"}`,
+			expected: time.Date(2024, 3, 5, 8, 7, 44, 221000000, time.UTC),
+		},
+		{
+			name:     "Before RabbitMQ 3.12",
+			payload:  `{"name":"orders","idle_since":"2024-03-05 9:07:44"}`,
+			expected: time.Date(2024, 3, 5, 9, 7, 44, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queue Queue
+			if err := json.Unmarshal([]byte(tt.payload), &queue); err != nil {
+				t.Fatalf("Expected queue to unmarshal, got %v", err)
+			}
+			if queue.IdleSince == nil || !queue.IdleSince.Equal(tt.expected) {
+				t.Errorf("Expected idle since %v, got %v", tt.expected, queue.IdleSince)
+			}
+		})
+	}
+
+	var queue Queue
+	if err := json.Unmarshal([]byte(`{"name":"orders","idle_since":"yesterday"}`), &queue); err == nil {
+		t.Error("Expected an invalid timestamp to fail")
+	}
+}
+
+func TestQueue_UnmarshalStreamFields(t *testing.T) {
+	tests := []struct {
+		name            string
+		payload         string
+		expectedReaders StreamReaders
+	}{
+		{
+			name:            "Readers as count",
+			payload:         `{"name":"events","type":"stream","committed_offset":1200,"segments":3,"readers":4}`,
+			expectedReaders: 4,
+		},
+		{
+			name:            "Readers per node",
+			payload:         `{"name":"events","type":"stream","committed_offset":1200,"segments":3,"readers":{"rabbit@a":2,"rabbit@b":3}}`,
+			expectedReaders: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queue Queue
+			if err := json.Unmarshal([]byte(tt.payload), &queue); err != nil {
+				t.Fatalf("Expected stream queue to unmarshal, got %v", err)
+			}
+			if !queue.IsStreamQueue() {
+				t.Error("Expected IsStreamQueue() to be true")
+			}
+			if queue.CommittedOffset != 1200 || queue.Segments != 3 {
+				t.Errorf("Expected offset 1200 and 3 segments, got %d and %d", queue.CommittedOffset, queue.Segments)
+			}
+			if queue.Readers != tt.expectedReaders {
+				t.Errorf("Expected %d readers, got %d", tt.expectedReaders, queue.Readers)
+			}
+		})
+	}
+}
+
+func TestConnection_UnmarshalClientProperties(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		application string
+	}{
+		{
+			name:        "Connection name set",
+			payload:     `{"name":"c1","user":"app","client_properties":{"connection_name":"billing","product":"amqp-go"}}`,
+			application: "billing",
+		},
+		{
+			name:    "Empty client properties",
+			payload: `{"name":"c1","user":"app","client_properties":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var connection Connection
+			if err := json.Unmarshal([]byte(tt.payload), &connection); err != nil {
+				t.Fatalf("Expected connection to unmarshal, got %v", err)
+			}
+			if connection.ClientProperties.ConnectionName != tt.application {
+				t.Errorf("Expected connection name %q, got %q", tt.application, connection.ClientProperties.ConnectionName)
+			}
+		})
+	}
+
+	var channel Channel
+	if err := json.Unmarshal([]byte(`{"name":"c1 (1)","user":"app","connection_details":[]}`), &channel); err != nil {
+		t.Fatalf("Expected channel without connection details to unmarshal, got %v", err)
+	}
+}
+
+func TestClient_GetRaw(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues":
+			w.Write([]byte(`[{"name":"orders","extra_field":true}]`))
+		case "/api/nodes":
+			w.Write([]byte(`not json`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second)
+
+	body, err := client.GetRaw(context.Background(), EndpointQueues)
+	if err != nil {
+		t.Fatalf("GetRaw: %v", err)
+	}
+	if string(body) != `[{"name":"orders","extra_field":true}]` {
+		t.Errorf("Expected the body unchanged, got %s", body)
+	}
+
+	if _, err := client.GetRaw(context.Background(), EndpointNodes); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+	if _, err := client.GetRaw(context.Background(), "connections"); err == nil {
+		t.Error("Expected error for unknown endpoint")
+	}
+}
+
+func TestClient_AlivenessTest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/aliveness-test/%2F":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/api/aliveness-test/degraded":
+			w.Write([]byte(`{"status":"failed","reason":"queue not found"}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"not_authorised"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second)
+
+	if err := client.AlivenessTest(context.Background(), "/"); err != nil {
+		t.Errorf("Expected the default vhost to pass, got %v", err)
+	}
+	if err := client.AlivenessTest(context.Background(), "degraded"); err == nil || !strings.Contains(err.Error(), "queue not found") {
+		t.Errorf("Expected the failure reason, got %v", err)
+	}
+	if err := client.AlivenessTest(context.Background(), "other"); err == nil {
+		t.Error("Expected an error for a non-200 response")
+	}
+}
+
+func TestClient_RunHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/health/checks/alarms":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/api/health/checks/port-listener/5672":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":"failed","reason":"No active listener on port 5672"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second, WithCircuitBreaker(1, time.Minute))
+
+	result, err := client.RunHealthCheck(context.Background(), "alarms")
+	if err != nil || !result.Passed {
+		t.Errorf("Expected alarms to pass, got %+v, %v", result, err)
+	}
+
+	result, err = client.RunHealthCheck(context.Background(), "port-listener/5672")
+	if err != nil || result.Passed || result.Reason != "No active listener on port 5672" {
+		t.Errorf("Expected a failed check with its reason, got %+v, %v", result, err)
+	}
+	if client.CircuitBreakerStatus()[EndpointHealthChecks].Open {
+		t.Error("Expected a failed check to leave the circuit breaker closed")
+	}
+
+	if _, err := client.RunHealthCheck(context.Background(), "unknown"); err == nil {
+		t.Error("Expected an error for a check the broker doesn't know")
+	}
+}
+
+func TestClient_MessageRates(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`[{"name":"orders","message_stats":{"publish_details":{"rate":50,"avg_rate":12.5,"avg":1000,"samples":[{"sample":1000,"timestamp":1}]}}}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second, WithMessageRates(time.Minute, 0))
+	queues, err := client.GetQueues(context.Background())
+	if err != nil {
+		t.Fatalf("GetQueues: %v", err)
+	}
+	if query != "msg_rates_age=60&msg_rates_incr=60" {
+		t.Errorf("Expected the rate window in the query, got %q", query)
+	}
+	if got := queues[0].GetPublishRate(); got != 12.5 {
+		t.Errorf("Expected the averaged rate 12.5, got %v", got)
+	}
+
+	client = NewClient(server.URL, "guest", "guest", time.Second)
+	if _, err := client.GetQueues(context.Background()); err != nil {
+		t.Fatalf("GetQueues: %v", err)
+	}
+	if query != "" {
+		t.Errorf("Expected no query without a rate window, got %q", query)
+	}
+}
+
+func TestValidateMessageRates(t *testing.T) {
+	tests := []struct {
+		age, incr time.Duration
+		valid     bool
+	}{
+		{0, 0, true},
+		{time.Minute, 10 * time.Second, true},
+		{time.Minute, time.Minute, true},
+		{time.Minute, 2 * time.Minute, false},
+		{1500 * time.Millisecond, time.Second, false},
+		{time.Minute, 0, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateMessageRates(tt.age, tt.incr); (err == nil) != tt.valid {
+			t.Errorf("ValidateMessageRates(%v, %v) = %v, expected valid %v", tt.age, tt.incr, err, tt.valid)
+		}
+	}
+}
+
+func TestClient_RequestObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/queues":
+			w.Write([]byte(`[]`))
+		case "/api/overview":
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	type observation struct{ endpoint, code string }
+	var observed []observation
+	client := NewClient(server.URL, "guest", "guest", time.Second, WithRequestObserver(func(endpoint, code string, d time.Duration) {
+		if d < 0 {
+			t.Errorf("Negative duration for %s", endpoint)
+		}
+		observed = append(observed, observation{endpoint, code})
+	}))
+
+	client.GetQueues(context.Background())
+	client.GetNodes(context.Background())
+	client.HealthCheck(context.Background())
+
+	want := []observation{{EndpointQueues, "200"}, {EndpointNodes, "503"}, {EndpointOverview, "200"}}
+	if len(observed) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, observed)
+	}
+	for i := range want {
+		if observed[i] != want[i] {
+			t.Errorf("Observation %d: expected %v, got %v", i, want[i], observed[i])
+		}
+	}
+
+	server.Close()
+	observed = nil
+	client.GetQueues(context.Background())
+	if len(observed) != 2 || observed[0].code != "error" || observed[1].code != "error" {
+		t.Errorf("Expected both attempts to be observed as errors, got %v", observed)
+	}
+}
+
+func TestClient_EndpointTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/queues" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(server.URL, "guest", "guest", 5*time.Second, WithTimeouts(Timeouts{
+		Endpoints: map[string]time.Duration{EndpointQueues: 50 * time.Millisecond},
+	}))
+	if got := client.timeout(EndpointNodes); got != 5*time.Second {
+		t.Errorf("Expected the request timeout for nodes, got %v", got)
+	}
+
+	start := time.Now()
+	if _, err := client.GetQueues(context.Background()); err == nil {
+		t.Error("Expected the queues request to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the endpoint timeout to apply, took %v", elapsed)
+	}
+	if _, err := client.GetNodes(context.Background()); err != nil {
+		t.Errorf("Expected nodes to use the request timeout, got %v", err)
+	}
+}
+
+func TestTimeouts_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts Timeouts
+		valid    bool
+	}{
+		{"empty", Timeouts{}, true},
+		{"endpoint", Timeouts{Endpoints: map[string]time.Duration{EndpointQueues: time.Minute}}, true},
+		{"negative dial", Timeouts{Dial: -time.Second}, false},
+		{"unknown endpoint", Timeouts{Endpoints: map[string]time.Duration{"exchanges": time.Minute}}, false},
+		{"negative endpoint", Timeouts{Endpoints: map[string]time.Duration{EndpointQueues: -time.Second}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.timeouts.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, expected valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestClient_MaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			peak := atomic.LoadInt32(&maxInFlight)
+			if n <= peak || atomic.CompareAndSwapInt32(&maxInFlight, peak, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second, WithMaxConcurrentRequests(2))
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetQueues(context.Background()); err != nil {
+				t.Errorf("GetQueues: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&maxInFlight); got != 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", got)
+	}
+
+	// Waiting for a slot gives up with the context
+	client.acquire(context.Background())
+	client.acquire(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetQueues(ctx); err == nil {
+		t.Error("Expected an error while no slot is free")
+	}
+}
+
+func TestClient_RequestTimeExcludesSlotWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second, WithMaxConcurrentRequests(1))
+	client.acquire(context.Background())
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		client.release()
+	}()
+
+	var elapsed time.Duration
+	start := time.Now()
+	if _, err := client.GetQueues(WithRequestTime(context.Background(), &elapsed)); err != nil {
+		t.Fatalf("GetQueues: %v", err)
+	}
+	if wall := time.Since(start); wall < 200*time.Millisecond {
+		t.Fatalf("Expected the request to wait for a slot, took %v", wall)
+	}
+	if elapsed <= 0 || elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected only the request to be timed, got %v", elapsed)
+	}
+}
+
+func TestClient_Timings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`[{"name":"orders","vhost":"/"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second)
+	var timings Timings
+	ctx := WithTimings(context.Background(), &timings)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetQueues(ctx); err != nil {
+				t.Errorf("GetQueues: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := timings.Fetch(); got < 40*time.Millisecond {
+		t.Errorf("Expected both requests to be timed, got %v", got)
+	}
+	if timings.Decode() <= 0 {
+		t.Error("Expected decoding to be timed")
+	}
+}
+
+func TestClient_Headers(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]http.Header)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = r.Header.Clone()
+		mu.Unlock()
+		if r.URL.Path == "/api/overview" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second, WithHeaders(map[string]string{
+		"x-forwarded-user": "exporter",
+		"X-Proxy-Token":    "secret",
+	}))
+	if _, err := client.GetQueues(context.Background()); err != nil {
+		t.Fatalf("GetQueues: %v", err)
+	}
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+
+	for _, path := range []string{"/api/queues", "/api/overview"} {
+		header := seen[path]
+		if got := header.Get("X-Forwarded-User"); got != "exporter" {
+			t.Errorf("%s: expected X-Forwarded-User exporter, got %q", path, got)
+		}
+		if got := header.Get("X-Proxy-Token"); got != "secret" {
+			t.Errorf("%s: expected X-Proxy-Token secret, got %q", path, got)
+		}
+		if _, _, ok := (&http.Request{Header: header}).BasicAuth(); !ok {
+			t.Errorf("%s: expected basic auth to be kept", path)
+		}
+	}
+}
+
+func TestClient_Proxy(t *testing.T) {
+	client := NewClient("http://rabbitmq:15672", "guest", "guest", time.Second)
+	if client.httpClient.Transport.(*http.Transport).Proxy == nil {
+		t.Error("Expected proxy environment variables to be honored")
+	}
+
+	tunnelled := NewClient("http://rabbitmq:15672", "guest", "guest", time.Second, WithDialContext((&net.Dialer{}).DialContext))
+	if tunnelled.httpClient.Transport.(*http.Transport).Proxy != nil {
+		t.Error("Expected a custom dialer to bypass the proxy")
+	}
+}
@@ -0,0 +1,79 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultDeadLetterPatterns are the queue name patterns treated as dead letter
+// queues when no patterns are configured
+var DefaultDeadLetterPatterns = []string{`\.dlq$`, `\.dead$`, `\.deadletter$`}
+
+var defaultDeadLetterRules, _ = NewDeadLetterRules(nil, nil)
+
+// DeadLetterRules decides which queues are dead letter queues, by queue name
+// pattern, by the x-dead-letter-exchange argument, or by being bound to one of
+// a set of named dead letter exchanges
+type DeadLetterRules struct {
+	patterns  []*regexp.Regexp
+	exchanges map[string]bool
+}
+
+// NewDeadLetterRules compiles the given name patterns, falling back to
+// DefaultDeadLetterPatterns when none are given
+func NewDeadLetterRules(patterns []string, exchanges []string) (*DeadLetterRules, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultDeadLetterPatterns
+	}
+
+	r := &DeadLetterRules{exchanges: make(map[string]bool, len(exchanges))}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dead letter pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, e := range exchanges {
+		r.exchanges[e] = true
+	}
+
+	return r, nil
+}
+
+// Match reports whether the queue is a dead letter queue based on its name
+// and arguments
+func (r *DeadLetterRules) Match(q *Queue) bool {
+	if _, hasDLX := q.Arguments["x-dead-letter-exchange"]; hasDLX {
+		return true
+	}
+
+	for _, re := range r.patterns {
+		if re.MatchString(q.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasExchanges reports whether any dead letter exchanges are configured, in
+// which case bindings need to be fetched
+func (r *DeadLetterRules) HasExchanges() bool {
+	return len(r.exchanges) > 0
+}
+
+// BoundQueues returns the queues bound to a configured dead letter exchange,
+// keyed by vhost and queue name
+func (r *DeadLetterRules) BoundQueues(bindings []Binding) map[string]map[string]bool {
+	bound := make(map[string]map[string]bool)
+	for _, b := range bindings {
+		if b.DestinationType != "queue" || !r.exchanges[b.Source] {
+			continue
+		}
+		if bound[b.Vhost] == nil {
+			bound[b.Vhost] = make(map[string]bool)
+		}
+		bound[b.Vhost][b.Destination] = true
+	}
+	return bound
+}
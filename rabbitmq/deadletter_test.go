@@ -0,0 +1,58 @@
+package rabbitmq
+
+import "testing"
+
+func TestDeadLetterRules_Match(t *testing.T) {
+	rules, err := NewDeadLetterRules([]string{`_failed$`}, nil)
+	if err != nil {
+		t.Fatalf("Expected rules to be created, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		queue    Queue
+		expected bool
+	}{
+		{name: "Custom pattern", queue: Queue{Name: "orders_failed"}, expected: true},
+		{name: "Default suffix no longer applies", queue: Queue{Name: "orders.dlq"}, expected: false},
+		{name: "Dead letter exchange argument", queue: Queue{Name: "orders", Arguments: map[string]interface{}{"x-dead-letter-exchange": "dlx"}}, expected: true},
+		{name: "Regular queue", queue: Queue{Name: "orders"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := rules.Match(&tt.queue); result != tt.expected {
+				t.Errorf("Expected Match() to be %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDeadLetterRules_BoundQueues(t *testing.T) {
+	rules, err := NewDeadLetterRules(nil, []string{"dlx"})
+	if err != nil {
+		t.Fatalf("Expected rules to be created, got %v", err)
+	}
+	if !rules.HasExchanges() {
+		t.Fatal("Expected HasExchanges() to be true")
+	}
+
+	bound := rules.BoundQueues([]Binding{
+		{Source: "dlx", Vhost: "/", Destination: "parked", DestinationType: "queue"},
+		{Source: "dlx", Vhost: "/", Destination: "other.exchange", DestinationType: "exchange"},
+		{Source: "orders", Vhost: "/", Destination: "orders", DestinationType: "queue"},
+	})
+
+	if !bound["/"]["parked"] {
+		t.Error("Expected queue bound to dlx to be a dead letter queue")
+	}
+	if bound["/"]["orders"] || bound["/"]["other.exchange"] {
+		t.Errorf("Expected only queues bound to dlx, got %v", bound)
+	}
+}
+
+func TestNewDeadLetterRules_InvalidPattern(t *testing.T) {
+	if _, err := NewDeadLetterRules([]string{"("}, nil); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,163 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrUnauthorized is matched by API errors caused by rejected credentials
+	// or a user lacking management permissions (HTTP 401 and 403)
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrCircuitOpen is returned without contacting the broker while the
+	// circuit breaker is open
+	ErrCircuitOpen = errors.New("circuit breaker is open - too many recent failures")
+	// ErrTimeout is matched by requests that exceeded their deadline
+	ErrTimeout = errors.New("request timed out")
+	// ErrConnectionRefused is matched by requests the broker host refused
+	ErrConnectionRefused = errors.New("connection refused")
+	// ErrDecode is matched by responses that could not be decoded
+	ErrDecode = errors.New("invalid response")
+	// ErrRateLimited is matched by 429 and 503 responses carrying
+	// Retry-After, and by requests refused while that pause lasts
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Error types reported by ErrorType, used as the error_type label of
+// rabbitmq_custom_scrape_errors_total
+const (
+	ErrorTypeTimeout           = "timeout"
+	ErrorTypeConnectionRefused = "connection_refused"
+	ErrorTypeAuth              = "auth"
+	ErrorTypeServerError       = "server_error"
+	ErrorTypeClientError       = "client_error"
+	ErrorTypeDecode            = "decode"
+	ErrorTypeCircuitOpen       = "circuit_open"
+	ErrorTypeRateLimited       = "rate_limited"
+	ErrorTypeOther             = "other"
+)
+
+// ErrorType classifies an error returned by the client by its cause
+func ErrorType(err error) string {
+	var apiErr *APIError
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return ErrorTypeCircuitOpen
+	case errors.Is(err, ErrRateLimited):
+		return ErrorTypeRateLimited
+	case errors.Is(err, ErrTimeout):
+		return ErrorTypeTimeout
+	case errors.Is(err, ErrConnectionRefused):
+		return ErrorTypeConnectionRefused
+	case errors.Is(err, ErrUnauthorized):
+		return ErrorTypeAuth
+	case errors.Is(err, ErrDecode):
+		return ErrorTypeDecode
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		return ErrorTypeServerError
+	case errors.As(err, &apiErr):
+		return ErrorTypeClientError
+	default:
+		return ErrorTypeOther
+	}
+}
+
+// APIError is a non-200 response from the management API. Error and Reason
+// are decoded from the JSON body when the broker provides one.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	ErrorMsg   string `json:"error"`
+	Reason     string `json:"reason"`
+	// Body holds the raw response when it was not a JSON error document
+	Body string `json:"-"`
+	// RetryAfter is the pause a 429 or 503 asked for, or 0
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.ErrorMsg + ": " + e.Reason
+	if e.ErrorMsg == "" && e.Reason == "" {
+		msg = e.Body
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, msg)
+	}
+	return msg
+}
+
+// Is lets errors.Is(err, ErrUnauthorized) match authentication failures and
+// errors.Is(err, ErrRateLimited) match responses carrying Retry-After
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.RetryAfter > 0
+	}
+	return false
+}
+
+// Temporary reports whether the request may succeed if retried, i.e. the
+// broker returned a 5xx or asked the client to slow down
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+func (e *APIError) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if errorVal, ok := raw["error"].(string); ok {
+		e.ErrorMsg = errorVal
+	}
+	if reasonVal, ok := raw["reason"].(string); ok {
+		e.Reason = reasonVal
+	}
+
+	return nil
+}
+
+// newAPIError builds an APIError from a non-200 response body
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{}
+	if json.Unmarshal(body, apiErr) != nil || (apiErr.ErrorMsg == "" && apiErr.Reason == "") {
+		apiErr.Body = string(body)
+	}
+	apiErr.StatusCode = statusCode
+	return apiErr
+}
+
+// transportError marks a transport error with its cause, e.g. ErrTimeout,
+// while still unwrapping to the original error
+type transportError struct {
+	err   error
+	cause error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+
+func (e *transportError) Unwrap() error { return e.err }
+
+func (e *transportError) Is(target error) bool { return target == e.cause }
+
+// classifyTransportError wraps deadline and network timeout errors so they
+// match ErrTimeout, and refused connections so they match
+// ErrConnectionRefused
+func classifyTransportError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &transportError{err: err, cause: ErrTimeout}
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return &transportError{err: err, cause: ErrConnectionRefused}
+	}
+	return err
+}
@@ -0,0 +1,245 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetQueues_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"not_authorized","reason":"Login failed"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "wrong", time.Second)
+	_, err := client.GetQueues(context.Background())
+
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Expected ErrUnauthorized, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %T", err)
+	}
+	if apiErr.StatusCode != http.StatusUnauthorized || apiErr.ErrorMsg != "not_authorized" {
+		t.Errorf("Unexpected API error: %+v", apiErr)
+	}
+	if apiErr.Error() != "HTTP 401: not_authorized: Login failed" {
+		t.Errorf("Unexpected error message: %s", apiErr.Error())
+	}
+}
+
+func TestGetQueues_NonJSONError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second)
+	_, err := client.GetQueues(context.Background())
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadGateway || !apiErr.Temporary() {
+		t.Errorf("Expected temporary 502, got %+v", apiErr)
+	}
+	if errors.Is(err, ErrUnauthorized) {
+		t.Error("502 should not match ErrUnauthorized")
+	}
+}
+
+func TestGetQueues_CircuitOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second)
+	for i := 0; i < client.maxFailures; i++ {
+		client.GetQueues(context.Background())
+	}
+
+	_, err := client.GetQueues(context.Background())
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	// Breakers are tracked per endpoint, so the overview endpoint is still tried
+	if err := client.HealthCheck(context.Background()); errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected health check to use its own breaker, got %v", err)
+	}
+}
+
+func TestGetQueues_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", 50*time.Millisecond)
+	_, err := client.GetQueues(context.Background())
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestHealthCheck_Forbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second)
+	if err := client.HealthCheck(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	responder := func(status int, body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+	}
+
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refusedURL := refused.URL
+	refused.Close()
+
+	tests := []struct {
+		name   string
+		url    func(t *testing.T) string
+		expect string
+	}{
+		{"auth", func(t *testing.T) string { s := responder(401, `{}`); t.Cleanup(s.Close); return s.URL }, ErrorTypeAuth},
+		{"server error", func(t *testing.T) string { s := responder(503, `down`); t.Cleanup(s.Close); return s.URL }, ErrorTypeServerError},
+		{"client error", func(t *testing.T) string { s := responder(404, `{}`); t.Cleanup(s.Close); return s.URL }, ErrorTypeClientError},
+		{"decode", func(t *testing.T) string { s := responder(200, `{"not":"a list"}`); t.Cleanup(s.Close); return s.URL }, ErrorTypeDecode},
+		{"connection refused", func(t *testing.T) string { return refusedURL }, ErrorTypeConnectionRefused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(tt.url(t), "guest", "guest", time.Second)
+			_, err := client.GetQueues(context.Background())
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if got := ErrorType(err); got != tt.expect {
+				t.Errorf("ErrorType(%v) = %s, want %s", err, got, tt.expect)
+			}
+		})
+	}
+
+	if got := ErrorType(ErrCircuitOpen); got != ErrorTypeCircuitOpen {
+		t.Errorf("Expected circuit_open, got %s", got)
+	}
+	if got := ErrorType(classifyTransportError(context.DeadlineExceeded)); got != ErrorTypeTimeout {
+		t.Errorf("Expected timeout, got %s", got)
+	}
+	if got := ErrorType(errors.New("boom")); got != ErrorTypeOther {
+		t.Errorf("Expected other, got %s", got)
+	}
+}
+
+func TestGetQueues_RetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second, WithCircuitBreaker(1, time.Hour))
+	_, err := client.GetQueues(context.Background())
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 30*time.Second {
+		t.Fatalf("Expected a 429 asking for 30s, got %v", err)
+	}
+	if !errors.Is(err, ErrRateLimited) || ErrorType(err) != ErrorTypeRateLimited {
+		t.Errorf("Expected a rate limited error, got %v (%s)", err, ErrorType(err))
+	}
+	if client.CircuitBreakerStatus()[EndpointQueues].TotalFailures != 0 {
+		t.Error("Expected Retry-After not to count toward the circuit breaker")
+	}
+	if wait := time.Until(client.BackoffUntil()); wait < 29*time.Second || wait > 30*time.Second {
+		t.Errorf("Expected a 30s backoff, got %v", wait)
+	}
+
+	// Other endpoints wait out the pause too, without contacting the broker
+	if _, err := client.GetNodes(context.Background()); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected requests to fail fast during the backoff, got %v", err)
+	}
+	if err := client.HealthCheck(context.Background()); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected health checks to fail fast during the backoff, got %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected one request to reach the broker, got %d", got)
+	}
+}
+
+func TestGetQueues_RetryAfterCapped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second, WithMaxRetryAfter(time.Minute))
+	client.GetQueues(context.Background())
+	if wait := time.Until(client.BackoffUntil()); wait > time.Minute {
+		t.Errorf("Expected the backoff capped at 1m, got %v", wait)
+	}
+}
+
+func TestGetQueues_ServiceUnavailableWithoutRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "guest", "guest", time.Second)
+	_, err := client.GetQueues(context.Background())
+	if errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected a plain 503 not to be rate limited, got %v", err)
+	}
+	if client.CircuitBreakerStatus()[EndpointQueues].TotalFailures != 1 {
+		t.Error("Expected a plain 503 to count toward the circuit breaker")
+	}
+	if client.BackoffUntil().After(time.Now()) {
+		t.Error("Expected no backoff without Retry-After")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{"0", 0, false},
+		{"-5", 0, false},
+		{"", 0, false},
+		{"soon", 0, false},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := parseRetryAfter(tt.value, now); got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; expected %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
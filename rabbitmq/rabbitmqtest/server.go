@@ -0,0 +1,330 @@
+// Package rabbitmqtest provides a fake RabbitMQ management API for tests.
+// It serves the /api endpoints the exporter reads from canned data, and can
+// fail or delay them to exercise error handling without a live broker.
+package rabbitmqtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// Default credentials accepted by a new Server
+const (
+	DefaultUsername = "guest"
+	DefaultPassword = "guest"
+)
+
+// DefaultPageSize is the page size of a paginated response when the request
+// doesn't set page_size, as in the management API
+const DefaultPageSize = 100
+
+// paths maps each endpoint to the path it is served at
+var paths = map[string]string{
+	rabbitmq.EndpointQueues:           "/api/queues",
+	rabbitmq.EndpointNodes:            "/api/nodes",
+	rabbitmq.EndpointBindings:         "/api/bindings",
+	rabbitmq.EndpointOverview:         "/api/overview",
+	rabbitmq.EndpointPolicies:         "/api/policies",
+	rabbitmq.EndpointOperatorPolicies: "/api/operator-policies",
+	rabbitmq.EndpointAliveness:        "/api/aliveness-test/",
+	rabbitmq.EndpointHealthChecks:     "/api/health/checks/",
+	rabbitmq.EndpointChannels:         "/api/channels",
+	rabbitmq.EndpointConsumers:        "/api/consumers",
+	rabbitmq.EndpointConnections:      "/api/connections",
+}
+
+// failure is a canned error response for an endpoint
+type failure struct {
+	status int
+	reason string
+}
+
+// Server is a fake management API listening on a local address. Its Set
+// methods may be called while a client is using it.
+type Server struct {
+	*httptest.Server
+
+	mu               sync.Mutex
+	username         string
+	password         string
+	queues           []rabbitmq.Queue
+	nodes            []rabbitmq.Node
+	bindings         []rabbitmq.Binding
+	channels         []rabbitmq.Channel
+	consumers        []rabbitmq.Consumer
+	connections      []rabbitmq.Connection
+	policies         []rabbitmq.Policy
+	operatorPolicies []rabbitmq.Policy
+	overview         rabbitmq.Overview
+	failures         map[string]failure
+	latency          map[string]time.Duration
+	requests         map[string]int
+}
+
+// NewServer starts a Server with no queues, nodes or other objects. Close it
+// when the test is done.
+func NewServer() *Server {
+	s := &Server{
+		username: DefaultUsername,
+		password: DefaultPassword,
+		failures: make(map[string]failure),
+		latency:  make(map[string]time.Duration),
+		requests: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	for endpoint, path := range paths {
+		mux.Handle(path, s.handler(endpoint))
+	}
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// SetCredentials changes the basic auth credentials the server accepts.
+// Requests with other credentials get 401 Unauthorized.
+func (s *Server) SetCredentials(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.username, s.password = username, password
+}
+
+// SetQueues replaces the queues served by /api/queues
+func (s *Server) SetQueues(queues ...rabbitmq.Queue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queues = queues
+}
+
+// SetNodes replaces the nodes served by /api/nodes
+func (s *Server) SetNodes(nodes ...rabbitmq.Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes = nodes
+}
+
+// SetBindings replaces the bindings served by /api/bindings
+func (s *Server) SetBindings(bindings ...rabbitmq.Binding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bindings = bindings
+}
+
+// SetChannels replaces the channels served by /api/channels
+func (s *Server) SetChannels(channels ...rabbitmq.Channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels = channels
+}
+
+// SetConsumers replaces the consumers served by /api/consumers
+func (s *Server) SetConsumers(consumers ...rabbitmq.Consumer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consumers = consumers
+}
+
+// SetConnections replaces the connections served by /api/connections
+func (s *Server) SetConnections(connections ...rabbitmq.Connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connections = connections
+}
+
+// SetPolicies replaces the policies served by /api/policies
+func (s *Server) SetPolicies(policies ...rabbitmq.Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies = policies
+}
+
+// SetOperatorPolicies replaces the policies served by /api/operator-policies
+func (s *Server) SetOperatorPolicies(policies ...rabbitmq.Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.operatorPolicies = policies
+}
+
+// SetOverview replaces the overview served by /api/overview
+func (s *Server) SetOverview(overview rabbitmq.Overview) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overview = overview
+}
+
+// Fail makes endpoint, e.g. rabbitmq.EndpointQueues, answer with status and
+// a management API error body carrying reason. A status of 0 makes it
+// succeed again.
+func (s *Server) Fail(endpoint string, status int, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 {
+		delete(s.failures, endpoint)
+		return
+	}
+	s.failures[endpoint] = failure{status: status, reason: reason}
+}
+
+// SetLatency delays every response of endpoint by d. The delay ends early
+// when the client gives up on the request.
+func (s *Server) SetLatency(endpoint string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency[endpoint] = d
+}
+
+// Requests returns how many requests endpoint has received
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+func (s *Server) handler(endpoint string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[endpoint]++
+		delay := s.latency[endpoint]
+		fail, failing := s.failures[endpoint]
+		username, password := s.username, s.password
+		s.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if user, pass, ok := r.BasicAuth(); !ok || user != username || pass != password {
+			writeError(w, http.StatusUnauthorized, "Login failed")
+			return
+		}
+		if failing {
+			writeError(w, fail.status, fail.reason)
+			return
+		}
+
+		switch endpoint {
+		case rabbitmq.EndpointAliveness, rabbitmq.EndpointHealthChecks:
+			writeJSON(w, map[string]string{"status": "ok"})
+		case rabbitmq.EndpointOverview:
+			s.mu.Lock()
+			overview := s.overview
+			s.mu.Unlock()
+			writeJSON(w, overview)
+		default:
+			s.writeList(w, r, endpoint)
+		}
+	})
+}
+
+// writeList serves a list endpoint, paginated when the request sets page
+func (s *Server) writeList(w http.ResponseWriter, r *http.Request, endpoint string) {
+	s.mu.Lock()
+	var items []interface{}
+	switch endpoint {
+	case rabbitmq.EndpointQueues:
+		items = toItems(s.queues)
+	case rabbitmq.EndpointNodes:
+		items = toItems(s.nodes)
+	case rabbitmq.EndpointBindings:
+		items = toItems(s.bindings)
+	case rabbitmq.EndpointChannels:
+		items = toItems(s.channels)
+	case rabbitmq.EndpointConsumers:
+		items = toItems(s.consumers)
+	case rabbitmq.EndpointConnections:
+		items = toItems(s.connections)
+	case rabbitmq.EndpointPolicies:
+		items = toItems(s.policies)
+	case rabbitmq.EndpointOperatorPolicies:
+		items = toItems(s.operatorPolicies)
+	}
+	s.mu.Unlock()
+
+	query := r.URL.Query()
+	if !query.Has("page") {
+		writeJSON(w, items)
+		return
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		writeError(w, http.StatusBadRequest, "page must be a positive integer")
+		return
+	}
+	pageSize := DefaultPageSize
+	if raw := query.Get("page_size"); raw != "" {
+		pageSize, err = strconv.Atoi(raw)
+		if err != nil || pageSize < 1 {
+			writeError(w, http.StatusBadRequest, "page_size must be a positive integer")
+			return
+		}
+	}
+
+	pageCount := (len(items) + pageSize - 1) / pageSize
+	if pageCount == 0 {
+		pageCount = 1
+	}
+	if page > pageCount {
+		writeError(w, http.StatusBadRequest, "page out of range")
+		return
+	}
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+
+	writeJSON(w, Page{
+		Items:         items[start:end],
+		Page:          page,
+		PageSize:      pageSize,
+		PageCount:     pageCount,
+		ItemCount:     end - start,
+		FilteredCount: len(items),
+		TotalCount:    len(items),
+	})
+}
+
+// Page is a paginated list response, in the management API's format
+type Page struct {
+	Items         []interface{} `json:"items"`
+	Page          int           `json:"page"`
+	PageSize      int           `json:"page_size"`
+	PageCount     int           `json:"page_count"`
+	ItemCount     int           `json:"item_count"`
+	FilteredCount int           `json:"filtered_count"`
+	TotalCount    int           `json:"total_count"`
+}
+
+func toItems[T any](list []T) []interface{} {
+	items := make([]interface{}, len(list))
+	for i, item := range list {
+		items[i] = item
+	}
+	return items
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the management API's format
+func writeError(w http.ResponseWriter, status int, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":  strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_")),
+		"reason": reason,
+	})
+}
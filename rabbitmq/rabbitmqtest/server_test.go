@@ -0,0 +1,140 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/rabbitmq"
+)
+
+func TestServer_ServesObjects(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetQueues(
+		rabbitmq.Queue{Name: "orders", Vhost: "/", Messages: 12},
+		rabbitmq.Queue{Name: "billing", Vhost: "/"},
+	)
+	server.SetNodes(rabbitmq.Node{Name: "rabbit@a", Running: true})
+
+	client := rabbitmq.NewClient(server.URL, DefaultUsername, DefaultPassword, time.Second)
+	defer client.Close()
+
+	queues, err := client.GetQueues(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queues) != 2 || queues[0].Name != "orders" || queues[0].Messages != 12 {
+		t.Errorf("Expected the configured queues, got %+v", queues)
+	}
+	nodes, err := client.GetNodes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || !nodes[0].Running {
+		t.Errorf("Expected one running node, got %+v", nodes)
+	}
+	if err := client.AlivenessTest(context.Background(), "/"); err != nil {
+		t.Errorf("Expected aliveness test to pass, got %v", err)
+	}
+	if got := server.Requests(rabbitmq.EndpointQueues); got != 1 {
+		t.Errorf("Expected 1 queues request, got %d", got)
+	}
+}
+
+func TestServer_Fail(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Fail(rabbitmq.EndpointQueues, http.StatusInternalServerError, "boom")
+
+	client := rabbitmq.NewClient(server.URL, DefaultUsername, DefaultPassword, time.Second)
+	defer client.Close()
+
+	_, err := client.GetQueues(context.Background())
+	var apiErr *rabbitmq.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError || apiErr.Reason != "boom" {
+		t.Fatalf("Expected a 500 API error with reason boom, got %v", err)
+	}
+
+	server.Fail(rabbitmq.EndpointQueues, 0, "")
+	if _, err := client.GetQueues(context.Background()); err != nil {
+		t.Errorf("Expected queues to succeed again, got %v", err)
+	}
+}
+
+func TestServer_Credentials(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetCredentials("exporter", "secret")
+
+	client := rabbitmq.NewClient(server.URL, DefaultUsername, DefaultPassword, time.Second)
+	defer client.Close()
+
+	if _, err := client.GetQueues(context.Background()); !errors.Is(err, rabbitmq.ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestServer_Latency(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetLatency(rabbitmq.EndpointNodes, time.Second)
+
+	client := rabbitmq.NewClient(server.URL, DefaultUsername, DefaultPassword, 50*time.Millisecond)
+	defer client.Close()
+
+	if _, err := client.GetNodes(context.Background()); !errors.Is(err, rabbitmq.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+	if _, err := client.GetQueues(context.Background()); err != nil {
+		t.Errorf("Expected other endpoints to stay fast, got %v", err)
+	}
+}
+
+func TestServer_Pagination(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	var queues []rabbitmq.Queue
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		queues = append(queues, rabbitmq.Queue{Name: name, Vhost: "/"})
+	}
+	server.SetQueues(queues...)
+
+	get := func(query string) (*http.Response, Page) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", server.URL+"/api/queues?"+query, nil)
+		req.SetBasicAuth(DefaultUsername, DefaultPassword)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var page Page
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp, page
+	}
+
+	_, page := get("page=2&page_size=2")
+	if page.Page != 2 || page.PageCount != 3 || page.ItemCount != 2 || page.TotalCount != 5 {
+		t.Errorf("Unexpected page metadata: %+v", page)
+	}
+	if len(page.Items) != 2 || page.Items[0].(map[string]interface{})["name"] != "c" {
+		t.Errorf("Expected queues c and d, got %v", page.Items)
+	}
+
+	_, page = get("page=3&page_size=2")
+	if page.ItemCount != 1 {
+		t.Errorf("Expected 1 queue on the last page, got %d", page.ItemCount)
+	}
+
+	if resp, _ := get("page=4&page_size=2"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a page out of range, got %d", resp.StatusCode)
+	}
+}
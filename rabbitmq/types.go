@@ -1,7 +1,10 @@
 package rabbitmq
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -13,10 +16,101 @@ type Queue struct {
 	MessagesUnacknowledged int64                  `json:"messages_unacknowledged"`
 	Consumers              int64                  `json:"consumers"`
 	ConsumerUtilisation    float64                `json:"consumer_utilisation"`
+	ConsumerCapacity       *float64               `json:"consumer_capacity,omitempty"`
 	MessageStats           *MessageStats          `json:"message_stats,omitempty"`
 	Arguments              map[string]interface{} `json:"arguments"`
 	State                  string                 `json:"state,omitempty"`
-	IdleSince              *time.Time             `json:"idle_since,omitempty"`
+	IdleSince              *Timestamp             `json:"idle_since,omitempty"`
+	Type                   string                 `json:"type,omitempty"`
+	Durable                bool                   `json:"durable"`
+	AutoDelete             bool                   `json:"auto_delete"`
+	Exclusive              bool                   `json:"exclusive"`
+	Policy                 string                 `json:"policy,omitempty"`
+	OperatorPolicy         string                 `json:"operator_policy,omitempty"`
+
+	// Node hosts the queue's process: the node a classic queue lives on, or
+	// the leader of a quorum queue or stream
+	Node string `json:"node,omitempty"`
+
+	// EffectivePolicyDefinition merges the queue's policy and operator
+	// policy, e.g. {"max-length": 10000}
+	EffectivePolicyDefinition map[string]interface{} `json:"effective_policy_definition,omitempty"`
+
+	// Memory and message size details
+	Memory                     int64 `json:"memory"`
+	MessageBytes               int64 `json:"message_bytes"`
+	MessageBytesReady          int64 `json:"message_bytes_ready"`
+	MessageBytesUnacknowledged int64 `json:"message_bytes_unacknowledged"`
+	MessagesRAM                int64 `json:"messages_ram"`
+	MessagesPersistent         int64 `json:"messages_persistent"`
+
+	// Quorum queue replication details
+	Leader    string           `json:"leader,omitempty"`
+	Members   []string         `json:"members,omitempty"`
+	Online    []string         `json:"online,omitempty"`
+	OpenFiles map[string]int64 `json:"open_files,omitempty"`
+
+	// BackingQueueStatus is reported by classic queues
+	BackingQueueStatus *BackingQueueStatus `json:"backing_queue_status,omitempty"`
+
+	// Stream queue details
+	CommittedOffset int64         `json:"committed_offset,omitempty"`
+	Segments        int64         `json:"segments,omitempty"`
+	Readers         StreamReaders `json:"readers,omitempty"`
+}
+
+// Timestamp is a time reported by the management API. RabbitMQ 3.12 and
+// later use RFC 3339; earlier versions report "2006-01-02 15:04:05", without
+// zero-padding the hour, which is read as UTC.
+type Timestamp struct {
+	time.Time
+}
+
+// legacyTimestampLayout matches the timestamps of RabbitMQ before 3.12
+const legacyTimestampLayout = "2006-1-2 15:4:5"
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		t.Time = parsed
+		return nil
+	}
+	parsed, err := time.Parse(legacyTimestampLayout, raw)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", raw)
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Time.Format(time.RFC3339Nano))
+}
+
+// StreamReaders is the number of stream readers. Depending on the broker
+// version the API reports either a single count or a count per member node.
+type StreamReaders int64
+
+func (r *StreamReaders) UnmarshalJSON(data []byte) error {
+	var count int64
+	if err := json.Unmarshal(data, &count); err == nil {
+		*r = StreamReaders(count)
+		return nil
+	}
+
+	var perNode map[string]int64
+	if err := json.Unmarshal(data, &perNode); err != nil {
+		return err
+	}
+	total := int64(0)
+	for _, n := range perNode {
+		total += n
+	}
+	*r = StreamReaders(total)
+	return nil
 }
 
 type MessageStats struct {
@@ -28,39 +122,250 @@ type MessageStats struct {
 	AckDetails       *RateDetails `json:"ack_details,omitempty"`
 	Redeliver        int64        `json:"redeliver"`
 	RedeliverDetails *RateDetails `json:"redeliver_details,omitempty"`
+	Get              int64        `json:"get"`
+
+	// Deliveries that need no ack, and basic.get in either mode
+	DeliverNoAckDetails *RateDetails `json:"deliver_no_ack_details,omitempty"`
+	GetDetails          *RateDetails `json:"get_details,omitempty"`
+	GetNoAckDetails     *RateDetails `json:"get_no_ack_details,omitempty"`
 }
 
 type RateDetails struct {
 	Rate float64 `json:"rate"`
 }
 
+// UnmarshalJSON prefers avg_rate, the rate averaged over the msg_rates_age
+// window, which the management API only reports when that window was
+// requested
+func (d *RateDetails) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Rate    float64  `json:"rate"`
+		AvgRate *float64 `json:"avg_rate"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	d.Rate = raw.Rate
+	if raw.AvgRate != nil {
+		d.Rate = *raw.AvgRate
+	}
+	return nil
+}
+
 type QueueState string
 
 const (
 	QueueStateIdle    QueueState = "idle"
 	QueueStateActive  QueueState = "active"
 	QueueStateBlocked QueueState = "blocked"
+	// QueueStateFlow means the broker is throttling publishers to the queue
+	QueueStateFlow QueueState = "flow"
+	QueueStateDown QueueState = "down"
 )
 
+// QueueStates lists every state GetQueueState returns
+var QueueStates = []QueueState{
+	QueueStateIdle,
+	QueueStateActive,
+	QueueStateBlocked,
+	QueueStateFlow,
+	QueueStateDown,
+}
+
+const (
+	QueueTypeClassic = "classic"
+	QueueTypeQuorum  = "quorum"
+	QueueTypeStream  = "stream"
+)
+
+// Modes of a classic queue
+const (
+	QueueModeDefault = "default"
+	QueueModeLazy    = "lazy"
+)
+
+// GetQueueType returns the queue type, using the broker-reported type and
+// falling back to the x-queue-type argument. Queues declared without either
+// are classic queues.
+func (q *Queue) GetQueueType() string {
+	if q.Type != "" {
+		return q.Type
+	}
+	if queueType, ok := q.Arguments["x-queue-type"].(string); ok && queueType != "" {
+		return queueType
+	}
+	return QueueTypeClassic
+}
+
+// IsQuorumQueue reports whether the queue is a quorum queue
+func (q *Queue) IsQuorumQueue() bool {
+	return q.GetQueueType() == QueueTypeQuorum
+}
+
+// IsStreamQueue reports whether the queue is a stream
+func (q *Queue) IsStreamQueue() bool {
+	return q.GetQueueType() == QueueTypeStream
+}
+
+// IsSingleActiveConsumer reports whether the queue delivers to one consumer
+// at a time
+func (q *Queue) IsSingleActiveConsumer() bool {
+	return q.GetArgumentString("x-single-active-consumer") == "true"
+}
+
+// IsUnderReplicated reports whether fewer quorum queue members are online
+// than are configured
+func (q *Queue) IsUnderReplicated() bool {
+	return len(q.Online) < len(q.Members)
+}
+
+// IsDeadLetterQueue reports whether the queue is a dead letter queue using the
+// default detection rules
+// BackingQueueStatus holds the internals of a classic queue's storage that
+// the exporter reads
+type BackingQueueStatus struct {
+	// Mode is the mode the queue runs in, default or lazy
+	Mode string `json:"mode,omitempty"`
+	// PriorityLengths is the number of ready messages at each priority
+	// level of a priority queue
+	PriorityLengths map[int]int64 `json:"priority_lengths,omitempty"`
+}
+
+// UnmarshalJSON accepts the empty list reported by queues whose process
+// is down
+func (s *BackingQueueStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalObjectOrList(data, (*backingQueueStatus)(s))
+}
+
+type backingQueueStatus BackingQueueStatus
+
+// MaxPriority returns the number of priority levels a priority queue was
+// declared with through x-max-priority
+func (q *Queue) MaxPriority() (int64, bool) {
+	priority, ok := q.GetIntArgument("x-max-priority")
+	if !ok || priority <= 0 {
+		return 0, false
+	}
+	return priority, true
+}
+
+// QueueMode returns the mode a classic queue runs in, or an empty string for
+// other queue types. The mode reported by the running queue wins, since it
+// reflects both the x-queue-mode argument and any policy; the argument and
+// then the policy are only used while the queue doesn't report one.
+func (q *Queue) QueueMode() string {
+	if q.GetQueueType() != QueueTypeClassic {
+		return ""
+	}
+	if q.BackingQueueStatus != nil && q.BackingQueueStatus.Mode != "" {
+		return q.BackingQueueStatus.Mode
+	}
+	if mode := q.GetArgumentString("x-queue-mode"); mode != "" {
+		return mode
+	}
+	if mode, ok := q.EffectivePolicyDefinition["queue-mode"].(string); ok && mode != "" {
+		return mode
+	}
+	return QueueModeDefault
+}
+
 func (q *Queue) IsDeadLetterQueue() bool {
-	if _, hasDLX := q.Arguments["x-dead-letter-exchange"]; hasDLX {
-		return true
+	return defaultDeadLetterRules.Match(q)
+}
+
+// GetIntArgument returns the named queue argument as an integer. Arguments set
+// through the management UI or clients may arrive as JSON numbers or strings.
+func (q *Queue) GetIntArgument(name string) (int64, bool) {
+	value, ok := q.Arguments[name]
+	if !ok {
+		return 0, false
+	}
+	return intValue(value)
+}
+
+// MaxLength returns the queue's message count limit from the x-max-length
+// argument or its policy. RabbitMQ enforces the lower of the two.
+func (q *Queue) MaxLength() (int64, bool) {
+	return q.limit("x-max-length", "max-length")
+}
+
+// MaxLengthBytes returns the queue's size limit from the x-max-length-bytes
+// argument or its policy. RabbitMQ enforces the lower of the two.
+func (q *Queue) MaxLengthBytes() (int64, bool) {
+	return q.limit("x-max-length-bytes", "max-length-bytes")
+}
+
+func (q *Queue) limit(argument, policyKey string) (int64, bool) {
+	fromArgument, argumentOK := q.GetIntArgument(argument)
+	var fromPolicy int64
+	policyOK := false
+	if value, ok := q.EffectivePolicyDefinition[policyKey]; ok {
+		fromPolicy, policyOK = intValue(value)
 	}
 
-	name := q.Name
-	if len(name) >= 4 && name[len(name)-4:] == ".dlq" {
-		return true
+	switch {
+	case argumentOK && policyOK:
+		return min(fromArgument, fromPolicy), true
+	case argumentOK:
+		return fromArgument, true
+	case policyOK:
+		return fromPolicy, true
 	}
-	if len(name) >= 5 && name[len(name)-5:] == ".dead" {
-		return true
+	return 0, false
+}
+
+func intValue(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return parsed, true
 	}
-	if len(name) >= 11 && name[len(name)-11:] == ".deadletter" {
-		return true
+	return 0, false
+}
+
+// GetArgumentString returns the named queue argument formatted as a label
+// value, or an empty string when it isn't set
+func (q *Queue) GetArgumentString(name string) string {
+	value, ok := q.Arguments[name]
+	if !ok || value == nil {
+		return ""
 	}
-	return false
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
 }
 
+// GetQueueState returns the state reported by the broker where it says more
+// than "running", so flow control and unavailable queues show up. Running
+// queues, and brokers that don't report a state, are classified from their
+// consumers and message rates.
 func (q *Queue) GetQueueState() QueueState {
+	switch q.State {
+	case "flow":
+		return QueueStateFlow
+	case "down", "crashed", "stopped":
+		return QueueStateDown
+	case "idle":
+		return QueueStateIdle
+	}
+
 	if q.Consumers == 0 {
 		if q.Messages == 0 {
 			return QueueStateIdle
@@ -89,6 +394,16 @@ func (q *Queue) GetQueueState() QueueState {
 	return QueueStateActive
 }
 
+// GetConsumerCapacity returns the fraction of time the queue can deliver to
+// its consumers immediately. Brokers without consumer_capacity only report
+// consumer_utilisation, its predecessor.
+func (q *Queue) GetConsumerCapacity() float64 {
+	if q.ConsumerCapacity != nil {
+		return *q.ConsumerCapacity
+	}
+	return q.ConsumerUtilisation
+}
+
 func (q *Queue) GetPublishRate() float64 {
 	if q.MessageStats != nil && q.MessageStats.PublishDetails != nil {
 		return q.MessageStats.PublishDetails.Rate
@@ -103,6 +418,26 @@ func (q *Queue) GetDeliverRate() float64 {
 	return 0.0
 }
 
+// GetConsumeRate returns the rate messages leave the queue through
+// consumers and basic.get, with or without acks
+func (q *Queue) GetConsumeRate() float64 {
+	if q.MessageStats == nil {
+		return 0.0
+	}
+	rate := 0.0
+	for _, details := range []*RateDetails{
+		q.MessageStats.DeliverDetails,
+		q.MessageStats.DeliverNoAckDetails,
+		q.MessageStats.GetDetails,
+		q.MessageStats.GetNoAckDetails,
+	} {
+		if details != nil {
+			rate += details.Rate
+		}
+	}
+	return rate
+}
+
 func (q *Queue) GetAckRate() float64 {
 	if q.MessageStats != nil && q.MessageStats.AckDetails != nil {
 		return q.MessageStats.AckDetails.Rate
@@ -124,27 +459,307 @@ func (q *Queue) GetTotalRedeliveries() int64 {
 	return 0
 }
 
-type APIError struct {
-	ErrorMsg string `json:"error"`
-	Reason   string `json:"reason"`
+type Node struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	Running       bool     `json:"running"`
+	MemAlarm      bool     `json:"mem_alarm"`
+	DiskFreeAlarm bool     `json:"disk_free_alarm"`
+	Partitions    []string `json:"partitions"`
+
+	// File I/O counters, only reported by running nodes. Average times are
+	// in milliseconds over the broker's sampling window.
+	IOReadCount         int64        `json:"io_read_count"`
+	IOReadCountDetails  *RateDetails `json:"io_read_count_details,omitempty"`
+	IOReadBytes         int64        `json:"io_read_bytes"`
+	IOReadAvgTime       float64      `json:"io_read_avg_time"`
+	IOWriteCount        int64        `json:"io_write_count"`
+	IOWriteCountDetails *RateDetails `json:"io_write_count_details,omitempty"`
+	IOWriteBytes        int64        `json:"io_write_bytes"`
+	IOWriteAvgTime      float64      `json:"io_write_avg_time"`
+	IOSyncCount         int64        `json:"io_sync_count"`
+	IOSyncCountDetails  *RateDetails `json:"io_sync_count_details,omitempty"`
+	IOSyncAvgTime       float64      `json:"io_sync_avg_time"`
+	IOSeekCount         int64        `json:"io_seek_count"`
+
+	// Schema database transactions and message store operations
+	MnesiaRAMTxCount     int64 `json:"mnesia_ram_tx_count"`
+	MnesiaDiskTxCount    int64 `json:"mnesia_disk_tx_count"`
+	QueueIndexReadCount  int64 `json:"queue_index_read_count"`
+	QueueIndexWriteCount int64 `json:"queue_index_write_count"`
+	MsgStoreReadCount    int64 `json:"msg_store_read_count"`
+	MsgStoreWriteCount   int64 `json:"msg_store_write_count"`
+
+	// Erlang runtime: garbage collection and the schedulers' run queue
+	GCNum                   int64        `json:"gc_num"`
+	GCNumDetails            *RateDetails `json:"gc_num_details,omitempty"`
+	GCBytesReclaimed        int64        `json:"gc_bytes_reclaimed"`
+	GCBytesReclaimedDetails *RateDetails `json:"gc_bytes_reclaimed_details,omitempty"`
+	ContextSwitches         int64        `json:"context_switches"`
+	// RunQueue is the number of Erlang processes waiting for a scheduler
+	RunQueue int64 `json:"run_queue"`
 }
 
-func (e *APIError) Error() string {
-	return e.ErrorMsg + ": " + e.Reason
+// Overview is the cluster-wide summary returned by /api/overview
+type Overview struct {
+	ClusterName     string                `json:"cluster_name"`
+	RabbitMQVersion string                `json:"rabbitmq_version"`
+	ErlangVersion   string                `json:"erlang_version"`
+	MessageStats    *OverviewMessageStats `json:"message_stats,omitempty"`
+	ChurnRates      *ChurnRates           `json:"churn_rates,omitempty"`
+	QueueTotals     QueueTotals           `json:"queue_totals"`
+	ObjectTotals    ObjectTotals          `json:"object_totals"`
 }
 
-func (e *APIError) UnmarshalJSON(data []byte) error {
-	var raw map[string]interface{}
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return err
+// OverviewMessageStats adds the cluster-wide counters that have no per-queue
+// equivalent to MessageStats
+type OverviewMessageStats struct {
+	MessageStats
+	DeliverGet              int64        `json:"deliver_get"`
+	DeliverGetDetails       *RateDetails `json:"deliver_get_details,omitempty"`
+	Confirm                 int64        `json:"confirm"`
+	ConfirmDetails          *RateDetails `json:"confirm_details,omitempty"`
+	ReturnUnroutable        int64        `json:"return_unroutable"`
+	ReturnUnroutableDetails *RateDetails `json:"return_unroutable_details,omitempty"`
+	DropUnroutable          int64        `json:"drop_unroutable"`
+	DropUnroutableDetails   *RateDetails `json:"drop_unroutable_details,omitempty"`
+}
+
+// Channel is an AMQP channel as listed by /api/channels
+type Channel struct {
+	Name              string               `json:"name"`
+	Vhost             string               `json:"vhost"`
+	User              string               `json:"user"`
+	ConnectionDetails ConnectionDetails    `json:"connection_details"`
+	MessageStats      *ChannelMessageStats `json:"message_stats,omitempty"`
+}
+
+// ConnectionDetails identifies the connection a channel belongs to
+type ConnectionDetails struct {
+	Name string `json:"name"`
+}
+
+// UnmarshalJSON accepts the empty list the management API reports for
+// channels whose connection is already gone
+func (d *ConnectionDetails) UnmarshalJSON(data []byte) error {
+	return unmarshalObjectOrList(data, (*connectionDetails)(d))
+}
+
+type connectionDetails ConnectionDetails
+
+// Connection is a client connection as listed by /api/connections
+type Connection struct {
+	Name     string `json:"name"`
+	Vhost    string `json:"vhost"`
+	User     string `json:"user"`
+	Protocol string `json:"protocol"`
+	// SSL is whether the client connected over TLS
+	SSL bool `json:"ssl"`
+	// Channels is the number of channels open on the connection
+	Channels         int64            `json:"channels"`
+	ClientProperties ClientProperties `json:"client_properties"`
+	RecvOctDetails   *RateDetails     `json:"recv_oct_details,omitempty"`
+	SendOctDetails   *RateDetails     `json:"send_oct_details,omitempty"`
+}
+
+// ClientProperties are the properties a client announced when connecting
+type ClientProperties struct {
+	// ConnectionName is the client-provided connection name, which
+	// applications set to identify themselves
+	ConnectionName string `json:"connection_name"`
+}
+
+// UnmarshalJSON accepts the empty list the management API reports for
+// connections without client properties, e.g. direct Erlang clients
+func (p *ClientProperties) UnmarshalJSON(data []byte) error {
+	return unmarshalObjectOrList(data, (*clientProperties)(p))
+}
+
+type clientProperties ClientProperties
+
+// unmarshalObjectOrList decodes a JSON object into v, leaving v unchanged
+// for a JSON list. Erlang's JSON encoder turns empty proplists into [].
+func unmarshalObjectOrList(data []byte, v interface{}) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return nil
 	}
+	return json.Unmarshal(data, v)
+}
 
-	if errorVal, ok := raw["error"].(string); ok {
-		e.ErrorMsg = errorVal
+// GetReceiveRate returns the rate in bytes per second the connection
+// receives from the client
+func (c *Connection) GetReceiveRate() float64 {
+	if c.RecvOctDetails != nil {
+		return c.RecvOctDetails.Rate
 	}
-	if reasonVal, ok := raw["reason"].(string); ok {
-		e.Reason = reasonVal
+	return 0.0
+}
+
+// GetSendRate returns the rate in bytes per second the connection sends to
+// the client
+func (c *Connection) GetSendRate() float64 {
+	if c.SendOctDetails != nil {
+		return c.SendOctDetails.Rate
 	}
+	return 0.0
+}
 
-	return nil
+// Consumer is a queue consumer as listed by /api/consumers
+type Consumer struct {
+	ConsumerTag string        `json:"consumer_tag"`
+	Queue       ConsumerQueue `json:"queue"`
+	// AckRequired is false for consumers in automatic acknowledgement mode
+	AckRequired bool `json:"ack_required"`
+	// PrefetchCount is the channel's prefetch limit; 0 means unlimited
+	PrefetchCount int64 `json:"prefetch_count"`
+	Active        bool  `json:"active"`
+	// ActivityStatus is "up", or on single active consumer queues
+	// "single_active" for the consumer receiving messages and "waiting"
+	// for the others
+	ActivityStatus string `json:"activity_status"`
+}
+
+// ConsumerQueue identifies the queue a consumer is attached to
+type ConsumerQueue struct {
+	Name  string `json:"name"`
+	Vhost string `json:"vhost"`
+}
+
+// ChannelMessageStats are the publishing stats of a channel. Unroutable
+// messages never reach a queue, so they only show up here and in the
+// overview.
+type ChannelMessageStats struct {
+	Publish                 int64        `json:"publish"`
+	PublishDetails          *RateDetails `json:"publish_details,omitempty"`
+	Confirm                 int64        `json:"confirm"`
+	ConfirmDetails          *RateDetails `json:"confirm_details,omitempty"`
+	ReturnUnroutable        int64        `json:"return_unroutable"`
+	ReturnUnroutableDetails *RateDetails `json:"return_unroutable_details,omitempty"`
+	DropUnroutable          int64        `json:"drop_unroutable"`
+	DropUnroutableDetails   *RateDetails `json:"drop_unroutable_details,omitempty"`
+}
+
+func (ch *Channel) GetPublishRate() float64 {
+	if ch.MessageStats != nil && ch.MessageStats.PublishDetails != nil {
+		return ch.MessageStats.PublishDetails.Rate
+	}
+	return 0.0
+}
+
+func (ch *Channel) GetConfirmRate() float64 {
+	if ch.MessageStats != nil && ch.MessageStats.ConfirmDetails != nil {
+		return ch.MessageStats.ConfirmDetails.Rate
+	}
+	return 0.0
+}
+
+// GetReturnUnroutableRate returns the rate of mandatory messages returned to
+// the publisher because no queue was bound to receive them
+func (ch *Channel) GetReturnUnroutableRate() float64 {
+	if ch.MessageStats != nil && ch.MessageStats.ReturnUnroutableDetails != nil {
+		return ch.MessageStats.ReturnUnroutableDetails.Rate
+	}
+	return 0.0
+}
+
+// GetDropUnroutableRate returns the rate of messages the broker silently
+// dropped because they were unroutable and not published as mandatory
+func (ch *Channel) GetDropUnroutableRate() float64 {
+	if ch.MessageStats != nil && ch.MessageStats.DropUnroutableDetails != nil {
+		return ch.MessageStats.DropUnroutableDetails.Rate
+	}
+	return 0.0
+}
+
+// ChurnRates counts objects created and closed across the cluster
+type ChurnRates struct {
+	ConnectionCreated        int64       `json:"connection_created"`
+	ConnectionCreatedDetails RateDetails `json:"connection_created_details"`
+	ConnectionClosed         int64       `json:"connection_closed"`
+	ConnectionClosedDetails  RateDetails `json:"connection_closed_details"`
+	ChannelCreated           int64       `json:"channel_created"`
+	ChannelCreatedDetails    RateDetails `json:"channel_created_details"`
+	ChannelClosed            int64       `json:"channel_closed"`
+	ChannelClosedDetails     RateDetails `json:"channel_closed_details"`
+	QueueCreated             int64       `json:"queue_created"`
+	QueueCreatedDetails      RateDetails `json:"queue_created_details"`
+	QueueDeclared            int64       `json:"queue_declared"`
+	QueueDeclaredDetails     RateDetails `json:"queue_declared_details"`
+	QueueDeleted             int64       `json:"queue_deleted"`
+	QueueDeletedDetails      RateDetails `json:"queue_deleted_details"`
+}
+
+type QueueTotals struct {
+	Messages               int64 `json:"messages"`
+	MessagesReady          int64 `json:"messages_ready"`
+	MessagesUnacknowledged int64 `json:"messages_unacknowledged"`
+}
+
+type ObjectTotals struct {
+	Queues      int64 `json:"queues"`
+	Exchanges   int64 `json:"exchanges"`
+	Connections int64 `json:"connections"`
+	Channels    int64 `json:"channels"`
+	Consumers   int64 `json:"consumers"`
+}
+
+// GetPublishRate returns the cluster-wide publish rate
+func (o *Overview) GetPublishRate() float64 {
+	if o.MessageStats != nil && o.MessageStats.PublishDetails != nil {
+		return o.MessageStats.PublishDetails.Rate
+	}
+	return 0.0
+}
+
+// GetDeliverRate returns the cluster-wide rate of deliveries including
+// basic.get, the overview's equivalent of a queue's deliver rate
+func (o *Overview) GetDeliverRate() float64 {
+	if o.MessageStats != nil && o.MessageStats.DeliverGetDetails != nil {
+		return o.MessageStats.DeliverGetDetails.Rate
+	}
+	return 0.0
+}
+
+func (o *Overview) GetAckRate() float64 {
+	if o.MessageStats != nil && o.MessageStats.AckDetails != nil {
+		return o.MessageStats.AckDetails.Rate
+	}
+	return 0.0
+}
+
+func (o *Overview) GetRedeliverRate() float64 {
+	if o.MessageStats != nil && o.MessageStats.RedeliverDetails != nil {
+		return o.MessageStats.RedeliverDetails.Rate
+	}
+	return 0.0
+}
+
+func (o *Overview) GetConfirmRate() float64 {
+	if o.MessageStats != nil && o.MessageStats.ConfirmDetails != nil {
+		return o.MessageStats.ConfirmDetails.Rate
+	}
+	return 0.0
+}
+
+// Policy is a policy or operator policy as listed by the management API
+type Policy struct {
+	Name       string                 `json:"name"`
+	Vhost      string                 `json:"vhost"`
+	Pattern    string                 `json:"pattern"`
+	ApplyTo    string                 `json:"apply-to"`
+	Priority   int                    `json:"priority"`
+	Definition map[string]interface{} `json:"definition"`
+}
+
+// AppliesToQueues reports whether the policy can match queues at all
+func (p *Policy) AppliesToQueues() bool {
+	return p.ApplyTo != "exchanges"
+}
+
+type Binding struct {
+	Source          string `json:"source"`
+	Vhost           string `json:"vhost"`
+	Destination     string `json:"destination"`
+	DestinationType string `json:"destination_type"`
+	RoutingKey      string `json:"routing_key"`
 }
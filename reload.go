@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sync"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+
+	"github.com/spf13/viper"
+)
+
+// reconfigurable is the part of *exporter.Collector a reload updates
+type reconfigurable interface {
+	Reconfigure(thresholds *exporter.DepthThresholdMatcher, deadLetter *rabbitmq.DeadLetterRules)
+	Refresh()
+}
+
+// configReloader re-reads the config file on request. Queue depth thresholds
+// and dead letter rules are applied in place and the cluster name is
+// re-detected; every other setting needs a restart.
+type configReloader struct {
+	configFile string
+	collector  reconfigurable
+	metrics    *metrics.Metrics
+	client     *rabbitmq.Client
+	cluster    *exporter.ClusterIdentity
+
+	mu      sync.Mutex
+	current exporter.Config
+}
+
+func newConfigReloader(configFile string, current exporter.Config, collector reconfigurable, m *metrics.Metrics, client *rabbitmq.Client, cluster *exporter.ClusterIdentity) *configReloader {
+	return &configReloader{
+		configFile: configFile,
+		collector:  collector,
+		metrics:    m,
+		client:     client,
+		cluster:    cluster,
+		current:    current,
+	}
+}
+
+// Reload applies the config file's current contents. An invalid file leaves
+// the running configuration untouched.
+func (r *configReloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := loadConfig(r.configFile, false)
+	if err != nil {
+		return err
+	}
+	thresholds, err := exporter.NewDepthThresholdMatcher(cfg.QueueDepthThresholds, exporter.DepthThresholds{
+		Warning:  exporter.DefaultDepthWarning,
+		Critical: exporter.DefaultDepthCritical,
+	})
+	if err != nil {
+		return err
+	}
+	deadLetter, err := rabbitmq.NewDeadLetterRules(cfg.DeadLetter.Patterns, cfg.DeadLetter.Exchanges)
+	if err != nil {
+		return err
+	}
+
+	r.collector.Reconfigure(thresholds, deadLetter)
+	if r.cluster != nil {
+		if err := r.cluster.Detect(ctx, r.client); err != nil {
+			log.Printf("Warning: %v; keeping cluster label %q", err, r.cluster.Name())
+		}
+	}
+	if !reflect.DeepEqual(restartOnlySettings(r.current), restartOnlySettings(cfg)) {
+		log.Printf("Warning: config reload changed settings that only take effect after a restart")
+	}
+	r.current.QueueDepthThresholds = cfg.QueueDepthThresholds
+	r.current.DeadLetter = cfg.DeadLetter
+	r.metrics.SetConfigInfo(configInfoValues(r.current, viper.ConfigFileUsed())...)
+	r.collector.Refresh()
+
+	log.Printf("Config reloaded: %d queue depth threshold rules", len(cfg.QueueDepthThresholds))
+	return nil
+}
+
+// Config returns the configuration in effect: the settings loaded at
+// startup with those from the last reload applied
+func (r *configReloader) Config() exporter.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// restartOnlySettings clears the settings Reload applies, leaving the ones
+// that need a restart
+func restartOnlySettings(cfg exporter.Config) exporter.Config {
+	cfg.QueueDepthThresholds = nil
+	cfg.DeadLetter = exporter.DeadLetterConfig{}
+	return cfg
+}
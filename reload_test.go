@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// fakeReconfigurable records what a reload applies
+type fakeReconfigurable struct {
+	thresholds *exporter.DepthThresholdMatcher
+	refreshes  int
+}
+
+func (f *fakeReconfigurable) Reconfigure(thresholds *exporter.DepthThresholdMatcher, deadLetter *rabbitmq.DeadLetterRules) {
+	f.thresholds = thresholds
+}
+
+func (f *fakeReconfigurable) Refresh() { f.refreshes++ }
+
+func TestConfigReloader_Reload(t *testing.T) {
+	path := writeCheckConfig(t, "queue_depth_thresholds:\n  - pattern: \"^orders\\\\.\"\n    warning: 10\n")
+	cfg, err := loadConfig(path, false)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cluster_name":"rabbit@prod"}`))
+	}))
+	defer rabbit.Close()
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+
+	collector := &fakeReconfigurable{}
+	cluster := exporter.NewClusterIdentity(exporter.ClusterLabelConfig{Enabled: true})
+	reloader := newConfigReloader(path, cfg, collector, metrics.NewMetrics(), client, cluster)
+
+	orders := rabbitmq.Queue{Name: "orders.created"}
+	if err := os.WriteFile(path, []byte("queue_depth_thresholds:\n  - pattern: \"^orders\\\\.\"\n    warning: 20\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloader.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got := collector.thresholds.ForQueue(orders).Warning; got != 20 {
+		t.Errorf("Expected the reloaded warning threshold 20, got %d", got)
+	}
+	if got := cluster.Name(); got != "rabbit@prod" {
+		t.Errorf("Expected the cluster name to be re-detected, got %q", got)
+	}
+	if collector.refreshes != 1 {
+		t.Errorf("Expected a reload to trigger a collection, got %d", collector.refreshes)
+	}
+
+	// An invalid file keeps the running rules
+	if err := os.WriteFile(path, []byte("queue_depth_thresholds:\n  - pattern: \"(\"\n    warning: 30\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloader.Reload(context.Background()); err == nil {
+		t.Fatal("Expected an invalid pattern to fail the reload")
+	}
+	if got := collector.thresholds.ForQueue(orders).Warning; got != 20 {
+		t.Errorf("Expected the warning threshold to stay 20, got %d", got)
+	}
+}
+
+func TestAdminEndpoints_RefreshAndReload(t *testing.T) {
+	path := writeCheckConfig(t, "scrape_interval: 15s\n")
+	cfg, err := loadConfig(path, false)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.AdminUsername = "admin"
+	cfg.AdminPassword = "secret"
+
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer rabbit.Close()
+	m := metrics.NewMetrics()
+	collector := exporter.NewCollector(rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second), m, time.Hour)
+	defer collector.Stop()
+
+	mux := http.NewServeMux()
+	registerAdminHandlers(mux, cfg, collector, newConfigReloader(path, cfg, collector, m, nil, nil))
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{path: "/-/refresh", expected: http.StatusAccepted},
+		{path: "/-/reload", expected: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req.SetBasicAuth("admin", "secret")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, rec.Code, rec.Body.String())
+			}
+
+			unauthorized := httptest.NewRecorder()
+			mux.ServeHTTP(unauthorized, httptest.NewRequest(http.MethodPost, tt.path, nil))
+			if unauthorized.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status 401 without credentials, got %d", unauthorized.Code)
+			}
+		})
+	}
+}
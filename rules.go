@@ -0,0 +1,410 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/metrics"
+)
+
+// RuleFile is a Prometheus rule file
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// RuleGroup is a named group of alerting rules
+type RuleGroup struct {
+	Name  string      `yaml:"name"`
+	Rules []AlertRule `yaml:"rules"`
+}
+
+// AlertRule is a single Prometheus alerting rule
+type AlertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Work with Prometheus alerting rules for this exporter",
+}
+
+var rulesGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Print Prometheus alerting rules matching the configured thresholds",
+	RunE:  runRulesGenerate,
+}
+
+func init() {
+	rulesGenerateCmd.Flags().String("config", "", "Path to config file (default: config.yaml)")
+	rulesGenerateCmd.Flags().StringP("output", "o", "", "Write the rules to a file instead of stdout")
+
+	rulesCmd.AddCommand(rulesGenerateCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
+
+func runRulesGenerate(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfig(configFile, false)
+	if err != nil {
+		return err
+	}
+
+	if err := checkGeneratedNaming(cfg.MetricNaming, "rules generate"); err != nil {
+		return err
+	}
+	rules, err := GenerateAlertRules(cfg.QueueDepthThresholds, cfg.SeverityTiers, cfg.HealthMetrics, cfg.MetricNamespace)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if path, _ := cmd.Flags().GetString("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create rules file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return writeRuleFile(out, rules)
+}
+
+func writeRuleFile(w io.Writer, rules RuleFile) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(rules); err != nil {
+		return fmt.Errorf("failed to encode rules: %w", err)
+	}
+	return enc.Close()
+}
+
+// GenerateAlertRules builds alerting rules whose thresholds match the
+// exporter's: one depth alert pair per queue_depth_thresholds rule plus the
+// defaults, a depth and utilisation alert per severity tier, and, when
+// healthMetrics is set, the health score cutoffs used by the collector. Each
+// tier's labels are added to the rules of its severity. Metric names use
+// namespace as their prefix.
+func GenerateAlertRules(depthConfigs []exporter.DepthThresholdConfig, tierConfigs []exporter.SeverityTierConfig, healthMetrics bool, namespace string) (RuleFile, error) {
+	metric := metricNamer(namespace)
+
+	tiers, err := exporter.NewSeverityTiers(tierConfigs)
+	if err != nil {
+		return RuleFile{}, err
+	}
+
+	matcher, err := exporter.NewDepthThresholdMatcher(depthConfigs, exporter.DepthThresholds{
+		Warning:  exporter.DefaultDepthWarning,
+		Critical: exporter.DefaultDepthCritical,
+	})
+	if err != nil {
+		return RuleFile{}, err
+	}
+
+	var rules []AlertRule
+
+	// Prometheus anchors regex matchers while the exporter doesn't, and the
+	// first matching pattern wins, so each rule excludes the ones before it
+	var seen []string
+	for _, rule := range matcher.Patterns() {
+		pattern := rule.Pattern
+		selector := "queue_name=~" + strconv.Quote(unanchored(pattern))
+		if len(seen) > 0 {
+			selector += ", queue_name!~" + strconv.Quote(unanchored(seen...))
+		}
+		rules = append(rules, depthAlertRules(metric("queue_messages"), selector, pattern, rule.Thresholds, tiers)...)
+		seen = append(seen, pattern)
+	}
+
+	var selector string
+	if len(seen) > 0 {
+		selector = "queue_name!~" + strconv.Quote(unanchored(seen...))
+	}
+	rules = append(rules, depthAlertRules(metric("queue_messages"), selector, "", matcher.Defaults(), tiers)...)
+
+	for _, tier := range tiers {
+		if tier.Severity == exporter.SeverityWarning || tier.Severity == exporter.SeverityCritical || tier.Depth == 0 {
+			continue
+		}
+		rules = append(rules, AlertRule{
+			Alert:  "QueueDepth" + alertSuffix(tier.Severity),
+			Expr:   fmt.Sprintf("%s > %d", metric("queue_messages"), tier.Depth),
+			For:    "5m",
+			Labels: map[string]string{"severity": tier.Severity},
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Queue depth above %s threshold", tier.Severity),
+				"description": fmt.Sprintf("Queue {{ $labels.queue_name }} in {{ $labels.vhost }} has {{ $value }} messages (%s at %d)", tier.Severity, tier.Depth),
+			},
+		})
+	}
+
+	for _, tier := range tiers {
+		if tier.Utilisation == 0 {
+			continue
+		}
+		rule := AlertRule{
+			Alert:  "LowConsumerUtilization",
+			Expr:   fmt.Sprintf("%s < %g", metric("queue_consumer_utilisation"), tier.Utilisation),
+			For:    "5m",
+			Labels: map[string]string{"severity": tier.Severity},
+			Annotations: map[string]string{
+				"summary":     "Low consumer utilization",
+				"description": "Queue {{ $labels.queue_name }} in {{ $labels.vhost }} has consumer utilisation {{ $value }}",
+			},
+		}
+		if tier.Severity == exporter.SeverityCritical {
+			rule.For = "2m"
+			rule.Annotations["summary"] = "Consumers are barely keeping up"
+		}
+		rules = append(rules, rule)
+	}
+
+	rules = append(rules,
+		AlertRule{
+			Alert:  "QueueNearMaxLength",
+			Expr:   saturationExpr(metric, exporter.SaturationWarning),
+			For:    "5m",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Queue is approaching its max-length limit",
+				"description": "Queue {{ $labels.queue_name }} in {{ $labels.vhost }} is at {{ $value | humanizePercentage }} of its max-length limit; messages will be dropped or rejected at 100%",
+			},
+		},
+		AlertRule{
+			Alert:  "QueueNearMaxLength",
+			Expr:   saturationExpr(metric, exporter.SaturationCritical),
+			For:    "1m",
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "Queue is about to overflow",
+				"description": "Queue {{ $labels.queue_name }} in {{ $labels.vhost }} is at {{ $value | humanizePercentage }} of its max-length limit; messages will be dropped or rejected at 100%",
+			},
+		},
+		AlertRule{
+			Alert:  "QueueBacklogGrowing",
+			Expr:   metric("queue_backlog_growing") + " == 1",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Queue backlog keeps growing",
+				"description": "Queue {{ $labels.queue_name }} in {{ $labels.vhost }} receives messages faster than its consumers take them; the backlog has grown for several collections in a row",
+			},
+		},
+	)
+
+	if healthMetrics {
+		rules = append(rules,
+			AlertRule{
+				Alert:  "PoorQueueHealth",
+				Expr:   fmt.Sprintf("%s < %d", metric("queue_health_score"), exporter.HealthScoreWarning),
+				For:    "5m",
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "Poor queue health detected",
+					"description": "Queue {{ $labels.queue_name }} in {{ $labels.vhost }} has health score {{ $value }}",
+				},
+			},
+			AlertRule{
+				Alert:  "PoorQueueHealth",
+				Expr:   fmt.Sprintf("%s < %d", metric("queue_health_score"), exporter.HealthScoreCritical),
+				For:    "5m",
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary":     "Very poor queue health detected",
+					"description": "Queue {{ $labels.queue_name }} in {{ $labels.vhost }} has health score {{ $value }}",
+				},
+			},
+		)
+	}
+
+	rules = append(rules,
+		AlertRule{
+			Alert:  "RabbitMQUnroutableMessages",
+			Expr:   metric("vhost_unroutable_alert") + " == 1",
+			For:    "5m",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Messages are unroutable",
+				"description": "Messages published in {{ $labels.vhost }} match no queue and are being returned or dropped; check bindings and the alternate exchange",
+			},
+		},
+		AlertRule{
+			Alert:  "RabbitMQMemoryAlarm",
+			Expr:   metric("node_mem_alarm") + " == 1",
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "RabbitMQ memory alarm",
+				"description": "Node {{ $labels.node }} has raised its memory alarm; publishers across the cluster are blocked",
+			},
+		},
+		AlertRule{
+			Alert:  "RabbitMQDiskAlarm",
+			Expr:   metric("node_disk_free_alarm") + " == 1",
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "RabbitMQ disk free alarm",
+				"description": "Node {{ $labels.node }} is low on disk space; publishers across the cluster are blocked",
+			},
+		},
+		AlertRule{
+			Alert:  "RabbitMQNetworkPartition",
+			Expr:   metric("node_partitions") + " > 0",
+			For:    "1m",
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "RabbitMQ network partition detected",
+				"description": "Node {{ $labels.node }} is partitioned from {{ $value }} cluster peers",
+			},
+		},
+		AlertRule{
+			Alert:  "RabbitMQClusterMemberMissing",
+			Expr:   metric("cluster_nodes_running") + " < " + metric("cluster_nodes_expected"),
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "RabbitMQ cluster member missing",
+				"description": "Only {{ $value }} cluster nodes are running, fewer than expected_nodes",
+			},
+		},
+		AlertRule{
+			Alert:  "RabbitMQExporterDown",
+			Expr:   metric("up") + " == 0",
+			For:    "2m",
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "RabbitMQ exporter cannot collect from the management API",
+				"description": "Background collection is failing; queue metrics are not being updated",
+			},
+		},
+		AlertRule{
+			Alert:  "RabbitMQCircuitBreakerOpen",
+			Expr:   metric("circuit_breaker_state") + " == 1",
+			For:    "1m",
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "RabbitMQ circuit breaker is open",
+				"description": "Too many failures on the {{ $labels.endpoint }} endpoint, circuit breaker has opened",
+			},
+		},
+	)
+
+	for _, tier := range tiers {
+		for i := range rules {
+			if rules[i].Labels["severity"] != tier.Severity {
+				continue
+			}
+			for name, value := range tier.Labels {
+				rules[i].Labels[name] = value
+			}
+		}
+	}
+
+	return RuleFile{Groups: []RuleGroup{{Name: "rabbitmq-exporter", Rules: rules}}}, nil
+}
+
+func depthAlertRules(series, selector, pattern string, thresholds exporter.DepthThresholds, tiers []exporter.SeverityTier) []AlertRule {
+	scope := "default thresholds"
+	if pattern != "" {
+		scope = fmt.Sprintf("threshold rule %q", pattern)
+	}
+
+	if selector != "" {
+		series += "{" + selector + "}"
+	}
+
+	var rules []AlertRule
+	for _, tier := range tiers {
+		switch tier.Severity {
+		case exporter.SeverityWarning:
+			rules = append(rules, AlertRule{
+				Alert:  "QueueDepthWarning",
+				Expr:   fmt.Sprintf("%s > %d", series, thresholds.Warning),
+				For:    "5m",
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "Queue depth above warning threshold",
+					"description": fmt.Sprintf("Queue {{ $labels.queue_name }} in {{ $labels.vhost }} has {{ $value }} messages (warning at %d, %s)", thresholds.Warning, scope),
+				},
+			})
+		case exporter.SeverityCritical:
+			rules = append(rules, AlertRule{
+				Alert:  "QueueDepthCritical",
+				Expr:   fmt.Sprintf("%s > %d", series, thresholds.Critical),
+				For:    "1m",
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary":     "Queue depth above critical threshold",
+					"description": fmt.Sprintf("Queue {{ $labels.queue_name }} in {{ $labels.vhost }} has {{ $value }} messages (critical at %d, %s)", thresholds.Critical, scope),
+				},
+			})
+		}
+	}
+	return rules
+}
+
+// alertSuffix turns a severity into the suffix of an alert name, e.g. page
+// into Page
+func alertSuffix(severity string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range severity {
+		if r == '_' || r == '-' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// saturationExpr matches queues above threshold of either max-length limit
+func saturationExpr(metric func(string) string, threshold float64) string {
+	return fmt.Sprintf("%s > %g or %s > %g",
+		metric("queue_max_length_ratio"), threshold, metric("queue_max_length_bytes_ratio"), threshold)
+}
+
+// metricNamer returns a function that prefixes metric names with namespace,
+// or the default namespace when empty
+func metricNamer(namespace string) func(string) string {
+	if namespace == "" {
+		namespace = metrics.DefaultNamespace
+	}
+	return func(suffix string) string {
+		return namespace + "_" + suffix
+	}
+}
+
+// checkGeneratedNaming rejects the kbudde naming scheme for generated rules
+// and dashboards. Under it only mapped metrics are renamed and lose their
+// queue_name label, so no single selector matches both kinds, while the
+// native names the generators use are missing for mapped metrics.
+func checkGeneratedNaming(naming, command string) error {
+	if naming == metrics.NamingKbudde {
+		return fmt.Errorf("%s needs the native metric names, which metric_naming %q doesn't expose; use %q instead", command, naming, metrics.NamingBoth)
+	}
+	return nil
+}
+
+// unanchored turns Go regexps, which match anywhere in a queue name, into a
+// single PromQL regex, which must match the whole label value
+func unanchored(patterns ...string) string {
+	groups := make([]string, len(patterns))
+	for i, p := range patterns {
+		groups[i] = "(?:" + p + ")"
+	}
+	return ".*(?:" + strings.Join(groups, "|") + ").*"
+}
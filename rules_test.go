@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/metrics"
+)
+
+var depthExprPattern = regexp.MustCompile(`^rabbitmq_custom_queue_messages(?:\{(.*)\})? > (\d+)$`)
+var selectorPattern = regexp.MustCompile(`queue_name(=~|!~)("(?:[^"\\]|\\.)*")`)
+
+// promMatches evaluates a generated depth selector against a queue name the
+// way Prometheus does, with fully anchored regexes
+func promMatches(t *testing.T, selector, queueName string) bool {
+	t.Helper()
+	for _, m := range selectorPattern.FindAllStringSubmatch(selector, -1) {
+		value, err := strconv.Unquote(m[2])
+		if err != nil {
+			t.Fatalf("Invalid selector value %s: %v", m[2], err)
+		}
+		matched := regexp.MustCompile("^(?:" + value + ")$").MatchString(queueName)
+		if (m[1] == "=~") != matched {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGenerateAlertRules_DepthThresholds(t *testing.T) {
+	configs := []exporter.DepthThresholdConfig{
+		{Pattern: `^orders\.`, Warning: 5000, Critical: 50000},
+		{Pattern: `batch`, Critical: 200000},
+	}
+
+	rules, err := GenerateAlertRules(configs, nil, true, "")
+	if err != nil {
+		t.Fatalf("GenerateAlertRules: %v", err)
+	}
+	matcher, err := exporter.NewDepthThresholdMatcher(configs, exporter.DepthThresholds{Warning: exporter.DefaultDepthWarning, Critical: exporter.DefaultDepthCritical})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rules.Groups) != 1 {
+		t.Fatalf("Expected one rule group, got %d", len(rules.Groups))
+	}
+
+	for _, queueName := range []string{"orders.created", "orders-batch", "nightly-batch", "payments", "orders.batch"} {
+		want := matcher.For(queueName)
+		var warning, critical []int64
+
+		for _, rule := range rules.Groups[0].Rules {
+			m := depthExprPattern.FindStringSubmatch(rule.Expr)
+			if m == nil || !promMatches(t, m[1], queueName) {
+				continue
+			}
+			threshold, _ := strconv.ParseInt(m[2], 10, 64)
+			switch rule.Alert {
+			case "QueueDepthWarning":
+				warning = append(warning, threshold)
+			case "QueueDepthCritical":
+				critical = append(critical, threshold)
+			}
+		}
+
+		if len(warning) != 1 || warning[0] != want.Warning {
+			t.Errorf("%s: expected exactly one warning rule at %d, got %v", queueName, want.Warning, warning)
+		}
+		if len(critical) != 1 || critical[0] != want.Critical {
+			t.Errorf("%s: expected exactly one critical rule at %d, got %v", queueName, want.Critical, critical)
+		}
+	}
+}
+
+func TestGenerateAlertRules_Defaults(t *testing.T) {
+	rules, err := GenerateAlertRules(nil, nil, true, "")
+	if err != nil {
+		t.Fatalf("GenerateAlertRules: %v", err)
+	}
+
+	exprs := make(map[string]bool)
+	for _, rule := range rules.Groups[0].Rules {
+		exprs[rule.Expr] = true
+		if rule.Labels["severity"] == "" {
+			t.Errorf("Rule %s has no severity label", rule.Alert)
+		}
+	}
+
+	for _, expr := range []string{
+		"rabbitmq_custom_queue_messages > 1000",
+		"rabbitmq_custom_queue_messages > 10000",
+		"rabbitmq_custom_queue_consumer_utilisation < 0.1",
+		"rabbitmq_custom_queue_consumer_utilisation < 0.01",
+		"rabbitmq_custom_queue_health_score < 50",
+		"rabbitmq_custom_queue_health_score < 25",
+		"rabbitmq_custom_queue_max_length_ratio > 0.8 or rabbitmq_custom_queue_max_length_bytes_ratio > 0.8",
+		"rabbitmq_custom_queue_max_length_ratio > 0.95 or rabbitmq_custom_queue_max_length_bytes_ratio > 0.95",
+		"rabbitmq_custom_cluster_nodes_running < rabbitmq_custom_cluster_nodes_expected",
+		"rabbitmq_custom_queue_backlog_growing == 1",
+	} {
+		if !exprs[expr] {
+			t.Errorf("Missing rule with expr %q", expr)
+		}
+	}
+}
+
+func TestGenerateAlertRules_NoHealthMetrics(t *testing.T) {
+	rules, err := GenerateAlertRules(nil, nil, false, "")
+	if err != nil {
+		t.Fatalf("GenerateAlertRules: %v", err)
+	}
+	for _, rule := range rules.Groups[0].Rules {
+		if strings.Contains(rule.Expr, "queue_health_score") {
+			t.Errorf("Expected no health score rules with health metrics off, got %s", rule.Expr)
+		}
+	}
+}
+
+func TestGenerateAlertRules_InvalidPattern(t *testing.T) {
+	if _, err := GenerateAlertRules([]exporter.DepthThresholdConfig{{Pattern: "(", Critical: 10}}, nil, true, ""); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestGenerateAlertRules_SeverityTiers(t *testing.T) {
+	tiers := []exporter.SeverityTierConfig{
+		{Severity: "critical"},
+		{Severity: "page", Depth: 100000, Utilisation: 0.001, Labels: map[string]string{"pager": "oncall"}},
+	}
+	rules, err := GenerateAlertRules(nil, tiers, true, "")
+	if err != nil {
+		t.Fatalf("GenerateAlertRules: %v", err)
+	}
+
+	byExpr := make(map[string]AlertRule)
+	for _, rule := range rules.Groups[0].Rules {
+		byExpr[rule.Expr] = rule
+	}
+
+	if _, ok := byExpr["rabbitmq_custom_queue_messages > 1000"]; ok {
+		t.Error("Expected no warning depth rule without a warning tier")
+	}
+	if rule, ok := byExpr["rabbitmq_custom_queue_messages > 10000"]; !ok || rule.Alert != "QueueDepthCritical" {
+		t.Errorf("Expected a critical depth rule, got %+v", rule)
+	}
+	page, ok := byExpr["rabbitmq_custom_queue_messages > 100000"]
+	if !ok || page.Alert != "QueueDepthPage" || page.Labels["severity"] != "page" || page.Labels["pager"] != "oncall" {
+		t.Errorf("Expected a page depth rule with the tier's labels, got %+v", page)
+	}
+	if rule := byExpr["rabbitmq_custom_queue_consumer_utilisation < 0.001"]; rule.Labels["pager"] != "oncall" {
+		t.Errorf("Expected a page utilisation rule with the tier's labels, got %+v", rule)
+	}
+	if rule := byExpr["rabbitmq_custom_queue_consumer_utilisation < 0.01"]; rule.Labels["pager"] != "" {
+		t.Errorf("Expected page labels only on page rules, got %+v", rule)
+	}
+
+	if _, err := GenerateAlertRules(nil, []exporter.SeverityTierConfig{{Severity: "warning", Depth: 10}}, true, ""); err == nil {
+		t.Error("Expected error for invalid severity tiers")
+	}
+}
+
+func TestWriteRuleFile(t *testing.T) {
+	rules, err := GenerateAlertRules([]exporter.DepthThresholdConfig{{Pattern: `^orders\.`, Critical: 50000}}, nil, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeRuleFile(&buf, rules); err != nil {
+		t.Fatalf("writeRuleFile: %v", err)
+	}
+
+	var decoded RuleFile
+	if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Generated rules are not valid YAML: %v\n%s", err, buf.String())
+	}
+	if len(decoded.Groups) != 1 || len(decoded.Groups[0].Rules) != len(rules.Groups[0].Rules) {
+		t.Fatalf("Round-tripped rules differ: %+v", decoded)
+	}
+	if !strings.HasPrefix(buf.String(), "groups:\n") {
+		t.Errorf("Unexpected rule file layout:\n%s", buf.String())
+	}
+}
+
+func TestGenerateAlertRules_Namespace(t *testing.T) {
+	rules, err := GenerateAlertRules(nil, nil, true, "rmq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, group := range rules.Groups {
+		for _, rule := range group.Rules {
+			if !strings.HasPrefix(rule.Expr, "rmq_") {
+				t.Errorf("Rule %s does not use the namespace: %s", rule.Alert, rule.Expr)
+			}
+		}
+	}
+}
+
+func TestCheckGeneratedNaming(t *testing.T) {
+	for _, naming := range []string{metrics.NamingNative, metrics.NamingBoth} {
+		if err := checkGeneratedNaming(naming, "rules generate"); err != nil {
+			t.Errorf("Expected %s naming to be accepted, got %v", naming, err)
+		}
+	}
+	if err := checkGeneratedNaming(metrics.NamingKbudde, "rules generate"); err == nil {
+		t.Error("Expected kbudde naming to be rejected")
+	}
+}
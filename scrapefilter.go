@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"rabbitmq-exporter/metrics"
+)
+
+// scrapeFilterParams maps /metrics query parameters to the labels they
+// filter. Queues are labelled queue instead of queue_name under the kbudde
+// naming scheme.
+var scrapeFilterParams = map[string][]string{
+	"vhost": {"vhost"},
+	"queue": {"queue_name", "queue"},
+}
+
+// parseScrapeFilters builds label filters from query parameters such as
+// ?vhost=prod&queue=orders.*. Values are regexes anchored like Prometheus
+// label matchers; repeating a parameter matches any of its values.
+func parseScrapeFilters(query url.Values) (map[string]*regexp.Regexp, error) {
+	var filters map[string]*regexp.Regexp
+
+	for param, labels := range scrapeFilterParams {
+		values := query[param]
+		if len(values) == 0 {
+			continue
+		}
+
+		re, err := regexp.Compile("^(?:" + strings.Join(values, "|") + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s filter: %w", param, err)
+		}
+		if filters == nil {
+			filters = make(map[string]*regexp.Regexp)
+		}
+		for _, label := range labels {
+			filters[label] = re
+		}
+	}
+
+	return filters, nil
+}
+
+// metricsHandler serves gatherer, restricting the exposition to matching
+// queue series when the request carries filter parameters. Collection is
+// unaffected; filtering only trims what one scrape receives.
+func metricsHandler(gatherer prometheus.Gatherer) http.Handler {
+	unfiltered := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filters, err := parseScrapeFilters(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if filters == nil {
+			unfiltered.ServeHTTP(w, r)
+			return
+		}
+
+		promhttp.HandlerFor(metrics.NewLabelFilterGatherer(gatherer, filters), promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
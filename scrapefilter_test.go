@@ -0,0 +1,134 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/metrics"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// newTestCollector returns a collector whose cache holds the queues served
+// by a fake management API
+func newTestCollector(t *testing.T, queuesJSON string) *exporter.Collector {
+	t.Helper()
+
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/nodes" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(queuesJSON))
+	}))
+	t.Cleanup(rabbit.Close)
+
+	client := rabbitmq.NewClient(rabbit.URL, "guest", "guest", time.Second)
+	collector := exporter.NewCollector(client, metrics.NewMetrics(), time.Hour)
+	t.Cleanup(collector.Stop)
+	if !collector.Warm(time.Second) {
+		t.Fatal("Expected the collection to succeed")
+	}
+	return collector
+}
+
+func TestMetricsHandler_Filters(t *testing.T) {
+	collector := newTestCollector(t, `[
+		{"name":"orders.created","vhost":"prod","consumers":1},
+		{"name":"orders.created","vhost":"staging","consumers":2},
+		{"name":"payments","vhost":"prod","consumers":3}
+	]`)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	handler := metricsHandler(registry)
+
+	scrape := func(query string) (int, string) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics"+query, nil))
+		body, _ := io.ReadAll(rec.Body)
+		return rec.Code, string(body)
+	}
+
+	_, body := scrape("")
+	if strings.Count(body, "rabbitmq_custom_queue_consumers{") != 3 {
+		t.Errorf("Expected every queue without filters:\n%s", body)
+	}
+
+	_, body = scrape("?vhost=prod&queue=orders.*")
+	if got := strings.Count(body, "rabbitmq_custom_queue_consumers{"); got != 1 {
+		t.Errorf("Expected one queue for vhost=prod&queue=orders.*, got %d", got)
+	}
+	if !strings.Contains(body, `rabbitmq_custom_queue_consumers{queue_name="orders.created",type="classic",vhost="prod"} 1`) {
+		t.Errorf("Missing filtered series:\n%s", body)
+	}
+	if !strings.Contains(body, "rabbitmq_custom_up 1") {
+		t.Error("Expected exporter metrics to survive filtering")
+	}
+
+	_, body = scrape("?queue=payments&queue=orders.created&vhost=prod")
+	if got := strings.Count(body, "rabbitmq_custom_queue_consumers{"); got != 2 {
+		t.Errorf("Expected repeated queue parameters to match either, got %d", got)
+	}
+
+	_, body = scrape("?vhost=pro")
+	if strings.Contains(body, "rabbitmq_custom_queue_consumers{") {
+		t.Error("Expected filters to be anchored")
+	}
+
+	if code, _ := scrape("?queue=("); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid regex, got %d", code)
+	}
+}
+
+func TestMetricsHandler_FiltersKbuddeNames(t *testing.T) {
+	collector := newTestCollector(t, `[
+		{"name":"orders","vhost":"prod","consumers":1},
+		{"name":"payments","vhost":"prod","consumers":3}
+	]`)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	gatherer, err := metrics.NewNamingGatherer(registry, metrics.DefaultNamespace, metrics.NamingKbudde)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	metricsHandler(gatherer).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics?queue=orders", nil))
+	body := rec.Body.String()
+
+	if !strings.Contains(body, `rabbitmq_queue_consumers{queue="orders",type="classic",vhost="prod"} 1`) {
+		t.Errorf("Missing kbudde-named series:\n%s", body)
+	}
+	if strings.Contains(body, `queue="payments"`) {
+		t.Errorf("Expected the queue filter to apply to the queue label:\n%s", body)
+	}
+}
+
+func TestExposedGatherer_ClusterLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "rabbitmq_custom_up", Help: "Whether the most recent background collection succeeded"})
+	registry.MustRegister(up)
+	up.Set(1)
+
+	cfg := exporter.Config{MetricNamespace: DefaultMetricNamespace, MetricNaming: DefaultMetricNaming}
+	gatherer, err := exposedGatherer(registry, cfg, exporter.NewClusterIdentity(exporter.ClusterLabelConfig{Enabled: true, Name: "eu-prod"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP rabbitmq_custom_up Whether the most recent background collection succeeded
+# TYPE rabbitmq_custom_up gauge
+rabbitmq_custom_up{cluster="eu-prod"} 1
+`
+	if err := testutil.GatherAndCompare(gatherer, strings.NewReader(expected), "rabbitmq_custom_up"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// collectionStatus is the part of *exporter.Collector the notifier watches
+type collectionStatus interface {
+	CacheValid() bool
+	LastSuccess() time.Time
+	WatchdogTimeout() time.Duration
+}
+
+// systemdNotifier reports readiness and liveness to systemd through the
+// sd_notify protocol, for units with Type=notify and optionally WatchdogSec
+type systemdNotifier struct {
+	socket string
+	// watchdog is the unit's WatchdogSec, or 0 when it has none
+	watchdog time.Duration
+	ready    bool
+}
+
+// newSystemdNotifier returns nil unless systemd started the exporter with a
+// notification socket
+func newSystemdNotifier() *systemdNotifier {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	n := &systemdNotifier{socket: socket}
+	// WATCHDOG_PID, when set, names the process expected to send the pings
+	if pid := os.Getenv("WATCHDOG_PID"); pid == "" || pid == strconv.Itoa(os.Getpid()) {
+		if usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64); err == nil && usec > 0 {
+			n.watchdog = time.Duration(usec) * time.Microsecond
+		}
+	}
+	return n
+}
+
+func (n *systemdNotifier) notify(state string) error {
+	name := n.socket
+	// A leading @ names a socket in the abstract namespace
+	if strings.HasPrefix(name, "@") {
+		name = "\x00" + name[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// run sends READY=1 once the collector holds a snapshot, then, if the unit
+// has a watchdog, WATCHDOG=1 for as long as collections keep succeeding. It
+// returns when stop is closed.
+func (n *systemdNotifier) run(collector collectionStatus, stop <-chan struct{}) {
+	interval := time.Second
+	if n.watchdog > 0 && n.watchdog/2 < interval {
+		interval = n.watchdog / 2
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n.update(collector, time.Now())
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update sends whichever notification is due at now
+func (n *systemdNotifier) update(collector collectionStatus, now time.Time) {
+	if !n.ready {
+		if !collector.CacheValid() {
+			return
+		}
+		if err := n.notify("READY=1"); err != nil {
+			log.Printf("Failed to notify systemd: %v", err)
+			return
+		}
+		n.ready = true
+	}
+
+	// Pings stop once no collection has succeeded for as long as the
+	// in-process watchdog waits, so systemd restarts an exporter that
+	// stopped producing data
+	if n.watchdog > 0 && now.Sub(collector.LastSuccess()) <= collector.WatchdogTimeout() {
+		if err := n.notify("WATCHDOG=1"); err != nil {
+			log.Printf("Failed to notify systemd: %v", err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+// received returns the notifications sent so far
+func received(t *testing.T, conn *net.UnixConn) []string {
+	t.Helper()
+	var states []string
+	buf := make([]byte, 256)
+	for {
+		conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+		n, err := conn.Read(buf)
+		if err != nil {
+			return states
+		}
+		states = append(states, string(buf[:n]))
+	}
+}
+
+func TestNewSystemdNotifier(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if newSystemdNotifier() != nil {
+		t.Fatal("Expected no notifier outside systemd")
+	}
+
+	t.Setenv("NOTIFY_SOCKET", "/run/systemd/notify")
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if n := newSystemdNotifier(); n == nil || n.watchdog != 30*time.Second {
+		t.Fatalf("Expected a 30s watchdog, got %+v", n)
+	}
+
+	// The watchdog belongs to another process
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	if n := newSystemdNotifier(); n == nil || n.watchdog != 0 {
+		t.Fatalf("Expected no watchdog, got %+v", n)
+	}
+}
+
+// fakeCollectionStatus stands in for the collector the notifier watches
+type fakeCollectionStatus struct {
+	valid       bool
+	lastSuccess time.Time
+}
+
+func (f *fakeCollectionStatus) CacheValid() bool               { return f.valid }
+func (f *fakeCollectionStatus) LastSuccess() time.Time         { return f.lastSuccess }
+func (f *fakeCollectionStatus) WatchdogTimeout() time.Duration { return time.Minute }
+
+func TestSystemdNotifier_Update(t *testing.T) {
+	conn := listenNotifySocket(t)
+	t.Setenv("WATCHDOG_USEC", "10000000")
+
+	collector := &fakeCollectionStatus{}
+	n := newSystemdNotifier()
+
+	// Nothing is sent before the cache is warm
+	n.update(collector, time.Now())
+	if got := received(t, conn); len(got) != 0 {
+		t.Fatalf("Expected no notifications before the first collection, got %v", got)
+	}
+
+	collector.valid = true
+	collector.lastSuccess = time.Now()
+
+	n.update(collector, time.Now())
+	n.update(collector, time.Now())
+	got := received(t, conn)
+	expected := []string{"READY=1", "WATCHDOG=1", "WATCHDOG=1"}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	}
+
+	// Collections that stopped succeeding stop the pings
+	n.update(collector, time.Now().Add(collector.WatchdogTimeout()+time.Second))
+	if got := received(t, conn); len(got) != 0 {
+		t.Errorf("Expected no pings without recent successful collections, got %v", got)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/rabbitmq"
+)
+
+// selftestFields are the fields the collectors rely on in each endpoint's
+// response. A broker version that renames or drops one still decodes, so
+// their presence is checked separately.
+var selftestFields = map[string][]string{
+	rabbitmq.EndpointOverview:         {"rabbitmq_version", "object_totals"},
+	rabbitmq.EndpointQueues:           {"name", "vhost", "durable"},
+	rabbitmq.EndpointNodes:            {"name", "running"},
+	rabbitmq.EndpointBindings:         {"source", "vhost", "destination", "destination_type"},
+	rabbitmq.EndpointPolicies:         {"name", "vhost", "pattern", "definition"},
+	rabbitmq.EndpointOperatorPolicies: {"name", "vhost", "pattern", "definition"},
+	rabbitmq.EndpointChannels:         {"name", "vhost"},
+	rabbitmq.EndpointConsumers:        {"queue", "ack_required", "prefetch_count"},
+	rabbitmq.EndpointConnections:      {"name", "user", "protocol", "channels", "client_properties"},
+}
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Exercise every enabled management API call and report what parses",
+	Long: `Connects to the configured broker and performs every management API call the
+enabled collectors make, including aliveness tests and health checks. Each
+response is decoded the way the collector decodes it and checked for the
+fields the collectors rely on. Exits non-zero if any call fails.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runSelftestCommand,
+}
+
+func init() {
+	selftestCmd.Flags().String("config", "", "Path to config file (default: config.yaml)")
+	selftestCmd.Flags().Bool("all", false, "Call every endpoint, not only the ones enabled features use")
+
+	rootCmd.AddCommand(selftestCmd)
+}
+
+func runSelftestCommand(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("config")
+	all, _ := cmd.Flags().GetBool("all")
+
+	cfg, err := loadConfig(configFile, false)
+	if err != nil {
+		return err
+	}
+
+	client, closeClient, err := newRabbitMQClient(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	return runSelftest(cmd.Context(), cmd.OutOrStdout(), client, cfg, all)
+}
+
+// runSelftest calls each endpoint the collector would call with cfg and
+// prints a line per call
+func runSelftest(ctx context.Context, out io.Writer, client *rabbitmq.Client, cfg exporter.Config, all bool) error {
+	failed := 0
+	report := func(name string, start time.Time, detail string, err error) {
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "%s: FAIL (%v)\n", name, err)
+			return
+		}
+		fmt.Fprintf(out, "%s: PASS (%s in %v)\n", name, detail, elapsed)
+	}
+
+	total := 0
+	for _, endpoint := range dumpEndpoints(cfg, all) {
+		total++
+		start := time.Now()
+		reqCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		body, err := client.GetRaw(reqCtx, endpoint)
+		cancel()
+		detail := ""
+		if err == nil {
+			detail, err = validateSelftestResponse(endpoint, body)
+		}
+		report(endpoint, start, detail, err)
+	}
+
+	for _, vhost := range cfg.AlivenessVhosts {
+		total++
+		start := time.Now()
+		reqCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		err := client.AlivenessTest(reqCtx, vhost)
+		cancel()
+		report(rabbitmq.EndpointAliveness+" "+vhost, start, "ok", err)
+	}
+
+	for _, check := range cfg.HealthChecks.Checks() {
+		total++
+		start := time.Now()
+		reqCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		result, err := client.RunHealthCheck(reqCtx, check)
+		cancel()
+		// A failing check means the broker is unhealthy, not that the call
+		// is broken, so it still passes
+		detail := "passed"
+		if err == nil && !result.Passed {
+			detail = "check failed: " + result.Reason
+		}
+		report(rabbitmq.EndpointHealthChecks+" "+check, start, detail, err)
+	}
+
+	fmt.Fprintf(out, "%d of %d calls passed\n", total-failed, total)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d selftest calls failed", failed, total)
+	}
+	return nil
+}
+
+// validateSelftestResponse decodes body into the type the client decodes
+// endpoint into and checks every object for selftestFields, returning a
+// summary of what it found
+func validateSelftestResponse(endpoint string, body []byte) (string, error) {
+	var typed interface{}
+	switch endpoint {
+	case rabbitmq.EndpointOverview:
+		typed = &rabbitmq.Overview{}
+	case rabbitmq.EndpointQueues:
+		typed = &[]rabbitmq.Queue{}
+	case rabbitmq.EndpointNodes:
+		typed = &[]rabbitmq.Node{}
+	case rabbitmq.EndpointBindings:
+		typed = &[]rabbitmq.Binding{}
+	case rabbitmq.EndpointPolicies, rabbitmq.EndpointOperatorPolicies:
+		typed = &[]rabbitmq.Policy{}
+	case rabbitmq.EndpointChannels:
+		typed = &[]rabbitmq.Channel{}
+	case rabbitmq.EndpointConsumers:
+		typed = &[]rabbitmq.Consumer{}
+	case rabbitmq.EndpointConnections:
+		typed = &[]rabbitmq.Connection{}
+	default:
+		return "", fmt.Errorf("no selftest for endpoint %s", endpoint)
+	}
+	if err := json.Unmarshal(body, typed); err != nil {
+		return "", fmt.Errorf("%w: %v", rabbitmq.ErrDecode, err)
+	}
+
+	if endpoint == rabbitmq.EndpointOverview {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(body, &object); err != nil {
+			return "", fmt.Errorf("%w: %v", rabbitmq.ErrDecode, err)
+		}
+		if err := checkSelftestFields(endpoint, object); err != nil {
+			return "", err
+		}
+		return "version " + typed.(*rabbitmq.Overview).RabbitMQVersion, nil
+	}
+
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &objects); err != nil {
+		return "", fmt.Errorf("%w: %v", rabbitmq.ErrDecode, err)
+	}
+	for i, object := range objects {
+		if err := checkSelftestFields(endpoint, object); err != nil {
+			return "", fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return fmt.Sprintf("%d items", len(objects)), nil
+}
+
+func checkSelftestFields(endpoint string, object map[string]json.RawMessage) error {
+	for _, field := range selftestFields[endpoint] {
+		if _, ok := object[field]; !ok {
+			return fmt.Errorf("missing field %q", field)
+		}
+	}
+	return nil
+}
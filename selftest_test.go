@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/exporter"
+	"rabbitmq-exporter/rabbitmq"
+	"rabbitmq-exporter/rabbitmq/rabbitmqtest"
+)
+
+func TestRunSelftest(t *testing.T) {
+	server := rabbitmqtest.NewServer()
+	defer server.Close()
+	server.SetQueues(rabbitmq.Queue{Name: "orders", Vhost: "/"})
+	server.SetNodes(rabbitmq.Node{Name: "rabbit@a", Running: true})
+	server.SetOverview(rabbitmq.Overview{RabbitMQVersion: "3.13.1"})
+
+	client := rabbitmq.NewClient(server.URL, rabbitmqtest.DefaultUsername, rabbitmqtest.DefaultPassword, time.Second)
+	defer client.Close()
+
+	cfg := exporter.Config{Collect: exporter.DefaultCollectGroups, Timeout: time.Second}
+	cfg.AlivenessVhosts = []string{"/"}
+	cfg.HealthChecks.Enabled = true
+
+	var out bytes.Buffer
+	if err := runSelftest(context.Background(), &out, client, cfg, false); err != nil {
+		t.Fatalf("Expected selftest to pass, got %v\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		"overview: PASS (version 3.13.1",
+		"queues: PASS (1 items",
+		"nodes: PASS (1 items",
+		"aliveness /: PASS",
+		"health_checks alarms: PASS",
+		"6 of 6 calls passed",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, out.String())
+		}
+	}
+
+	server.Fail(rabbitmq.EndpointNodes, http.StatusInternalServerError, "boom")
+	out.Reset()
+	if err := runSelftest(context.Background(), &out, client, cfg, false); err == nil {
+		t.Error("Expected a failing endpoint to fail the selftest")
+	}
+	if !strings.Contains(out.String(), "nodes: FAIL") || !strings.Contains(out.String(), "5 of 6 calls passed") {
+		t.Errorf("Expected the nodes failure to be reported:\n%s", out.String())
+	}
+}
+
+func TestValidateSelftestResponse(t *testing.T) {
+	if _, err := validateSelftestResponse(rabbitmq.EndpointQueues, []byte(`[{"name":"orders","durable":true}]`)); err == nil || !strings.Contains(err.Error(), `"vhost"`) {
+		t.Errorf("Expected the missing vhost to be reported, got %v", err)
+	}
+	if _, err := validateSelftestResponse(rabbitmq.EndpointNodes, []byte(`{"name":"rabbit@a"}`)); err == nil {
+		t.Error("Expected an object where a list belongs to fail decoding")
+	}
+	detail, err := validateSelftestResponse(rabbitmq.EndpointBindings, []byte(`[]`))
+	if err != nil || detail != "0 items" {
+		t.Errorf("Expected an empty listing to pass, got %q, %v", detail, err)
+	}
+}
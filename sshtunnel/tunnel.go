@@ -0,0 +1,269 @@
+package sshtunnel
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	DefaultPort      = "22"
+	DefaultKeepAlive = 30 * time.Second
+	DefaultTimeout   = 10 * time.Second
+)
+
+// Config describes how to reach the management API through an SSH bastion
+type Config struct {
+	Host                  string        `mapstructure:"host"`
+	User                  string        `mapstructure:"user"`
+	KeyFile               string        `mapstructure:"key_file"`
+	KeyPassphrase         string        `mapstructure:"key_passphrase"`
+	KnownHostsFile        string        `mapstructure:"known_hosts_file"`
+	InsecureIgnoreHostKey bool          `mapstructure:"insecure_ignore_host_key"`
+	RemoteAddr            string        `mapstructure:"remote_addr"`
+	KeepAlive             time.Duration `mapstructure:"keep_alive"`
+	Timeout               time.Duration `mapstructure:"timeout"`
+}
+
+// Enabled reports whether an SSH tunnel has been configured
+func (c Config) Enabled() bool {
+	return c.Host != ""
+}
+
+// Tunnel dials connections through an SSH server, establishing the SSH
+// session lazily and re-establishing it after failures
+type Tunnel struct {
+	addr      string
+	remote    string
+	sshConfig *ssh.ClientConfig
+	keepAlive time.Duration
+
+	mu     sync.Mutex
+	client *ssh.Client
+
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+func New(cfg Config) (*Tunnel, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("ssh tunnel host is required")
+	}
+	if cfg.User == "" {
+		return nil, fmt.Errorf("ssh tunnel user is required")
+	}
+	if cfg.KeyFile == "" {
+		return nil, fmt.Errorf("ssh tunnel key_file is required")
+	}
+
+	key, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh key %s: %w", cfg.KeyFile, err)
+	}
+
+	var signer ssh.Signer
+	if cfg.KeyPassphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(cfg.KeyPassphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh key %s: %w", cfg.KeyFile, err)
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case cfg.KnownHostsFile != "":
+		hostKeyCallback, err = knownhosts.New(cfg.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load known hosts %s: %w", cfg.KnownHostsFile, err)
+		}
+	case cfg.InsecureIgnoreHostKey:
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	default:
+		return nil, fmt.Errorf("ssh tunnel requires known_hosts_file or insecure_ignore_host_key")
+	}
+
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = DefaultKeepAlive
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	addr := cfg.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, DefaultPort)
+	}
+
+	t := &Tunnel{
+		addr:   addr,
+		remote: cfg.RemoteAddr,
+		sshConfig: &ssh.ClientConfig{
+			User:            cfg.User,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         cfg.Timeout,
+		},
+		keepAlive: cfg.KeepAlive,
+		stopChan:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go t.keepAliveLoop()
+
+	return t, nil
+}
+
+// DialContext opens a connection to the remote address through the tunnel.
+// When no remote_addr is configured the requested address is dialed from the
+// SSH server. It is suitable for use as http.Transport.DialContext.
+func (t *Tunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if t.remote != "" {
+		addr = t.remote
+	}
+
+	client, err := t.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.DialContext(ctx, network, addr)
+	if err == nil {
+		return conn, nil
+	}
+
+	// The SSH session may have silently died; reconnect once before giving up
+	t.dropClient(client)
+	client, err = t.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err = client.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s through ssh tunnel: %w", addr, err)
+	}
+	return conn, nil
+}
+
+// getClient returns the active SSH client, connecting if there is none. The
+// connection is made without holding the lock, so a slow handshake doesn't
+// stall requests or keepalives on an existing session.
+func (t *Tunnel) getClient(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	client := t.client
+	t.mu.Unlock()
+	if client != nil {
+		return client, nil
+	}
+
+	client, err := t.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh server %s: %w", t.addr, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// A concurrent request connected first
+	if t.client != nil {
+		client.Close()
+		return t.client, nil
+	}
+	log.Printf("SSH tunnel established via %s", t.addr)
+
+	t.client = client
+	return client, nil
+}
+
+// connect dials the SSH server and completes the handshake within the
+// configured timeout, giving up early when ctx is done. ssh.Dial's timeout
+// only bounds the TCP connect.
+func (t *Tunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	dialer := net.Dialer{Timeout: t.sshConfig.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(t.sshConfig.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, t.addr, t.sshConfig)
+	if !stop() {
+		if err == nil {
+			sshConn.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// dropClient closes the given SSH client if it is still the active one so the
+// next dial reconnects
+func (t *Tunnel) dropClient(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client == client && client != nil {
+		client.Close()
+		t.client = nil
+	}
+}
+
+func (t *Tunnel) keepAliveLoop() {
+	ticker := time.NewTicker(t.keepAlive)
+	defer ticker.Stop()
+	defer close(t.done)
+
+	for {
+		select {
+		case <-t.stopChan:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			client := t.client
+			t.mu.Unlock()
+
+			if client == nil {
+				continue
+			}
+			if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				log.Printf("SSH tunnel keepalive failed, reconnecting on next request: %v", err)
+				t.dropClient(client)
+			}
+		}
+	}
+}
+
+// Close stops the keepalive loop and tears down the SSH session
+func (t *Tunnel) Close() error {
+	close(t.stopChan)
+	<-t.done
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		err := t.client.Close()
+		t.client = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package sshtunnel
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func writeTestKey(t *testing.T) (string, ssh.Signer) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return path, signer
+}
+
+// startSSHServer runs a minimal SSH server that only supports direct-tcpip
+// forwarding for the given client key
+func startSSHServer(t *testing.T, clientKey ssh.PublicKey) string {
+	t.Helper()
+
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, _ := ssh.NewSignerFromKey(hostPriv)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChan := range chans {
+					if newChan.ChannelType() != "direct-tcpip" {
+						newChan.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					extra := newChan.ExtraData()
+					hostLen := binary.BigEndian.Uint32(extra[:4])
+					host := string(extra[4 : 4+hostLen])
+					port := binary.BigEndian.Uint32(extra[4+hostLen : 8+hostLen])
+
+					target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10)))
+					if err != nil {
+						newChan.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					channel, chanReqs, err := newChan.Accept()
+					if err != nil {
+						target.Close()
+						continue
+					}
+					go ssh.DiscardRequests(chanReqs)
+					go func() {
+						io.Copy(channel, target)
+						channel.Close()
+					}()
+					go func() {
+						io.Copy(target, channel)
+						target.Close()
+					}()
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestNew_Validation(t *testing.T) {
+	keyFile, _ := writeTestKey(t)
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "Missing host", cfg: Config{User: "exporter", KeyFile: keyFile, InsecureIgnoreHostKey: true}},
+		{name: "Missing user", cfg: Config{Host: "bastion", KeyFile: keyFile, InsecureIgnoreHostKey: true}},
+		{name: "Missing key", cfg: Config{Host: "bastion", User: "exporter", InsecureIgnoreHostKey: true}},
+		{name: "Unreadable key", cfg: Config{Host: "bastion", User: "exporter", KeyFile: "/nonexistent", InsecureIgnoreHostKey: true}},
+		{name: "No host key verification", cfg: Config{Host: "bastion", User: "exporter", KeyFile: keyFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("Expected configuration error, got nil")
+			}
+		})
+	}
+}
+
+func TestTunnel_DialContext(t *testing.T) {
+	keyFile, signer := writeTestKey(t)
+	sshAddr := startSSHServer(t, signer.PublicKey())
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tunneled"))
+	}))
+	defer api.Close()
+
+	tunnel, err := New(Config{
+		Host:                  sshAddr,
+		User:                  "exporter",
+		KeyFile:               keyFile,
+		InsecureIgnoreHostKey: true,
+		RemoteAddr:            api.Listener.Addr().String(),
+	})
+	if err != nil {
+		t.Fatalf("Expected tunnel to be created, got %v", err)
+	}
+	defer tunnel.Close()
+
+	client := &http.Client{Transport: &http.Transport{DialContext: tunnel.DialContext}}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		resp, err := client.Get("http://rabbitmq.internal:15672/")
+		if err != nil {
+			t.Fatalf("Expected request through tunnel to succeed, got %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "tunneled" {
+			t.Errorf("Expected body 'tunneled', got '%s'", string(body))
+		}
+
+		// Simulate the SSH session dying between requests
+		tunnel.dropClient(tunnel.client)
+		client.CloseIdleConnections()
+	}
+
+	if _, err := tunnel.DialContext(context.Background(), "tcp", "ignored:1"); err != nil {
+		t.Errorf("Expected dial after reconnect to succeed, got %v", err)
+	}
+}
+
+func TestTunnel_HandshakeHonoursContext(t *testing.T) {
+	keyFile, _ := writeTestKey(t)
+
+	// Accepts connections but never speaks SSH
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	tunnel, err := New(Config{
+		Host:                  listener.Addr().String(),
+		User:                  "exporter",
+		KeyFile:               keyFile,
+		InsecureIgnoreHostKey: true,
+		Timeout:               time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Expected tunnel to be created, got %v", err)
+	}
+	defer tunnel.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := tunnel.DialContext(ctx, "tcp", "ignored:1"); err == nil {
+		t.Fatal("Expected dial to fail without an SSH handshake")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the stalled handshake to end with the context, took %v", elapsed)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+)
+
+// StateStore persists exporter counters to disk so restarts don't reset them
+type StateStore struct {
+	path     string
+	metrics  *metrics.Metrics
+	interval time.Duration
+
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+func NewStateStore(path string, metrics *metrics.Metrics, interval time.Duration) *StateStore {
+	return &StateStore{
+		path:     path,
+		metrics:  metrics,
+		interval: interval,
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Load restores counters from the state file. A missing file is not an error.
+func (s *StateStore) Load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read state file %s: %w", s.path, err)
+	}
+
+	var state metrics.CounterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse state file %s: %w", s.path, err)
+	}
+
+	s.metrics.RestoreCounters(state)
+	return nil
+}
+
+// Save writes the current counter values to the state file atomically
+func (s *StateStore) Save() error {
+	data, err := json.Marshal(s.metrics.SnapshotCounters())
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Start periodically saves the state file until Stop is called
+func (s *StateStore) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		defer close(s.done)
+
+		for {
+			select {
+			case <-s.stopChan:
+				return
+			case <-ticker.C:
+				if err := s.Save(); err != nil {
+					log.Printf("State persistence error: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop halts periodic saving and writes a final snapshot
+func (s *StateStore) Stop() {
+	close(s.stopChan)
+	<-s.done
+
+	if err := s.Save(); err != nil {
+		log.Printf("State persistence error: %v", err)
+	}
+}
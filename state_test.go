@@ -0,0 +1,44 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rabbitmq-exporter/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestStateStore_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	original := metrics.NewMetrics()
+	original.ScrapeErrorsTotal.WithLabelValues("api_error").Add(3)
+	original.CircuitBreakerFailures.WithLabelValues("rabbitmq_api").Add(7)
+
+	if err := NewStateStore(path, original, time.Minute).Save(); err != nil {
+		t.Fatalf("Expected save to succeed, got %v", err)
+	}
+
+	restored := metrics.NewMetrics()
+	restored.ScrapeErrorsTotal.WithLabelValues("api_error").Inc()
+	if err := NewStateStore(path, restored, time.Minute).Load(); err != nil {
+		t.Fatalf("Expected load to succeed, got %v", err)
+	}
+
+	if got := testutil.ToFloat64(restored.ScrapeErrorsTotal.WithLabelValues("api_error")); got != 4 {
+		t.Errorf("Expected scrape errors to be 4, got %v", got)
+	}
+	if got := testutil.ToFloat64(restored.CircuitBreakerFailures.WithLabelValues("rabbitmq_api")); got != 7 {
+		t.Errorf("Expected circuit breaker failures to be 7, got %v", got)
+	}
+}
+
+func TestStateStore_LoadMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+
+	if err := NewStateStore(path, metrics.NewMetrics(), time.Minute).Load(); err != nil {
+		t.Errorf("Expected missing state file to be ignored, got %v", err)
+	}
+}
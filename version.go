@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, set at build time via -ldflags "-X main.Version=..."
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = "unknown"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version information",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Fprintln(cmd.OutOrStdout(), versionString())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.Version = versionString()
+	rootCmd.SetVersionTemplate("{{.Version}}\n")
+}
+
+// buildCommit returns the commit set via ldflags, falling back to the VCS
+// revision the Go toolchain stamps into binaries built from a checkout
+func buildCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				if len(setting.Value) > 12 {
+					return setting.Value[:12]
+				}
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+func versionString() string {
+	return fmt.Sprintf("rabbitmq-exporter %s (commit %s, built %s, %s %s/%s)",
+		Version, buildCommit(), BuildTime, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+
+	"rabbitmq-exporter/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestVersionCommand(t *testing.T) {
+	var out bytes.Buffer
+	versionCmd.SetOut(&out)
+	versionCmd.Run(versionCmd, nil)
+
+	got := out.String()
+	if !strings.HasPrefix(got, "rabbitmq-exporter "+Version) || !strings.Contains(got, runtime.Version()) {
+		t.Errorf("Unexpected version output: %q", got)
+	}
+}
+
+func TestBuildInfoMetric(t *testing.T) {
+	m := metrics.NewMetrics()
+	m.SetBuildInfo(Version, buildCommit(), runtime.Version())
+
+	if got := testutil.ToFloat64(m.BuildInfo.WithLabelValues(Version, buildCommit(), runtime.Version())); got != 1 {
+		t.Errorf("Expected build info gauge 1, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.BuildInfo); got != 1 {
+		t.Errorf("Expected a single build info series, got %d", got)
+	}
+}